@@ -4,11 +4,13 @@ import (
 	"github.com/aws/aws-lambda-go/lambda"
 	"github.com/manishMandal02/tabsflow-backend/config"
 	"github.com/manishMandal02/tabsflow-backend/internal/auth"
+	"github.com/manishMandal02/tabsflow-backend/pkg/telemetry"
 )
 
 func main() {
 	// Initialize
 	config.Init()
 
-	lambda.Start(auth.LambdaAuthorizer)
+	// metrics of the invocation are exported before it ends
+	lambda.Start(telemetry.Flushed(auth.LambdaAuthorizer))
 }
@@ -4,6 +4,7 @@ import (
 	"github.com/aws/aws-lambda-go/lambda"
 	"github.com/manishMandal02/tabsflow-backend/config"
 	"github.com/manishMandal02/tabsflow-backend/internal/email"
+	"github.com/manishMandal02/tabsflow-backend/pkg/telemetry"
 )
 
 func main() {
@@ -11,5 +12,6 @@ func main() {
 	// load config
 	config.Init()
 
-	lambda.Start(email.SendEmail)
+	// metrics of the invocation are exported before it ends
+	lambda.Start(telemetry.Flushed(email.SendEmail))
 }
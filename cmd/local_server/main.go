@@ -3,6 +3,7 @@ package main
 import (
 	"fmt"
 	"net/http"
+	"strings"
 
 	"github.com/manishMandal02/tabsflow-backend/config"
 	"github.com/manishMandal02/tabsflow-backend/internal/auth"
@@ -11,36 +12,63 @@ import (
 	"github.com/manishMandal02/tabsflow-backend/internal/spaces"
 	"github.com/manishMandal02/tabsflow-backend/internal/users"
 	"github.com/manishMandal02/tabsflow-backend/pkg/db"
+	"github.com/manishMandal02/tabsflow-backend/pkg/errs"
 	"github.com/manishMandal02/tabsflow-backend/pkg/events"
+	"github.com/manishMandal02/tabsflow-backend/pkg/health"
 	"github.com/manishMandal02/tabsflow-backend/pkg/http_api"
+	"github.com/manishMandal02/tabsflow-backend/pkg/openapi"
+	"github.com/manishMandal02/tabsflow-backend/pkg/telemetry"
 )
 
-// lambda authorizer simple moc
-func authorizer(next http.Handler) http.Handler {
+// lambda authorizer mock, validates the session against the sessions table
+func authorizer(sessionsTable *db.DDB, next http.Handler) http.Handler {
 
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		// allow paddle webhook, without auth tokens
-		if r.URL.Path == "/users/subscription/webhook" {
+		// allow paddle webhook, data export download link & the health routes, without auth tokens
+		if r.URL.Path == "/users/subscription/webhook" || r.URL.Path == "/users/export/download" || isHealthPath(r.URL.Path) {
 			next.ServeHTTP(w, r)
 			return
 		}
 
+		// personal api token, with the scopes checked by the service routers
+		if token := auth.BearerToken(r); token != "" {
+			userId, scopes, err := auth.ValidateAPIToken(r.Context(), token)
+
+			if err != nil {
+				http_api.ErrorRes(w, errs.Unauthorized)
+				return
+			}
+
+			r.Header.Set("UserId", userId)
+			r.Header.Set(http_api.TokenScopesHeader, strings.Join(scopes, " "))
+
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		// only set by the authorizer
+		r.Header.Del(http_api.TokenScopesHeader)
+
 		c, err := r.Cookie("session")
 
 		if err != nil {
-			http_api.ErrorRes(w, "Unauthorized", http.StatusUnauthorized)
+			http_api.ErrorRes(w, errs.Unauthorized)
 			return
 		}
 
-		sessionId, userId, err := auth.GetSessionValues(c.Value)
-
-		if err != nil || sessionId == "" || userId == "" {
-			http_api.ErrorRes(w, "Unauthorized", http.StatusUnauthorized)
+		userId, cookie, err := auth.AuthorizeSessionCookie(r.Context(), sessionsTable, c.Value, r.UserAgent())
 
+		if err != nil {
+			http_api.ErrorRes(w, errs.Unauthorized)
 			return
 		}
 
-		// token valid, allow
+		// session was rotated, the client must replace its cookie
+		if cookie != nil {
+			http.SetCookie(w, cookie)
+		}
+
+		// session valid, allow
 		r.Header.Set("UserId", userId)
 
 		next.ServeHTTP(w, r)
@@ -48,6 +76,22 @@ func authorizer(next http.Handler) http.Handler {
 
 }
 
+// public health routes of the services, e.g. /notes/ready
+func isHealthPath(p string) bool {
+	service, route, _ := strings.Cut(strings.TrimPrefix(p, "/"), "/")
+
+	return service != "" && (route == "health" || route == "ready")
+}
+
+// exports the metrics of each request (stdout exporter), as the lambdas do for each invocation
+func flushMetrics(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		defer telemetry.Flush(r.Context())
+
+		next.ServeHTTP(w, r)
+	})
+}
+
 func main() {
 
 	// load config
@@ -57,12 +101,11 @@ func main() {
 
 	ddb := db.New()
 	searchIndexTable := db.NewSearchIndexTable()
+	sessionsTable := db.NewSessionTable()
 
 	emailQueue := events.NewEmailQueue()
 	notificationQueue := events.NewNotificationQueue()
-	// client := &http.Client{}
-
-	httpClient := http.DefaultClient
+	usersQueue := events.NewUsersQueue()
 
 	paddle, err := users.NewPaddleSubscriptionClient()
 
@@ -70,20 +113,51 @@ func main() {
 		panic(err)
 	}
 
-	mux.Handle("/auth/", auth.Router(ddb, emailQueue))
-	mux.Handle("/users/", authorizer(users.Router(ddb, emailQueue, httpClient, paddle)))
-	mux.Handle("/spaces/", authorizer(spaces.Router(ddb, notificationQueue)))
-	mux.Handle("/notes/", authorizer(notes.Router(ddb, searchIndexTable, notificationQueue)))
-	mux.Handle("/notifications/", authorizer(notifications.Router(ddb)))
+	authRouter := auth.Router(sessionsTable, emailQueue)
+	usersRouter := users.Router(ddb, searchIndexTable, sessionsTable, emailQueue, usersQueue, notificationQueue, paddle)
+	spacesRouter := spaces.Router(ddb, notificationQueue)
+	notesRouter := notes.Router(ddb, searchIndexTable, notificationQueue)
+	notificationsRouter := notifications.Router(ddb)
+
+	mux.Handle("/auth/", authRouter)
+	mux.Handle("/users/", authorizer(sessionsTable, usersRouter))
+	mux.Handle("/spaces/", authorizer(sessionsTable, spacesRouter))
+	mux.Handle("/notes/", authorizer(sessionsTable, notesRouter))
+	mux.Handle("/notifications/", authorizer(sessionsTable, notificationsRouter))
+
+	// health of all the services, with the email service's config
+	healthRouter := http_api.NewRouter("")
+
+	checks := []health.Check{
+		health.Table(ddb),
+		health.Table(searchIndexTable),
+		health.Table(sessionsTable),
+		health.Queue("email", emailQueue),
+		health.Queue("notifications", notificationQueue),
+		health.Queue("users", usersQueue),
+		health.Config("zepto_mail", map[string]string{"ZEPTO_MAIL_API_KEY": config.ZEPTO_MAIL_API_KEY}),
+		health.Config("paddle", map[string]string{"PADDLE_API_KEY": config.PADDLE_API_KEY, "PADDLE_WEBHOOK_SECRET_KEY": config.PADDLE_WEBHOOK_SECRET_KEY}),
+		health.Config("vapid", map[string]string{"VAPID_PRIVATE_KEY": config.VAPID_PRIVATE_KEY, "VAPID_PUBLIC_KEY": config.VAPID_PUBLIC_KEY}),
+	}
+
+	health.Routes(healthRouter, checks...)
+	health.DiagnosticsRoute(healthRouter, checks...)
+
+	mux.Handle("/health", healthRouter)
+	mux.Handle("/ready", healthRouter)
+	mux.Handle("/diagnostics", authorizer(sessionsTable, healthRouter))
+
+	// api docs of the services
+	mux.Handle("/openapi.json", openapi.Handler(openapi.New("TabsFlow API", "1.0.0", authRouter, usersRouter, spacesRouter, notesRouter, notificationsRouter, healthRouter)))
 
 	// handle unknown service routes
 	mux.Handle("/", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		http_api.ErrorRes(w, "Unknown Service", http.StatusNotFound)
+		http_api.ErrorRes(w, errs.NotFound.WithMessage("Unknown Service"))
 	}))
 
 	fmt.Println("Running auth service on port 8080")
 
-	err = http.ListenAndServe(":8080", mux)
+	err = http.ListenAndServe(":8080", flushMetrics(mux))
 
 	if err != nil {
 		fmt.Println("Error starting server:", err)
@@ -7,6 +7,7 @@ import (
 	"github.com/manishMandal02/tabsflow-backend/pkg/db"
 	"github.com/manishMandal02/tabsflow-backend/pkg/events"
 	"github.com/manishMandal02/tabsflow-backend/pkg/http_api"
+	"github.com/manishMandal02/tabsflow-backend/pkg/telemetry"
 )
 
 func main() {
@@ -24,6 +25,7 @@ func main() {
 
 	handler := http_api.NewAPIGatewayHandlerWithSQSHandler("/notifications/", notifications.Router(ddb), sqsHandler)
 
-	lambda.Start(handler.Handle)
+	// metrics of the invocation are exported before it ends
+	lambda.Start(telemetry.Flushed(handler.Handle))
 
 }
@@ -1,14 +1,13 @@
 package main
 
 import (
-	"net/http"
-
 	"github.com/aws/aws-lambda-go/lambda"
 	"github.com/manishMandal02/tabsflow-backend/config"
 	"github.com/manishMandal02/tabsflow-backend/internal/users"
 	"github.com/manishMandal02/tabsflow-backend/pkg/db"
 	"github.com/manishMandal02/tabsflow-backend/pkg/events"
 	"github.com/manishMandal02/tabsflow-backend/pkg/http_api"
+	"github.com/manishMandal02/tabsflow-backend/pkg/telemetry"
 )
 
 func main() {
@@ -17,9 +16,15 @@ func main() {
 
 	ddb := db.New()
 
+	searchIndexTable := db.NewSearchIndexTable()
+
+	sessionsTable := db.NewSessionTable()
+
 	queue := events.NewEmailQueue()
 
-	httpClient := &http.Client{}
+	usersQueue := events.NewUsersQueue()
+
+	notificationQueue := events.NewNotificationQueue()
 
 	paddle, err := users.NewPaddleSubscriptionClient()
 
@@ -27,8 +32,11 @@ func main() {
 		panic(err)
 	}
 
-	handler := http_api.NewAPIGatewayHandler("/users/", users.Router(ddb, queue, httpClient, paddle))
+	sqsHandler := users.SQSMessagesHandler(usersQueue, queue)
+
+	handler := http_api.NewAPIGatewayHandlerWithSQSHandler("/users/", users.Router(ddb, searchIndexTable, sessionsTable, queue, usersQueue, notificationQueue, paddle), sqsHandler)
 
-	lambda.Start(handler.Handle)
+	// metrics of the invocation are exported before it ends
+	lambda.Start(telemetry.Flushed(handler.Handle))
 
 }
@@ -5,12 +5,14 @@ import (
 	"flag"
 	"log"
 	"os"
+	"strconv"
 
 	"github.com/aws/aws-sdk-go-v2/aws"
 	"github.com/aws/aws-sdk-go-v2/aws/retry"
 	"github.com/aws/aws-sdk-go-v2/config"
 	"github.com/joho/godotenv"
 	"github.com/manishMandal02/tabsflow-backend/pkg/logger"
+	"github.com/manishMandal02/tabsflow-backend/pkg/telemetry"
 )
 
 //TODO: Use transaction for delete/insert operations at critical points, ex: user created, space created,
@@ -21,35 +23,74 @@ import (
 var (
 	AWS_REGION                  string
 	JWT_SECRET_KEY              string
+	JWT_SECRET_KEY_ID           string
+	JWT_PREVIOUS_SECRET_KEYS    string
+	GOOGLE_CLIENT_IDS           string
 	EMAIL_QUEUE_URL             string
 	NOTIFICATIONS_QUEUE_URL     string
+	USERS_QUEUE_URL             string
 	NOTIFICATIONS_QUEUE_ARN     string
 	SCHEDULER_ROLE_ARN          string
 	DDB_MAIN_TABLE_NAME         string
 	DDB_SEARCH_INDEX_TABLE_NAME string
 	DDB_SESSIONS_TABLE_NAME     string
 
+	API_DOMAIN_NAME           string
 	ZEPTO_MAIL_API_KEY        string
 	PADDLE_API_KEY            string
 	PADDLE_WEBHOOK_SECRET_KEY string
 	VAPID_PRIVATE_KEY         string
 	VAPID_PUBLIC_KEY          string
+	// user ids of the admins (comma separated), for the diagnostics routes
+	ADMIN_USER_IDS string
 
 	AWS_CONFIG    aws.Config
 	LOCAL_DEV_ENV = false
 )
 
 const (
-	DEFAULT_SPACE_TITLE      = "TabsFlow - sample space"
-	APP_DOMAIN_NAME          = "tabsflow.com"
-	TRAIL_DAYS               = 14
-	OTP_EXPIRY_TIME_IN_MIN   = 5
+	DEFAULT_SPACE_TITLE    = "TabsFlow - sample space"
+	APP_DOMAIN_NAME        = "tabsflow.com"
+	TRAIL_DAYS             = 14
+	OTP_EXPIRY_TIME_IN_MIN = 5
+	// email is locked out of otp login after the failed attempts
+	OTP_MAX_FAILED_ATTEMPTS       = 5
+	OTP_LOCKOUT_TIME_IN_MIN       = 15
+	OTP_RESEND_COOLDOWN_SEC       = 60
+	MAGIC_LINK_EXPIRY_TIME_IN_MIN = 15
+	PASSKEY_CHALLENGE_TIMEOUT_SEC = 300
+	// max requests to /auth/* per client ip
+	AUTH_RATE_LIMIT_PER_MIN  = 30
 	JWT_TOKEN_EXPIRY_IN_DAYS = 10
 	USER_SESSION_EXPIRY_DAYS = 360
-	DATE_TIME_FORMAT         = "2006-01-02T15:04:05"
-	ZEPTO_MAIL_API_URL       = "https://api.zeptomail.in/v1.1/email/template"
+	// session is rotated (replaced with a new one) after the refresh interval
+	SESSION_REFRESH_INTERVAL_HOURS = 24
+	// rotated session is still accepted for concurrent requests within the grace period
+	SESSION_ROTATION_GRACE_SEC = 30
+	// rotated sessions are kept to detect their reuse
+	SESSION_REUSE_DETECTION_DAYS   = 7
+	SESSION_LAST_SEEN_INTERVAL_MIN = 5
+	// revoked sessions & api tokens stay valid on a warm authorizer till the cached response expires;
+	// shorter than the rotation grace period, so the reuse of a rotated cookie is still detected
+	AUTHORIZER_CACHE_TTL_SEC = 10
+	// personal api tokens
+	API_TOKEN_DEFAULT_EXPIRY_DAYS = 30
+	API_TOKEN_MAX_EXPIRY_DAYS     = 365
+	API_TOKEN_MAX_PER_USER        = 20
+	DATE_TIME_FORMAT              = "2006-01-02T15:04:05"
+	ZEPTO_MAIL_API_URL            = "https://api.zeptomail.in/v1.1/email/template"
+	ZEPTO_MAIL_HTML_API_URL       = "https://api.zeptomail.in/v1.1/email"
+	GOOGLE_JWKS_URL               = "https://www.googleapis.com/oauth2/v3/certs"
+	DATA_EXPORT_EXPIRY_DAYS       = 7
+	// browsers cache the CORS preflight response for the max age
+	CORS_MAX_AGE_SEC = 600
+	// unsigned session cookies are accepted until this date
+	LEGACY_SESSION_COOKIE_UNTIL = "2027-01-31"
 )
 
+// request headers allowed by the CORS preflight response
+var CORSAllowedHeaders = []string{"Content-Type", "Authorization"}
+
 var AllowedOrigins = []string{"chrome-extension://eidcobgdojgmpdkaajefdgniiaklpfno", "https://local.tabsflow.com:3000", "https://tabsflow.com", "https://app.tabsflow.com"}
 
 func Init() {
@@ -88,6 +129,7 @@ func Init() {
 		DDB_SESSIONS_TABLE_NAME = "TabsFlow-Sessions_dev"
 		EMAIL_QUEUE_URL = "TabsFlow-Emails_dev"
 		NOTIFICATIONS_QUEUE_URL = "TabsFlow-Notifications_dev"
+		USERS_QUEUE_URL = "TabsFlow-Users_dev"
 	} else {
 		// lambda config
 		config, err := config.LoadDefaultConfig(context.Background(),
@@ -108,15 +150,60 @@ func Init() {
 		DDB_SEARCH_INDEX_TABLE_NAME = os.Getenv("DDB_SEARCH_INDEX_TABLE_NAME")
 		EMAIL_QUEUE_URL = os.Getenv("EMAIL_QUEUE_URL")
 		NOTIFICATIONS_QUEUE_URL = os.Getenv("NOTIFICATIONS_QUEUE_URL")
+		USERS_QUEUE_URL = os.Getenv("USERS_QUEUE_URL")
 		SCHEDULER_ROLE_ARN = os.Getenv("SCHEDULER_ROLE_ARN")
 		NOTIFICATIONS_QUEUE_ARN = os.Getenv("NOTIFICATIONS_QUEUE_ARN")
 	}
 
 	AWS_REGION = os.Getenv("AWS_REGION")
 	JWT_SECRET_KEY = os.Getenv("JWT_SECRET_KEY")
+	JWT_SECRET_KEY_ID = os.Getenv("JWT_SECRET_KEY_ID")
+	JWT_PREVIOUS_SECRET_KEYS = os.Getenv("JWT_PREVIOUS_SECRET_KEYS")
+	GOOGLE_CLIENT_IDS = os.Getenv("GOOGLE_CLIENT_IDS")
+	API_DOMAIN_NAME = os.Getenv("API_DOMAIN_NAME")
 	ZEPTO_MAIL_API_KEY = os.Getenv("ZEPTO_MAIL_API_KEY")
 	PADDLE_API_KEY = os.Getenv("PADDLE_API_KEY")
 	PADDLE_WEBHOOK_SECRET_KEY = os.Getenv("PADDLE_WEBHOOK_SECRET_KEY")
 	VAPID_PRIVATE_KEY = os.Getenv("VAPID_PRIVATE_KEY")
 	VAPID_PUBLIC_KEY = os.Getenv("VAPID_PUBLIC_KEY")
+	ADMIN_USER_IDS = os.Getenv("ADMIN_USER_IDS")
+
+	// set after the .env is loaded, in local development
+	logger.SetLevel(os.Getenv("LOG_LEVEL"))
+	telemetry.SetExporter(os.Getenv("TELEMETRY_EXPORTER"))
+}
+
+// config values for the diagnostics, the secrets are only shown as set or not
+func Redacted() map[string]string {
+	secret := func(v string) string {
+		if v == "" {
+			return ""
+		}
+
+		return "[redacted]"
+	}
+
+	return map[string]string{
+		"AWS_REGION":                  AWS_REGION,
+		"LOCAL_DEV_ENV":               strconv.FormatBool(LOCAL_DEV_ENV),
+		"API_DOMAIN_NAME":             API_DOMAIN_NAME,
+		"DDB_MAIN_TABLE_NAME":         DDB_MAIN_TABLE_NAME,
+		"DDB_SEARCH_INDEX_TABLE_NAME": DDB_SEARCH_INDEX_TABLE_NAME,
+		"DDB_SESSIONS_TABLE_NAME":     DDB_SESSIONS_TABLE_NAME,
+		"EMAIL_QUEUE_URL":             EMAIL_QUEUE_URL,
+		"NOTIFICATIONS_QUEUE_URL":     NOTIFICATIONS_QUEUE_URL,
+		"USERS_QUEUE_URL":             USERS_QUEUE_URL,
+		"NOTIFICATIONS_QUEUE_ARN":     NOTIFICATIONS_QUEUE_ARN,
+		"SCHEDULER_ROLE_ARN":          SCHEDULER_ROLE_ARN,
+		"GOOGLE_CLIENT_IDS":           GOOGLE_CLIENT_IDS,
+		"JWT_SECRET_KEY_ID":           JWT_SECRET_KEY_ID,
+		"VAPID_PUBLIC_KEY":            VAPID_PUBLIC_KEY,
+		"ADMIN_USER_IDS":              ADMIN_USER_IDS,
+		"JWT_SECRET_KEY":              secret(JWT_SECRET_KEY),
+		"JWT_PREVIOUS_SECRET_KEYS":    secret(JWT_PREVIOUS_SECRET_KEYS),
+		"ZEPTO_MAIL_API_KEY":          secret(ZEPTO_MAIL_API_KEY),
+		"PADDLE_API_KEY":              secret(PADDLE_API_KEY),
+		"PADDLE_WEBHOOK_SECRET_KEY":   secret(PADDLE_WEBHOOK_SECRET_KEY),
+		"VAPID_PRIVATE_KEY":           secret(VAPID_PRIVATE_KEY),
+	}
 }
@@ -41,7 +41,10 @@ const DynamoDB = {
   SearchIndexTableName: `${AppName}-SearchIndex_${getEnv('DEPLOY_STAGE')}`,
   PrimaryKey: 'PK',
   SortKey: 'SK',
-  TTL: 'TTL'
+  TTL: 'TTL',
+  // sparse index of the users' delete unsaved spaces preference, for the daily cleanup
+  DeleteUnsavedSpacesIndex: 'DeleteUnsavedSpaces-index',
+  DeleteUnsavedSpacesAttribute: 'DeleteUnsavedSpaces'
 } as const;
 
 const ssmParamNameBase = `/${AppName.toLowerCase()}/${getEnv('DEPLOY_STAGE')}`;
@@ -68,10 +71,17 @@ const Env = {
   AWS_REGION: getEnv('AWS_REGION'),
   DEPLOY_STAGE: getEnv('DEPLOY_STAGE'),
   JWT_SECRET_KEY: getEnv('JWT_SECRET_KEY'),
+  // optional, for rotating the session signing key
+  JWT_SECRET_KEY_ID: process.env.JWT_SECRET_KEY_ID ?? '',
+  JWT_PREVIOUS_SECRET_KEYS: process.env.JWT_PREVIOUS_SECRET_KEYS ?? '',
   API_DOMAIN_NAME: getEnv('API_DOMAIN_NAME'),
+  // audience of the google id tokens (extension & web client ids, comma separated)
+  GOOGLE_CLIENT_IDS: getEnv('GOOGLE_CLIENT_IDS'),
   VAPID_PUBLIC_KEY: getEnv('VAPID_PUBLIC_KEY'),
   VAPID_PRIVATE_KEY: getEnv('VAPID_PRIVATE_KEY'),
-  ZEPTO_MAIL_API_KEY: getEnv('ZEPTO_MAIL_API_KEY')
+  ZEPTO_MAIL_API_KEY: getEnv('ZEPTO_MAIL_API_KEY'),
+  // optional, user ids of the admins (comma separated)
+  ADMIN_USER_IDS: process.env.ADMIN_USER_IDS ?? ''
 } as const;
 
 const AllowedOrigins = [
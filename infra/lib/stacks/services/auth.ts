@@ -18,7 +18,7 @@ export class AuthService extends Construct {
   constructor(scope: Construct, props: AuthServiceProps, id: string = 'AuthService') {
     super(scope, id);
 
-    const { JWT_SECRET_KEY } = config.Env;
+    const { JWT_SECRET_KEY, JWT_SECRET_KEY_ID, JWT_PREVIOUS_SECRET_KEYS, GOOGLE_CLIENT_IDS } = config.Env;
 
     const authLambdaName = id + '_' + props.stage;
 
@@ -34,6 +34,11 @@ export class AuthService extends Construct {
       architecture: config.Lambda.Architecture,
       environment: {
         JWT_SECRET_KEY,
+        JWT_SECRET_KEY_ID,
+        JWT_PREVIOUS_SECRET_KEYS,
+        GOOGLE_CLIENT_IDS,
+        // magic link urls
+        API_DOMAIN_NAME: config.Env.API_DOMAIN_NAME,
         EMAIL_QUEUE_URL: props.emailQueue.queueUrl,
         DDB_SESSIONS_TABLE_NAME: props.sessionsDB.tableName
       }
@@ -63,6 +68,8 @@ export class AuthService extends Construct {
       bundling: config.Lambda.GoBundling,
       environment: {
         JWT_SECRET_KEY,
+        JWT_SECRET_KEY_ID,
+        JWT_PREVIOUS_SECRET_KEYS,
         DDB_SESSIONS_TABLE_NAME: props.sessionsDB.tableName
       }
     });
@@ -73,8 +80,10 @@ export class AuthService extends Construct {
     const authorizer = new apiGateway.RequestAuthorizer(this, authorizerName, {
       authorizerName,
       handler: authorizerLambda,
-      identitySources: [apiGateway.IdentitySource.header('Cookie')],
-      resultsCacheTtl: Duration.minutes(5)
+      // requests are authorized by the session cookie or an api token (Authorization header),
+      // identity sources are all required so they can't be set; the authorizer caches the responses in memory
+      identitySources: [],
+      resultsCacheTtl: Duration.seconds(0)
     });
 
     props.sessionsDB.grantReadWriteData(authorizerLambda);
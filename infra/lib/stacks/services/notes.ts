@@ -33,7 +33,9 @@ export class NotesService extends Construct {
       environment: {
         DDB_MAIN_TABLE_NAME: props.mainDB.tableName,
         DDB_SEARCH_INDEX_TABLE_NAME: props.searchIndexDB.tableName,
-        NOTIFICATIONS_QUEUE_URL: props.notificationQueue.queueUrl
+        NOTIFICATIONS_QUEUE_URL: props.notificationQueue.queueUrl,
+        // user ids of the admins, for the diagnostics route
+        ADMIN_USER_IDS: config.Env.ADMIN_USER_IDS
       }
     });
 
@@ -50,6 +52,13 @@ export class NotesService extends Construct {
       authorizer: props.apiAuthorizer
     });
 
+    // health & readiness routes are public, without the authorizer
+    for (const path of ['health', 'ready']) {
+      notesResource
+        .addResource(path)
+        .addMethod('GET', new aws_apigateway.LambdaIntegration(notesServiceLambda));
+    }
+
     // add proxy resource
     const proxyResource = notesResource.addProxy({ anyMethod: false });
 
@@ -7,7 +7,8 @@ import {
   Duration,
   aws_sqs as sqs,
   Stack,
-  RemovalPolicy
+  RemovalPolicy,
+  aws_scheduler as scheduler
 } from 'aws-cdk-lib';
 import { config } from '../../../config';
 import { GoFunction } from '@aws-cdk/aws-lambda-go-alpha';
@@ -54,6 +55,18 @@ export class NotificationsService extends Construct {
 
     notificationsQueue.grantSendMessages(schedulerExecutionRole);
 
+    // daily schedule to clean up the unsaved spaces of all users
+    new scheduler.CfnSchedule(this, 'CleanupUnsavedSpacesSchedule', {
+      name: `${config.AppName}-CleanupUnsavedSpaces_${props.stage}`,
+      scheduleExpression: 'cron(0 3 * * ? *)',
+      flexibleTimeWindow: { mode: 'OFF' },
+      target: {
+        arn: notificationsQueue.queueArn,
+        roleArn: schedulerExecutionRole.roleArn,
+        input: JSON.stringify({ event_type: 'cleanup_unsaved_spaces', payload: {} })
+      }
+    });
+
     const notificationsServiceLambdaName = `${id}_${props.stage}`;
 
     const notificationsServiceLambda = new GoFunction(this, notificationsServiceLambdaName, {
@@ -72,7 +85,9 @@ export class NotificationsService extends Construct {
         SCHEDULER_ROLE_ARN: schedulerExecutionRole.roleArn,
         NOTIFICATIONS_QUEUE_URL: notificationsQueue.queueUrl,
         VAPID_PRIVATE_KEY: config.Env.VAPID_PRIVATE_KEY,
-        VAPID_PUBLIC_KEY: config.Env.VAPID_PUBLIC_KEY
+        VAPID_PUBLIC_KEY: config.Env.VAPID_PUBLIC_KEY,
+        // user ids of the admins, for the diagnostics route
+        ADMIN_USER_IDS: config.Env.ADMIN_USER_IDS
       }
     });
 
@@ -130,6 +145,13 @@ export class NotificationsService extends Construct {
       authorizer: props.apiAuthorizer
     });
 
+    // health & readiness routes are public, without the authorizer
+    for (const path of ['health', 'ready']) {
+      notificationsResource
+        .addResource(path)
+        .addMethod('GET', new aws_apigateway.LambdaIntegration(notificationsServiceLambda));
+    }
+
     // add proxy resource
     const proxyResource = notificationsResource.addProxy({ anyMethod: false });
 
@@ -23,6 +23,10 @@ export class RestApi extends Construct {
         allowMethods: apiGateway.Cors.ALL_METHODS,
         allowCredentials: true
       },
+      // data export downloads & imports; API Gateway only decodes the base64 body when the Accept (or Content-Type)
+      // header matches a binary media type, a browser opening the download link sends Accept: text/html,...
+      // (the lambdas decode base64 request bodies & only base64 encode the binary responses)
+      binaryMediaTypes: ['*/*'],
       deployOptions: {
         stageName: props.stage
       }
@@ -55,7 +55,11 @@ export class ServiceStack extends Stack {
 
     // get Dynamodb tables form ARNs
 
-    const mainDB: aws_dynamodb.ITable = aws_dynamodb.Table.fromTableArn(this, 'MainTableAr', mainTableArn);
+    // with the indexes, so the grants include them
+    const mainDB: aws_dynamodb.ITable = aws_dynamodb.Table.fromTableAttributes(this, 'MainTableAr', {
+      tableArn: mainTableArn,
+      globalIndexes: [config.DynamoDB.DeleteUnsavedSpacesIndex]
+    });
     const searchIndexDB = aws_dynamodb.Table.fromTableArn(this, 'SearchIndexTable', searchIndexTableArn);
     const sessionsDB: aws_dynamodb.ITable = aws_dynamodb.Table.fromTableArn(
       this,
@@ -104,10 +108,14 @@ export class ServiceStack extends Stack {
     new UsersService(this, {
       lambdaRole,
       db: mainDB,
+      searchIndexDB,
+      sessionsDB,
       stage: props.stage,
       apiGW: apiG.restAPI,
       apiAuthorizer: authService.apiAuthorizer,
-      emailQueue: emailService.Queue
+      emailQueue: emailService.Queue,
+      notificationQueue: notificationsService.Queue,
+      removalPolicy: props.removalPolicy
     });
 
     new SpacesService(this, {
@@ -31,7 +31,9 @@ export class SpacesService extends Construct {
       bundling: config.Lambda.GoBundling,
       environment: {
         DDB_MAIN_TABLE_NAME: props.db.tableName,
-        NOTIFICATIONS_QUEUE_URL: props.notificationQueue.queueUrl
+        NOTIFICATIONS_QUEUE_URL: props.notificationQueue.queueUrl,
+        // user ids of the admins, for the diagnostics route
+        ADMIN_USER_IDS: config.Env.ADMIN_USER_IDS
       }
     });
 
@@ -47,6 +49,13 @@ export class SpacesService extends Construct {
       authorizer: props.apiAuthorizer
     });
 
+    // health & readiness routes are public, without the authorizer
+    for (const path of ['health', 'ready']) {
+      spacesResource
+        .addResource(path)
+        .addMethod('GET', new aws_apigateway.LambdaIntegration(spaceServiceLambda));
+    }
+
     // add proxy resource
     const proxyResource = spacesResource.addProxy({ anyMethod: false });
 
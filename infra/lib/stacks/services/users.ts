@@ -1,8 +1,9 @@
 import { Construct } from 'constructs';
 
 import { GoFunction } from '@aws-cdk/aws-lambda-go-alpha';
-import { aws_apigateway, aws_dynamodb, aws_iam } from 'aws-cdk-lib';
+import { aws_apigateway, aws_dynamodb, aws_iam, Duration, RemovalPolicy } from 'aws-cdk-lib';
 import * as sqs from 'aws-cdk-lib/aws-sqs';
+import * as eventSources from 'aws-cdk-lib/aws-lambda-event-sources';
 
 import { config } from '../../../config';
 
@@ -10,15 +11,37 @@ type UsersServiceProps = {
   stage: string;
   apiGW: aws_apigateway.RestApi;
   db: aws_dynamodb.ITable;
+  searchIndexDB: aws_dynamodb.ITable;
+  sessionsDB: aws_dynamodb.ITable;
   lambdaRole: aws_iam.Role;
   emailQueue: sqs.Queue;
+  notificationQueue: sqs.Queue;
   apiAuthorizer: aws_apigateway.RequestAuthorizer;
+  removalPolicy: RemovalPolicy;
 };
 
 export class UsersService extends Construct {
   constructor(scope: Construct, props: UsersServiceProps, id = 'UsersService') {
     super(scope, id);
 
+    const queueName = `${config.AppName}-Users_${props.stage}`;
+
+    // sqs queue for background jobs (data export, account deletion)
+    const dlqUsers = new sqs.Queue(this, queueName + '-dlq', {
+      visibilityTimeout: Duration.seconds(300),
+      removalPolicy: props.removalPolicy
+    });
+
+    const usersQueue = new sqs.Queue(this, queueName, {
+      queueName,
+      visibilityTimeout: Duration.seconds(300),
+      deadLetterQueue: {
+        queue: dlqUsers,
+        maxReceiveCount: 3
+      },
+      removalPolicy: props.removalPolicy
+    });
+
     const userServiceLambdaName = `${id}_${props.stage}`;
     const usersServiceLambda = new GoFunction(this, userServiceLambdaName, {
       functionName: userServiceLambdaName,
@@ -32,13 +55,39 @@ export class UsersService extends Construct {
       bundling: config.Lambda.GoBundling,
       environment: {
         EMAIL_QUEUE_URL: props.emailQueue.queueUrl,
-        DDB_MAIN_TABLE_NAME: props.db.tableName
+        USERS_QUEUE_URL: usersQueue.queueUrl,
+        NOTIFICATIONS_QUEUE_URL: props.notificationQueue.queueUrl,
+        DDB_MAIN_TABLE_NAME: props.db.tableName,
+        DDB_SEARCH_INDEX_TABLE_NAME: props.searchIndexDB.tableName,
+        DDB_SESSIONS_TABLE_NAME: props.sessionsDB.tableName,
+        // signs the data export download links
+        JWT_SECRET_KEY: config.Env.JWT_SECRET_KEY,
+        API_DOMAIN_NAME: config.Env.API_DOMAIN_NAME,
+        // user ids of the admins, for the diagnostics route
+        ADMIN_USER_IDS: config.Env.ADMIN_USER_IDS
       }
     });
 
+    usersServiceLambda.addEventSource(
+      new eventSources.SqsEventSource(usersQueue, {
+        batchSize: 1
+      })
+    );
+
+    // sqs queue permissions
+    usersQueue.grantConsumeMessages(usersServiceLambda);
+    usersQueue.grantSendMessages(usersServiceLambda);
+
     // grant permissions to lambda to read/write to dynamodb and send message to email queue
     props.db.grantReadWriteData(usersServiceLambda);
 
+    // account data import re-indexes the notes & schedules the remainders
+    props.searchIndexDB.grantReadWriteData(usersServiceLambda);
+    props.notificationQueue.grantSendMessages(usersServiceLambda);
+
+    // account deletion removes the user's sessions
+    props.sessionsDB.grantReadWriteData(usersServiceLambda);
+
     props.emailQueue.grantSendMessages(usersServiceLambda);
 
     // add users resource/endpoints to api gateway
@@ -49,6 +98,13 @@ export class UsersService extends Construct {
       authorizer: props.apiAuthorizer
     });
 
+    // health & readiness routes are public, without the authorizer
+    for (const path of ['health', 'ready']) {
+      usersResource
+        .addResource(path)
+        .addMethod('GET', new aws_apigateway.LambdaIntegration(usersServiceLambda));
+    }
+
     // add proxy resource
     const proxyResource = usersResource.addProxy({ anyMethod: false });
 
@@ -57,5 +113,18 @@ export class UsersService extends Construct {
       authorizationType: aws_apigateway.AuthorizationType.CUSTOM,
       authorizer: props.apiAuthorizer
     });
+
+    // the static export resource takes precedence over the proxy, the export route needs its own authorized method
+    const exportResource = usersResource.addResource('export');
+
+    exportResource.addMethod('GET', new aws_apigateway.LambdaIntegration(usersServiceLambda), {
+      authorizationType: aws_apigateway.AuthorizationType.CUSTOM,
+      authorizer: props.apiAuthorizer
+    });
+
+    // data export download link is opened from email without session cookie, authorized by the signed token
+    exportResource
+      .addResource('download')
+      .addMethod('GET', new aws_apigateway.LambdaIntegration(usersServiceLambda));
   }
 }
@@ -33,6 +33,20 @@ export class StatefulStack extends Stack {
       ...commonTableProps
     });
 
+    // only the general preferences items have the attribute, the cleanup job queries the users by their preference
+    mainTable.addGlobalSecondaryIndex({
+      indexName: config.DynamoDB.DeleteUnsavedSpacesIndex,
+      partitionKey: {
+        name: config.DynamoDB.DeleteUnsavedSpacesAttribute,
+        type: aws_dynamodb.AttributeType.STRING
+      },
+      sortKey: {
+        name: config.DynamoDB.PrimaryKey,
+        type: aws_dynamodb.AttributeType.STRING
+      },
+      projectionType: aws_dynamodb.ProjectionType.KEYS_ONLY
+    });
+
     const searchIndexTable = new aws_dynamodb.Table(this, config.DynamoDB.SearchIndexTableName, {
       tableName: config.DynamoDB.SearchIndexTableName,
       pointInTimeRecovery: props.removalPolicy === RemovalPolicy.RETAIN,
@@ -103,6 +103,15 @@ describe('ServiceStack', () => {
       baseURL: 'users',
       hasAuthorization: true
     });
+
+    // the static /users/export resource (parent of the public download) is authorized, not left without methods
+    template.hasResourceProperties('AWS::ApiGateway::Method', {
+      HttpMethod: 'GET',
+      AuthorizationType: 'CUSTOM',
+      ResourceId: {
+        Ref: Match.stringLikeRegexp('usersexport')
+      }
+    });
   });
 
   test('SpacesService', () => {
@@ -44,7 +44,17 @@ test('StatefulStack', () => {
   });
   template.hasResourceProperties('AWS::DynamoDB::Table', {
     TableName: `${config.DynamoDB.MainTableName}`,
-    ...ddbPros
+    ...ddbPros,
+    GlobalSecondaryIndexes: [
+      {
+        IndexName: config.DynamoDB.DeleteUnsavedSpacesIndex,
+        KeySchema: [
+          { AttributeName: config.DynamoDB.DeleteUnsavedSpacesAttribute, KeyType: 'HASH' },
+          { AttributeName: config.DynamoDB.PrimaryKey, KeyType: 'RANGE' }
+        ],
+        Projection: { ProjectionType: 'KEYS_ONLY' }
+      }
+    ]
   });
 
   template.hasResourceProperties('AWS::DynamoDB::Table', {
@@ -0,0 +1,115 @@
+package auth
+
+import (
+	"context"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/hex"
+	"strings"
+	"time"
+
+	lambda_events "github.com/aws/aws-lambda-go/events"
+
+	"github.com/manishMandal02/tabsflow-backend/config"
+	"github.com/manishMandal02/tabsflow-backend/pkg/logger"
+	"github.com/manishMandal02/tabsflow-backend/pkg/utils"
+)
+
+// * personal api tokens
+// token format: tfp.{base64(userId)}.{tokenId}.{secret}, only the secret hash is saved
+
+const apiTokenPrefix = "tfp"
+
+// returns the token & the hash of its secret
+func newAPITokenValue(userId, id string) (string, string) {
+	secret := utils.GenerateRandomString(64)
+
+	token := strings.Join([]string{apiTokenPrefix, base64.RawURLEncoding.EncodeToString([]byte(userId)), id, secret}, ".")
+
+	return token, hashAPITokenSecret(secret)
+}
+
+func hashAPITokenSecret(secret string) string {
+	h := sha256.Sum256([]byte(secret))
+	return hex.EncodeToString(h[:])
+}
+
+// returns the userId, token id & secret
+func parseAPIToken(token string) (string, string, string, error) {
+	parts := strings.Split(token, ".")
+
+	if len(parts) != 4 || parts[0] != apiTokenPrefix || parts[2] == "" || parts[3] == "" {
+		return "", "", "", errInvalidAPIToken
+	}
+
+	userId, err := base64.RawURLEncoding.DecodeString(parts[1])
+
+	if err != nil || len(userId) == 0 {
+		return "", "", "", errInvalidAPIToken
+	}
+
+	return string(userId), parts[2], parts[3], nil
+}
+
+// token from the `Authorization: Bearer` header, empty if not set
+func bearerToken(header string) string {
+	scheme, token, ok := strings.Cut(strings.TrimSpace(header), " ")
+
+	if !ok || !strings.EqualFold(scheme, "Bearer") {
+		return ""
+	}
+
+	return strings.TrimSpace(token)
+}
+
+// validates the token secret & expiry, returns the saved token
+func authorizeAPIToken(ctx context.Context, token string, aR authRepository) (*apiToken, error) {
+	userId, id, secret, err := parseAPIToken(token)
+
+	if err != nil {
+		return nil, err
+	}
+
+	t, err := aR.getAPIToken(ctx, userId, id)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if t == nil {
+		return nil, errInvalidAPIToken
+	}
+
+	if subtle.ConstantTimeCompare([]byte(t.Hash), []byte(hashAPITokenSecret(secret))) != 1 {
+		return nil, errInvalidAPIToken
+	}
+
+	now := time.Now().Unix()
+
+	// expired tokens are not removed by ttl immediately,
+	// a token without scopes would be authorized as a session
+	if t.ExpiresAt < now || len(t.Scopes) == 0 {
+		return nil, errInvalidAPIToken
+	}
+
+	if now-t.LastUsedAt >= int64((time.Minute * config.SESSION_LAST_SEEN_INTERVAL_MIN).Seconds()) {
+		err = aR.updateAPITokenLastUsed(ctx, userId, id)
+
+		// last used is informational, don't block the request
+		if err != nil {
+			logger.Errorf("Couldn't update api token last used, userId: %v. \n[Error]: %v", userId, err)
+		}
+	}
+
+	return t, nil
+}
+
+// authorizer response for an api token request, with the token scopes in the context
+func apiTokenPolicy(t *apiToken, methodArn string) *lambda_events.APIGatewayCustomAuthorizerResponse {
+	res := generatePolicy(t.UserId, "Allow", methodArn, t.UserId, nil)
+
+	res.Context["Scopes"] = strings.Join(t.Scopes, " ")
+
+	return res
+}
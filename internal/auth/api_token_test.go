@@ -0,0 +1,120 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+// in-memory api tokens of a user
+type apiTokensRepoMock struct {
+	authRepository
+	tokens   map[string]*apiToken
+	lastUsed map[string]bool
+}
+
+func (m *apiTokensRepoMock) getAPIToken(_ context.Context, _, id string) (*apiToken, error) {
+	t, ok := m.tokens[id]
+
+	if !ok {
+		return nil, nil
+	}
+
+	c := *t
+	return &c, nil
+}
+
+func (m *apiTokensRepoMock) updateAPITokenLastUsed(_ context.Context, _, id string) error {
+	m.lastUsed[id] = true
+	return nil
+}
+
+func TestAuthorizeAPIToken(t *testing.T) {
+	m := &apiTokensRepoMock{tokens: map[string]*apiToken{}, lastUsed: map[string]bool{}}
+
+	newToken := func(id string, expiresAt int64, scopes ...string) string {
+		token, hash := newAPITokenValue("user-1", id)
+
+		m.tokens[id] = &apiToken{
+			UserId:    "user-1",
+			Id:        id,
+			Hash:      hash,
+			Scopes:    scopes,
+			ExpiresAt: expiresAt,
+		}
+
+		return token
+	}
+
+	valid := newToken("token-1", time.Now().Add(time.Hour).Unix(), "read:spaces")
+	expired := newToken("token-2", time.Now().Add(-time.Hour).Unix(), "read:spaces")
+	noScopes := newToken("token-3", time.Now().Add(time.Hour).Unix())
+
+	// same id, different secret
+	wrongSecret := valid[:len(valid)-4] + "0000"
+
+	if wrongSecret == valid {
+		wrongSecret = valid[:len(valid)-4] + "1111"
+	}
+
+	tests := []struct {
+		name    string
+		token   string
+		wantErr bool
+	}{
+		{name: "valid", token: valid},
+		{name: "wrong secret", token: wrongSecret, wantErr: true},
+		{name: "expired", token: expired, wantErr: true},
+		{name: "without scopes", token: noScopes, wantErr: true},
+		{name: "unknown token", token: "tfp.dXNlci0x.token-4.secret", wantErr: true},
+		{name: "malformed", token: "not-a-token", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			token, err := authorizeAPIToken(context.Background(), tt.token, m)
+
+			if tt.wantErr {
+				if !errors.Is(err, errInvalidAPIToken) {
+					t.Fatalf("authorizeAPIToken(context.Background(), ) error = %v, wantErr %v", err, errInvalidAPIToken)
+				}
+				return
+			}
+
+			if err != nil {
+				t.Fatalf("authorizeAPIToken(context.Background(), ) unexpected error = %v", err)
+			}
+
+			if token.UserId != "user-1" || token.Id != "token-1" {
+				t.Errorf("authorizeAPIToken(context.Background(), ) token = %+v", token)
+			}
+		})
+	}
+
+	if !m.lastUsed["token-1"] {
+		t.Errorf("authorizeAPIToken(context.Background(), ) didn't update last used")
+	}
+
+	res := apiTokenPolicy(m.tokens["token-1"], "arn:aws:execute-api:us-east-1:123:api-id/stage/GET/spaces")
+
+	if res.Context["Scopes"] != "read:spaces" || res.Context["UserId"] != "user-1" {
+		t.Errorf("apiTokenPolicy() context = %v", res.Context)
+	}
+}
+
+func TestBearerToken(t *testing.T) {
+	tests := map[string]string{
+		"Bearer tfp.a.b.c": "tfp.a.b.c",
+		"bearer tfp.a.b.c": "tfp.a.b.c",
+		"Basic dXNlcg==":   "",
+		"tfp.a.b.c":        "",
+		"":                 "",
+	}
+
+	for header, want := range tests {
+		if got := bearerToken(header); got != want {
+			t.Errorf("bearerToken(%q) = %q, want %q", header, got, want)
+		}
+	}
+}
@@ -1,11 +1,67 @@
 package auth
 
+import "github.com/manishMandal02/tabsflow-backend/pkg/errs"
+
 type emailOTP struct {
 	Email string `json:"email" dynamodbav:"PK"`
 	OTP   string `json:"otp"`
 	TTL   int64  `json:"ttl"`
 }
 
+// failed otp attempts & last sent time for an email
+type otpAttempts struct {
+	Email       string `json:"email" dynamodbav:"PK"`
+	Failures    int    `json:"failures" dynamodbav:"Failures"`
+	LockedUntil int64  `json:"lockedUntil" dynamodbav:"LockedUntil"`
+	LastSentAt  int64  `json:"lastSentAt" dynamodbav:"LastSentAt"`
+	TTL         int64  `json:"ttl" dynamodbav:"TTL"`
+}
+
+// single use login link sent to the email
+type magicLink struct {
+	Email string `json:"email" dynamodbav:"PK"`
+	Id    string `json:"id" dynamodbav:"SK"`
+	// app origin to redirect to, after login
+	Origin string `json:"origin" dynamodbav:"Origin"`
+	TTL    int64  `json:"ttl" dynamodbav:"TTL"`
+}
+
+// webauthn credential of the user
+type passkey struct {
+	UserId string `json:"-" dynamodbav:"PK"`
+	// base64url credential id
+	Id string `json:"id" dynamodbav:"SK"`
+	// PKIX public key (base64)
+	PublicKey  string `json:"-" dynamodbav:"PublicKey"`
+	Alg        int64  `json:"-" dynamodbav:"Alg"`
+	SignCount  uint32 `json:"-" dynamodbav:"SignCount"`
+	Name       string `json:"name" dynamodbav:"Name"`
+	CreatedAt  int64  `json:"createdAt" dynamodbav:"CreatedAt"`
+	LastUsedAt int64  `json:"lastUsedAt" dynamodbav:"LastUsedAt"`
+}
+
+// challenge of a passkey registration/login ceremony
+type passkeyChallenge struct {
+	Challenge string `json:"challenge" dynamodbav:"PK"`
+	Ceremony  string `json:"ceremony" dynamodbav:"Ceremony"`
+	// set for registration
+	UserId string `json:"userId" dynamodbav:"UserId"`
+	TTL    int64  `json:"ttl" dynamodbav:"TTL"`
+}
+
+// personal api token, for scripts & integrations
+type apiToken struct {
+	UserId string `json:"-" dynamodbav:"PK"`
+	Id     string `json:"id" dynamodbav:"SK"`
+	Name   string `json:"name" dynamodbav:"Name"`
+	// sha256 of the token secret, the token is shown only once
+	Hash       string   `json:"-" dynamodbav:"Hash"`
+	Scopes     []string `json:"scopes" dynamodbav:"Scopes"`
+	CreatedAt  int64    `json:"createdAt" dynamodbav:"CreatedAt"`
+	LastUsedAt int64    `json:"lastUsedAt" dynamodbav:"LastUsedAt"`
+	ExpiresAt  int64    `json:"expiresAt" dynamodbav:"TTL"`
+}
+
 type emailWithUserId struct {
 	Email  string `json:"email" dynamodbav:"PK"`
 	UserId string `json:"userId" dynamodbav:"SK"`
@@ -23,6 +79,83 @@ type session struct {
 	Id         string      `json:"id" dynamodbav:"SK"`
 	TTL        int64       `json:"ttl" dynamodbav:"TTL"`
 	DeviceInfo *deviceInfo `json:"deviceInfo" dynamodbav:"DeviceInfo"`
+	CreatedAt  int64       `json:"createdAt" dynamodbav:"CreatedAt"`
+	LastSeenAt int64       `json:"lastSeenAt" dynamodbav:"LastSeenAt"`
+	// id of the first session (login), shared by all its rotated sessions
+	FamilyId string `json:"familyId" dynamodbav:"FamilyId"`
+	// time the session was issued, a rotated session keeps the CreatedAt of its family
+	IssuedAt int64 `json:"issuedAt" dynamodbav:"IssuedAt"`
+	// set after the session is rotated
+	ReplacedBy string `json:"replacedBy" dynamodbav:"ReplacedBy"`
+	RotatedAt  int64  `json:"rotatedAt" dynamodbav:"RotatedAt"`
+}
+
+func (s *session) family() string {
+	if s.FamilyId == "" {
+		return s.Id
+	}
+	return s.FamilyId
+}
+
+func (s *session) isRotated() bool {
+	return s.ReplacedBy != ""
+}
+
+// active session, listed to the user for managing their devices
+type sessionInfo struct {
+	Id         string      `json:"id"`
+	DeviceInfo *deviceInfo `json:"deviceInfo"`
+	CreatedAt  int64       `json:"createdAt"`
+	LastSeenAt int64       `json:"lastSeenAt"`
+	// session of the current request
+	Current bool `json:"current"`
+}
+
+// * request & response bodies
+
+type emailReq struct {
+	Email string `json:"email"`
+}
+
+type otpReq struct {
+	Email string `json:"email"`
+	OTP   string `json:"otp"`
+}
+
+type googleAuthReq struct {
+	IdToken string `json:"idToken"`
+}
+
+type revokedSessionsRes struct {
+	Revoked int `json:"revoked"`
+}
+
+type passkeyOptionsReq struct {
+	// shown by the authenticator
+	UserName string `json:"userName"`
+}
+
+type registerPasskeyReq struct {
+	Challenge  string              `json:"challenge"`
+	Name       string              `json:"name"`
+	Credential passkeyRegistration `json:"credential"`
+}
+
+type passkeyLoginReq struct {
+	Challenge  string           `json:"challenge"`
+	Credential passkeyAssertion `json:"credential"`
+}
+
+type createAPITokenReq struct {
+	Name          string   `json:"name"`
+	Scopes        []string `json:"scopes"`
+	ExpiresInDays int      `json:"expiresInDays"`
+}
+
+// the token is returned only on create
+type createdAPITokenRes struct {
+	*apiToken
+	Token string `json:"token"`
 }
 
 var SessionCookieName = "session"
@@ -30,8 +163,6 @@ var SessionCookieName = "session"
 var errMsg = struct {
 	sendOTP             string
 	validateOTP         string
-	inValidOTP          string
-	expiredOTP          string
 	createToken         string
 	createSession       string
 	deleteSession       string
@@ -42,11 +173,25 @@ var errMsg = struct {
 	invalidSession      string
 	getUserId           string
 	logout              string
+	getSessions         string
+	revokeSession       string
+	sessionNotFound     string
+	sendMagicLink       string
+	usedMagicLink       string
+	passkeyNotFound     string
+	registerPasskey     string
+	getPasskeys         string
+	deletePasskey       string
+	createAPIToken      string
+	getAPITokens        string
+	deleteAPIToken      string
+	apiTokenNotFound    string
+	apiTokenLimit       string
+	invalidTokenScope   string
+	invalidTokenExpiry  string
 }{
 	sendOTP:             "Error sending OTP",
 	validateOTP:         "Error validating OTP",
-	inValidOTP:          "Invalid OTP",
-	expiredOTP:          "OTP expired",
 	googleAuth:          "Error authenticating with google",
 	createSession:       "Error creating session",
 	deleteSession:       "Error deleting session",
@@ -57,4 +202,75 @@ var errMsg = struct {
 	invalidSession:      "Invalid session",
 	getUserId:           "Error getting user id",
 	logout:              "Error logging out",
+	getSessions:         "Error getting sessions",
+	revokeSession:       "Error revoking session",
+	sessionNotFound:     "Session not found",
+	sendMagicLink:       "Error sending magic link",
+	usedMagicLink:       "Magic link already used",
+	passkeyNotFound:     "Passkey not found",
+	registerPasskey:     "Error registering passkey",
+	getPasskeys:         "Error getting passkeys",
+	deletePasskey:       "Error deleting passkey",
+	createAPIToken:      "Error creating api token",
+	getAPITokens:        "Error getting api tokens",
+	deleteAPIToken:      "Error deleting api token",
+	apiTokenNotFound:    "API token not found",
+	apiTokenLimit:       "Max api tokens reached",
+	invalidTokenScope:   "Invalid api token scope",
+	invalidTokenExpiry:  "Invalid api token expiry",
+}
+
+var (
+	errInvalidOTP             = errs.BadRequest.New(errCode.invalidOTP, "Invalid OTP")
+	errExpiredOTP             = errs.BadRequest.New(errCode.expiredOTP, "OTP expired")
+	errOTPLocked              = errs.TooManyRequests.New(errCode.otpLocked, "Too many failed attempts, please try again later")
+	errOTPCooldown            = errs.TooManyRequests.New(errCode.otpCooldown, "Please wait before requesting a new OTP")
+	errRateLimited            = errs.TooManyRequests.New(errCode.rateLimited, "Too many requests, please try again later")
+	errInvalidGoogleToken     = errs.Unauthorized.New(errCode.invalidGoogleToken, "Invalid google token")
+	errGoogleEmailNotVerified = errs.Unauthorized.New(errCode.googleEmailNotVerified, "Google account email is not verified")
+	errInvalidMagicLink       = errs.Unauthorized.New(errCode.invalidMagicLink, "Invalid magic link")
+	errExpiredMagicLink       = errs.Unauthorized.New(errCode.expiredMagicLink, "Magic link expired")
+	errInvalidPasskey         = errs.Unauthorized.New(errCode.invalidPasskey, "Invalid passkey")
+	errPasskeySignCount       = errInvalidPasskey.WithMessage("Passkey sign count not increased")
+	errPasskeyAlgorithm       = errInvalidPasskey.WithMessage("Passkey algorithm not supported")
+	errPasskeyExists          = errs.Conflict.New(errCode.invalidPasskey, "Passkey already registered")
+	errPasskeyChallenge       = errs.BadRequest.New(errCode.passkeyChallenge, "Passkey challenge expired")
+	errInvalidAPIToken        = errs.Unauthorized.New(errCode.invalidAPIToken, "Invalid api token")
+	errSessionRotated         = errs.Conflict.New(errCode.sessionRotated, "Session already rotated")
+	errSessionReused          = errs.Unauthorized.New(errCode.sessionReused, "Rotated session reused")
+)
+
+// error codes, for the extension to display the errors
+var errCode = struct {
+	invalidOTP             string
+	expiredOTP             string
+	otpLocked              string
+	otpCooldown            string
+	rateLimited            string
+	invalidEmail           string
+	invalidGoogleToken     string
+	googleEmailNotVerified string
+	invalidMagicLink       string
+	expiredMagicLink       string
+	invalidPasskey         string
+	passkeyChallenge       string
+	invalidAPIToken        string
+	sessionRotated         string
+	sessionReused          string
+}{
+	invalidOTP:             "OTP_INVALID",
+	expiredOTP:             "OTP_EXPIRED",
+	otpLocked:              "OTP_LOCKED",
+	otpCooldown:            "OTP_RESEND_COOLDOWN",
+	rateLimited:            "RATE_LIMITED",
+	invalidEmail:           "INVALID_EMAIL",
+	invalidGoogleToken:     "GOOGLE_TOKEN_INVALID",
+	googleEmailNotVerified: "GOOGLE_EMAIL_NOT_VERIFIED",
+	invalidMagicLink:       "MAGIC_LINK_INVALID",
+	expiredMagicLink:       "MAGIC_LINK_EXPIRED",
+	invalidPasskey:         "PASSKEY_INVALID",
+	passkeyChallenge:       "PASSKEY_CHALLENGE_EXPIRED",
+	invalidAPIToken:        "API_TOKEN_INVALID",
+	sessionRotated:         "SESSION_ROTATED",
+	sessionReused:          "SESSION_REUSED",
 }
@@ -0,0 +1,156 @@
+package auth
+
+import (
+	"encoding/binary"
+	"errors"
+)
+
+// * minimal CBOR decoder for webauthn attestation objects & COSE keys
+// supports ints, byte/text strings, arrays, maps & simple values (no floats, tags or indefinite lengths)
+
+var errInvalidCBOR = errors.New("invalid cbor")
+
+// max nesting, to not overflow the stack with a crafted input
+const cborMaxDepth = 16
+
+// decodes the first cbor item in data, returns the item & the number of bytes read
+func decodeCBOR(data []byte) (any, int, error) {
+	return decodeCBORItem(data, 0)
+}
+
+func decodeCBORItem(data []byte, depth int) (any, int, error) {
+	if len(data) < 1 || depth > cborMaxDepth {
+		return nil, 0, errInvalidCBOR
+	}
+
+	major := data[0] >> 5
+
+	arg, n, err := cborArgument(data)
+
+	if err != nil {
+		return nil, 0, err
+	}
+
+	switch major {
+	// unsigned int
+	case 0:
+		if arg > 1<<63-1 {
+			return nil, 0, errInvalidCBOR
+		}
+		return int64(arg), n, nil
+
+	// negative int
+	case 1:
+		if arg > 1<<63-1 {
+			return nil, 0, errInvalidCBOR
+		}
+		return -1 - int64(arg), n, nil
+
+	// byte string & text string
+	case 2, 3:
+		if arg > uint64(len(data)-n) {
+			return nil, 0, errInvalidCBOR
+		}
+
+		end := n + int(arg)
+
+		b := make([]byte, arg)
+		copy(b, data[n:end])
+
+		if major == 3 {
+			return string(b), end, nil
+		}
+
+		return b, end, nil
+
+	// array
+	case 4:
+		if arg > uint64(len(data)) {
+			return nil, 0, errInvalidCBOR
+		}
+
+		items := make([]any, 0, arg)
+
+		for i := uint64(0); i < arg; i++ {
+			item, read, err := decodeCBORItem(data[n:], depth+1)
+
+			if err != nil {
+				return nil, 0, err
+			}
+
+			items = append(items, item)
+			n += read
+		}
+
+		return items, n, nil
+
+	// map
+	case 5:
+		if arg > uint64(len(data)) {
+			return nil, 0, errInvalidCBOR
+		}
+
+		m := make(map[any]any, arg)
+
+		for i := uint64(0); i < arg; i++ {
+			key, read, err := decodeCBORItem(data[n:], depth+1)
+
+			if err != nil {
+				return nil, 0, err
+			}
+
+			n += read
+
+			switch key.(type) {
+			case int64, string:
+			default:
+				return nil, 0, errInvalidCBOR
+			}
+
+			value, read, err := decodeCBORItem(data[n:], depth+1)
+
+			if err != nil {
+				return nil, 0, err
+			}
+
+			n += read
+
+			m[key] = value
+		}
+
+		return m, n, nil
+
+	// simple values
+	case 7:
+		switch data[0] & 0x1f {
+		case 20:
+			return false, 1, nil
+		case 21:
+			return true, 1, nil
+		case 22, 23:
+			return nil, 1, nil
+		}
+	}
+
+	return nil, 0, errInvalidCBOR
+}
+
+// argument of the item head & the head length
+func cborArgument(data []byte) (uint64, int, error) {
+	info := data[0] & 0x1f
+
+	switch {
+	case info < 24:
+		return uint64(info), 1, nil
+	case info == 24 && len(data) >= 2:
+		return uint64(data[1]), 2, nil
+	case info == 25 && len(data) >= 3:
+		return uint64(binary.BigEndian.Uint16(data[1:3])), 3, nil
+	case info == 26 && len(data) >= 5:
+		return uint64(binary.BigEndian.Uint32(data[1:5])), 5, nil
+	case info == 27 && len(data) >= 9:
+		return binary.BigEndian.Uint64(data[1:9]), 9, nil
+	}
+
+	return 0, 0, errInvalidCBOR
+}
@@ -0,0 +1,298 @@
+package auth
+
+import (
+	"crypto"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"net/http"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/manishMandal02/tabsflow-backend/config"
+	"github.com/manishMandal02/tabsflow-backend/pkg/logger"
+)
+
+// * google id token verification
+
+// public keys that sign the google id tokens, by their key id
+type jwksProvider interface {
+	key(kid string) (*rsa.PublicKey, error)
+}
+
+type googleTokenVerifier struct {
+	keys      jwksProvider
+	clientIds []string
+	now       func() time.Time
+}
+
+func newGoogleTokenVerifier(keys jwksProvider, clientIds []string) *googleTokenVerifier {
+	return &googleTokenVerifier{
+		keys:      keys,
+		clientIds: clientIds,
+		now:       time.Now,
+	}
+}
+
+var googleIssuers = []string{"accounts.google.com", "https://accounts.google.com"}
+
+// allowed clock difference with google servers
+const googleTokenLeeway = 60
+
+type googleTokenClaims struct {
+	Issuer        string          `json:"iss"`
+	Audience      string          `json:"aud"`
+	Subject       string          `json:"sub"`
+	Email         string          `json:"email"`
+	EmailVerified json.RawMessage `json:"email_verified"`
+	ExpiresAt     int64           `json:"exp"`
+	IssuedAt      int64           `json:"iat"`
+}
+
+// verifies the id token signature & claims, returns the verified email
+func (v *googleTokenVerifier) verify(idToken string) (string, error) {
+	parts := strings.Split(idToken, ".")
+
+	if len(parts) != 3 {
+		return "", errInvalidGoogleToken
+	}
+
+	var header struct {
+		Alg string `json:"alg"`
+		Kid string `json:"kid"`
+	}
+
+	err := decodeJWTPart(parts[0], &header)
+
+	if err != nil || header.Alg != "RS256" || header.Kid == "" {
+		return "", errInvalidGoogleToken
+	}
+
+	key, err := v.keys.key(header.Kid)
+
+	if err != nil {
+		logger.Errorf("Couldn't get google public key, kid: %v. \n[Error]: %v", header.Kid, err)
+		return "", errInvalidGoogleToken
+	}
+
+	signature, err := base64.RawURLEncoding.DecodeString(parts[2])
+
+	if err != nil {
+		return "", errInvalidGoogleToken
+	}
+
+	hash := sha256.Sum256([]byte(parts[0] + "." + parts[1]))
+
+	err = rsa.VerifyPKCS1v15(key, crypto.SHA256, hash[:], signature)
+
+	if err != nil {
+		return "", errInvalidGoogleToken
+	}
+
+	var claims googleTokenClaims
+
+	err = decodeJWTPart(parts[1], &claims)
+
+	if err != nil {
+		return "", errInvalidGoogleToken
+	}
+
+	now := v.now().Unix()
+
+	if !slices.Contains(googleIssuers, claims.Issuer) || !slices.Contains(v.clientIds, claims.Audience) {
+		return "", errInvalidGoogleToken
+	}
+
+	if claims.ExpiresAt+googleTokenLeeway < now || claims.IssuedAt-googleTokenLeeway > now {
+		return "", errInvalidGoogleToken
+	}
+
+	// email_verified is a bool, or a string in older tokens
+	verified := strings.Trim(string(claims.EmailVerified), `"`)
+
+	if claims.Email == "" || verified != "true" {
+		return "", errGoogleEmailNotVerified
+	}
+
+	return claims.Email, nil
+}
+
+func decodeJWTPart(part string, v any) error {
+	data, err := base64.RawURLEncoding.DecodeString(part)
+
+	if err != nil {
+		return err
+	}
+
+	return json.Unmarshal(data, v)
+}
+
+// * google jwks
+
+type jwk struct {
+	Kid string `json:"kid"`
+	Kty string `json:"kty"`
+	N   string `json:"n"`
+	E   string `json:"e"`
+}
+
+// google's public keys, cached for the max-age of the response
+type googleJWKS struct {
+	mu        sync.Mutex
+	url       string
+	client    *http.Client
+	keys      map[string]*rsa.PublicKey
+	expiresAt time.Time
+	// last fetch, to not re-fetch for every unknown key id
+	fetchedAt time.Time
+}
+
+// default cache time, if the response has no max-age
+const jwksDefaultCacheTime = time.Hour
+
+// min time between fetches for unknown key ids
+const jwksMinRefetchInterval = time.Minute
+
+func newGoogleJWKS(client *http.Client) *googleJWKS {
+	return &googleJWKS{
+		url:    config.GOOGLE_JWKS_URL,
+		client: client,
+		keys:   map[string]*rsa.PublicKey{},
+	}
+}
+
+func (j *googleJWKS) key(kid string) (*rsa.PublicKey, error) {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	now := time.Now()
+
+	k, ok := j.keys[kid]
+
+	if ok && now.Before(j.expiresAt) {
+		return k, nil
+	}
+
+	// keys rotated by google, or cache expired
+	if now.Before(j.expiresAt) && now.Sub(j.fetchedAt) < jwksMinRefetchInterval {
+		return nil, fmt.Errorf("unknown key id: %v", kid)
+	}
+
+	err := j.fetch()
+
+	if err != nil {
+		return nil, err
+	}
+
+	k, ok = j.keys[kid]
+
+	if !ok {
+		return nil, fmt.Errorf("unknown key id: %v", kid)
+	}
+
+	return k, nil
+}
+
+func (j *googleJWKS) fetch() error {
+	res, err := j.client.Get(j.url)
+
+	if err != nil {
+		return err
+	}
+
+	defer res.Body.Close()
+
+	if res.StatusCode != http.StatusOK {
+		return fmt.Errorf("unsuccessful jwks response: %v", res.Status)
+	}
+
+	var body struct {
+		Keys []jwk `json:"keys"`
+	}
+
+	err = json.NewDecoder(res.Body).Decode(&body)
+
+	if err != nil {
+		return err
+	}
+
+	keys, err := parseJWKs(body.Keys)
+
+	if err != nil {
+		return err
+	}
+
+	now := time.Now()
+
+	j.keys = keys
+	j.fetchedAt = now
+	j.expiresAt = now.Add(cacheMaxAge(res.Header.Get("Cache-Control")))
+
+	return nil
+}
+
+func parseJWKs(jwks []jwk) (map[string]*rsa.PublicKey, error) {
+	keys := map[string]*rsa.PublicKey{}
+
+	for _, k := range jwks {
+		if k.Kty != "RSA" {
+			continue
+		}
+
+		n, err := base64.RawURLEncoding.DecodeString(k.N)
+
+		if err != nil {
+			return nil, fmt.Errorf("invalid jwk modulus, kid: %v", k.Kid)
+		}
+
+		e, err := base64.RawURLEncoding.DecodeString(k.E)
+
+		if err != nil || len(e) == 0 {
+			return nil, fmt.Errorf("invalid jwk exponent, kid: %v", k.Kid)
+		}
+
+		keys[k.Kid] = &rsa.PublicKey{
+			N: new(big.Int).SetBytes(n),
+			E: int(new(big.Int).SetBytes(e).Int64()),
+		}
+	}
+
+	return keys, nil
+}
+
+var maxAgeRegex = regexp.MustCompile(`max-age=(\d+)`)
+
+func cacheMaxAge(cacheControl string) time.Duration {
+	m := maxAgeRegex.FindStringSubmatch(cacheControl)
+
+	if len(m) != 2 {
+		return jwksDefaultCacheTime
+	}
+
+	seconds, err := strconv.Atoi(m[1])
+
+	if err != nil || seconds <= 0 {
+		return jwksDefaultCacheTime
+	}
+
+	return time.Duration(seconds) * time.Second
+}
+
+func googleClientIds() []string {
+	ids := []string{}
+
+	for _, id := range strings.Split(config.GOOGLE_CLIENT_IDS, ",") {
+		if id = strings.TrimSpace(id); id != "" {
+			ids = append(ids, id)
+		}
+	}
+
+	return ids
+}
@@ -0,0 +1,212 @@
+package auth
+
+import (
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+// static keys, verifies the tokens offline
+type jwksMock map[string]*rsa.PublicKey
+
+func (m jwksMock) key(kid string) (*rsa.PublicKey, error) {
+	k, ok := m[kid]
+
+	if !ok {
+		return nil, errors.New("unknown key id")
+	}
+
+	return k, nil
+}
+
+func signTestJWT(t *testing.T, key *rsa.PrivateKey, header, claims map[string]any) string {
+	t.Helper()
+
+	h, _ := json.Marshal(header)
+	c, _ := json.Marshal(claims)
+
+	unsigned := base64.RawURLEncoding.EncodeToString(h) + "." + base64.RawURLEncoding.EncodeToString(c)
+
+	hash := sha256.Sum256([]byte(unsigned))
+
+	sig, err := rsa.SignPKCS1v15(rand.Reader, key, crypto.SHA256, hash[:])
+
+	if err != nil {
+		t.Fatalf("error signing jwt: %v", err)
+	}
+
+	return unsigned + "." + base64.RawURLEncoding.EncodeToString(sig)
+}
+
+func TestGoogleTokenVerify(t *testing.T) {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+
+	if err != nil {
+		t.Fatalf("error generating key: %v", err)
+	}
+
+	otherKey, err := rsa.GenerateKey(rand.Reader, 2048)
+
+	if err != nil {
+		t.Fatalf("error generating key: %v", err)
+	}
+
+	v := newGoogleTokenVerifier(jwksMock{"kid-1": &key.PublicKey}, []string{"client-id"})
+
+	now := time.Now().Unix()
+
+	validClaims := func() map[string]any {
+		return map[string]any{
+			"iss":            "https://accounts.google.com",
+			"aud":            "client-id",
+			"sub":            "123",
+			"email":          "test@tabsflow.com",
+			"email_verified": true,
+			"iat":            now,
+			"exp":            now + 3600,
+		}
+	}
+
+	header := map[string]any{"alg": "RS256", "kid": "kid-1"}
+
+	withClaim := func(k string, v any) map[string]any {
+		c := validClaims()
+		c[k] = v
+		return c
+	}
+
+	tests := []struct {
+		name    string
+		token   string
+		wantErr error
+	}{
+		{
+			name:  "valid token",
+			token: signTestJWT(t, key, header, validClaims()),
+		},
+		{
+			name:  "email verified as string",
+			token: signTestJWT(t, key, header, withClaim("email_verified", "true")),
+		},
+		{
+			name:    "wrong audience",
+			token:   signTestJWT(t, key, header, withClaim("aud", "other-client")),
+			wantErr: errInvalidGoogleToken,
+		},
+		{
+			name:    "wrong issuer",
+			token:   signTestJWT(t, key, header, withClaim("iss", "https://evil.com")),
+			wantErr: errInvalidGoogleToken,
+		},
+		{
+			name:    "expired",
+			token:   signTestJWT(t, key, header, withClaim("exp", now-3600)),
+			wantErr: errInvalidGoogleToken,
+		},
+		{
+			name:    "email not verified",
+			token:   signTestJWT(t, key, header, withClaim("email_verified", false)),
+			wantErr: errGoogleEmailNotVerified,
+		},
+		{
+			name:    "signed with other key",
+			token:   signTestJWT(t, otherKey, header, validClaims()),
+			wantErr: errInvalidGoogleToken,
+		},
+		{
+			name:    "unknown key id",
+			token:   signTestJWT(t, key, map[string]any{"alg": "RS256", "kid": "kid-2"}, validClaims()),
+			wantErr: errInvalidGoogleToken,
+		},
+		{
+			name:    "unsigned token",
+			token:   signTestJWT(t, key, map[string]any{"alg": "none", "kid": "kid-1"}, validClaims()),
+			wantErr: errInvalidGoogleToken,
+		},
+		{
+			name:    "malformed",
+			token:   "not-a-jwt",
+			wantErr: errInvalidGoogleToken,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			email, err := v.verify(tt.token)
+
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) {
+					t.Fatalf("verify() error = %v, wantErr %v", err, tt.wantErr)
+				}
+				return
+			}
+
+			if err != nil {
+				t.Fatalf("verify() unexpected error = %v", err)
+			}
+
+			if email != "test@tabsflow.com" {
+				t.Errorf("verify() email = %v", email)
+			}
+		})
+	}
+}
+
+func TestGoogleJWKSCache(t *testing.T) {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+
+	if err != nil {
+		t.Fatalf("error generating key: %v", err)
+	}
+
+	fetches := 0
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fetches++
+
+		w.Header().Set("Cache-Control", "public, max-age=3600")
+
+		_ = json.NewEncoder(w).Encode(map[string]any{
+			"keys": []jwk{{
+				Kid: "kid-1",
+				Kty: "RSA",
+				N:   base64.RawURLEncoding.EncodeToString(key.N.Bytes()),
+				E:   base64.RawURLEncoding.EncodeToString([]byte{1, 0, 1}),
+			}},
+		})
+	}))
+
+	defer server.Close()
+
+	j := newGoogleJWKS(server.Client())
+	j.url = server.URL
+
+	for i := 0; i < 3; i++ {
+		k, err := j.key("kid-1")
+
+		if err != nil {
+			t.Fatalf("key() unexpected error = %v", err)
+		}
+
+		if k.N.Cmp(key.N) != 0 || k.E != key.E {
+			t.Fatalf("key() returned a different key")
+		}
+	}
+
+	// unknown key id, not re-fetched within the min interval
+	if _, err := j.key("kid-2"); err == nil {
+		t.Errorf("key() expected error for unknown key id")
+	}
+
+	if fetches != 1 {
+		t.Errorf("jwks fetched %v times, want 1", fetches)
+	}
+}
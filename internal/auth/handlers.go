@@ -1,15 +1,24 @@
 package auth
 
 import (
+	"context"
+	"encoding/base64"
 	"encoding/json"
 	"errors"
+	"fmt"
 	"net/http"
+	"net/mail"
+	"net/url"
+	"slices"
+	"strconv"
 	"strings"
 	"time"
 
 	lambda_events "github.com/aws/aws-lambda-go/events"
+	"github.com/mssola/useragent"
 
 	"github.com/manishMandal02/tabsflow-backend/config"
+	"github.com/manishMandal02/tabsflow-backend/pkg/errs"
 	"github.com/manishMandal02/tabsflow-backend/pkg/events"
 	"github.com/manishMandal02/tabsflow-backend/pkg/http_api"
 	"github.com/manishMandal02/tabsflow-backend/pkg/logger"
@@ -21,19 +30,21 @@ import (
 type authHandler struct {
 	r          authRepository
 	emailQueue *events.Queue
+	google     *googleTokenVerifier
 }
 
 func newAuthHandler(repo authRepository, q *events.Queue) *authHandler {
+	jwks := newGoogleJWKS(&http.Client{Timeout: 5 * time.Second})
+
 	return &authHandler{
 		r:          repo,
 		emailQueue: q,
+		google:     newGoogleTokenVerifier(jwks, googleClientIds()),
 	}
 }
 
 func (h *authHandler) sendOTP(w http.ResponseWriter, r *http.Request) {
-	var b struct {
-		Email string `json:"email"`
-	}
+	var b emailReq
 
 	r.Body = http.MaxBytesReader(w, r.Body, 1048576)
 
@@ -42,20 +53,56 @@ func (h *authHandler) sendOTP(w http.ResponseWriter, r *http.Request) {
 
 	if err != nil {
 		logger.Error("Error decoding request body for sendOTP", err)
-		http_api.ErrorRes(w, errMsg.sendOTP, http.StatusBadRequest)
+		http_api.ErrorRes(w, errs.BadRequest.WithMessage(errMsg.sendOTP))
+		return
+	}
+
+	if _, err := mail.ParseAddress(b.Email); err != nil {
+		http_api.ErrorRes(w, errs.BadRequest.New(errCode.invalidEmail, errMsg.sendOTP))
+		return
+	}
+
+	attempts, err := h.r.getOTPAttempts(r.Context(), b.Email)
+
+	if err != nil {
+		http_api.ErrorRes(w, errs.BadGateway.WithMessage(errMsg.sendOTP))
+		return
+	}
+
+	if isOTPLocked(w, attempts) {
+		return
+	}
+
+	err = h.r.recordOTPSent(r.Context(), b.Email)
+
+	if err != nil {
+		if errors.Is(err, errOTPCooldown) {
+			setRetryAfter(w, config.OTP_RESEND_COOLDOWN_SEC)
+			http_api.ErrorRes(w, errOTPCooldown)
+			return
+		}
+		http_api.ErrorRes(w, errs.BadGateway.WithMessage(errMsg.sendOTP))
+		return
+	}
+
+	// only the latest otp is valid
+	err = h.r.invalidateOTPs(r.Context(), b.Email, false)
+
+	if err != nil {
+		http_api.ErrorRes(w, errs.BadGateway.WithMessage(errMsg.sendOTP))
 		return
 	}
 
 	otp := utils.GenerateOTP()
 
-	err = h.r.saveOTP(&emailOTP{
+	err = h.r.saveOTP(r.Context(), &emailOTP{
 		OTP:   otp,
 		Email: b.Email,
 		TTL:   time.Now().Add(time.Minute * time.Duration(config.OTP_EXPIRY_TIME_IN_MIN)).Unix(),
 	})
 
 	if err != nil {
-		http_api.ErrorRes(w, errMsg.sendOTP, http.StatusBadGateway)
+		http_api.ErrorRes(w, errs.BadGateway.WithMessage(errMsg.sendOTP))
 		return
 	}
 
@@ -66,10 +113,10 @@ func (h *authHandler) sendOTP(w http.ResponseWriter, r *http.Request) {
 		OTP:   otp,
 	})
 
-	err = h.emailQueue.AddMessage(event)
+	err = h.emailQueue.AddMessage(r.Context(), event)
 
 	if err != nil {
-		http_api.ErrorRes(w, errMsg.sendOTP, http.StatusBadGateway)
+		http_api.ErrorRes(w, errs.BadGateway.WithMessage(errMsg.sendOTP))
 		return
 	}
 
@@ -78,10 +125,7 @@ func (h *authHandler) sendOTP(w http.ResponseWriter, r *http.Request) {
 }
 
 func (h *authHandler) verifyOTP(w http.ResponseWriter, r *http.Request) {
-	var b struct {
-		Email string `json:"email"`
-		OTP   string `json:"otp"`
-	}
+	var b otpReq
 
 	r.Body = http.MaxBytesReader(w, r.Body, 1048576)
 
@@ -91,39 +135,61 @@ func (h *authHandler) verifyOTP(w http.ResponseWriter, r *http.Request) {
 
 	if err != nil {
 		logger.Error("Error decoding request body for verify otp", err)
-		http_api.ErrorRes(w, errMsg.validateOTP, http.StatusBadRequest)
+		http_api.ErrorRes(w, errs.BadRequest.WithMessage(errMsg.validateOTP))
+		return
+	}
+
+	// counted before the otp is checked, so concurrent guesses can't exceed the max attempts
+	attempts, err := h.r.recordOTPAttempt(r.Context(), b.Email)
+
+	if err != nil {
+		if errors.Is(err, errOTPLocked) {
+			setRetryAfter(w, max(attempts.LockedUntil, attempts.TTL)-time.Now().Unix())
+			http_api.ErrorRes(w, errOTPLocked)
+			return
+		}
+		http_api.ErrorRes(w, errs.BadGateway.WithMessage(errMsg.validateOTP))
 		return
 	}
 
-	valid, err := h.r.validateOTP(b.Email, b.OTP)
+	// otp can be used only once
+	valid, err := h.r.consumeOTP(r.Context(), b.Email, b.OTP)
 
 	if err != nil {
-		if err.Error() == errMsg.expiredOTP {
-			http_api.ErrorRes(w, errMsg.expiredOTP, http.StatusBadRequest)
+		if errors.Is(err, errExpiredOTP) {
+			http_api.ErrorRes(w, errExpiredOTP)
 			return
 		}
-		http_api.ErrorRes(w, errMsg.validateOTP, http.StatusBadGateway)
+		http_api.ErrorRes(w, errs.BadGateway.WithMessage(errMsg.validateOTP))
 		return
 	}
 
 	if !valid {
-		http_api.ErrorRes(w, errMsg.inValidOTP, http.StatusBadRequest)
+		h.otpFailed(r.Context(), w, b.Email, attempts)
+		return
+	}
+
+	// other otps of the email & the attempts are removed
+	err = h.r.invalidateOTPs(r.Context(), b.Email, true)
+
+	if err != nil {
+		http_api.ErrorRes(w, errs.BadGateway.WithMessage(errMsg.validateOTP))
 		return
 	}
 
 	// check if user exists
-	resData, err := checkIfNewUser(b.Email, h.r)
+	resData, err := checkIfNewUser(r.Context(), b.Email, h.r)
 
 	if err != nil {
-		http_api.ErrorRes(w, errMsg.googleAuth, http.StatusBadGateway)
+		http_api.ErrorRes(w, errs.BadGateway.WithMessage(errMsg.googleAuth))
 		return
 	}
 
 	// create new session
-	cookie, err := createNewSession(resData.UserId, userAgent, h.r)
+	cookie, err := createNewSession(r.Context(), resData.UserId, userAgent, h.r)
 
 	if err != nil {
-		http_api.ErrorRes(w, errMsg.createSession, http.StatusInternalServerError)
+		http_api.ErrorRes(w, errs.Internal.WithMessage(errMsg.createSession))
 		return
 	}
 
@@ -133,68 +199,220 @@ func (h *authHandler) verifyOTP(w http.ResponseWriter, r *http.Request) {
 	http_api.SuccessResMsgWithBody(w, "OTP verified successfully", resData)
 }
 
-func (h *authHandler) googleAuth(w http.ResponseWriter, r *http.Request) {
-	var b struct {
-		Email string `json:"email"`
+func (h *authHandler) sendMagicLink(w http.ResponseWriter, r *http.Request) {
+	var b emailReq
+
+	r.Body = http.MaxBytesReader(w, r.Body, 1048576)
+
+	err := json.NewDecoder(r.Body).Decode(&b)
+
+	if err != nil {
+		logger.Error("Error decoding request body for sendMagicLink", err)
+		http_api.ErrorRes(w, errs.BadRequest.WithMessage(errMsg.sendMagicLink))
+		return
 	}
 
-	userAgent := r.Header.Get("User-Agent")
+	if _, err := mail.ParseAddress(b.Email); err != nil {
+		http_api.ErrorRes(w, errs.BadRequest.New(errCode.invalidEmail, errMsg.sendMagicLink))
+		return
+	}
 
-	decoder := json.NewDecoder(r.Body)
+	attempts, err := h.r.getOTPAttempts(r.Context(), b.Email)
 
-	err := decoder.Decode(&b)
+	if err != nil {
+		http_api.ErrorRes(w, errs.BadGateway.WithMessage(errMsg.sendMagicLink))
+		return
+	}
+
+	if isOTPLocked(w, attempts) {
+		return
+	}
+
+	// shares the resend cooldown with otp
+	err = h.r.recordOTPSent(r.Context(), b.Email)
 
 	if err != nil {
-		logger.Error("Error decoding request body for google auth", err)
-		http_api.ErrorRes(w, errMsg.googleAuth, http.StatusBadRequest)
+		if errors.Is(err, errOTPCooldown) {
+			setRetryAfter(w, config.OTP_RESEND_COOLDOWN_SEC)
+			http_api.ErrorRes(w, errOTPCooldown)
+			return
+		}
+		http_api.ErrorRes(w, errs.BadGateway.WithMessage(errMsg.sendMagicLink))
 		return
 	}
 
-	// check if user exists
-	resData, err := checkIfNewUser(b.Email, h.r)
+	link := &magicLink{
+		Email:  b.Email,
+		Id:     utils.GenerateRandomString(32),
+		Origin: magicLinkOrigin(r.Header.Get("Origin")),
+		TTL:    time.Now().Add(time.Minute * config.MAGIC_LINK_EXPIRY_TIME_IN_MIN).Unix(),
+	}
+
+	token, err := signMagicLinkToken(link.Email, link.Id, link.TTL)
 
 	if err != nil {
-		http_api.ErrorRes(w, errMsg.googleAuth, http.StatusBadGateway)
+		http_api.ErrorRes(w, errs.Internal.WithMessage(errMsg.sendMagicLink))
 		return
 	}
 
-	// create new session
-	cookie, err := createNewSession(resData.UserId, userAgent, h.r)
+	err = h.r.saveMagicLink(r.Context(), link)
 
 	if err != nil {
-		http_api.ErrorRes(w, errMsg.createSession, http.StatusInternalServerError)
+		http_api.ErrorRes(w, errs.BadGateway.WithMessage(errMsg.sendMagicLink))
+		return
+	}
+
+	event := events.New(events.EventTypeSendMagicLink, &events.SendMagicLinkPayload{
+		Email:     b.Email,
+		Link:      magicLinkURL(token),
+		ExpiresIn: fmt.Sprintf("%d minutes", config.MAGIC_LINK_EXPIRY_TIME_IN_MIN),
+	})
+
+	err = h.emailQueue.AddMessage(r.Context(), event)
+
+	if err != nil {
+		http_api.ErrorRes(w, errs.BadGateway.WithMessage(errMsg.sendMagicLink))
+		return
+	}
+
+	http_api.SuccessResMsg(w, "magic link sent successfully")
+}
+
+// opened from the email; logs in & redirects back to the app
+func (h *authHandler) verifyMagicLink(w http.ResponseWriter, r *http.Request) {
+	email, id, err := verifyMagicLinkToken(r.URL.Query().Get("token"))
+
+	if err != nil {
+		code := errCode.invalidMagicLink
+
+		if errors.Is(err, errExpiredMagicLink) {
+			code = errCode.expiredMagicLink
+		}
+
+		magicLinkRedirect(w, r, "", url.Values{"error": {code}})
+		return
+	}
+
+	// single use
+	link, err := h.r.consumeMagicLink(r.Context(), email, id)
+
+	if err != nil {
+		magicLinkRedirect(w, r, "", url.Values{"error": {errCode.invalidMagicLink}})
+		return
+	}
+
+	resData, err := checkIfNewUser(r.Context(), email, h.r)
+
+	if err != nil {
+		magicLinkRedirect(w, r, link.Origin, url.Values{"error": {errCode.invalidMagicLink}})
+		return
+	}
+
+	cookie, err := createNewSession(r.Context(), resData.UserId, r.Header.Get("User-Agent"), h.r)
+
+	if err != nil {
+		magicLinkRedirect(w, r, link.Origin, url.Values{"error": {errCode.invalidMagicLink}})
 		return
 	}
 
-	// set session cookie
 	http.SetCookie(w, cookie)
 
-	http_api.SuccessResMsgWithBody(w, "OTP verified successfully", resData)
+	magicLinkRedirect(w, r, link.Origin, url.Values{
+		"userId":    {resData.UserId},
+		"isNewUser": {strconv.FormatBool(resData.NewUser)},
+	})
 }
 
-func (h *authHandler) getUserId(w http.ResponseWriter, r *http.Request) {
+// locks the email if the failed attempt was the last one
+func (h *authHandler) otpFailed(ctx context.Context, w http.ResponseWriter, email string, attempts *otpAttempts) {
+	if attempts.Failures < config.OTP_MAX_FAILED_ATTEMPTS {
+		http_api.ErrorRes(w, errInvalidOTP)
+		return
+	}
+
+	lockedUntil := time.Now().Add(time.Minute * config.OTP_LOCKOUT_TIME_IN_MIN).Unix()
+
+	err := h.r.lockOTP(ctx, email, lockedUntil)
+
+	if err != nil {
+		http_api.ErrorRes(w, errs.BadGateway.WithMessage(errMsg.validateOTP))
+		return
+	}
+
+	// a new otp must be requested after the lockout
+	err = h.r.invalidateOTPs(ctx, email, false)
+
+	if err != nil {
+		logger.Error("Error invalidating OTPs after lockout", err)
+	}
+
+	setRetryAfter(w, lockedUntil-time.Now().Unix())
+	http_api.ErrorRes(w, errOTPLocked)
+}
+
+// writes the lockout error response, if the email is locked
+func isOTPLocked(w http.ResponseWriter, attempts *otpAttempts) bool {
+	now := time.Now().Unix()
+
+	if attempts.LockedUntil <= now {
+		return false
+	}
+
+	setRetryAfter(w, attempts.LockedUntil-now)
+	http_api.ErrorRes(w, errOTPLocked)
+
+	return true
+}
+
+func (h *authHandler) googleAuth(w http.ResponseWriter, r *http.Request) {
+	var b googleAuthReq
+
+	userAgent := r.Header.Get("User-Agent")
+
+	r.Body = http.MaxBytesReader(w, r.Body, 1048576)
+
+	decoder := json.NewDecoder(r.Body)
+
+	err := decoder.Decode(&b)
 
-	email := r.PathValue("email")
+	if err != nil || b.IdToken == "" {
+		logger.Error("Error decoding request body for google auth", err)
+		http_api.ErrorRes(w, errs.BadRequest.WithMessage(errMsg.googleAuth))
+		return
+	}
 
-	userId, err := h.r.userIdByEmail(email)
+	// email is trusted only from the verified id token
+	email, err := h.google.verify(b.IdToken)
 
 	if err != nil {
-		http_api.ErrorRes(w, errMsg.getUserId, http.StatusBadRequest)
+		if errors.Is(err, errGoogleEmailNotVerified) {
+			http_api.ErrorRes(w, errGoogleEmailNotVerified)
+			return
+		}
+		http_api.ErrorRes(w, errInvalidGoogleToken)
 		return
 	}
 
-	if userId == "" {
-		http_api.ErrorRes(w, "No user with given email", http.StatusBadRequest)
+	// check if user exists
+	resData, err := checkIfNewUser(r.Context(), email, h.r)
+
+	if err != nil {
+		http_api.ErrorRes(w, errs.BadGateway.WithMessage(errMsg.googleAuth))
 		return
 	}
 
-	resData := &struct {
-		UserId string `json:"userId"`
-	}{
-		UserId: userId,
+	// create new session
+	cookie, err := createNewSession(r.Context(), resData.UserId, userAgent, h.r)
+
+	if err != nil {
+		http_api.ErrorRes(w, errs.Internal.WithMessage(errMsg.createSession))
+		return
 	}
 
-	http_api.SuccessResData(w, resData)
+	// set session cookie
+	http.SetCookie(w, cookie)
+
+	http_api.SuccessResMsgWithBody(w, "OTP verified successfully", resData)
 }
 
 func (h *authHandler) logout(w http.ResponseWriter, r *http.Request) {
@@ -236,7 +454,15 @@ func (h *authHandler) logout(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	err = h.r.deleteSession(userId, sId)
+	s, err := h.r.getSession(r.Context(), userId, sId)
+
+	if err != nil || s == nil {
+		logoutResponse()
+		return
+	}
+
+	// delete the session with its rotated sessions
+	err = revokeSessionFamily(r.Context(), userId, s.family(), h.r)
 
 	if err != nil {
 		logger.Error(errMsg.deleteSession, err)
@@ -247,7 +473,559 @@ func (h *authHandler) logout(w http.ResponseWriter, r *http.Request) {
 	logoutResponse()
 }
 
-func (h *authHandler) lambdaAuthorizer(ev *lambda_events.APIGatewayCustomAuthorizerRequestTypeRequest) (*lambda_events.APIGatewayCustomAuthorizerResponse, error) {
+func (h *authHandler) getSessions(w http.ResponseWriter, r *http.Request) {
+	sId, userId, err := h.validSessionFromCookie(r)
+
+	if err != nil {
+		http_api.ErrorRes(w, errs.Unauthorized.WithMessage(errMsg.invalidSession))
+		return
+	}
+
+	sessions, err := h.r.getSessions(r.Context(), userId)
+
+	if err != nil {
+		http_api.ErrorRes(w, errs.BadGateway.WithMessage(errMsg.getSessions))
+		return
+	}
+
+	currentFamily := sessionFamily(sessions, sId)
+
+	resData := []sessionInfo{}
+
+	for _, s := range sessions {
+		// rotated sessions are kept only for reuse detection
+		if s.isRotated() {
+			continue
+		}
+
+		resData = append(resData, sessionInfo{
+			Id:         s.Id,
+			DeviceInfo: s.DeviceInfo,
+			CreatedAt:  s.CreatedAt,
+			LastSeenAt: s.LastSeenAt,
+			Current:    s.family() == currentFamily,
+		})
+	}
+
+	http_api.SuccessResData(w, resData)
+}
+
+func (h *authHandler) revokeSession(w http.ResponseWriter, r *http.Request) {
+	_, userId, err := h.validSessionFromCookie(r)
+
+	if err != nil {
+		http_api.ErrorRes(w, errs.Unauthorized.WithMessage(errMsg.invalidSession))
+		return
+	}
+
+	id := r.PathValue("id")
+
+	if id == "" {
+		http_api.ErrorRes(w, errs.BadRequest.WithMessage(errMsg.revokeSession))
+		return
+	}
+
+	sessions, err := h.r.getSessions(r.Context(), userId)
+
+	if err != nil {
+		http_api.ErrorRes(w, errs.BadGateway.WithMessage(errMsg.revokeSession))
+		return
+	}
+
+	familyId := sessionFamily(sessions, id)
+
+	if familyId == "" {
+		http_api.ErrorRes(w, errs.NotFound.WithMessage(errMsg.sessionNotFound))
+		return
+	}
+
+	// revoke the device, with its rotated sessions
+	err = revokeSessionFamily(r.Context(), userId, familyId, h.r)
+
+	if err != nil {
+		http_api.ErrorRes(w, errs.BadGateway.WithMessage(errMsg.revokeSession))
+		return
+	}
+
+	http_api.SuccessResMsg(w, "session revoked")
+}
+
+// revokes all the sessions of the user, except the current one
+func (h *authHandler) revokeOtherSessions(w http.ResponseWriter, r *http.Request) {
+	sId, userId, err := h.validSessionFromCookie(r)
+
+	if err != nil {
+		http_api.ErrorRes(w, errs.Unauthorized.WithMessage(errMsg.invalidSession))
+		return
+	}
+
+	sessions, err := h.r.getSessions(r.Context(), userId)
+
+	if err != nil {
+		http_api.ErrorRes(w, errs.BadGateway.WithMessage(errMsg.revokeSession))
+		return
+	}
+
+	currentFamily := sessionFamily(sessions, sId)
+
+	sIds := []string{}
+
+	revoked := 0
+
+	for _, s := range sessions {
+		if s.family() == currentFamily {
+			continue
+		}
+
+		sIds = append(sIds, s.Id)
+
+		if !s.isRotated() {
+			revoked++
+		}
+	}
+
+	err = h.r.deleteSessions(r.Context(), userId, sIds)
+
+	if err != nil {
+		http_api.ErrorRes(w, errs.BadGateway.WithMessage(errMsg.revokeSession))
+		return
+	}
+
+	http_api.SuccessResMsgWithBody(w, "sessions revoked", &revokedSessionsRes{Revoked: revoked})
+}
+
+// * passkeys
+
+// challenge to register a passkey for the logged in user
+func (h *authHandler) passkeyRegisterOptions(w http.ResponseWriter, r *http.Request) {
+	_, userId, err := h.validSessionFromCookie(r)
+
+	if err != nil {
+		http_api.ErrorRes(w, errs.Unauthorized.WithMessage(errMsg.invalidSession))
+		return
+	}
+
+	var b passkeyOptionsReq
+
+	r.Body = http.MaxBytesReader(w, r.Body, 1048576)
+
+	// body is optional
+	_ = json.NewDecoder(r.Body).Decode(&b)
+
+	if b.UserName == "" {
+		b.UserName = "TabsFlow account"
+	}
+
+	existing, err := h.r.getPasskeys(r.Context(), userId)
+
+	if err != nil {
+		http_api.ErrorRes(w, errs.BadGateway.WithMessage(errMsg.registerPasskey))
+		return
+	}
+
+	challenge := utils.GenerateRandomString(64)
+
+	err = h.r.savePasskeyChallenge(r.Context(), &passkeyChallenge{
+		Challenge: challenge,
+		Ceremony:  ceremonyCreate,
+		UserId:    userId,
+		TTL:       time.Now().Add(time.Second * config.PASSKEY_CHALLENGE_TIMEOUT_SEC).Unix(),
+	})
+
+	if err != nil {
+		http_api.ErrorRes(w, errs.BadGateway.WithMessage(errMsg.registerPasskey))
+		return
+	}
+
+	http_api.SuccessResData(w, newPasskeyCreationOptions(challenge, userId, b.UserName, existing))
+}
+
+func (h *authHandler) registerPasskey(w http.ResponseWriter, r *http.Request) {
+	_, userId, err := h.validSessionFromCookie(r)
+
+	if err != nil {
+		http_api.ErrorRes(w, errs.Unauthorized.WithMessage(errMsg.invalidSession))
+		return
+	}
+
+	var b registerPasskeyReq
+
+	r.Body = http.MaxBytesReader(w, r.Body, 1048576)
+
+	err = json.NewDecoder(r.Body).Decode(&b)
+
+	if err != nil || b.Challenge == "" {
+		logger.Error("Error decoding request body for registerPasskey", err)
+		http_api.ErrorRes(w, errs.BadRequest.WithMessage(errMsg.registerPasskey))
+		return
+	}
+
+	c, err := h.r.consumePasskeyChallenge(r.Context(), b.Challenge)
+
+	// challenge must be issued to the same user
+	if err != nil || c.Ceremony != ceremonyCreate || c.UserId != userId {
+		http_api.ErrorRes(w, errPasskeyChallenge)
+		return
+	}
+
+	authData, err := verifyPasskeyRegistration(&b.Credential, c.Challenge)
+
+	if err != nil {
+		http_api.ErrorRes(w, errs.BadRequest.New(errCode.invalidPasskey, err.Error()))
+		return
+	}
+
+	if b.Name == "" {
+		ua := useragent.New(r.Header.Get("User-Agent"))
+		browser, _ := ua.Browser()
+		b.Name = strings.TrimSpace(browser + " " + ua.OS())
+	}
+
+	now := time.Now().Unix()
+
+	p := &passkey{
+		UserId:     userId,
+		Id:         b.Credential.Id,
+		PublicKey:  base64.StdEncoding.EncodeToString(authData.publicKey),
+		Alg:        authData.alg,
+		SignCount:  authData.signCount,
+		Name:       b.Name,
+		CreatedAt:  now,
+		LastUsedAt: now,
+	}
+
+	err = h.r.savePasskey(r.Context(), p)
+
+	if err != nil {
+		if errors.Is(err, errPasskeyExists) {
+			http_api.ErrorRes(w, errPasskeyExists)
+			return
+		}
+		http_api.ErrorRes(w, errs.BadGateway.WithMessage(errMsg.registerPasskey))
+		return
+	}
+
+	http_api.SuccessResMsgWithBody(w, "passkey registered", p)
+}
+
+// challenge to login with a passkey, the user is identified by the credential
+func (h *authHandler) passkeyLoginOptions(w http.ResponseWriter, r *http.Request) {
+	challenge := utils.GenerateRandomString(64)
+
+	err := h.r.savePasskeyChallenge(r.Context(), &passkeyChallenge{
+		Challenge: challenge,
+		Ceremony:  ceremonyGet,
+		TTL:       time.Now().Add(time.Second * config.PASSKEY_CHALLENGE_TIMEOUT_SEC).Unix(),
+	})
+
+	if err != nil {
+		http_api.ErrorRes(w, errs.BadGateway.WithMessage(errPasskeyChallenge.Message))
+		return
+	}
+
+	http_api.SuccessResData(w, newPasskeyRequestOptions(challenge))
+}
+
+func (h *authHandler) passkeyLogin(w http.ResponseWriter, r *http.Request) {
+	var b passkeyLoginReq
+
+	r.Body = http.MaxBytesReader(w, r.Body, 1048576)
+
+	err := json.NewDecoder(r.Body).Decode(&b)
+
+	if err != nil || b.Challenge == "" {
+		logger.Error("Error decoding request body for passkeyLogin", err)
+		http_api.ErrorRes(w, errs.BadRequest.WithMessage(errInvalidPasskey.Message))
+		return
+	}
+
+	c, err := h.r.consumePasskeyChallenge(r.Context(), b.Challenge)
+
+	if err != nil || c.Ceremony != ceremonyGet {
+		http_api.ErrorRes(w, errPasskeyChallenge)
+		return
+	}
+
+	userId, err := passkeyUserId(&b.Credential)
+
+	if err != nil {
+		http_api.ErrorRes(w, errInvalidPasskey)
+		return
+	}
+
+	p, err := h.r.getPasskey(r.Context(), userId, b.Credential.Id)
+
+	if err != nil {
+		http_api.ErrorRes(w, errs.BadGateway.WithMessage(errInvalidPasskey.Message))
+		return
+	}
+
+	if p == nil {
+		http_api.ErrorRes(w, errInvalidPasskey)
+		return
+	}
+
+	signCount, err := verifyPasskeyAssertion(&b.Credential, c.Challenge, p)
+
+	if err != nil {
+		logger.Errorf("Passkey verification failed for userId: %#v: \n[Error]: %v", userId, err)
+		http_api.ErrorRes(w, errInvalidPasskey)
+		return
+	}
+
+	err = h.r.updatePasskeySignCount(r.Context(), userId, p.Id, p.SignCount, signCount)
+
+	if err != nil {
+		http_api.ErrorRes(w, errInvalidPasskey)
+		return
+	}
+
+	cookie, err := createNewSession(r.Context(), userId, r.Header.Get("User-Agent"), h.r)
+
+	if err != nil {
+		http_api.ErrorRes(w, errs.Internal.WithMessage(errMsg.createSession))
+		return
+	}
+
+	http.SetCookie(w, cookie)
+
+	http_api.SuccessResMsgWithBody(w, "passkey verified successfully", &checkNewUserRes{
+		UserId:  userId,
+		NewUser: false,
+	})
+}
+
+func (h *authHandler) getPasskeys(w http.ResponseWriter, r *http.Request) {
+	_, userId, err := h.validSessionFromCookie(r)
+
+	if err != nil {
+		http_api.ErrorRes(w, errs.Unauthorized.WithMessage(errMsg.invalidSession))
+		return
+	}
+
+	passkeys, err := h.r.getPasskeys(r.Context(), userId)
+
+	if err != nil {
+		http_api.ErrorRes(w, errs.BadGateway.WithMessage(errMsg.getPasskeys))
+		return
+	}
+
+	http_api.SuccessResData(w, passkeys)
+}
+
+func (h *authHandler) deletePasskey(w http.ResponseWriter, r *http.Request) {
+	_, userId, err := h.validSessionFromCookie(r)
+
+	if err != nil {
+		http_api.ErrorRes(w, errs.Unauthorized.WithMessage(errMsg.invalidSession))
+		return
+	}
+
+	id := r.PathValue("id")
+
+	if id == "" {
+		http_api.ErrorRes(w, errs.BadRequest.WithMessage(errMsg.deletePasskey))
+		return
+	}
+
+	p, err := h.r.getPasskey(r.Context(), userId, id)
+
+	if err != nil {
+		http_api.ErrorRes(w, errs.BadGateway.WithMessage(errMsg.deletePasskey))
+		return
+	}
+
+	if p == nil {
+		http_api.ErrorRes(w, errs.NotFound.WithMessage(errMsg.passkeyNotFound))
+		return
+	}
+
+	err = h.r.deletePasskey(r.Context(), userId, id)
+
+	if err != nil {
+		http_api.ErrorRes(w, errs.BadGateway.WithMessage(errMsg.deletePasskey))
+		return
+	}
+
+	http_api.SuccessResMsg(w, "passkey deleted")
+}
+
+// * personal api tokens
+
+// creates a scoped, expiring api token; the token is returned only once
+func (h *authHandler) createAPIToken(w http.ResponseWriter, r *http.Request) {
+	_, userId, err := h.validSessionFromCookie(r)
+
+	if err != nil {
+		http_api.ErrorRes(w, errs.Unauthorized.WithMessage(errMsg.invalidSession))
+		return
+	}
+
+	var b createAPITokenReq
+
+	r.Body = http.MaxBytesReader(w, r.Body, 1048576)
+
+	err = json.NewDecoder(r.Body).Decode(&b)
+
+	if err != nil || strings.TrimSpace(b.Name) == "" {
+		logger.Error("Error decoding request body for createAPIToken", err)
+		http_api.ErrorRes(w, errs.BadRequest.WithMessage(errMsg.createAPIToken))
+		return
+	}
+
+	if len(b.Scopes) == 0 {
+		http_api.ErrorRes(w, errs.BadRequest.WithMessage(errMsg.invalidTokenScope))
+		return
+	}
+
+	for _, scope := range b.Scopes {
+		if !slices.Contains(http_api.TokenScopes, scope) {
+			http_api.ErrorRes(w, errs.BadRequest.WithMessage(errMsg.invalidTokenScope).WithDetails(map[string]string{"scope": scope}))
+			return
+		}
+	}
+
+	if b.ExpiresInDays == 0 {
+		b.ExpiresInDays = config.API_TOKEN_DEFAULT_EXPIRY_DAYS
+	}
+
+	if b.ExpiresInDays < 0 || b.ExpiresInDays > config.API_TOKEN_MAX_EXPIRY_DAYS {
+		http_api.ErrorRes(w, errs.BadRequest.WithMessage(errMsg.invalidTokenExpiry))
+		return
+	}
+
+	tokens, err := h.r.getAPITokens(r.Context(), userId)
+
+	if err != nil {
+		http_api.ErrorRes(w, errs.BadGateway.WithMessage(errMsg.createAPIToken))
+		return
+	}
+
+	if len(tokens) >= config.API_TOKEN_MAX_PER_USER {
+		http_api.ErrorRes(w, errs.Conflict.WithMessage(errMsg.apiTokenLimit))
+		return
+	}
+
+	slices.Sort(b.Scopes)
+
+	id := utils.GenerateID()
+
+	token, hash := newAPITokenValue(userId, id)
+
+	t := &apiToken{
+		UserId:    userId,
+		Id:        id,
+		Name:      strings.TrimSpace(b.Name),
+		Hash:      hash,
+		Scopes:    slices.Compact(b.Scopes),
+		CreatedAt: time.Now().Unix(),
+		ExpiresAt: time.Now().AddDate(0, 0, b.ExpiresInDays).Unix(),
+	}
+
+	err = h.r.saveAPIToken(r.Context(), t)
+
+	if err != nil {
+		http_api.ErrorRes(w, errs.BadGateway.WithMessage(errMsg.createAPIToken))
+		return
+	}
+
+	http_api.SuccessResMsgWithBody(w, "api token created", &createdAPITokenRes{
+		apiToken: t,
+		Token:    token,
+	})
+}
+
+func (h *authHandler) getAPITokens(w http.ResponseWriter, r *http.Request) {
+	_, userId, err := h.validSessionFromCookie(r)
+
+	if err != nil {
+		http_api.ErrorRes(w, errs.Unauthorized.WithMessage(errMsg.invalidSession))
+		return
+	}
+
+	tokens, err := h.r.getAPITokens(r.Context(), userId)
+
+	if err != nil {
+		http_api.ErrorRes(w, errs.BadGateway.WithMessage(errMsg.getAPITokens))
+		return
+	}
+
+	http_api.SuccessResData(w, tokens)
+}
+
+func (h *authHandler) revokeAPIToken(w http.ResponseWriter, r *http.Request) {
+	_, userId, err := h.validSessionFromCookie(r)
+
+	if err != nil {
+		http_api.ErrorRes(w, errs.Unauthorized.WithMessage(errMsg.invalidSession))
+		return
+	}
+
+	id := r.PathValue("id")
+
+	if id == "" {
+		http_api.ErrorRes(w, errs.BadRequest.WithMessage(errMsg.deleteAPIToken))
+		return
+	}
+
+	t, err := h.r.getAPIToken(r.Context(), userId, id)
+
+	if err != nil {
+		http_api.ErrorRes(w, errs.BadGateway.WithMessage(errMsg.deleteAPIToken))
+		return
+	}
+
+	if t == nil {
+		http_api.ErrorRes(w, errs.NotFound.WithMessage(errMsg.apiTokenNotFound))
+		return
+	}
+
+	err = h.r.deleteAPIToken(r.Context(), userId, id)
+
+	if err != nil {
+		http_api.ErrorRes(w, errs.BadGateway.WithMessage(errMsg.deleteAPIToken))
+		return
+	}
+
+	http_api.SuccessResMsg(w, "api token revoked")
+}
+
+// family of the session with the id, empty if not found
+func sessionFamily(sessions []session, sId string) string {
+	i := slices.IndexFunc(sessions, func(s session) bool { return s.Id == sId })
+
+	if i < 0 {
+		return ""
+	}
+
+	return sessions[i].family()
+}
+
+// session & user id from the request's session cookie, after validating the session
+// (auth routes are not behind the lambda authorizer)
+func (h *authHandler) validSessionFromCookie(r *http.Request) (string, string, error) {
+	c, err := r.Cookie(SessionCookieName)
+
+	if err != nil {
+		return "", "", errors.New(errMsg.invalidSession)
+	}
+
+	sId, userId, err := GetSessionValues(c.Value)
+
+	if err != nil {
+		return "", "", errors.New(errMsg.invalidSessionValue)
+	}
+
+	isValid, err := h.r.ValidateSession(r.Context(), userId, sId)
+
+	if err != nil || !isValid {
+		return "", "", errors.New(errMsg.invalidSession)
+	}
+
+	return sId, userId, nil
+}
+
+func (h *authHandler) lambdaAuthorizer(ctx context.Context, ev *lambda_events.APIGatewayCustomAuthorizerRequestTypeRequest) (*lambda_events.APIGatewayCustomAuthorizerResponse, error) {
 
 	// allow paddle webhook url, without auth tokens
 	if strings.Contains(ev.Path, "/users/subscription/webhook") {
@@ -255,6 +1033,32 @@ func (h *authHandler) lambdaAuthorizer(ev *lambda_events.APIGatewayCustomAuthori
 		return generatePolicy("paddle-webhook", "Allow", ev.MethodArn, "", nil), nil
 	}
 
+	authHeader := ev.Headers["Authorization"]
+
+	if authHeader == "" {
+		authHeader = ev.Headers["authorization"]
+	}
+
+	// personal api token, for scripts & integrations
+	if token := bearerToken(authHeader); token != "" {
+		if res := getCachedAuthorizerRes(token); res != nil {
+			return res, nil
+		}
+
+		t, err := authorizeAPIToken(ctx, token, h.r)
+
+		if err != nil {
+			logger.Error("Error validating api token", err)
+			return nil, errors.New("Unauthorized")
+		}
+
+		res := apiTokenPolicy(t, ev.MethodArn)
+
+		cacheAuthorizerRes(token, res)
+
+		return res, nil
+	}
+
 	cookieHeader := ev.Headers["Cookie"]
 
 	if ev.Headers["Cookie"] == "" {
@@ -268,6 +1072,11 @@ func (h *authHandler) lambdaAuthorizer(ev *lambda_events.APIGatewayCustomAuthori
 		return nil, errors.New("Unauthorized")
 	}
 
+	if res := getCachedAuthorizerRes(cookies["session"]); res != nil {
+		return res, nil
+	}
+
+	// verify the cookie signature before the db lookup
 	sId, userId, err := GetSessionValues(cookies["session"])
 
 	if err != nil {
@@ -275,29 +1084,17 @@ func (h *authHandler) lambdaAuthorizer(ev *lambda_events.APIGatewayCustomAuthori
 		return nil, errors.New("Unauthorized")
 	}
 
-	// validate session
-	isValid, err := h.r.ValidateSession(userId, sId)
+	// validate session, and rotate it after the refresh interval
+	cookie, err := authorizeSession(ctx, userId, sId, ev.Headers["User-Agent"], h.r)
 
 	if err != nil {
-		logger.Error("Error validating session", errors.New(errMsg.ValidateSession))
+		logger.Error("Error validating session", err)
 		return nil, errors.New("Unauthorized")
 	}
 
-	// if session, valid then refresh token and allow access
-	if !isValid {
-		logger.Error("Error validating session", errors.New(errMsg.ValidateSession))
-		return nil, errors.New("Unauthorized")
-	}
-
-	cookie, err := createNewSession(userId, ev.Headers["User-Agent"], h.r)
+	res := generatePolicy(userId, "Allow", ev.MethodArn, userId, cookie)
 
-	if err != nil {
-		return nil, errors.New("Unauthorized")
-	}
-
-	newCookies := map[string]string{
-		"session": cookie.Value,
-	}
+	cacheAuthorizerRes(cookies["session"], res)
 
-	return generatePolicy(userId, "Allow", ev.MethodArn, userId, newCookies), nil
+	return res, nil
 }
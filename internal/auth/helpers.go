@@ -1,7 +1,7 @@
 package auth
 
 import (
-	"errors"
+	"context"
 	"fmt"
 	"net/http"
 	"strings"
@@ -11,31 +11,20 @@ import (
 	"github.com/mssola/useragent"
 
 	"github.com/manishMandal02/tabsflow-backend/config"
+	"github.com/manishMandal02/tabsflow-backend/pkg/http_api"
 	"github.com/manishMandal02/tabsflow-backend/pkg/logger"
 	"github.com/manishMandal02/tabsflow-backend/pkg/utils"
 )
 
 // * helpers
-// validate jwt token
+// verifies the session cookie signature and returns the sessionId & userId, without a db lookup
 func GetSessionValues(cookieValue string) (string, string, error) {
 
-	//cookieValue = id={sessionId}//uid={userId}
-
-	sessionValues := strings.Split(cookieValue, "//")
-
-	if len(sessionValues) != 2 {
-		return "", "", errors.New("invalid session value")
-	}
-
-	sessionId := strings.Split(sessionValues[0], "=")[1]
-
-	userId := strings.Split(sessionValues[1], "=")[1]
-
-	if sessionId == "" || userId == "" {
-		return "", "", errors.New("invalid session value")
+	if strings.HasPrefix(cookieValue, sessionTokenVersion+".") {
+		return verifySessionToken(cookieValue)
 	}
 
-	return sessionId, userId, nil
+	return parseLegacySessionValue(cookieValue)
 }
 
 // parse cookie
@@ -63,8 +52,8 @@ type checkNewUserRes = struct {
 	NewUser bool   `json:"isNewUser"`
 }
 
-func checkIfNewUser(email string, aR authRepository) (*checkNewUserRes, error) {
-	userId, err := aR.userIdByEmail(email)
+func checkIfNewUser(ctx context.Context, email string, aR authRepository) (*checkNewUserRes, error) {
+	userId, err := aR.userIdByEmail(ctx, email)
 
 	var res checkNewUserRes
 
@@ -72,7 +61,7 @@ func checkIfNewUser(email string, aR authRepository) (*checkNewUserRes, error) {
 		// new user
 		newUserId := utils.GenerateID()
 
-		err = aR.attachUserId(&emailWithUserId{
+		err = aR.attachUserId(ctx, &emailWithUserId{
 			Email:  email,
 			UserId: newUserId,
 		})
@@ -92,18 +81,38 @@ func checkIfNewUser(email string, aR authRepository) (*checkNewUserRes, error) {
 	return &res, nil
 }
 
-func createNewSession(userId, userAgent string, aR authRepository) (*http.Cookie, error) {
+// creates a new session (login) & returns its cookie
+func createNewSession(ctx context.Context, userId, userAgent string, aR authRepository) (*http.Cookie, error) {
+	session := newSession(userId, userAgent, nil)
+
+	err := aR.createSession(ctx, session)
+
+	if err != nil {
+		logger.Error(errMsg.createSession, err)
+		return nil, err
+	}
+
+	return sessionCookie(session.Id, userId)
+}
 
+// new session in the family of the parent session (rotation), or a new family if parent is nil
+func newSession(userId, userAgent string, parent *session) *session {
 	ua := useragent.New(userAgent)
 
 	browser, _ := ua.Browser()
 
 	sId := utils.GenerateID()
 
-	session := session{
-		UserId: userId,
-		Id:     sId,
-		TTL:    time.Now().AddDate(0, 0, config.USER_SESSION_EXPIRY_DAYS).Unix(),
+	now := time.Now().Unix()
+
+	s := &session{
+		UserId:     userId,
+		Id:         sId,
+		TTL:        time.Now().AddDate(0, 0, config.USER_SESSION_EXPIRY_DAYS).Unix(),
+		FamilyId:   sId,
+		CreatedAt:  now,
+		IssuedAt:   now,
+		LastSeenAt: now,
 		DeviceInfo: &deviceInfo{
 			Browser:  browser,
 			OS:       ua.OS(),
@@ -112,17 +121,24 @@ func createNewSession(userId, userAgent string, aR authRepository) (*http.Cookie
 		},
 	}
 
-	err := aR.createSession(&session)
+	if parent != nil {
+		s.FamilyId = parent.family()
+		s.CreatedAt = parent.CreatedAt
+	}
+
+	return s
+}
+
+func sessionCookie(sId, userId string) (*http.Cookie, error) {
+	sValue, err := signSessionToken(sId, userId)
 
 	if err != nil {
-		logger.Error(errMsg.createSession, err)
+		logger.Error(errMsg.createToken, err)
 		return nil, err
 	}
 
-	sValue := fmt.Sprintf("id=%s//uid=%s", sId, userId)
-
 	cookie := &http.Cookie{
-		Name:     "session",
+		Name:     SessionCookieName,
 		Value:    sValue,
 		HttpOnly: true,
 		Secure:   true,
@@ -135,7 +151,8 @@ func createNewSession(userId, userAgent string, aR authRepository) (*http.Cookie
 }
 
 // generate policy for lambda authorizer
-func generatePolicy(principalId, effect, methodArn, userId string, cookies map[string]string) *lambda_events.APIGatewayCustomAuthorizerResponse {
+// the cookie (rotated session) is set in the context, the services write it to the response
+func generatePolicy(principalId, effect, methodArn, userId string, cookie *http.Cookie) *lambda_events.APIGatewayCustomAuthorizerResponse {
 
 	// remove the path and method from the arn, so it allows all the path and method even with cached data
 	arnParts := strings.Split(methodArn, ":")
@@ -163,13 +180,9 @@ func generatePolicy(principalId, effect, methodArn, userId string, cookies map[s
 		}
 	}
 
-	if cookies != nil {
-		cookieStrings := make([]string, 0, len(cookies))
-		for key, value := range cookies {
-			cookieStrings = append(cookieStrings, fmt.Sprintf("%s=%s; HttpOnly; Secure; SameSite=Strict; Expires:%v", key, value, time.Now().AddDate(0, 0, config.JWT_TOKEN_EXPIRY_IN_DAYS*3).Format(time.RFC1123)))
-		}
+	if cookie != nil {
 		authResponse.Context = map[string]interface{}{
-			"Set-Cookie": strings.Join(cookieStrings, ", "),
+			http_api.SetCookieContextKey: cookie.String(),
 		}
 	}
 
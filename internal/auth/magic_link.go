@@ -0,0 +1,130 @@
+package auth
+
+import (
+	"crypto/hmac"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/manishMandal02/tabsflow-backend/config"
+	"github.com/manishMandal02/tabsflow-backend/pkg/http_api"
+)
+
+// * signed magic link
+// token format: {keyId}.{base64(email:linkId:expiresAt)}.{signature}
+
+func signMagicLinkToken(email, id string, expiresAt int64) (string, error) {
+	if config.JWT_SECRET_KEY == "" {
+		return "", errors.New(errMsg.createToken)
+	}
+
+	payload := fmt.Sprintf("%s:%s:%d", email, id, expiresAt)
+
+	unsigned := fmt.Sprintf("%s.%s", sessionKeyId(), base64.RawURLEncoding.EncodeToString([]byte(payload)))
+
+	return unsigned + "." + sessionTokenSignature(unsigned, config.JWT_SECRET_KEY), nil
+}
+
+// verifies the token and returns the email & link id
+func verifyMagicLinkToken(token string) (string, string, error) {
+	parts := strings.Split(token, ".")
+
+	if len(parts) != 3 {
+		return "", "", errInvalidMagicLink
+	}
+
+	key, ok := sessionSigningKeys()[parts[0]]
+
+	if !ok || key == "" {
+		return "", "", errInvalidMagicLink
+	}
+
+	unsigned := parts[0] + "." + parts[1]
+
+	if !hmac.Equal([]byte(parts[2]), []byte(sessionTokenSignature(unsigned, key))) {
+		return "", "", errInvalidMagicLink
+	}
+
+	payloadBytes, err := base64.RawURLEncoding.DecodeString(parts[1])
+
+	if err != nil {
+		return "", "", errInvalidMagicLink
+	}
+
+	// split from the end, the email may have ':'
+	rest, exp, ok1 := cutLast(string(payloadBytes), ":")
+	email, id, ok2 := cutLast(rest, ":")
+
+	if !ok1 || !ok2 || email == "" || id == "" {
+		return "", "", errInvalidMagicLink
+	}
+
+	expiresAt, err := strconv.ParseInt(exp, 10, 64)
+
+	if err != nil {
+		return "", "", errInvalidMagicLink
+	}
+
+	if expiresAt < time.Now().Unix() {
+		return "", "", errExpiredMagicLink
+	}
+
+	return email, id, nil
+}
+
+func cutLast(s, sep string) (string, string, bool) {
+	i := strings.LastIndex(s, sep)
+
+	if i < 0 {
+		return s, "", false
+	}
+
+	return s[:i], s[i+len(sep):], true
+}
+
+func magicLinkURL(token string) string {
+	p := "https"
+	if config.LOCAL_DEV_ENV {
+		p = "http"
+	}
+
+	return fmt.Sprintf("%s://%s/auth/magic-link/verify?token=%s", p, config.API_DOMAIN_NAME, url.QueryEscape(token))
+}
+
+// app origin to redirect to after login, must be an allowed origin
+func magicLinkOrigin(origin string) string {
+	origin = strings.TrimSuffix(origin, "/")
+
+	if slices.Contains(config.AllowedOrigins, origin) {
+		return origin
+	}
+
+	return "https://" + config.APP_DOMAIN_NAME
+}
+
+// redirects back to the app, with the login result in the query
+func magicLinkRedirect(w http.ResponseWriter, r *http.Request, origin string, query url.Values) {
+	http.Redirect(w, r, magicLinkOrigin(origin)+"/?"+query.Encode(), http.StatusFound)
+}
+
+// magic link is opened from the email, without an origin header
+func skipForPath(path string, m http_api.Middleware) http_api.Middleware {
+	return func(next http_api.Handler) http_api.Handler {
+		withMiddleware := m(next)
+
+		return func(w http.ResponseWriter, r *http.Request) {
+			if strings.HasSuffix(strings.TrimSuffix(r.URL.Path, "/"), path) {
+				next(w, r)
+				return
+			}
+
+			withMiddleware(w, r)
+		}
+	}
+}
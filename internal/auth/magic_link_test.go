@@ -0,0 +1,94 @@
+package auth
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/manishMandal02/tabsflow-backend/config"
+)
+
+func TestVerifyMagicLinkToken(t *testing.T) {
+	config.JWT_SECRET_KEY = "secret"
+	config.JWT_SECRET_KEY_ID = ""
+	config.JWT_PREVIOUS_SECRET_KEYS = ""
+
+	expiresAt := time.Now().Add(time.Minute).Unix()
+
+	token, err := signMagicLinkToken("test@tabsflow.com", "link-1", expiresAt)
+
+	if err != nil {
+		t.Fatalf("signMagicLinkToken() unexpected error = %v", err)
+	}
+
+	expired, err := signMagicLinkToken("test@tabsflow.com", "link-1", time.Now().Add(-time.Minute).Unix())
+
+	if err != nil {
+		t.Fatalf("signMagicLinkToken() unexpected error = %v", err)
+	}
+
+	parts := strings.Split(token, ".")
+
+	tests := []struct {
+		name    string
+		token   string
+		wantErr error
+	}{
+		{
+			name:  "valid",
+			token: token,
+		},
+		{
+			name:    "expired",
+			token:   expired,
+			wantErr: errExpiredMagicLink,
+		},
+		{
+			name:    "tampered signature",
+			token:   parts[0] + "." + parts[1] + ".invalid",
+			wantErr: errInvalidMagicLink,
+		},
+		{
+			name:    "unknown key id",
+			token:   "9." + parts[1] + "." + parts[2],
+			wantErr: errInvalidMagicLink,
+		},
+		{
+			name:    "empty",
+			token:   "",
+			wantErr: errInvalidMagicLink,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			email, id, err := verifyMagicLinkToken(tt.token)
+
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) {
+					t.Fatalf("verifyMagicLinkToken() error = %v, wantErr %v", err, tt.wantErr)
+				}
+				return
+			}
+
+			if err != nil {
+				t.Fatalf("verifyMagicLinkToken() unexpected error = %v", err)
+			}
+
+			if email != "test@tabsflow.com" || id != "link-1" {
+				t.Errorf("verifyMagicLinkToken() = %v, %v", email, id)
+			}
+		})
+	}
+}
+
+func TestMagicLinkOrigin(t *testing.T) {
+	if got := magicLinkOrigin("https://app.tabsflow.com/"); got != "https://app.tabsflow.com" {
+		t.Errorf("magicLinkOrigin() = %v, want allowed origin", got)
+	}
+
+	if got := magicLinkOrigin("https://evil.com"); got != "https://"+config.APP_DOMAIN_NAME {
+		t.Errorf("magicLinkOrigin() = %v, want default origin", got)
+	}
+}
@@ -0,0 +1,129 @@
+package auth
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/manishMandal02/tabsflow-backend/config"
+	"github.com/manishMandal02/tabsflow-backend/pkg/errs"
+	"github.com/manishMandal02/tabsflow-backend/pkg/http_api"
+)
+
+// in-memory otp & attempts of an email
+type otpRepoMock struct {
+	authRepository
+	otp         string
+	attempts    otpAttempts
+	invalidated bool
+}
+
+func (m *otpRepoMock) getOTPAttempts(_ context.Context, _ string) (*otpAttempts, error) {
+	a := m.attempts
+	return &a, nil
+}
+
+func (m *otpRepoMock) recordOTPAttempt(_ context.Context, _ string) (*otpAttempts, error) {
+	if m.attempts.Failures >= config.OTP_MAX_FAILED_ATTEMPTS || m.attempts.LockedUntil > time.Now().Unix() {
+		a := m.attempts
+		return &a, errOTPLocked
+	}
+
+	m.attempts.Failures++
+	a := m.attempts
+	return &a, nil
+}
+
+func (m *otpRepoMock) lockOTP(_ context.Context, _ string, until int64) error {
+	m.attempts.LockedUntil = until
+	return nil
+}
+
+func (m *otpRepoMock) invalidateOTPs(_ context.Context, _ string, resetAttempts bool) error {
+	m.otp = ""
+	m.invalidated = true
+
+	if resetAttempts {
+		m.attempts = otpAttempts{}
+	}
+
+	return nil
+}
+
+func (m *otpRepoMock) consumeOTP(_ context.Context, _, otp string) (bool, error) {
+	if m.otp == "" || m.otp != otp {
+		return false, nil
+	}
+
+	m.otp = ""
+	return true, nil
+}
+
+// response & its error, empty if the response has none
+func verifyOTPReq(h *authHandler, otp string) (*httptest.ResponseRecorder, *errs.Error) {
+	req := httptest.NewRequest(http.MethodPost, "/auth/verify-otp", strings.NewReader(`{"email":"test@tabsflow.com","otp":"`+otp+`"}`))
+	w := httptest.NewRecorder()
+
+	h.verifyOTP(w, req)
+
+	res := &http_api.APIResponse{}
+	_ = json.NewDecoder(w.Body).Decode(res)
+
+	if res.Error == nil {
+		return w, &errs.Error{}
+	}
+
+	return w, res.Error
+}
+
+func TestVerifyOTPLockout(t *testing.T) {
+	r := &otpRepoMock{otp: "123456"}
+	h := newAuthHandler(r, nil)
+
+	for i := 1; i < config.OTP_MAX_FAILED_ATTEMPTS; i++ {
+		w, res := verifyOTPReq(h, "000000")
+
+		if w.Code != http.StatusBadRequest || res.Code != errCode.invalidOTP {
+			t.Fatalf("attempt %v: status = %v, code = %v, want %v, %v", i, w.Code, res.Code, http.StatusBadRequest, errCode.invalidOTP)
+		}
+	}
+
+	w, res := verifyOTPReq(h, "000000")
+
+	if w.Code != http.StatusTooManyRequests || res.Code != errCode.otpLocked || w.Header().Get("Retry-After") == "" {
+		t.Fatalf("last attempt: status = %v, code = %v, want %v, %v with Retry-After", w.Code, res.Code, http.StatusTooManyRequests, errCode.otpLocked)
+	}
+
+	if !r.invalidated || r.otp != "" {
+		t.Errorf("OTP not invalidated after lockout")
+	}
+
+	// correct otp is rejected while locked
+	r.otp = "123456"
+
+	w, res = verifyOTPReq(h, "123456")
+
+	if w.Code != http.StatusTooManyRequests || res.Code != errCode.otpLocked {
+		t.Errorf("locked: status = %v, code = %v, want %v, %v", w.Code, res.Code, http.StatusTooManyRequests, errCode.otpLocked)
+	}
+}
+
+func TestVerifyOTPMaxAttemptsInFlight(t *testing.T) {
+	// max attempts used by concurrent guesses, the email isn't locked yet
+	r := &otpRepoMock{otp: "123456", attempts: otpAttempts{Failures: config.OTP_MAX_FAILED_ATTEMPTS, TTL: time.Now().Add(time.Minute).Unix()}}
+	h := newAuthHandler(r, nil)
+
+	w, res := verifyOTPReq(h, "123456")
+
+	if w.Code != http.StatusTooManyRequests || res.Code != errCode.otpLocked || w.Header().Get("Retry-After") == "" {
+		t.Fatalf("status = %v, code = %v, want %v, %v with Retry-After", w.Code, res.Code, http.StatusTooManyRequests, errCode.otpLocked)
+	}
+
+	if r.otp != "123456" {
+		t.Errorf("OTP consumed after max attempts")
+	}
+}
@@ -0,0 +1,66 @@
+package auth
+
+import (
+	"net"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/manishMandal02/tabsflow-backend/config"
+	"github.com/manishMandal02/tabsflow-backend/pkg/http_api"
+	"github.com/manishMandal02/tabsflow-backend/pkg/logger"
+)
+
+// throttles the requests per client ip, in fixed 1 minute windows
+func rateLimitByIP(aR authRepository) http_api.Middleware {
+	return func(next http_api.Handler) http_api.Handler {
+		return func(w http.ResponseWriter, r *http.Request) {
+			ip := clientIP(r)
+
+			if ip == "" {
+				next(w, r)
+				return
+			}
+
+			now := time.Now().Unix()
+
+			window := now - now%60
+
+			count, err := aR.incrementRequestCount(r.Context(), ip, window)
+
+			// allow the request, if the limit can't be checked
+			if err != nil {
+				logger.Error("Error checking rate limit", err)
+				next(w, r)
+				return
+			}
+
+			if count > config.AUTH_RATE_LIMIT_PER_MIN {
+				setRetryAfter(w, window+60-now)
+				http_api.ErrorRes(w, errRateLimited)
+				return
+			}
+
+			next(w, r)
+		}
+	}
+}
+
+// source ip of the request, set by the API GW proxy adapter
+func clientIP(r *http.Request) string {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+
+	if err != nil {
+		return r.RemoteAddr
+	}
+
+	return host
+}
+
+func setRetryAfter(w http.ResponseWriter, seconds int64) {
+	if seconds < 1 {
+		seconds = 1
+	}
+
+	w.Header().Set("Retry-After", strconv.FormatInt(seconds, 10))
+}
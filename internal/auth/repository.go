@@ -11,18 +11,44 @@ import (
 	"github.com/aws/aws-sdk-go-v2/feature/dynamodb/expression"
 	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
 	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+	"github.com/manishMandal02/tabsflow-backend/config"
 	"github.com/manishMandal02/tabsflow-backend/pkg/db"
 	"github.com/manishMandal02/tabsflow-backend/pkg/logger"
 )
 
 type authRepository interface {
-	saveOTP(data *emailOTP) error
-	attachUserId(data *emailWithUserId) error
-	userIdByEmail(email string) (string, error)
-	validateOTP(email, otp string) (bool, error)
-	ValidateSession(email, id string) (bool, error)
-	createSession(s *session) error
-	deleteSession(email, sessionId string) error
+	saveOTP(ctx context.Context, data *emailOTP) error
+	attachUserId(ctx context.Context, data *emailWithUserId) error
+	userIdByEmail(ctx context.Context, email string) (string, error)
+	consumeOTP(ctx context.Context, email, otp string) (bool, error)
+	ValidateSession(ctx context.Context, email, id string) (bool, error)
+	createSession(ctx context.Context, s *session) error
+	deleteSession(ctx context.Context, email, sessionId string) error
+	getSession(ctx context.Context, userId, sessionId string) (*session, error)
+	getSessions(ctx context.Context, userId string) ([]session, error)
+	markSessionRotated(ctx context.Context, userId, sessionId, newSessionId string, ttl int64) error
+	updateLastSeen(ctx context.Context, userId, sessionId string) error
+	getOTPAttempts(ctx context.Context, email string) (*otpAttempts, error)
+	recordOTPSent(ctx context.Context, email string) error
+	recordOTPAttempt(ctx context.Context, email string) (*otpAttempts, error)
+	lockOTP(ctx context.Context, email string, until int64) error
+	invalidateOTPs(ctx context.Context, email string, resetAttempts bool) error
+	incrementRequestCount(ctx context.Context, ip string, window int64) (int, error)
+	saveMagicLink(ctx context.Context, m *magicLink) error
+	consumeMagicLink(ctx context.Context, email, id string) (*magicLink, error)
+	deleteSessions(ctx context.Context, userId string, sessionIds []string) error
+	savePasskeyChallenge(ctx context.Context, c *passkeyChallenge) error
+	consumePasskeyChallenge(ctx context.Context, challenge string) (*passkeyChallenge, error)
+	savePasskey(ctx context.Context, p *passkey) error
+	getPasskey(ctx context.Context, userId, id string) (*passkey, error)
+	getPasskeys(ctx context.Context, userId string) ([]passkey, error)
+	updatePasskeySignCount(ctx context.Context, userId, id string, prevCount, signCount uint32) error
+	deletePasskey(ctx context.Context, userId, id string) error
+	saveAPIToken(ctx context.Context, t *apiToken) error
+	getAPIToken(ctx context.Context, userId, id string) (*apiToken, error)
+	getAPITokens(ctx context.Context, userId string) ([]apiToken, error)
+	updateAPITokenLastUsed(ctx context.Context, userId, id string) error
+	deleteAPIToken(ctx context.Context, userId, id string) error
 }
 
 type authRepo struct {
@@ -36,7 +62,7 @@ func newAuthRepository(db *db.DDB) authRepository {
 }
 
 // save OTP to DB
-func (r *authRepo) saveOTP(data *emailOTP) error {
+func (r *authRepo) saveOTP(ctx context.Context, data *emailOTP) error {
 
 	ttl := strconv.FormatInt(data.TTL, 10)
 
@@ -46,7 +72,7 @@ func (r *authRepo) saveOTP(data *emailOTP) error {
 		db.TTL_KEY_NAME: &types.AttributeValueMemberN{Value: ttl},
 	}
 
-	_, err := r.db.Client.PutItem(context.TODO(), &dynamodb.PutItemInput{
+	_, err := r.db.Client.PutItem(ctx, &dynamodb.PutItemInput{
 		TableName: &r.db.TableName,
 		Item:      saveItem,
 	})
@@ -59,25 +85,806 @@ func (r *authRepo) saveOTP(data *emailOTP) error {
 	return nil
 }
 
-func (r *authRepo) validateOTP(email, otp string) (bool, error) {
+// failed attempts for the email, zero if none within the lockout window
+func (r *authRepo) getOTPAttempts(ctx context.Context, email string) (*otpAttempts, error) {
+	key := map[string]types.AttributeValue{
+		db.PK_NAME: &types.AttributeValueMemberS{Value: email},
+		db.SK_NAME: &types.AttributeValueMemberS{Value: db.SORT_KEY_SESSIONS.OTPAttempts},
+	}
+
+	response, err := r.db.Client.GetItem(ctx, &dynamodb.GetItemInput{
+		TableName: &r.db.TableName,
+		Key:       key,
+	})
+
+	if err != nil {
+		logger.Errorf("Couldn't get OTP attempts from db for email: %#v: \n[Error]: %v", email, err)
+		return nil, errors.New(errMsg.validateOTP)
+	}
+
+	attempts := &otpAttempts{Email: email}
+
+	if len(response.Item) == 0 {
+		return attempts, nil
+	}
+
+	err = attributevalue.UnmarshalMap(response.Item, attempts)
+
+	if err != nil {
+		logger.Errorf("Couldn't unmarshal OTP attempts for email: %#v: \n[Error]: %v", email, err)
+		return nil, errors.New(errMsg.validateOTP)
+	}
+
+	// expired, but not yet removed by ttl; deleted so the old count isn't updated again
+	if attempts.TTL < time.Now().Unix() {
+		_, err = r.db.Client.DeleteItem(ctx, &dynamodb.DeleteItemInput{
+			TableName: &r.db.TableName,
+			Key:       key,
+		})
+
+		if err != nil {
+			logger.Errorf("Couldn't delete expired OTP attempts for email: %#v: \n[Error]: %v", email, err)
+		}
+
+		return &otpAttempts{Email: email}, nil
+	}
+
+	return attempts, nil
+}
+
+// saves the otp sent time, fails if an otp was sent within the resend cooldown
+func (r *authRepo) recordOTPSent(ctx context.Context, email string) error {
+	now := time.Now().Unix()
+
+	update := expression.Set(expression.Name("LastSentAt"), expression.Value(now)).
+		Set(expression.Name(db.TTL_KEY_NAME), expression.Value(otpAttemptsTTL()))
+
+	condition := expression.AttributeNotExists(expression.Name("LastSentAt")).
+		Or(expression.Name("LastSentAt").LessThanEqual(expression.Value(now - config.OTP_RESEND_COOLDOWN_SEC)))
+
+	_, err := r.updateOTPAttempts(ctx, email, update, &condition)
+
+	if err != nil {
+		var conditionErr *types.ConditionalCheckFailedException
+
+		if errors.As(err, &conditionErr) {
+			return errOTPCooldown
+		}
+
+		logger.Errorf("Couldn't save OTP sent time for email: %#v: \n[Error]: %v", email, err)
+		return errors.New(errMsg.sendOTP)
+	}
+
+	return nil
+}
+
+// counts the attempt before the otp is checked, fails with errOTPLocked (& the current attempts)
+// once the max attempts are used or while locked; the count is reset if the attempts have expired
+func (r *authRepo) recordOTPAttempt(ctx context.Context, email string) (*otpAttempts, error) {
+	now := time.Now().Unix()
+
+	update := expression.Add(expression.Name("Failures"), expression.Value(1)).
+		Set(expression.Name(db.TTL_KEY_NAME), expression.Value(otpAttemptsTTL()))
+
+	notExpired := expression.AttributeNotExists(expression.Name(db.TTL_KEY_NAME)).
+		Or(expression.Name(db.TTL_KEY_NAME).GreaterThanEqual(expression.Value(now)))
+
+	belowMax := expression.AttributeNotExists(expression.Name("Failures")).
+		Or(expression.Name("Failures").LessThan(expression.Value(config.OTP_MAX_FAILED_ATTEMPTS)))
+
+	notLocked := expression.AttributeNotExists(expression.Name("LockedUntil")).
+		Or(expression.Name("LockedUntil").LessThanEqual(expression.Value(now)))
+
+	condition := expression.And(notExpired, belowMax, notLocked)
+
+	attempts, err := r.updateOTPAttempts(ctx, email, update, &condition)
+
+	var conditionErr *types.ConditionalCheckFailedException
+
+	if errors.As(err, &conditionErr) {
+		// expired attempts
+		update = expression.Set(expression.Name("Failures"), expression.Value(1)).
+			Set(expression.Name(db.TTL_KEY_NAME), expression.Value(otpAttemptsTTL())).
+			Remove(expression.Name("LockedUntil"))
+
+		condition = expression.Name(db.TTL_KEY_NAME).LessThan(expression.Value(now))
+
+		attempts, err = r.updateOTPAttempts(ctx, email, update, &condition)
+
+		if errors.As(err, &conditionErr) {
+			attempts, err = r.getOTPAttempts(ctx, email)
+
+			if err != nil {
+				return nil, err
+			}
+
+			return attempts, errOTPLocked
+		}
+	}
+
+	if err != nil {
+		logger.Errorf("Couldn't save OTP attempt for email: %#v: \n[Error]: %v", email, err)
+		return nil, errors.New(errMsg.validateOTP)
+	}
+
+	return attempts, nil
+}
+
+func (r *authRepo) lockOTP(ctx context.Context, email string, until int64) error {
+	update := expression.Set(expression.Name("LockedUntil"), expression.Value(until)).
+		Set(expression.Name(db.TTL_KEY_NAME), expression.Value(until))
+
+	_, err := r.updateOTPAttempts(ctx, email, update, nil)
+
+	if err != nil {
+		logger.Errorf("Couldn't lock OTP for email: %#v: \n[Error]: %v", email, err)
+		return errors.New(errMsg.validateOTP)
+	}
+
+	return nil
+}
+
+// deletes the sent otps of the email, and the failed attempts if reset
+func (r *authRepo) invalidateOTPs(ctx context.Context, email string, resetAttempts bool) error {
+	keyCondition := expression.KeyAnd(expression.Key(db.PK_NAME).Equal(expression.Value(email)), expression.Key(db.SK_NAME).BeginsWith(db.SORT_KEY_SESSIONS.OTP("")))
+
+	expr, err := expression.NewBuilder().WithKeyCondition(keyCondition).Build()
+
+	if err != nil {
+		logger.Errorf("Couldn't build OTPs expression for email: %#v: \n[Error]: %v", email, err)
+		return errors.New(errMsg.validateOTP)
+	}
+
+	response, err := r.db.Client.Query(ctx, &dynamodb.QueryInput{
+		TableName:                 &r.db.TableName,
+		ExpressionAttributeNames:  expr.Names(),
+		ExpressionAttributeValues: expr.Values(),
+		KeyConditionExpression:    expr.KeyCondition(),
+	})
+
+	if err != nil {
+		logger.Errorf("Couldn't get OTPs from db for email: %#v: \n[Error]: %v", email, err)
+		return errors.New(errMsg.validateOTP)
+	}
+
+	sks := []string{}
+
+	for _, item := range response.Items {
+		if sk, ok := item[db.SK_NAME].(*types.AttributeValueMemberS); ok {
+			sks = append(sks, sk.Value)
+		}
+	}
+
+	if resetAttempts {
+		sks = append(sks, db.SORT_KEY_SESSIONS.OTPAttempts)
+	}
+
+	err = r.db.DeleteItems(ctx, email, sks)
+
+	if err != nil {
+		logger.Errorf("Couldn't delete OTPs for email: %#v: \n[Error]: %v", email, err)
+		return errors.New(errMsg.validateOTP)
+	}
+
+	return nil
+}
+
+// updates the attempts of the email, returns the updated attempts
+func (r *authRepo) updateOTPAttempts(ctx context.Context, email string, update expression.UpdateBuilder, condition *expression.ConditionBuilder) (*otpAttempts, error) {
+	key := map[string]types.AttributeValue{
+		db.PK_NAME: &types.AttributeValueMemberS{Value: email},
+		db.SK_NAME: &types.AttributeValueMemberS{Value: db.SORT_KEY_SESSIONS.OTPAttempts},
+	}
+
+	builder := expression.NewBuilder().WithUpdate(update)
+
+	if condition != nil {
+		builder = builder.WithCondition(*condition)
+	}
+
+	expr, err := builder.Build()
+
+	if err != nil {
+		return nil, err
+	}
+
+	response, err := r.db.Client.UpdateItem(ctx, &dynamodb.UpdateItemInput{
+		TableName:                 &r.db.TableName,
+		Key:                       key,
+		ExpressionAttributeNames:  expr.Names(),
+		ExpressionAttributeValues: expr.Values(),
+		UpdateExpression:          expr.Update(),
+		ConditionExpression:       expr.Condition(),
+		ReturnValues:              types.ReturnValueAllNew,
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	attempts := &otpAttempts{}
+
+	err = attributevalue.UnmarshalMap(response.Attributes, attempts)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return attempts, nil
+}
+
+func otpAttemptsTTL() int64 {
+	return time.Now().Add(time.Minute * config.OTP_LOCKOUT_TIME_IN_MIN).Unix()
+}
+
+// increments the requests count of the client ip in the rate limit window, returns the new count
+func (r *authRepo) incrementRequestCount(ctx context.Context, ip string, window int64) (int, error) {
+	key := map[string]types.AttributeValue{
+		db.PK_NAME: &types.AttributeValueMemberS{Value: db.PARTITION_KEY.ClientIP(ip)},
+		db.SK_NAME: &types.AttributeValueMemberS{Value: db.SORT_KEY_SESSIONS.RateLimit(strconv.FormatInt(window, 10))},
+	}
+
+	// window start + 1 min, kept for another minute
+	update := expression.Add(expression.Name("Count"), expression.Value(1)).
+		Set(expression.Name(db.TTL_KEY_NAME), expression.Value(window+120))
+
+	expr, err := expression.NewBuilder().WithUpdate(update).Build()
+
+	if err != nil {
+		return 0, err
+	}
+
+	response, err := r.db.Client.UpdateItem(ctx, &dynamodb.UpdateItemInput{
+		TableName:                 &r.db.TableName,
+		Key:                       key,
+		ExpressionAttributeNames:  expr.Names(),
+		ExpressionAttributeValues: expr.Values(),
+		UpdateExpression:          expr.Update(),
+		ReturnValues:              types.ReturnValueUpdatedNew,
+	})
+
+	if err != nil {
+		logger.Errorf("Couldn't increment request count for ip: %#v: \n[Error]: %v", ip, err)
+		return 0, errRateLimited
+	}
+
+	var c struct {
+		Count int
+	}
+
+	err = attributevalue.UnmarshalMap(response.Attributes, &c)
+
+	if err != nil {
+		return 0, err
+	}
+
+	return c.Count, nil
+}
+
+func (r *authRepo) saveMagicLink(ctx context.Context, m *magicLink) error {
+	item := map[string]types.AttributeValue{
+		db.PK_NAME:      &types.AttributeValueMemberS{Value: m.Email},
+		db.SK_NAME:      &types.AttributeValueMemberS{Value: db.SORT_KEY_SESSIONS.MagicLink(m.Id)},
+		db.TTL_KEY_NAME: &types.AttributeValueMemberN{Value: strconv.FormatInt(m.TTL, 10)},
+		"Origin":        &types.AttributeValueMemberS{Value: m.Origin},
+	}
+
+	_, err := r.db.Client.PutItem(ctx, &dynamodb.PutItemInput{
+		TableName: &r.db.TableName,
+		Item:      item,
+	})
+
+	if err != nil {
+		logger.Errorf("Couldn't save magic link for email: %#v, \n[Error:] %v", m.Email, err)
+		return errors.New(errMsg.sendMagicLink)
+	}
+
+	return nil
+}
+
+// deletes the magic link & returns it, fails if the link was already used
+func (r *authRepo) consumeMagicLink(ctx context.Context, email, id string) (*magicLink, error) {
+	key := map[string]types.AttributeValue{
+		db.PK_NAME: &types.AttributeValueMemberS{Value: email},
+		db.SK_NAME: &types.AttributeValueMemberS{Value: db.SORT_KEY_SESSIONS.MagicLink(id)},
+	}
+
+	expr, err := expression.NewBuilder().WithCondition(expression.AttributeExists(expression.Name(db.PK_NAME))).Build()
+
+	if err != nil {
+		logger.Errorf("Couldn't build magic link expression for email: %#v, \n[Error:] %v", email, err)
+		return nil, errInvalidMagicLink
+	}
+
+	response, err := r.db.Client.DeleteItem(ctx, &dynamodb.DeleteItemInput{
+		TableName:                &r.db.TableName,
+		Key:                      key,
+		ExpressionAttributeNames: expr.Names(),
+		ConditionExpression:      expr.Condition(),
+		ReturnValues:             types.ReturnValueAllOld,
+	})
+
+	if err != nil {
+		var conditionErr *types.ConditionalCheckFailedException
+
+		if errors.As(err, &conditionErr) {
+			return nil, errors.New(errMsg.usedMagicLink)
+		}
+
+		logger.Errorf("Couldn't delete magic link for email: %#v, \n[Error:] %v", email, err)
+		return nil, errInvalidMagicLink
+	}
+
+	m := &magicLink{}
+
+	err = attributevalue.UnmarshalMap(response.Attributes, m)
+
+	if err != nil {
+		logger.Errorf("Couldn't unmarshal magic link for email: %#v, \n[Error:] %v", email, err)
+		return nil, errInvalidMagicLink
+	}
+
+	m.Id = id
+
+	return m, nil
+}
+
+func (r *authRepo) savePasskeyChallenge(ctx context.Context, c *passkeyChallenge) error {
+	item := map[string]types.AttributeValue{
+		db.PK_NAME:      &types.AttributeValueMemberS{Value: db.PARTITION_KEY.PasskeyChallenge(c.Challenge)},
+		db.SK_NAME:      &types.AttributeValueMemberS{Value: db.SORT_KEY_SESSIONS.PasskeyChallenge},
+		db.TTL_KEY_NAME: &types.AttributeValueMemberN{Value: strconv.FormatInt(c.TTL, 10)},
+		"Ceremony":      &types.AttributeValueMemberS{Value: c.Ceremony},
+		"UserId":        &types.AttributeValueMemberS{Value: c.UserId},
+	}
+
+	_, err := r.db.Client.PutItem(ctx, &dynamodb.PutItemInput{
+		TableName: &r.db.TableName,
+		Item:      item,
+	})
+
+	if err != nil {
+		logger.Errorf("Couldn't save passkey challenge for ceremony: %#v, \n[Error:] %v", c.Ceremony, err)
+		return errPasskeyChallenge
+	}
+
+	return nil
+}
+
+// deletes the challenge & returns it, a challenge can be used only once
+func (r *authRepo) consumePasskeyChallenge(ctx context.Context, challenge string) (*passkeyChallenge, error) {
+	key := map[string]types.AttributeValue{
+		db.PK_NAME: &types.AttributeValueMemberS{Value: db.PARTITION_KEY.PasskeyChallenge(challenge)},
+		db.SK_NAME: &types.AttributeValueMemberS{Value: db.SORT_KEY_SESSIONS.PasskeyChallenge},
+	}
+
+	expr, err := expression.NewBuilder().WithCondition(expression.AttributeExists(expression.Name(db.PK_NAME))).Build()
+
+	if err != nil {
+		logger.Errorf("Couldn't build passkey challenge expression, \n[Error:] %v", err)
+		return nil, errPasskeyChallenge
+	}
+
+	response, err := r.db.Client.DeleteItem(ctx, &dynamodb.DeleteItemInput{
+		TableName:                &r.db.TableName,
+		Key:                      key,
+		ExpressionAttributeNames: expr.Names(),
+		ConditionExpression:      expr.Condition(),
+		ReturnValues:             types.ReturnValueAllOld,
+	})
+
+	if err != nil {
+		var conditionErr *types.ConditionalCheckFailedException
+
+		if !errors.As(err, &conditionErr) {
+			logger.Errorf("Couldn't delete passkey challenge, \n[Error:] %v", err)
+		}
+
+		return nil, errPasskeyChallenge
+	}
+
+	c := &passkeyChallenge{}
+
+	err = attributevalue.UnmarshalMap(response.Attributes, c)
+
+	if err != nil {
+		logger.Errorf("Couldn't unmarshal passkey challenge, \n[Error:] %v", err)
+		return nil, errPasskeyChallenge
+	}
+
+	// expired challenges are not removed by ttl immediately
+	if c.TTL < time.Now().Unix() {
+		return nil, errPasskeyChallenge
+	}
+
+	c.Challenge = challenge
+
+	return c, nil
+}
+
+func (r *authRepo) savePasskey(ctx context.Context, p *passkey) error {
+	item, err := attributevalue.MarshalMap(p)
+
+	if err != nil {
+		logger.Errorf("Couldn't marshal passkey for userId: %#v: \n[Error]: %v", p.UserId, err)
+		return errors.New(errMsg.registerPasskey)
+	}
+
+	item[db.SK_NAME] = &types.AttributeValueMemberS{Value: db.SORT_KEY_SESSIONS.Passkey(p.Id)}
+
+	// a credential can't be registered twice
+	expr, err := expression.NewBuilder().WithCondition(expression.AttributeNotExists(expression.Name(db.PK_NAME))).Build()
+
+	if err != nil {
+		logger.Errorf("Couldn't build save passkey expression for userId: %#v: \n[Error]: %v", p.UserId, err)
+		return errors.New(errMsg.registerPasskey)
+	}
+
+	_, err = r.db.Client.PutItem(ctx, &dynamodb.PutItemInput{
+		TableName:                &r.db.TableName,
+		Item:                     item,
+		ExpressionAttributeNames: expr.Names(),
+		ConditionExpression:      expr.Condition(),
+	})
+
+	if err != nil {
+		var conditionErr *types.ConditionalCheckFailedException
+
+		if errors.As(err, &conditionErr) {
+			return errPasskeyExists
+		}
+
+		logger.Errorf("Couldn't save passkey for userId: %#v: \n[Error]: %v", p.UserId, err)
+		return errors.New(errMsg.registerPasskey)
+	}
+
+	return nil
+}
+
+// returns nil if the passkey doesn't exist
+func (r *authRepo) getPasskey(ctx context.Context, userId, id string) (*passkey, error) {
+	key := map[string]types.AttributeValue{
+		db.PK_NAME: &types.AttributeValueMemberS{Value: userId},
+		db.SK_NAME: &types.AttributeValueMemberS{Value: db.SORT_KEY_SESSIONS.Passkey(id)},
+	}
+
+	response, err := r.db.Client.GetItem(ctx, &dynamodb.GetItemInput{
+		TableName: &r.db.TableName,
+		Key:       key,
+	})
+
+	if err != nil {
+		logger.Errorf("Couldn't get passkey for userId: %#v: \n[Error]: %v", userId, err)
+		return nil, errors.New(errMsg.getPasskeys)
+	}
+
+	if len(response.Item) == 0 {
+		return nil, nil
+	}
+
+	p := &passkey{}
+
+	err = attributevalue.UnmarshalMap(response.Item, p)
+
+	if err != nil {
+		logger.Errorf("Couldn't unmarshal passkey for userId: %#v: \n[Error]: %v", userId, err)
+		return nil, errors.New(errMsg.getPasskeys)
+	}
+
+	p.Id = id
+
+	return p, nil
+}
+
+func (r *authRepo) getPasskeys(ctx context.Context, userId string) ([]passkey, error) {
+	keyCondition := expression.KeyAnd(expression.Key(db.PK_NAME).Equal(expression.Value(userId)), expression.Key(db.SK_NAME).BeginsWith(db.SORT_KEY_SESSIONS.Passkey("")))
+
+	expr, err := expression.NewBuilder().WithKeyCondition(keyCondition).Build()
+
+	if err != nil {
+		logger.Errorf("Couldn't build getPasskeys expression for userId: %#v: \n[Error]: %v", userId, err)
+		return nil, errors.New(errMsg.getPasskeys)
+	}
+
+	paginator := dynamodb.NewQueryPaginator(r.db.Client, &dynamodb.QueryInput{
+		TableName:                 &r.db.TableName,
+		ExpressionAttributeNames:  expr.Names(),
+		ExpressionAttributeValues: expr.Values(),
+		KeyConditionExpression:    expr.KeyCondition(),
+	})
+
+	passkeys := []passkey{}
+
+	for paginator.HasMorePages() {
+		page, err := paginator.NextPage(ctx)
+
+		if err != nil {
+			logger.Errorf("Couldn't query passkeys for userId: %#v: \n[Error]: %v", userId, err)
+			return nil, errors.New(errMsg.getPasskeys)
+		}
+
+		var pagePasskeys []passkey
+
+		err = attributevalue.UnmarshalListOfMaps(page.Items, &pagePasskeys)
 
-	// primary key - partition+sort key
+		if err != nil {
+			logger.Errorf("Couldn't unmarshal passkeys for userId: %#v: \n[Error]: %v", userId, err)
+			return nil, errors.New(errMsg.getPasskeys)
+		}
+
+		for _, p := range pagePasskeys {
+			p.Id = strings.TrimPrefix(p.Id, db.SORT_KEY_SESSIONS.Passkey(""))
+
+			passkeys = append(passkeys, p)
+		}
+	}
+
+	return passkeys, nil
+}
+
+// updates the sign count, fails if the passkey was used concurrently (count changed)
+func (r *authRepo) updatePasskeySignCount(ctx context.Context, userId, id string, prevCount, signCount uint32) error {
 	key := map[string]types.AttributeValue{
-		db.PK_NAME: &types.AttributeValueMemberS{Value: email},
-		db.SK_NAME: &types.AttributeValueMemberS{Value: db.SORT_KEY_SESSIONS.OTP(otp)},
+		db.PK_NAME: &types.AttributeValueMemberS{Value: userId},
+		db.SK_NAME: &types.AttributeValueMemberS{Value: db.SORT_KEY_SESSIONS.Passkey(id)},
+	}
+
+	update := expression.Set(expression.Name("SignCount"), expression.Value(signCount)).Set(expression.Name("LastUsedAt"), expression.Value(time.Now().Unix()))
+
+	condition := expression.Name("SignCount").Equal(expression.Value(prevCount))
+
+	expr, err := expression.NewBuilder().WithUpdate(update).WithCondition(condition).Build()
+
+	if err != nil {
+		logger.Errorf("Couldn't build passkey sign count expression for userId: %#v: \n[Error]: %v", userId, err)
+		return errInvalidPasskey
+	}
+
+	_, err = r.db.Client.UpdateItem(ctx, &dynamodb.UpdateItemInput{
+		TableName:                 &r.db.TableName,
+		Key:                       key,
+		ExpressionAttributeNames:  expr.Names(),
+		ExpressionAttributeValues: expr.Values(),
+		UpdateExpression:          expr.Update(),
+		ConditionExpression:       expr.Condition(),
+	})
+
+	if err != nil {
+		var conditionErr *types.ConditionalCheckFailedException
+
+		if errors.As(err, &conditionErr) {
+			return errPasskeySignCount
+		}
+
+		logger.Errorf("Couldn't update passkey sign count for userId: %#v: \n[Error]: %v", userId, err)
+		return errInvalidPasskey
+	}
+
+	return nil
+}
+
+func (r *authRepo) deletePasskey(ctx context.Context, userId, id string) error {
+	key := map[string]types.AttributeValue{
+		db.PK_NAME: &types.AttributeValueMemberS{Value: userId},
+		db.SK_NAME: &types.AttributeValueMemberS{Value: db.SORT_KEY_SESSIONS.Passkey(id)},
+	}
+
+	_, err := r.db.Client.DeleteItem(ctx, &dynamodb.DeleteItemInput{
+		TableName: &r.db.TableName,
+		Key:       key,
+	})
+
+	if err != nil {
+		logger.Errorf("Couldn't delete passkey for userId: %#v: \n[Error]: %v", userId, err)
+		return errors.New(errMsg.deletePasskey)
+	}
+
+	return nil
+}
+
+func (r *authRepo) saveAPIToken(ctx context.Context, t *apiToken) error {
+	item, err := attributevalue.MarshalMap(t)
+
+	if err != nil {
+		logger.Errorf("Couldn't marshal api token for userId: %#v: \n[Error]: %v", t.UserId, err)
+		return errors.New(errMsg.createAPIToken)
+	}
+
+	item[db.SK_NAME] = &types.AttributeValueMemberS{Value: db.SORT_KEY_SESSIONS.APIToken(t.Id)}
+
+	_, err = r.db.Client.PutItem(ctx, &dynamodb.PutItemInput{
+		TableName: &r.db.TableName,
+		Item:      item,
+	})
+
+	if err != nil {
+		logger.Errorf("Couldn't save api token for userId: %#v: \n[Error]: %v", t.UserId, err)
+		return errors.New(errMsg.createAPIToken)
+	}
+
+	return nil
+}
+
+// returns nil if the token doesn't exist
+func (r *authRepo) getAPIToken(ctx context.Context, userId, id string) (*apiToken, error) {
+	key := map[string]types.AttributeValue{
+		db.PK_NAME: &types.AttributeValueMemberS{Value: userId},
+		db.SK_NAME: &types.AttributeValueMemberS{Value: db.SORT_KEY_SESSIONS.APIToken(id)},
+	}
+
+	response, err := r.db.Client.GetItem(ctx, &dynamodb.GetItemInput{
+		TableName: &r.db.TableName,
+		Key:       key,
+	})
+
+	if err != nil {
+		logger.Errorf("Couldn't get api token for userId: %#v: \n[Error]: %v", userId, err)
+		return nil, errors.New(errMsg.getAPITokens)
+	}
+
+	if len(response.Item) == 0 {
+		return nil, nil
+	}
+
+	t := &apiToken{}
+
+	err = attributevalue.UnmarshalMap(response.Item, t)
+
+	if err != nil {
+		logger.Errorf("Couldn't unmarshal api token for userId: %#v: \n[Error]: %v", userId, err)
+		return nil, errors.New(errMsg.getAPITokens)
+	}
+
+	t.Id = id
+
+	return t, nil
+}
+
+// get the unexpired api tokens of the user
+func (r *authRepo) getAPITokens(ctx context.Context, userId string) ([]apiToken, error) {
+	keyCondition := expression.KeyAnd(expression.Key(db.PK_NAME).Equal(expression.Value(userId)), expression.Key(db.SK_NAME).BeginsWith(db.SORT_KEY_SESSIONS.APIToken("")))
+
+	expr, err := expression.NewBuilder().WithKeyCondition(keyCondition).Build()
+
+	if err != nil {
+		logger.Errorf("Couldn't build getAPITokens expression for userId: %#v: \n[Error]: %v", userId, err)
+		return nil, errors.New(errMsg.getAPITokens)
+	}
+
+	paginator := dynamodb.NewQueryPaginator(r.db.Client, &dynamodb.QueryInput{
+		TableName:                 &r.db.TableName,
+		ExpressionAttributeNames:  expr.Names(),
+		ExpressionAttributeValues: expr.Values(),
+		KeyConditionExpression:    expr.KeyCondition(),
+	})
+
+	tokens := []apiToken{}
+
+	now := time.Now().Unix()
+
+	for paginator.HasMorePages() {
+		page, err := paginator.NextPage(ctx)
+
+		if err != nil {
+			logger.Errorf("Couldn't query api tokens for userId: %#v: \n[Error]: %v", userId, err)
+			return nil, errors.New(errMsg.getAPITokens)
+		}
+
+		var pageTokens []apiToken
+
+		err = attributevalue.UnmarshalListOfMaps(page.Items, &pageTokens)
+
+		if err != nil {
+			logger.Errorf("Couldn't unmarshal api tokens for userId: %#v: \n[Error]: %v", userId, err)
+			return nil, errors.New(errMsg.getAPITokens)
+		}
+
+		for _, t := range pageTokens {
+			if t.ExpiresAt < now {
+				continue
+			}
+
+			t.Id = strings.TrimPrefix(t.Id, db.SORT_KEY_SESSIONS.APIToken(""))
+
+			tokens = append(tokens, t)
+		}
+	}
+
+	return tokens, nil
+}
+
+func (r *authRepo) updateAPITokenLastUsed(ctx context.Context, userId, id string) error {
+	key := map[string]types.AttributeValue{
+		db.PK_NAME: &types.AttributeValueMemberS{Value: userId},
+		db.SK_NAME: &types.AttributeValueMemberS{Value: db.SORT_KEY_SESSIONS.APIToken(id)},
+	}
+
+	update := expression.Set(expression.Name("LastUsedAt"), expression.Value(time.Now().Unix()))
+
+	// don't re-create a revoked token
+	condition := expression.AttributeExists(expression.Name(db.PK_NAME))
+
+	expr, err := expression.NewBuilder().WithUpdate(update).WithCondition(condition).Build()
+
+	if err != nil {
+		logger.Errorf("Couldn't build api token last used expression for userId: %#v: \n[Error]: %v", userId, err)
+		return errInvalidAPIToken
+	}
+
+	_, err = r.db.Client.UpdateItem(ctx, &dynamodb.UpdateItemInput{
+		TableName:                 &r.db.TableName,
+		Key:                       key,
+		ExpressionAttributeNames:  expr.Names(),
+		ExpressionAttributeValues: expr.Values(),
+		UpdateExpression:          expr.Update(),
+		ConditionExpression:       expr.Condition(),
+	})
+
+	if err != nil {
+		logger.Errorf("Couldn't update api token last used for userId: %#v: \n[Error]: %v", userId, err)
+		return errInvalidAPIToken
+	}
+
+	return nil
+}
+
+func (r *authRepo) deleteAPIToken(ctx context.Context, userId, id string) error {
+	key := map[string]types.AttributeValue{
+		db.PK_NAME: &types.AttributeValueMemberS{Value: userId},
+		db.SK_NAME: &types.AttributeValueMemberS{Value: db.SORT_KEY_SESSIONS.APIToken(id)},
 	}
 
-	response, err := r.db.Client.GetItem(context.TODO(), &dynamodb.GetItemInput{
+	_, err := r.db.Client.DeleteItem(ctx, &dynamodb.DeleteItemInput{
 		TableName: &r.db.TableName,
 		Key:       key,
 	})
 
 	if err != nil {
-		logger.Errorf("Couldn't get OTP from db for email: %#v: \n[Error]: %v", email, err)
+		logger.Errorf("Couldn't delete api token for userId: %#v: \n[Error]: %v", userId, err)
+		return errors.New(errMsg.deleteAPIToken)
+	}
+
+	return nil
+}
+
+// deletes the otp if it matches, so it can be used only once; false if invalid or already used
+func (r *authRepo) consumeOTP(ctx context.Context, email, otp string) (bool, error) {
+	sk := db.SORT_KEY_SESSIONS.OTP(otp)
+
+	// primary key - partition+sort key
+	key := map[string]types.AttributeValue{
+		db.PK_NAME: &types.AttributeValueMemberS{Value: email},
+		db.SK_NAME: &types.AttributeValueMemberS{Value: sk},
+	}
+
+	expr, err := expression.NewBuilder().WithCondition(expression.Name(db.SK_NAME).Equal(expression.Value(sk))).Build()
+
+	if err != nil {
+		logger.Errorf("Couldn't build OTP expression for email: %#v: \n[Error]: %v", email, err)
+		return false, errors.New(errMsg.validateOTP)
+	}
+
+	response, err := r.db.Client.DeleteItem(ctx, &dynamodb.DeleteItemInput{
+		TableName:                 &r.db.TableName,
+		Key:                       key,
+		ExpressionAttributeNames:  expr.Names(),
+		ExpressionAttributeValues: expr.Values(),
+		ConditionExpression:       expr.Condition(),
+		ReturnValues:              types.ReturnValueAllOld,
+	})
+
+	if err != nil {
+		var conditionErr *types.ConditionalCheckFailedException
+
+		if errors.As(err, &conditionErr) {
+			return false, nil
+		}
+
+		logger.Errorf("Couldn't delete OTP from db for email: %#v: \n[Error]: %v", email, err)
 		return false, errors.New(errMsg.validateOTP)
 	}
 
-	if response.Item == nil || response.Item[db.TTL_KEY_NAME] == nil {
+	if response.Attributes[db.TTL_KEY_NAME] == nil {
 		return false, nil
 	}
 
@@ -86,28 +893,28 @@ func (r *authRepo) validateOTP(email, otp string) (bool, error) {
 		TTL int64
 	}
 
-	err = attributevalue.UnmarshalMap(response.Item, &ttlAtr)
+	err = attributevalue.UnmarshalMap(response.Attributes, &ttlAtr)
 
 	if err != nil {
 		logger.Errorf("Couldn't unmarshal OTP ttl from db for email: %#v: \n[Error]: %v", email, err)
-		return false, errors.New(errMsg.inValidOTP)
+		return false, errInvalidOTP
 	}
 
 	if ttlAtr.TTL < time.Now().Unix() {
-		return false, errors.New(errMsg.expiredOTP)
+		return false, errExpiredOTP
 	}
 
 	return true, nil
 }
 
-func (r *authRepo) attachUserId(data *emailWithUserId) error {
+func (r *authRepo) attachUserId(ctx context.Context, data *emailWithUserId) error {
 	// primary key - partition+sort key
 	key := map[string]types.AttributeValue{
 		db.PK_NAME: &types.AttributeValueMemberS{Value: data.Email},
 		db.SK_NAME: &types.AttributeValueMemberS{Value: db.SORT_KEY_SESSIONS.UserId(data.UserId)},
 	}
 
-	_, err := r.db.Client.PutItem(context.TODO(), &dynamodb.PutItemInput{
+	_, err := r.db.Client.PutItem(ctx, &dynamodb.PutItemInput{
 		TableName: &r.db.TableName,
 		Item:      key,
 	})
@@ -119,7 +926,7 @@ func (r *authRepo) attachUserId(data *emailWithUserId) error {
 	return nil
 }
 
-func (r *authRepo) userIdByEmail(email string) (string, error) {
+func (r *authRepo) userIdByEmail(ctx context.Context, email string) (string, error) {
 	// primary key - partition+sort key
 	keyCondition := expression.KeyAnd(expression.Key("PK").Equal(expression.Value(email)), expression.Key("SK").BeginsWith(db.SORT_KEY_SESSIONS.UserId("")))
 
@@ -130,7 +937,7 @@ func (r *authRepo) userIdByEmail(email string) (string, error) {
 		return "", errors.New(errMsg.createSession)
 	}
 
-	response, err := r.db.Client.Query(context.TODO(), &dynamodb.QueryInput{
+	response, err := r.db.Client.Query(ctx, &dynamodb.QueryInput{
 		TableName:                 &r.db.TableName,
 		ExpressionAttributeNames:  expr.Names(),
 		ExpressionAttributeValues: expr.Values(),
@@ -171,13 +978,16 @@ func (r *authRepo) userIdByEmail(email string) (string, error) {
 
 }
 
-func (r *authRepo) createSession(s *session) error {
+func (r *authRepo) createSession(ctx context.Context, s *session) error {
 
 	item := map[string]types.AttributeValue{
 		db.PK_NAME:      &types.AttributeValueMemberS{Value: s.UserId},
 		db.SK_NAME:      &types.AttributeValueMemberS{Value: db.SORT_KEY_SESSIONS.Session(s.Id)},
 		db.TTL_KEY_NAME: &types.AttributeValueMemberN{Value: strconv.FormatInt(s.TTL, 10)},
-		"CreatedAt":     &types.AttributeValueMemberN{Value: strconv.FormatInt(time.Now().Unix(), 10)},
+		"CreatedAt":     &types.AttributeValueMemberN{Value: strconv.FormatInt(s.CreatedAt, 10)},
+		"IssuedAt":      &types.AttributeValueMemberN{Value: strconv.FormatInt(s.IssuedAt, 10)},
+		"LastSeenAt":    &types.AttributeValueMemberN{Value: strconv.FormatInt(s.LastSeenAt, 10)},
+		"FamilyId":      &types.AttributeValueMemberS{Value: s.family()},
 		"DeviceInfo": &types.AttributeValueMemberM{
 			Value: map[string]types.AttributeValue{
 				"os":       &types.AttributeValueMemberS{Value: s.DeviceInfo.OS},
@@ -188,7 +998,7 @@ func (r *authRepo) createSession(s *session) error {
 		},
 	}
 
-	_, err := r.db.Client.PutItem(context.TODO(), &dynamodb.PutItemInput{
+	_, err := r.db.Client.PutItem(ctx, &dynamodb.PutItemInput{
 		TableName: &r.db.TableName,
 		Item:      item,
 	})
@@ -201,52 +1011,220 @@ func (r *authRepo) createSession(s *session) error {
 	return nil
 }
 
-func (r *authRepo) deleteSession(userId, sId string) error {
+// returns nil if the session doesn't exist
+func (r *authRepo) getSession(ctx context.Context, userId, sId string) (*session, error) {
 	key := map[string]types.AttributeValue{
 		db.PK_NAME: &types.AttributeValueMemberS{Value: userId},
 		db.SK_NAME: &types.AttributeValueMemberS{Value: db.SORT_KEY_SESSIONS.Session(sId)},
 	}
 
-	_, err := r.db.Client.DeleteItem(context.TODO(), &dynamodb.DeleteItemInput{
+	response, err := r.db.Client.GetItem(ctx, &dynamodb.GetItemInput{
 		TableName: &r.db.TableName,
 		Key:       key,
 	})
 
 	if err != nil {
-		logger.Errorf("Couldn't delete session for user with userId: %#v: \n[Error]: %v", userId, err)
+		logger.Errorf("Couldn't get session from db, for userId: %#v: \n[Error]: %v", userId, err)
+		return nil, errors.New(errMsg.ValidateSession)
+	}
 
-		return errors.New(errMsg.deleteSession)
+	if len(response.Item) == 0 {
+		return nil, nil
+	}
+
+	var userSession session
+
+	err = attributevalue.UnmarshalMap(response.Item, &userSession)
+
+	if err != nil {
+		logger.Errorf("Couldn't unmarshal session from db for userId: %#v: \n[Error]: %v", userId, err)
+		return nil, errors.New(errMsg.ValidateSession)
+	}
+
+	userSession.Id = strings.TrimPrefix(userSession.Id, db.SORT_KEY_SESSIONS.Session(""))
+
+	return &userSession, nil
+}
+
+// marks the session as replaced by the new session & shortens its ttl,
+// fails if the session was already rotated by a concurrent request
+func (r *authRepo) markSessionRotated(ctx context.Context, userId, sId, newSId string, ttl int64) error {
+	key := map[string]types.AttributeValue{
+		db.PK_NAME: &types.AttributeValueMemberS{Value: userId},
+		db.SK_NAME: &types.AttributeValueMemberS{Value: db.SORT_KEY_SESSIONS.Session(sId)},
+	}
+
+	update := expression.Set(expression.Name("ReplacedBy"), expression.Value(newSId)).
+		Set(expression.Name("RotatedAt"), expression.Value(time.Now().Unix())).
+		Set(expression.Name(db.TTL_KEY_NAME), expression.Value(ttl))
+
+	condition := expression.AttributeExists(expression.Name(db.PK_NAME)).And(expression.AttributeNotExists(expression.Name("ReplacedBy")))
+
+	expr, err := expression.NewBuilder().WithUpdate(update).WithCondition(condition).Build()
+
+	if err != nil {
+		logger.Errorf("Couldn't build rotate session expression for userId: %#v: \n[Error]: %v", userId, err)
+		return errors.New(errMsg.createSession)
+	}
+
+	_, err = r.db.Client.UpdateItem(ctx, &dynamodb.UpdateItemInput{
+		TableName:                 &r.db.TableName,
+		Key:                       key,
+		ExpressionAttributeNames:  expr.Names(),
+		ExpressionAttributeValues: expr.Values(),
+		UpdateExpression:          expr.Update(),
+		ConditionExpression:       expr.Condition(),
+	})
+
+	if err != nil {
+		var conditionErr *types.ConditionalCheckFailedException
+
+		if errors.As(err, &conditionErr) {
+			return errSessionRotated
+		}
+
+		logger.Errorf("Couldn't mark session as rotated for userId: %#v: \n[Error]: %v", userId, err)
+		return errors.New(errMsg.createSession)
+	}
+
+	return nil
+}
+
+func (r *authRepo) updateLastSeen(ctx context.Context, userId, sId string) error {
+	key := map[string]types.AttributeValue{
+		db.PK_NAME: &types.AttributeValueMemberS{Value: userId},
+		db.SK_NAME: &types.AttributeValueMemberS{Value: db.SORT_KEY_SESSIONS.Session(sId)},
+	}
+
+	update := expression.Set(expression.Name("LastSeenAt"), expression.Value(time.Now().Unix()))
+
+	// don't re-create a deleted session
+	condition := expression.AttributeExists(expression.Name(db.PK_NAME))
+
+	expr, err := expression.NewBuilder().WithUpdate(update).WithCondition(condition).Build()
+
+	if err != nil {
+		logger.Errorf("Couldn't build last seen expression for userId: %#v: \n[Error]: %v", userId, err)
+		return errors.New(errMsg.ValidateSession)
+	}
+
+	_, err = r.db.Client.UpdateItem(ctx, &dynamodb.UpdateItemInput{
+		TableName:                 &r.db.TableName,
+		Key:                       key,
+		ExpressionAttributeNames:  expr.Names(),
+		ExpressionAttributeValues: expr.Values(),
+		UpdateExpression:          expr.Update(),
+		ConditionExpression:       expr.Condition(),
+	})
+
+	if err != nil {
+		logger.Errorf("Couldn't update session last seen for userId: %#v: \n[Error]: %v", userId, err)
+		return errors.New(errMsg.ValidateSession)
 	}
 
 	return nil
 }
 
-func (r *authRepo) ValidateSession(userId, sId string) (bool, error) {
+func (r *authRepo) deleteSession(ctx context.Context, userId, sId string) error {
 	key := map[string]types.AttributeValue{
 		db.PK_NAME: &types.AttributeValueMemberS{Value: userId},
 		db.SK_NAME: &types.AttributeValueMemberS{Value: db.SORT_KEY_SESSIONS.Session(sId)},
 	}
 
-	response, err := r.db.Client.GetItem(context.TODO(), &dynamodb.GetItemInput{
+	_, err := r.db.Client.DeleteItem(ctx, &dynamodb.DeleteItemInput{
 		TableName: &r.db.TableName,
 		Key:       key,
 	})
 
 	if err != nil {
-		logger.Errorf("Couldn't get session from db, for userId: %#v: \n[Error]: %v", userId, err)
-		return false, errors.New(errMsg.ValidateSession)
+		logger.Errorf("Couldn't delete session for user with userId: %#v: \n[Error]: %v", userId, err)
+
+		return errors.New(errMsg.deleteSession)
 	}
 
-	var userSession session
+	return nil
+}
 
-	err = attributevalue.UnmarshalMap(response.Item, &userSession)
+// get all the active sessions of the user
+func (r *authRepo) getSessions(ctx context.Context, userId string) ([]session, error) {
+	keyCondition := expression.KeyAnd(expression.Key(db.PK_NAME).Equal(expression.Value(userId)), expression.Key(db.SK_NAME).BeginsWith(db.SORT_KEY_SESSIONS.Session("")))
+
+	expr, err := expression.NewBuilder().WithKeyCondition(keyCondition).Build()
 
 	if err != nil {
-		logger.Errorf("Couldn't unmarshal session expiry from db for userId: %#v: \n[Error]: %v", userId, err)
-		return false, errors.New(errMsg.ValidateSession)
+		logger.Errorf("Couldn't build getSessions expression for userId: %#v: \n[Error]: %v", userId, err)
+		return nil, errors.New(errMsg.getSessions)
+	}
+
+	paginator := dynamodb.NewQueryPaginator(r.db.Client, &dynamodb.QueryInput{
+		TableName:                 &r.db.TableName,
+		ExpressionAttributeNames:  expr.Names(),
+		ExpressionAttributeValues: expr.Values(),
+		KeyConditionExpression:    expr.KeyCondition(),
+	})
+
+	sessions := []session{}
+
+	now := time.Now().Unix()
+
+	for paginator.HasMorePages() {
+		page, err := paginator.NextPage(ctx)
+
+		if err != nil {
+			logger.Errorf("Couldn't query sessions for userId: %#v: \n[Error]: %v", userId, err)
+			return nil, errors.New(errMsg.getSessions)
+		}
+
+		var pageSessions []session
+
+		err = attributevalue.UnmarshalListOfMaps(page.Items, &pageSessions)
+
+		if err != nil {
+			logger.Errorf("Couldn't unmarshal sessions for userId: %#v: \n[Error]: %v", userId, err)
+			return nil, errors.New(errMsg.getSessions)
+		}
+
+		for _, s := range pageSessions {
+			// expired sessions are not removed by ttl immediately
+			if s.TTL < now {
+				continue
+			}
+
+			s.Id = strings.TrimPrefix(s.Id, db.SORT_KEY_SESSIONS.Session(""))
+
+			sessions = append(sessions, s)
+		}
+	}
+
+	return sessions, nil
+}
+
+func (r *authRepo) deleteSessions(ctx context.Context, userId string, sIds []string) error {
+	sks := []string{}
+
+	for _, sId := range sIds {
+		sks = append(sks, db.SORT_KEY_SESSIONS.Session(sId))
+	}
+
+	err := r.db.DeleteItems(ctx, userId, sks)
+
+	if err != nil {
+		logger.Errorf("Couldn't delete sessions for userId: %#v: \n[Error]: %v", userId, err)
+		return errors.New(errMsg.revokeSession)
+	}
+
+	return nil
+}
+
+// valid if the session exists, hasn't expired & wasn't rotated
+func (r *authRepo) ValidateSession(ctx context.Context, userId, sId string) (bool, error) {
+	userSession, err := r.getSession(ctx, userId, sId)
+
+	if err != nil {
+		return false, err
 	}
 
-	if userSession.Id == "" {
+	if userSession == nil || userSession.isRotated() {
 		return false, errors.New(errMsg.ValidateSession)
 	}
 
@@ -1,43 +1,209 @@
 package auth
 
 import (
+	"context"
+	"net/http"
+
 	lambda_events "github.com/aws/aws-lambda-go/events"
+	"github.com/manishMandal02/tabsflow-backend/config"
 	"github.com/manishMandal02/tabsflow-backend/pkg/db"
 	"github.com/manishMandal02/tabsflow-backend/pkg/events"
+	"github.com/manishMandal02/tabsflow-backend/pkg/health"
 	"github.com/manishMandal02/tabsflow-backend/pkg/http_api"
 )
 
 // custom API_GW lambda authorizer
-func LambdaAuthorizer(ev *lambda_events.APIGatewayCustomAuthorizerRequestTypeRequest) (*lambda_events.APIGatewayCustomAuthorizerResponse, error) {
+func LambdaAuthorizer(ctx context.Context, ev *lambda_events.APIGatewayCustomAuthorizerRequestTypeRequest) (*lambda_events.APIGatewayCustomAuthorizerResponse, error) {
 
 	db := db.NewSessionTable()
 	ar := newAuthRepository(db)
 
 	handler := newAuthHandler(ar, nil)
 
-	return handler.lambdaAuthorizer(ev)
+	return handler.lambdaAuthorizer(ctx, ev)
 }
 
-func Router(db *db.DDB, q *events.Queue) http_api.IRouter {
+// validates the personal api token, returns the userId & the token scopes
+func ValidateAPIToken(ctx context.Context, token string) (string, []string, error) {
+	ar := newAuthRepository(db.NewSessionTable())
 
-	ar := newAuthRepository(db)
+	t, err := authorizeAPIToken(ctx, token, ar)
 
-	handler := newAuthHandler(ar, q)
+	if err != nil {
+		return "", nil, err
+	}
+
+	return t.UserId, t.Scopes, nil
+}
+
+// validates the session cookie against the sessions table, as the lambda authorizer does (local server);
+// returns the userId & the cookie of the new session if it was rotated
+func AuthorizeSessionCookie(ctx context.Context, sessionsTable *db.DDB, cookieValue, userAgent string) (string, *http.Cookie, error) {
+	// verify the cookie signature before the db lookup
+	sId, userId, err := GetSessionValues(cookieValue)
+
+	if err != nil {
+		return "", nil, err
+	}
 
-	authRouter := http_api.NewRouter("/auth")
+	cookie, err := authorizeSession(ctx, userId, sId, userAgent, newAuthRepository(sessionsTable))
 
-	authRouter.Use(http_api.SetAllowOriginHeader())
+	if err != nil {
+		return "", nil, err
+	}
 
-	authRouter.POST("/verify-otp", handler.verifyOTP)
+	return userId, cookie, nil
+}
+
+// user id of the email, for the other services (replaces the public /auth/user/:email route)
+func UserIdByEmail(ctx context.Context, sessionsTable *db.DDB, email string) (string, error) {
+	return newAuthRepository(sessionsTable).userIdByEmail(ctx, email)
+}
+
+// token from the `Authorization: Bearer` header, empty if not set
+func BearerToken(r *http.Request) string {
+	return bearerToken(r.Header.Get("Authorization"))
+}
 
-	authRouter.POST("/send-otp", handler.sendOTP)
+func Router(db *db.DDB, q *events.Queue) http_api.IRouter {
 
-	authRouter.POST("/google", handler.googleAuth)
+	ar := newAuthRepository(db)
 
-	authRouter.GET("/logout", handler.logout)
+	handler := newAuthHandler(ar, q)
 
-	authRouter.GET("/user/:email", handler.getUserId)
+	router := http_api.NewRouter("/auth")
+
+	checks := []health.Check{
+		health.Table(db),
+		health.Queue("email", q),
+		health.Config("jwt", map[string]string{"JWT_SECRET_KEY": config.JWT_SECRET_KEY}),
+		health.Config("google", map[string]string{"GOOGLE_CLIENT_IDS": config.GOOGLE_CLIENT_IDS}),
+	}
+
+	// health routes, outside the service's middleware
+	health.Routes(router, checks...)
+
+	authRouter := router.Group("/")
+
+	authRouter.Use(skipForPath("/magic-link/verify", http_api.SetAllowOriginHeader()))
+
+	authRouter.Use(rateLimitByIP(ar))
+
+	authRouter.POST("/verify-otp", handler.verifyOTP).Doc(http_api.RouteDoc{
+		Summary:  "Login with the email OTP, sets the session cookie",
+		Body:     otpReq{},
+		Response: checkNewUserRes{},
+		Auth:     http_api.AuthNone,
+	})
+
+	authRouter.POST("/send-otp", handler.sendOTP).Doc(http_api.RouteDoc{
+		Summary: "Send a login OTP to the email",
+		Body:    emailReq{},
+		Auth:    http_api.AuthNone,
+	})
+
+	authRouter.POST("/google", handler.googleAuth).Doc(http_api.RouteDoc{
+		Summary:  "Login with a google id token, sets the session cookie",
+		Body:     googleAuthReq{},
+		Response: checkNewUserRes{},
+		Auth:     http_api.AuthNone,
+	})
+
+	authRouter.POST("/magic-link", handler.sendMagicLink).Doc(http_api.RouteDoc{
+		Summary: "Send a login link to the email",
+		Body:    emailReq{},
+		Auth:    http_api.AuthNone,
+	})
+
+	authRouter.GET("/magic-link/verify", handler.verifyMagicLink).Doc(http_api.RouteDoc{
+		Summary:      "Login with the link from the email, redirects to the app with the user id or the error code",
+		Query:        []http_api.QueryParam{{Name: "token", Description: "signed token of the link", Required: true}},
+		Redirect:     http.StatusFound,
+		RedirectOnly: true,
+		Auth:         http_api.AuthNone,
+	})
+
+	authRouter.GET("/logout", handler.logout).Doc(http_api.RouteDoc{
+		Summary: "Logout, deletes the session & its cookie",
+		Auth:    http_api.AuthNone,
+	})
+
+	// manage the active sessions (devices) of the logged in user
+	authRouter.GET("/sessions", handler.getSessions).Doc(http_api.RouteDoc{
+		Summary:  "Active sessions of the user",
+		Response: []sessionInfo{},
+		Auth:     http_api.AuthSession,
+	})
+
+	authRouter.DELETE("/sessions/:id", handler.revokeSession).Doc(http_api.RouteDoc{
+		Summary: "Revoke a session",
+		Auth:    http_api.AuthSession,
+	})
+
+	authRouter.POST("/sessions/revoke-others", handler.revokeOtherSessions).Doc(http_api.RouteDoc{
+		Summary:  "Revoke the sessions other than the current one",
+		Response: revokedSessionsRes{},
+		Auth:     http_api.AuthSession,
+	})
+
+	// passkey (webauthn) registration & login
+	authRouter.POST("/passkeys/register/options", handler.passkeyRegisterOptions).Doc(http_api.RouteDoc{
+		Summary:  "Options to create a passkey, the body is optional",
+		Body:     passkeyOptionsReq{},
+		Response: passkeyCreationOptions{},
+		Auth:     http_api.AuthSession,
+	})
+
+	authRouter.POST("/passkeys/register", handler.registerPasskey).Doc(http_api.RouteDoc{
+		Summary:  "Register a passkey",
+		Body:     registerPasskeyReq{},
+		Response: passkey{},
+		Auth:     http_api.AuthSession,
+	})
+
+	authRouter.POST("/passkeys/login/options", handler.passkeyLoginOptions).Doc(http_api.RouteDoc{
+		Summary:  "Options to login with a passkey",
+		Response: passkeyRequestOptions{},
+		Auth:     http_api.AuthNone,
+	})
+
+	authRouter.POST("/passkeys/login", handler.passkeyLogin).Doc(http_api.RouteDoc{
+		Summary:  "Login with a passkey, sets the session cookie",
+		Body:     passkeyLoginReq{},
+		Response: checkNewUserRes{},
+		Auth:     http_api.AuthNone,
+	})
+
+	authRouter.GET("/passkeys", handler.getPasskeys).Doc(http_api.RouteDoc{
+		Summary:  "Passkeys of the user",
+		Response: []passkey{},
+		Auth:     http_api.AuthSession,
+	})
+
+	authRouter.DELETE("/passkeys/:id", handler.deletePasskey).Doc(http_api.RouteDoc{
+		Summary: "Delete a passkey",
+		Auth:    http_api.AuthSession,
+	})
+
+	// personal api tokens, sent as `Authorization: Bearer {token}`
+	authRouter.POST("/tokens", handler.createAPIToken).Doc(http_api.RouteDoc{
+		Summary:  "Create a personal api token, the token is returned only once",
+		Body:     createAPITokenReq{},
+		Response: createdAPITokenRes{},
+		Auth:     http_api.AuthSession,
+	})
+
+	authRouter.GET("/tokens", handler.getAPITokens).Doc(http_api.RouteDoc{
+		Summary:  "Personal api tokens of the user",
+		Response: []apiToken{},
+		Auth:     http_api.AuthSession,
+	})
+
+	authRouter.DELETE("/tokens/:id", handler.revokeAPIToken).Doc(http_api.RouteDoc{
+		Summary: "Revoke a personal api token",
+		Auth:    http_api.AuthSession,
+	})
 
 	// serve API routes
-	return authRouter
+	return router
 }
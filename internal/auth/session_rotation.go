@@ -0,0 +1,190 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"sync"
+	"time"
+
+	lambda_events "github.com/aws/aws-lambda-go/events"
+
+	"github.com/manishMandal02/tabsflow-backend/config"
+	"github.com/manishMandal02/tabsflow-backend/pkg/logger"
+)
+
+// validates the session & rotates it after the refresh interval,
+// returns the cookie of the new session if it was rotated
+func authorizeSession(ctx context.Context, userId, sId, userAgent string, aR authRepository) (*http.Cookie, error) {
+	s, err := aR.getSession(ctx, userId, sId)
+
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now().Unix()
+
+	if s == nil || s.TTL < now {
+		return nil, errors.New(errMsg.invalidSession)
+	}
+
+	if s.isRotated() {
+		// concurrent requests sent with the old cookie, while the session was being rotated
+		if now-s.RotatedAt <= config.SESSION_ROTATION_GRACE_SEC {
+			return sessionCookie(s.ReplacedBy, userId)
+		}
+
+		// rotated session used again, the cookie may be stolen; revoke the whole family
+		logger.Errorf("Rotated session reused, revoking session family: %v for userId: %v", s.family(), userId)
+
+		err = revokeSessionFamily(ctx, userId, s.family(), aR)
+
+		if err != nil {
+			return nil, err
+		}
+
+		return nil, errSessionReused
+	}
+
+	issuedAt := s.IssuedAt
+
+	if issuedAt == 0 {
+		issuedAt = s.CreatedAt
+	}
+
+	if now-issuedAt >= int64((time.Hour * config.SESSION_REFRESH_INTERVAL_HOURS).Seconds()) {
+		return rotateSession(ctx, s, userAgent, aR)
+	}
+
+	if now-s.LastSeenAt >= int64((time.Minute * config.SESSION_LAST_SEEN_INTERVAL_MIN).Seconds()) {
+		err = aR.updateLastSeen(ctx, userId, sId)
+
+		// last seen is informational, don't block the request
+		if err != nil {
+			logger.Errorf("Couldn't update last seen for session, userId: %v. \n[Error]: %v", userId, err)
+		}
+	}
+
+	return nil, nil
+}
+
+// replaces the session with a new one in the same family; the old session is kept for reuse detection
+func rotateSession(ctx context.Context, s *session, userAgent string, aR authRepository) (*http.Cookie, error) {
+	newS := newSession(s.UserId, userAgent, s)
+
+	err := aR.createSession(ctx, newS)
+
+	if err != nil {
+		return nil, err
+	}
+
+	ttl := time.Now().AddDate(0, 0, config.SESSION_REUSE_DETECTION_DAYS).Unix()
+
+	if ttl > s.TTL {
+		ttl = s.TTL
+	}
+
+	err = aR.markSessionRotated(ctx, s.UserId, s.Id, newS.Id, ttl)
+
+	if err == nil {
+		return sessionCookie(newS.Id, s.UserId)
+	}
+
+	// discard the new session, the existing one was already rotated by a concurrent request
+	_ = aR.deleteSession(ctx, s.UserId, newS.Id)
+
+	if !errors.Is(err, errSessionRotated) {
+		return nil, err
+	}
+
+	rotated, err := aR.getSession(ctx, s.UserId, s.Id)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if rotated == nil || !rotated.isRotated() {
+		return nil, errors.New(errMsg.invalidSession)
+	}
+
+	return sessionCookie(rotated.ReplacedBy, s.UserId)
+}
+
+// deletes all the sessions of a login (family), including the rotated ones
+func revokeSessionFamily(ctx context.Context, userId, familyId string, aR authRepository) error {
+	sessions, err := aR.getSessions(ctx, userId)
+
+	if err != nil {
+		return err
+	}
+
+	sIds := []string{}
+
+	for _, s := range sessions {
+		if s.family() == familyId {
+			sIds = append(sIds, s.Id)
+		}
+	}
+
+	return aR.deleteSessions(ctx, userId, sIds)
+}
+
+// * authorizer cache
+// allowed authorizer responses by the session cookie, reused by the warm lambda instance
+
+type cachedAuthorizerRes struct {
+	res       *lambda_events.APIGatewayCustomAuthorizerResponse
+	expiresAt time.Time
+}
+
+var authorizerCache = struct {
+	sync.Mutex
+	entries map[string]cachedAuthorizerRes
+}{
+	entries: map[string]cachedAuthorizerRes{},
+}
+
+// max cached responses, before the expired ones are evicted
+const authorizerCacheSize = 1000
+
+func getCachedAuthorizerRes(cookie string) *lambda_events.APIGatewayCustomAuthorizerResponse {
+	authorizerCache.Lock()
+	defer authorizerCache.Unlock()
+
+	c, ok := authorizerCache.entries[cookie]
+
+	if !ok {
+		return nil
+	}
+
+	if time.Now().After(c.expiresAt) {
+		delete(authorizerCache.entries, cookie)
+		return nil
+	}
+
+	return c.res
+}
+
+func cacheAuthorizerRes(cookie string, res *lambda_events.APIGatewayCustomAuthorizerResponse) {
+	authorizerCache.Lock()
+	defer authorizerCache.Unlock()
+
+	now := time.Now()
+
+	if len(authorizerCache.entries) >= authorizerCacheSize {
+		for k, c := range authorizerCache.entries {
+			if now.After(c.expiresAt) {
+				delete(authorizerCache.entries, k)
+			}
+		}
+	}
+
+	if len(authorizerCache.entries) >= authorizerCacheSize {
+		return
+	}
+
+	authorizerCache.entries[cookie] = cachedAuthorizerRes{
+		res:       res,
+		expiresAt: now.Add(time.Second * config.AUTHORIZER_CACHE_TTL_SEC),
+	}
+}
@@ -0,0 +1,257 @@
+package auth
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+
+	lambda_events "github.com/aws/aws-lambda-go/events"
+
+	"github.com/manishMandal02/tabsflow-backend/config"
+	"github.com/manishMandal02/tabsflow-backend/pkg/http_api"
+)
+
+// in-memory sessions of a user
+type sessionsRepoMock struct {
+	authRepository
+	sessions map[string]*session
+}
+
+func newSessionsRepoMock(sessions ...*session) *sessionsRepoMock {
+	m := &sessionsRepoMock{sessions: map[string]*session{}}
+
+	for _, s := range sessions {
+		m.sessions[s.Id] = s
+	}
+
+	return m
+}
+
+func (m *sessionsRepoMock) createSession(_ context.Context, s *session) error {
+	c := *s
+	m.sessions[s.Id] = &c
+	return nil
+}
+
+func (m *sessionsRepoMock) getSession(_ context.Context, _, sId string) (*session, error) {
+	s, ok := m.sessions[sId]
+
+	if !ok {
+		return nil, nil
+	}
+
+	c := *s
+	return &c, nil
+}
+
+func (m *sessionsRepoMock) getSessions(_ context.Context, _ string) ([]session, error) {
+	sessions := []session{}
+
+	for _, s := range m.sessions {
+		sessions = append(sessions, *s)
+	}
+
+	return sessions, nil
+}
+
+func (m *sessionsRepoMock) markSessionRotated(_ context.Context, _, sId, newSId string, ttl int64) error {
+	s, ok := m.sessions[sId]
+
+	if !ok || s.isRotated() {
+		return errSessionRotated
+	}
+
+	s.ReplacedBy = newSId
+	s.RotatedAt = time.Now().Unix()
+	s.TTL = ttl
+
+	return nil
+}
+
+func (m *sessionsRepoMock) updateLastSeen(_ context.Context, _, sId string) error {
+	m.sessions[sId].LastSeenAt = time.Now().Unix()
+	return nil
+}
+
+func (m *sessionsRepoMock) deleteSession(_ context.Context, _, sId string) error {
+	delete(m.sessions, sId)
+	return nil
+}
+
+func (m *sessionsRepoMock) deleteSessions(_ context.Context, _ string, sIds []string) error {
+	for _, sId := range sIds {
+		delete(m.sessions, sId)
+	}
+	return nil
+}
+
+func TestAuthorizeSession(t *testing.T) {
+	config.JWT_SECRET_KEY = "secret"
+
+	now := time.Now().Unix()
+	ttl := time.Now().AddDate(0, 0, 30).Unix()
+	day := int64(24 * 60 * 60)
+
+	t.Run("recent session is not rotated", func(t *testing.T) {
+		r := newSessionsRepoMock(&session{Id: "s1", UserId: "u1", TTL: ttl, IssuedAt: now, LastSeenAt: now - day})
+
+		cookie, err := authorizeSession(context.Background(), "u1", "s1", "", r)
+
+		if err != nil || cookie != nil {
+			t.Fatalf("authorizeSession(context.Background(), ) = %v, %v, want no rotation", cookie, err)
+		}
+
+		if len(r.sessions) != 1 || r.sessions["s1"].LastSeenAt < now {
+			t.Errorf("authorizeSession(context.Background(), ) expected last seen update, sessions: %v", r.sessions)
+		}
+	})
+
+	t.Run("session is rotated after refresh interval", func(t *testing.T) {
+		r := newSessionsRepoMock(&session{Id: "s1", UserId: "u1", TTL: ttl, CreatedAt: now - 2*day, IssuedAt: now - 2*day})
+
+		cookie, err := authorizeSession(context.Background(), "u1", "s1", "", r)
+
+		if err != nil || cookie == nil {
+			t.Fatalf("authorizeSession(context.Background(), ) = %v, %v, want new cookie", cookie, err)
+		}
+
+		newSId, _, err := GetSessionValues(cookie.Value)
+
+		if err != nil {
+			t.Fatalf("GetSessionValues() unexpected error = %v", err)
+		}
+
+		old, newS := r.sessions["s1"], r.sessions[newSId]
+
+		if old.ReplacedBy != newSId || newS == nil || newS.family() != "s1" || newS.CreatedAt != now-2*day {
+			t.Errorf("authorizeSession(context.Background(), ) unexpected rotation, old: %+v, new: %+v", old, newS)
+		}
+
+		// old cookie within the grace period returns the new session
+		cookie, err = authorizeSession(context.Background(), "u1", "s1", "", r)
+
+		if err != nil || cookie == nil {
+			t.Fatalf("authorizeSession(context.Background(), ) = %v, %v, want cookie of the new session", cookie, err)
+		}
+
+		if sId, _, _ := GetSessionValues(cookie.Value); sId != newSId || len(r.sessions) != 2 {
+			t.Errorf("authorizeSession(context.Background(), ) sId = %v, want %v, sessions: %v", sId, newSId, len(r.sessions))
+		}
+	})
+
+	t.Run("reused rotated session revokes the family", func(t *testing.T) {
+		r := newSessionsRepoMock(
+			&session{Id: "s1", UserId: "u1", TTL: ttl, FamilyId: "s1", ReplacedBy: "s2", RotatedAt: now - day},
+			&session{Id: "s2", UserId: "u1", TTL: ttl, FamilyId: "s1", IssuedAt: now},
+			&session{Id: "s3", UserId: "u1", TTL: ttl, FamilyId: "s3", IssuedAt: now},
+		)
+
+		_, err := authorizeSession(context.Background(), "u1", "s1", "", r)
+
+		if !errors.Is(err, errSessionReused) {
+			t.Fatalf("authorizeSession(context.Background(), ) error = %v, want %v", err, errSessionReused)
+		}
+
+		if len(r.sessions) != 1 || r.sessions["s3"] == nil {
+			t.Errorf("authorizeSession(context.Background(), ) expected only other family left, sessions: %v", r.sessions)
+		}
+	})
+
+	t.Run("expired session", func(t *testing.T) {
+		r := newSessionsRepoMock(&session{Id: "s1", UserId: "u1", TTL: now - 1, IssuedAt: now})
+
+		if _, err := authorizeSession(context.Background(), "u1", "s1", "", r); err == nil {
+			t.Fatal("authorizeSession(context.Background(), ) expected error for expired session")
+		}
+	})
+}
+
+func TestLambdaAuthorizerRotatedCookie(t *testing.T) {
+	config.JWT_SECRET_KEY = "secret"
+
+	issuedAt := time.Now().Add(-time.Hour * (config.SESSION_REFRESH_INTERVAL_HOURS + 1)).Unix()
+
+	r := newSessionsRepoMock(&session{Id: "s1", UserId: "u1", TTL: time.Now().AddDate(0, 0, 30).Unix(), IssuedAt: issuedAt})
+
+	h := &authHandler{r: r}
+
+	authorize := func(cookie string) *lambda_events.APIGatewayCustomAuthorizerResponse {
+		t.Helper()
+
+		res, err := h.lambdaAuthorizer(context.Background(), &lambda_events.APIGatewayCustomAuthorizerRequestTypeRequest{
+			MethodArn: "arn:aws:execute-api:us-east-1:123456789012:api-id/dev/GET/spaces/my",
+			Path:      "/spaces/my",
+			Headers:   map[string]string{"Cookie": "session=" + cookie},
+		})
+
+		if err != nil {
+			t.Fatalf("lambdaAuthorizer() error = %v", err)
+		}
+
+		return res
+	}
+
+	oldCookie, err := sessionCookie("s1", "u1")
+
+	if err != nil {
+		t.Fatalf("sessionCookie() error = %v", err)
+	}
+
+	res := authorize(oldCookie.Value)
+
+	// the service writes the rotated cookie from the authorizer context to the response
+	router := http_api.NewRouter("/spaces")
+
+	router.GET("/my", func(w http.ResponseWriter, r *http.Request) {
+		http_api.SuccessResMsg(w, "ok")
+	})
+
+	event, _ := json.Marshal(lambda_events.APIGatewayProxyRequest{
+		HTTPMethod:     http.MethodGet,
+		Path:           "/spaces/my",
+		RequestContext: lambda_events.APIGatewayProxyRequestContext{APIID: "test", Authorizer: res.Context},
+	})
+
+	out, err := http_api.NewAPIGatewayHandler("/spaces/", router).Handle(context.Background(), event)
+
+	if err != nil {
+		t.Fatalf("Handle() error = %v", err)
+	}
+
+	proxyRes, ok := out.(lambda_events.APIGatewayProxyResponse)
+
+	if !ok {
+		t.Fatalf("Handle() response = %T, want APIGatewayProxyResponse", out)
+	}
+
+	header := http.Header{"Set-Cookie": proxyRes.MultiValueHeaders["Set-Cookie"]}
+
+	cookies := (&http.Response{Header: header}).Cookies()
+
+	if len(cookies) != 1 || cookies[0].Name != SessionCookieName {
+		t.Fatalf("Handle() Set-Cookie = %v, want the rotated session cookie", header.Values("Set-Cookie"))
+	}
+
+	newSId, _, err := GetSessionValues(cookies[0].Value)
+
+	if err != nil || newSId == "s1" || r.sessions["s1"].ReplacedBy != newSId {
+		t.Fatalf("Set-Cookie session = %v, %v, want the session replacing s1", newSId, err)
+	}
+
+	// the client sends the rotated cookie, no further rotation
+	res = authorize(cookies[0].Value)
+
+	if _, ok := res.Context[http_api.SetCookieContextKey]; ok || res.Context["UserId"] != "u1" {
+		t.Errorf("lambdaAuthorizer() context = %v, want user without a new cookie", res.Context)
+	}
+}
+
+func TestAuthorizerCacheTTL(t *testing.T) {
+	// a cached response of a rotated cookie must expire within the grace period, or its reuse is not detected
+	if config.AUTHORIZER_CACHE_TTL_SEC >= config.SESSION_ROTATION_GRACE_SEC {
+		t.Errorf("AUTHORIZER_CACHE_TTL_SEC = %v, want less than SESSION_ROTATION_GRACE_SEC (%v)", config.AUTHORIZER_CACHE_TTL_SEC, config.SESSION_ROTATION_GRACE_SEC)
+	}
+}
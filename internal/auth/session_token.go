@@ -0,0 +1,128 @@
+package auth
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/manishMandal02/tabsflow-backend/config"
+)
+
+// * signed session token
+// format: v1.{keyId}.{base64(sessionId:userId:issuedAt)}.{signature}
+
+const sessionTokenVersion = "v1"
+
+// signs the session cookie value with the current secret key
+func signSessionToken(sId, userId string) (string, error) {
+	if config.JWT_SECRET_KEY == "" {
+		return "", errors.New(errMsg.createToken)
+	}
+
+	payload := fmt.Sprintf("%s:%s:%d", sId, userId, time.Now().Unix())
+
+	unsigned := fmt.Sprintf("%s.%s.%s", sessionTokenVersion, sessionKeyId(), base64.RawURLEncoding.EncodeToString([]byte(payload)))
+
+	return unsigned + "." + sessionTokenSignature(unsigned, config.JWT_SECRET_KEY), nil
+}
+
+// verifies the signed token and returns the sessionId & userId
+func verifySessionToken(token string) (string, string, error) {
+	parts := strings.Split(token, ".")
+
+	if len(parts) != 4 || parts[0] != sessionTokenVersion {
+		return "", "", errors.New(errMsg.invalidSessionValue)
+	}
+
+	key, ok := sessionSigningKeys()[parts[1]]
+
+	if !ok || key == "" {
+		return "", "", errors.New(errMsg.invalidSessionValue)
+	}
+
+	unsigned := strings.Join(parts[:3], ".")
+
+	if !hmac.Equal([]byte(parts[3]), []byte(sessionTokenSignature(unsigned, key))) {
+		return "", "", errors.New(errMsg.invalidSessionValue)
+	}
+
+	payloadBytes, err := base64.RawURLEncoding.DecodeString(parts[2])
+
+	if err != nil {
+		return "", "", errors.New(errMsg.invalidSessionValue)
+	}
+
+	values := strings.Split(string(payloadBytes), ":")
+
+	if len(values) != 3 || values[0] == "" || values[1] == "" {
+		return "", "", errors.New(errMsg.invalidSessionValue)
+	}
+
+	if _, err := strconv.ParseInt(values[2], 10, 64); err != nil {
+		return "", "", errors.New(errMsg.invalidSessionValue)
+	}
+
+	return values[0], values[1], nil
+}
+
+// unsigned cookie value (id={sessionId}//uid={userId}), accepted until the migration window ends
+func parseLegacySessionValue(cookieValue string) (string, string, error) {
+	legacyUntil, err := time.Parse(time.DateOnly, config.LEGACY_SESSION_COOKIE_UNTIL)
+
+	if err != nil || time.Now().After(legacyUntil) {
+		return "", "", errors.New(errMsg.invalidSessionValue)
+	}
+
+	idValue, uidValue, ok := strings.Cut(cookieValue, "//")
+
+	if !ok {
+		return "", "", errors.New(errMsg.invalidSessionValue)
+	}
+
+	sessionId, ok1 := strings.CutPrefix(idValue, "id=")
+	userId, ok2 := strings.CutPrefix(uidValue, "uid=")
+
+	if !ok1 || !ok2 || sessionId == "" || userId == "" {
+		return "", "", errors.New(errMsg.invalidSessionValue)
+	}
+
+	return sessionId, userId, nil
+}
+
+func sessionTokenSignature(unsigned, key string) string {
+	mac := hmac.New(sha256.New, []byte(key))
+	mac.Write([]byte(unsigned))
+
+	return base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
+
+func sessionKeyId() string {
+	if config.JWT_SECRET_KEY_ID == "" {
+		return "1"
+	}
+
+	return config.JWT_SECRET_KEY_ID
+}
+
+// current key & the previous (rotated) keys by their key id,
+// previous keys are set as: {keyId}:{key},{keyId}:{key}
+func sessionSigningKeys() map[string]string {
+	keys := map[string]string{}
+
+	for _, k := range strings.Split(config.JWT_PREVIOUS_SECRET_KEYS, ",") {
+		kid, key, ok := strings.Cut(strings.TrimSpace(k), ":")
+
+		if ok && kid != "" && key != "" {
+			keys[kid] = key
+		}
+	}
+
+	keys[sessionKeyId()] = config.JWT_SECRET_KEY
+
+	return keys
+}
@@ -0,0 +1,110 @@
+package auth
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/manishMandal02/tabsflow-backend/config"
+)
+
+func TestGetSessionValues(t *testing.T) {
+	config.JWT_SECRET_KEY = "current-secret"
+	config.JWT_SECRET_KEY_ID = "2"
+	config.JWT_PREVIOUS_SECRET_KEYS = "1:previous-secret"
+
+	token, err := signSessionToken("session-1", "user-1")
+
+	if err != nil {
+		t.Fatalf("signSessionToken() unexpected error = %v", err)
+	}
+
+	// token signed with the rotated key
+	config.JWT_SECRET_KEY_ID = "1"
+	config.JWT_SECRET_KEY = "previous-secret"
+
+	oldToken, err := signSessionToken("session-2", "user-2")
+
+	if err != nil {
+		t.Fatalf("signSessionToken() unexpected error = %v", err)
+	}
+
+	config.JWT_SECRET_KEY_ID = "2"
+	config.JWT_SECRET_KEY = "current-secret"
+
+	parts := strings.Split(token, ".")
+
+	legacyUntil, _ := time.Parse(time.DateOnly, config.LEGACY_SESSION_COOKIE_UNTIL)
+
+	// legacy cookies are rejected after the migration window
+	legacyExpired := time.Now().After(legacyUntil)
+
+	tests := []struct {
+		name        string
+		cookieValue string
+		wantSId     string
+		wantUserId  string
+		wantErr     bool
+	}{
+		{
+			name:        "signed token",
+			cookieValue: token,
+			wantSId:     "session-1",
+			wantUserId:  "user-1",
+		},
+		{
+			name:        "token signed with previous key",
+			cookieValue: oldToken,
+			wantSId:     "session-2",
+			wantUserId:  "user-2",
+		},
+		{
+			name:        "tampered payload",
+			cookieValue: strings.Join([]string{parts[0], parts[1], "c2Vzc2lvbi0xOmF0dGFja2VyOjA", parts[3]}, "."),
+			wantErr:     true,
+		},
+		{
+			name:        "unknown key id",
+			cookieValue: strings.Join([]string{parts[0], "9", parts[2], parts[3]}, "."),
+			wantErr:     true,
+		},
+		{
+			name:        "legacy cookie",
+			cookieValue: "id=session-3//uid=user-3",
+			wantSId:     "session-3",
+			wantUserId:  "user-3",
+			wantErr:     legacyExpired,
+		},
+		{
+			name:        "invalid legacy cookie",
+			cookieValue: "id//uid=user-3",
+			wantErr:     true,
+		},
+		{
+			name:        "empty",
+			cookieValue: "",
+			wantErr:     true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sId, userId, err := GetSessionValues(tt.cookieValue)
+
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("GetSessionValues() expected error, got sId: %v, userId: %v", sId, userId)
+				}
+				return
+			}
+
+			if err != nil {
+				t.Fatalf("GetSessionValues() unexpected error = %v", err)
+			}
+
+			if sId != tt.wantSId || userId != tt.wantUserId {
+				t.Errorf("GetSessionValues() = %v, %v, want %v, %v", sId, userId, tt.wantSId, tt.wantUserId)
+			}
+		})
+	}
+}
@@ -0,0 +1,462 @@
+package auth
+
+import (
+	"bytes"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/json"
+	"math/big"
+	"slices"
+	"strings"
+
+	"github.com/manishMandal02/tabsflow-backend/config"
+)
+
+// * webauthn (passkey) ceremonies verification
+
+// COSE algorithms
+const (
+	coseAlgES256 int64 = -7
+	coseAlgRS256 int64 = -257
+)
+
+// authenticator data flags
+const (
+	authDataFlagUserPresent  byte = 0x01
+	authDataFlagAttestedData byte = 0x40
+)
+
+type clientData struct {
+	Type      string `json:"type"`
+	Challenge string `json:"challenge"`
+	Origin    string `json:"origin"`
+}
+
+type authenticatorData struct {
+	rpIdHash  []byte
+	flags     byte
+	signCount uint32
+	// attested credential, only in registration
+	credentialId []byte
+	publicKey    []byte
+	alg          int64
+}
+
+// registration response (navigator.credentials.create), binary fields are base64url
+type passkeyRegistration struct {
+	Id       string `json:"id"`
+	Type     string `json:"type"`
+	Response struct {
+		ClientDataJSON    string `json:"clientDataJSON"`
+		AttestationObject string `json:"attestationObject"`
+	} `json:"response"`
+}
+
+// authentication response (navigator.credentials.get), binary fields are base64url
+type passkeyAssertion struct {
+	Id       string `json:"id"`
+	Type     string `json:"type"`
+	Response struct {
+		ClientDataJSON    string `json:"clientDataJSON"`
+		AuthenticatorData string `json:"authenticatorData"`
+		Signature         string `json:"signature"`
+		UserHandle        string `json:"userHandle"`
+	} `json:"response"`
+}
+
+// ceremony types of the client data
+const (
+	ceremonyCreate = "webauthn.create"
+	ceremonyGet    = "webauthn.get"
+)
+
+type credentialDescriptor struct {
+	Type string `json:"type"`
+	Id   string `json:"id"`
+}
+
+// options for navigator.credentials.create, binary fields are base64url
+type passkeyCreationOptions struct {
+	Challenge string `json:"challenge"`
+	RP        struct {
+		Id   string `json:"id"`
+		Name string `json:"name"`
+	} `json:"rp"`
+	User struct {
+		Id          string `json:"id"`
+		Name        string `json:"name"`
+		DisplayName string `json:"displayName"`
+	} `json:"user"`
+	PubKeyCredParams []struct {
+		Type string `json:"type"`
+		Alg  int64  `json:"alg"`
+	} `json:"pubKeyCredParams"`
+	Timeout                int64                  `json:"timeout"`
+	Attestation            string                 `json:"attestation"`
+	ExcludeCredentials     []credentialDescriptor `json:"excludeCredentials"`
+	AuthenticatorSelection struct {
+		ResidentKey      string `json:"residentKey"`
+		UserVerification string `json:"userVerification"`
+	} `json:"authenticatorSelection"`
+}
+
+// options for navigator.credentials.get, binary fields are base64url
+type passkeyRequestOptions struct {
+	Challenge        string `json:"challenge"`
+	RPId             string `json:"rpId"`
+	Timeout          int64  `json:"timeout"`
+	UserVerification string `json:"userVerification"`
+}
+
+func webauthnRPId() string {
+	return config.APP_DOMAIN_NAME
+}
+
+func newPasskeyCreationOptions(challenge, userId, userName string, existing []passkey) *passkeyCreationOptions {
+	o := &passkeyCreationOptions{
+		Challenge:          challenge,
+		Timeout:            config.PASSKEY_CHALLENGE_TIMEOUT_SEC * 1000,
+		Attestation:        "none",
+		ExcludeCredentials: []credentialDescriptor{},
+	}
+
+	o.RP.Id = webauthnRPId()
+	o.RP.Name = "TabsFlow"
+
+	// user handle, returned by the authenticator on login
+	o.User.Id = base64.RawURLEncoding.EncodeToString([]byte(userId))
+	o.User.Name = userName
+	o.User.DisplayName = userName
+
+	for _, alg := range []int64{coseAlgES256, coseAlgRS256} {
+		o.PubKeyCredParams = append(o.PubKeyCredParams, struct {
+			Type string `json:"type"`
+			Alg  int64  `json:"alg"`
+		}{Type: "public-key", Alg: alg})
+	}
+
+	// discoverable credential, to login without the email
+	o.AuthenticatorSelection.ResidentKey = "required"
+	o.AuthenticatorSelection.UserVerification = "preferred"
+
+	for _, p := range existing {
+		o.ExcludeCredentials = append(o.ExcludeCredentials, credentialDescriptor{Type: "public-key", Id: p.Id})
+	}
+
+	return o
+}
+
+func newPasskeyRequestOptions(challenge string) *passkeyRequestOptions {
+	return &passkeyRequestOptions{
+		Challenge:        challenge,
+		RPId:             webauthnRPId(),
+		Timeout:          config.PASSKEY_CHALLENGE_TIMEOUT_SEC * 1000,
+		UserVerification: "preferred",
+	}
+}
+
+// user id from the user handle of the assertion
+func passkeyUserId(a *passkeyAssertion) (string, error) {
+	userId, err := base64.RawURLEncoding.DecodeString(a.Response.UserHandle)
+
+	if err != nil || len(userId) == 0 {
+		return "", errInvalidPasskey
+	}
+
+	return string(userId), nil
+}
+
+// verifies the registration for the challenge, returns the credential to save
+func verifyPasskeyRegistration(reg *passkeyRegistration, challenge string) (*authenticatorData, error) {
+	if reg.Type != "public-key" {
+		return nil, errInvalidPasskey
+	}
+
+	_, err := verifyClientData(reg.Response.ClientDataJSON, ceremonyCreate, challenge)
+
+	if err != nil {
+		return nil, err
+	}
+
+	attestation, err := base64.RawURLEncoding.DecodeString(reg.Response.AttestationObject)
+
+	if err != nil {
+		return nil, errInvalidPasskey
+	}
+
+	obj, _, err := decodeCBOR(attestation)
+
+	if err != nil {
+		return nil, errInvalidPasskey
+	}
+
+	m, ok := obj.(map[any]any)
+
+	if !ok {
+		return nil, errInvalidPasskey
+	}
+
+	rawAuthData, ok := m["authData"].([]byte)
+
+	// attestation isn't requested, the authenticator is not verified
+	if !ok || m["fmt"] != "none" {
+		return nil, errInvalidPasskey
+	}
+
+	authData, err := parseAuthenticatorData(rawAuthData)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if authData.flags&authDataFlagAttestedData == 0 || len(authData.credentialId) == 0 {
+		return nil, errInvalidPasskey
+	}
+
+	if base64.RawURLEncoding.EncodeToString(authData.credentialId) != reg.Id {
+		return nil, errInvalidPasskey
+	}
+
+	return authData, nil
+}
+
+// verifies the assertion signature with the saved credential, returns the new sign count
+func verifyPasskeyAssertion(a *passkeyAssertion, challenge string, p *passkey) (uint32, error) {
+	if a.Type != "public-key" {
+		return 0, errInvalidPasskey
+	}
+
+	clientDataJSON, err := verifyClientData(a.Response.ClientDataJSON, ceremonyGet, challenge)
+
+	if err != nil {
+		return 0, err
+	}
+
+	rawAuthData, err := base64.RawURLEncoding.DecodeString(a.Response.AuthenticatorData)
+
+	if err != nil {
+		return 0, errInvalidPasskey
+	}
+
+	authData, err := parseAuthenticatorData(rawAuthData)
+
+	if err != nil {
+		return 0, err
+	}
+
+	signature, err := base64.RawURLEncoding.DecodeString(a.Response.Signature)
+
+	if err != nil {
+		return 0, errInvalidPasskey
+	}
+
+	clientDataHash := sha256.Sum256(clientDataJSON)
+
+	signed := slices.Concat(rawAuthData, clientDataHash[:])
+
+	err = verifyPasskeySignature(p, signed, signature)
+
+	if err != nil {
+		return 0, err
+	}
+
+	// counter not increased, the authenticator may be cloned (counter is 0 if not supported)
+	if (authData.signCount != 0 || p.SignCount != 0) && authData.signCount <= p.SignCount {
+		return 0, errPasskeySignCount
+	}
+
+	return authData.signCount, nil
+}
+
+// verifies the client data type, challenge & origin, returns the raw json
+func verifyClientData(encoded, ceremony, challenge string) ([]byte, error) {
+	raw, err := base64.RawURLEncoding.DecodeString(encoded)
+
+	if err != nil {
+		return nil, errInvalidPasskey
+	}
+
+	var c clientData
+
+	err = json.Unmarshal(raw, &c)
+
+	if err != nil {
+		return nil, errInvalidPasskey
+	}
+
+	if c.Type != ceremony || c.Challenge != challenge || challenge == "" {
+		return nil, errInvalidPasskey
+	}
+
+	if !slices.Contains(config.AllowedOrigins, strings.TrimSuffix(c.Origin, "/")) {
+		return nil, errInvalidPasskey
+	}
+
+	return raw, nil
+}
+
+// parses the authenticator data & checks the relying party id and user presence
+func parseAuthenticatorData(data []byte) (*authenticatorData, error) {
+	// rpIdHash (32) + flags (1) + signCount (4)
+	if len(data) < 37 {
+		return nil, errInvalidPasskey
+	}
+
+	a := &authenticatorData{
+		rpIdHash:  data[:32],
+		flags:     data[32],
+		signCount: binary.BigEndian.Uint32(data[33:37]),
+	}
+
+	rpIdHash := sha256.Sum256([]byte(webauthnRPId()))
+
+	if !bytes.Equal(a.rpIdHash, rpIdHash[:]) {
+		return nil, errInvalidPasskey
+	}
+
+	if a.flags&authDataFlagUserPresent == 0 {
+		return nil, errInvalidPasskey
+	}
+
+	if a.flags&authDataFlagAttestedData == 0 {
+		return a, nil
+	}
+
+	// aaguid (16) + credentialId length (2)
+	rest := data[37:]
+
+	if len(rest) < 18 {
+		return nil, errInvalidPasskey
+	}
+
+	idLen := int(binary.BigEndian.Uint16(rest[16:18]))
+
+	rest = rest[18:]
+
+	if len(rest) < idLen {
+		return nil, errInvalidPasskey
+	}
+
+	a.credentialId = rest[:idLen]
+
+	coseKey, _, err := decodeCBOR(rest[idLen:])
+
+	if err != nil {
+		return nil, errInvalidPasskey
+	}
+
+	pub, alg, err := coseToPublicKey(coseKey)
+
+	if err != nil {
+		return nil, err
+	}
+
+	der, err := x509.MarshalPKIXPublicKey(pub)
+
+	if err != nil {
+		return nil, errInvalidPasskey
+	}
+
+	a.publicKey = der
+	a.alg = alg
+
+	return a, nil
+}
+
+// converts the COSE key to a public key, supports ES256 & RS256
+func coseToPublicKey(key any) (crypto.PublicKey, int64, error) {
+	m, ok := key.(map[any]any)
+
+	if !ok {
+		return nil, 0, errInvalidPasskey
+	}
+
+	alg, _ := m[int64(3)].(int64)
+
+	switch alg {
+	case coseAlgES256:
+		// kty: EC2, crv: P-256
+		if m[int64(1)] != int64(2) || m[int64(-1)] != int64(1) {
+			return nil, 0, errInvalidPasskey
+		}
+
+		x, okX := m[int64(-2)].([]byte)
+		y, okY := m[int64(-3)].([]byte)
+
+		if !okX || !okY || len(x) != 32 || len(y) != 32 {
+			return nil, 0, errInvalidPasskey
+		}
+
+		pub := &ecdsa.PublicKey{
+			Curve: elliptic.P256(),
+			X:     new(big.Int).SetBytes(x),
+			Y:     new(big.Int).SetBytes(y),
+		}
+
+		if !pub.Curve.IsOnCurve(pub.X, pub.Y) {
+			return nil, 0, errInvalidPasskey
+		}
+
+		return pub, alg, nil
+
+	case coseAlgRS256:
+		// kty: RSA
+		if m[int64(1)] != int64(3) {
+			return nil, 0, errInvalidPasskey
+		}
+
+		n, okN := m[int64(-1)].([]byte)
+		e, okE := m[int64(-2)].([]byte)
+
+		if !okN || !okE || len(n) < 256 || len(e) == 0 || len(e) > 4 {
+			return nil, 0, errInvalidPasskey
+		}
+
+		return &rsa.PublicKey{
+			N: new(big.Int).SetBytes(n),
+			E: int(new(big.Int).SetBytes(e).Int64()),
+		}, alg, nil
+	}
+
+	return nil, 0, errPasskeyAlgorithm
+}
+
+func verifyPasskeySignature(p *passkey, signed, signature []byte) error {
+	der, err := base64.StdEncoding.DecodeString(p.PublicKey)
+
+	if err != nil {
+		return errInvalidPasskey
+	}
+
+	pub, err := x509.ParsePKIXPublicKey(der)
+
+	if err != nil {
+		return errInvalidPasskey
+	}
+
+	hash := sha256.Sum256(signed)
+
+	switch p.Alg {
+	case coseAlgES256:
+		k, ok := pub.(*ecdsa.PublicKey)
+
+		if ok && ecdsa.VerifyASN1(k, hash[:], signature) {
+			return nil
+		}
+
+	case coseAlgRS256:
+		k, ok := pub.(*rsa.PublicKey)
+
+		if ok && rsa.VerifyPKCS1v15(k, crypto.SHA256, hash[:], signature) == nil {
+			return nil
+		}
+	}
+
+	return errInvalidPasskey
+}
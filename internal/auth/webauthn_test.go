@@ -0,0 +1,255 @@
+package auth
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"slices"
+	"testing"
+
+	"github.com/manishMandal02/tabsflow-backend/config"
+)
+
+// * minimal cbor encoder, to build authenticator responses
+
+func cborHead(major byte, n int) []byte {
+	switch {
+	case n < 24:
+		return []byte{major<<5 | byte(n)}
+	case n < 256:
+		return []byte{major<<5 | 24, byte(n)}
+	default:
+		return []byte{major<<5 | 25, byte(n >> 8), byte(n)}
+	}
+}
+
+func cborInt(n int64) []byte {
+	if n < 0 {
+		return cborHead(1, int(-1-n))
+	}
+	return cborHead(0, int(n))
+}
+
+func cborBytes(b []byte) []byte {
+	return append(cborHead(2, len(b)), b...)
+}
+
+func cborText(s string) []byte {
+	return append(cborHead(3, len(s)), s...)
+}
+
+// key-value pairs, already encoded
+func cborMap(pairs ...[]byte) []byte {
+	return slices.Concat(append([][]byte{cborHead(5, len(pairs)/2)}, pairs...)...)
+}
+
+type testAuthenticator struct {
+	key    *ecdsa.PrivateKey
+	credId []byte
+	rpId   string
+	origin string
+}
+
+func newTestAuthenticator(t *testing.T) *testAuthenticator {
+	t.Helper()
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+
+	if err != nil {
+		t.Fatalf("error generating key: %v", err)
+	}
+
+	return &testAuthenticator{
+		key:    key,
+		credId: []byte("credential-1"),
+		rpId:   config.APP_DOMAIN_NAME,
+		origin: "https://app.tabsflow.com",
+	}
+}
+
+func (a *testAuthenticator) clientData(ceremony, challenge string) []byte {
+	c, _ := json.Marshal(clientData{Type: ceremony, Challenge: challenge, Origin: a.origin})
+	return c
+}
+
+func (a *testAuthenticator) authData(flags byte, signCount uint32, attested []byte) []byte {
+	rpIdHash := sha256.Sum256([]byte(a.rpId))
+
+	count := make([]byte, 4)
+	binary.BigEndian.PutUint32(count, signCount)
+
+	return slices.Concat(rpIdHash[:], []byte{flags}, count, attested)
+}
+
+func (a *testAuthenticator) register(challenge string) *passkeyRegistration {
+	coseKey := cborMap(
+		cborInt(1), cborInt(2),
+		cborInt(3), cborInt(coseAlgES256),
+		cborInt(-1), cborInt(1),
+		cborInt(-2), cborBytes(a.key.X.FillBytes(make([]byte, 32))),
+		cborInt(-3), cborBytes(a.key.Y.FillBytes(make([]byte, 32))),
+	)
+
+	idLen := make([]byte, 2)
+	binary.BigEndian.PutUint16(idLen, uint16(len(a.credId)))
+
+	attested := slices.Concat(make([]byte, 16), idLen, a.credId, coseKey)
+
+	attestation := cborMap(
+		cborText("fmt"), cborText("none"),
+		cborText("attStmt"), cborMap(),
+		cborText("authData"), cborBytes(a.authData(authDataFlagUserPresent|authDataFlagAttestedData, 0, attested)),
+	)
+
+	reg := &passkeyRegistration{
+		Id:   base64.RawURLEncoding.EncodeToString(a.credId),
+		Type: "public-key",
+	}
+
+	reg.Response.ClientDataJSON = base64.RawURLEncoding.EncodeToString(a.clientData(ceremonyCreate, challenge))
+	reg.Response.AttestationObject = base64.RawURLEncoding.EncodeToString(attestation)
+
+	return reg
+}
+
+func (a *testAuthenticator) assert(t *testing.T, challenge string, signCount uint32) *passkeyAssertion {
+	t.Helper()
+
+	authData := a.authData(authDataFlagUserPresent, signCount, nil)
+	clientDataJSON := a.clientData(ceremonyGet, challenge)
+
+	clientDataHash := sha256.Sum256(clientDataJSON)
+	hash := sha256.Sum256(slices.Concat(authData, clientDataHash[:]))
+
+	sig, err := ecdsa.SignASN1(rand.Reader, a.key, hash[:])
+
+	if err != nil {
+		t.Fatalf("error signing assertion: %v", err)
+	}
+
+	as := &passkeyAssertion{
+		Id:   base64.RawURLEncoding.EncodeToString(a.credId),
+		Type: "public-key",
+	}
+
+	as.Response.ClientDataJSON = base64.RawURLEncoding.EncodeToString(clientDataJSON)
+	as.Response.AuthenticatorData = base64.RawURLEncoding.EncodeToString(authData)
+	as.Response.Signature = base64.RawURLEncoding.EncodeToString(sig)
+	as.Response.UserHandle = base64.RawURLEncoding.EncodeToString([]byte("user-1"))
+
+	return as
+}
+
+func TestVerifyPasskeyRegistration(t *testing.T) {
+	a := newTestAuthenticator(t)
+
+	authData, err := verifyPasskeyRegistration(a.register("challenge-1"), "challenge-1")
+
+	if err != nil {
+		t.Fatalf("verifyPasskeyRegistration() unexpected error = %v", err)
+	}
+
+	if authData.alg != coseAlgES256 || string(authData.credentialId) != string(a.credId) {
+		t.Errorf("verifyPasskeyRegistration() credential = %+v", authData)
+	}
+
+	if _, err := verifyPasskeyRegistration(a.register("challenge-1"), "challenge-2"); err == nil {
+		t.Errorf("verifyPasskeyRegistration() expected error for a different challenge")
+	}
+
+	a.origin = "https://evil.com"
+
+	if _, err := verifyPasskeyRegistration(a.register("challenge-1"), "challenge-1"); err == nil {
+		t.Errorf("verifyPasskeyRegistration() expected error for a different origin")
+	}
+
+	a.origin = "https://app.tabsflow.com"
+	a.rpId = "evil.com"
+
+	if _, err := verifyPasskeyRegistration(a.register("challenge-1"), "challenge-1"); err == nil {
+		t.Errorf("verifyPasskeyRegistration() expected error for a different rp id")
+	}
+}
+
+func TestVerifyPasskeyAssertion(t *testing.T) {
+	a := newTestAuthenticator(t)
+
+	authData, err := verifyPasskeyRegistration(a.register("challenge-1"), "challenge-1")
+
+	if err != nil {
+		t.Fatalf("verifyPasskeyRegistration() unexpected error = %v", err)
+	}
+
+	p := &passkey{
+		UserId:    "user-1",
+		Id:        base64.RawURLEncoding.EncodeToString(authData.credentialId),
+		PublicKey: base64.StdEncoding.EncodeToString(authData.publicKey),
+		Alg:       authData.alg,
+		SignCount: 5,
+	}
+
+	otherKey := newTestAuthenticator(t)
+
+	tests := []struct {
+		name      string
+		assertion *passkeyAssertion
+		challenge string
+		wantErr   error
+	}{
+		{
+			name:      "valid",
+			assertion: a.assert(t, "challenge-2", 6),
+			challenge: "challenge-2",
+		},
+		{
+			name:      "different challenge",
+			assertion: a.assert(t, "challenge-2", 6),
+			challenge: "challenge-3",
+			wantErr:   errInvalidPasskey,
+		},
+		{
+			name:      "sign count not increased",
+			assertion: a.assert(t, "challenge-2", 5),
+			challenge: "challenge-2",
+			wantErr:   errPasskeySignCount,
+		},
+		{
+			name:      "signed with other key",
+			assertion: otherKey.assert(t, "challenge-2", 6),
+			challenge: "challenge-2",
+			wantErr:   errInvalidPasskey,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			signCount, err := verifyPasskeyAssertion(tt.assertion, tt.challenge, p)
+
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) {
+					t.Fatalf("verifyPasskeyAssertion() error = %v, wantErr %v", err, tt.wantErr)
+				}
+				return
+			}
+
+			if err != nil {
+				t.Fatalf("verifyPasskeyAssertion() unexpected error = %v", err)
+			}
+
+			if signCount != 6 {
+				t.Errorf("verifyPasskeyAssertion() sign count = %v, want 6", signCount)
+			}
+		})
+	}
+
+	userId, err := passkeyUserId(a.assert(t, "challenge-2", 6))
+
+	if err != nil || userId != "user-1" {
+		t.Errorf("passkeyUserId() = %v, %v", userId, err)
+	}
+}
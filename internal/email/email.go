@@ -9,9 +9,10 @@ import (
 	"github.com/manishMandal02/tabsflow-backend/config"
 	"github.com/manishMandal02/tabsflow-backend/pkg/events"
 	"github.com/manishMandal02/tabsflow-backend/pkg/logger"
+	"github.com/manishMandal02/tabsflow-backend/pkg/telemetry"
 )
 
-func SendEmail(_ context.Context, event lambda_events.SQSEvent) (interface{}, error) {
+func SendEmail(ctx context.Context, event lambda_events.SQSEvent) (interface{}, error) {
 
 	if len(event.Records) == 0 {
 		err := fmt.Errorf("no records found in event")
@@ -21,25 +22,26 @@ func SendEmail(_ context.Context, event lambda_events.SQSEvent) (interface{}, er
 
 	//  process batch of events
 	for _, record := range event.Records {
-		event := events.Event[any]{}
-
-		logger.Info("processing record: %v", record.Body)
+		// logs of the request that queued the email
+		ctx := events.MessageContext(ctx, record)
 
 		eventType := *record.MessageAttributes["event_type"].StringValue
 
-		logger.Info("processing event_type: %v", event.EventType)
+		logger.InfoContext(ctx, "processing event", "event_type", eventType)
 
 		err := processEvent(eventType, record.Body)
 
+		telemetry.Count(ctx, "email.deliveries", telemetry.String("event_type", eventType), telemetry.Status(err))
+
 		if err != nil {
-			logger.Errorf("error processing event: %v", err)
+			logger.ErrorContext(ctx, "error processing event", err, "event_type", eventType)
 			continue
 		}
 
 		// remove message from sqs
 		q := events.NewEmailQueue()
 
-		err = q.DeleteMessage(record.ReceiptHandle)
+		err = q.DeleteMessage(ctx, record.ReceiptHandle)
 
 		if err != nil {
 			return nil, err
@@ -84,6 +86,51 @@ func processEvent(eventType string, body string) error {
 
 		return handleUserRegistered(*ev.Payload)
 
+	case events.EventTypeSendDataExport:
+		ev, err := events.NewFromJSON[events.SendDataExportPayload](body)
+
+		if err != nil {
+			logger.Errorf("error un_marshalling event: %v", err)
+			return err
+		}
+
+		// zepto mail key not set for test account, so skip sending email
+		if config.ZEPTO_MAIL_API_KEY == "" {
+			return nil
+		}
+
+		return handleSendDataExport(*ev.Payload)
+
+	case events.EventTypeSendMagicLink:
+		ev, err := events.NewFromJSON[events.SendMagicLinkPayload](body)
+
+		if err != nil {
+			logger.Errorf("error un_marshalling event: %v", err)
+			return err
+		}
+
+		// zepto mail key not set for test account, so skip sending email
+		if config.ZEPTO_MAIL_API_KEY == "" {
+			return nil
+		}
+
+		return handleSendMagicLink(*ev.Payload)
+
+	case events.EventTypeAccountDeleted:
+		ev, err := events.NewFromJSON[events.AccountDeletedPayload](body)
+
+		if err != nil {
+			logger.Errorf("error un_marshalling event: %v", err)
+			return err
+		}
+
+		// zepto mail key not set for test account, so skip sending email
+		if config.ZEPTO_MAIL_API_KEY == "" {
+			return nil
+		}
+
+		return handleAccountDeleted(*ev.Payload)
+
 	default:
 		logger.Errorf("Unknown sqs event: %v", eventType)
 	}
@@ -128,3 +175,54 @@ func handleUserRegistered(payload events.UserRegisteredPayload) error {
 
 	return nil
 }
+
+func handleSendDataExport(payload events.SendDataExportPayload) error {
+	z := NewZeptoMail()
+
+	to := &NameAddr{
+		Name:    payload.Name,
+		Address: payload.Email,
+	}
+
+	err := z.sendDataExportMail(to, payload.DownloadURL, payload.ExpiresAt)
+
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func handleSendMagicLink(payload events.SendMagicLinkPayload) error {
+	z := NewZeptoMail()
+
+	to := &NameAddr{
+		Name:    payload.Email,
+		Address: payload.Email,
+	}
+
+	err := z.sendMagicLinkMail(to, payload.Link, payload.ExpiresIn)
+
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func handleAccountDeleted(payload events.AccountDeletedPayload) error {
+	z := NewZeptoMail()
+
+	to := &NameAddr{
+		Name:    payload.Name,
+		Address: payload.Email,
+	}
+
+	err := z.sendAccountDeletedMail(to, payload.ReceiptId, payload.DeletedAt)
+
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
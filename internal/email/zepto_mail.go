@@ -3,6 +3,7 @@ package email
 import (
 	"encoding/json"
 	"fmt"
+	"html"
 	"net/http"
 	"strings"
 
@@ -149,6 +150,137 @@ func (z *ZeptoMail) sendWelcomeMail(to *NameAddr, trailEndDate string) error {
 	return nil
 }
 
+// data export, magic link & account deleted emails, sent without a template
+type htmlEmailBody struct {
+	To       []ToEmailAddress `json:"to"`
+	From     *NameAddr        `json:"from"`
+	Subject  string           `json:"subject"`
+	HTMLBody string           `json:"htmlbody"`
+}
+
+const dataExportMailHTML = `<p>Hi %s,</p>
+<p>Your TabsFlow data export is ready. You can download it from the link below.</p>
+<p><a href="%s">Download your data</a></p>
+<p>The link will expire on %s.</p>
+<p>If you didn't request this export, please contact us at support@tabsflow.com</p>`
+
+func (z *ZeptoMail) sendDataExportMail(to *NameAddr, downloadURL, expiresAt string) error {
+
+	name := to.Name
+
+	if name == "" {
+		name = "there"
+	}
+
+	body := &htmlEmailBody{
+		To: append(
+			[]ToEmailAddress{},
+			ToEmailAddress{
+				EmailAddress: *to,
+			},
+		),
+		From: &NameAddr{
+			Name:    z.From.Name,
+			Address: z.From.Address,
+		},
+		Subject:  "Your TabsFlow data export is ready",
+		HTMLBody: fmt.Sprintf(dataExportMailHTML, html.EscapeString(name), html.EscapeString(downloadURL), expiresAt),
+	}
+
+	bodyBytes, err := json.Marshal(body)
+
+	if err != nil {
+		return err
+	}
+
+	err = sendMail(config.ZEPTO_MAIL_HTML_API_URL, z.Headers, bodyBytes)
+
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+const magicLinkMailHTML = `<p>Hi,</p>
+<p>Click the link below to log in to TabsFlow. The link can be used only once and will expire in %s.</p>
+<p><a href="%s">Log in to TabsFlow</a></p>
+<p>If you didn't request this link, you can safely ignore this email.</p>`
+
+func (z *ZeptoMail) sendMagicLinkMail(to *NameAddr, link, expiresIn string) error {
+	body := &htmlEmailBody{
+		To: append(
+			[]ToEmailAddress{},
+			ToEmailAddress{
+				EmailAddress: *to,
+			},
+		),
+		From: &NameAddr{
+			Name:    z.From.Name,
+			Address: z.From.Address,
+		},
+		Subject:  "Your TabsFlow login link",
+		HTMLBody: fmt.Sprintf(magicLinkMailHTML, expiresIn, html.EscapeString(link)),
+	}
+
+	bodyBytes, err := json.Marshal(body)
+
+	if err != nil {
+		return err
+	}
+
+	err = sendMail(config.ZEPTO_MAIL_HTML_API_URL, z.Headers, bodyBytes)
+
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+const accountDeletedMailHTML = `<p>Hi %s,</p>
+<p>Your TabsFlow account and all its data were deleted on %s.</p>
+<p>Deletion receipt: <b>%s</b></p>
+<p>Please keep this receipt for your records. If you didn't request this deletion, please contact us at support@tabsflow.com</p>`
+
+func (z *ZeptoMail) sendAccountDeletedMail(to *NameAddr, receiptId, deletedAt string) error {
+
+	name := to.Name
+
+	if name == "" {
+		name = "there"
+	}
+
+	body := &htmlEmailBody{
+		To: append(
+			[]ToEmailAddress{},
+			ToEmailAddress{
+				EmailAddress: *to,
+			},
+		),
+		From: &NameAddr{
+			Name:    z.From.Name,
+			Address: z.From.Address,
+		},
+		Subject:  "Your TabsFlow account has been deleted",
+		HTMLBody: fmt.Sprintf(accountDeletedMailHTML, html.EscapeString(name), deletedAt, html.EscapeString(receiptId)),
+	}
+
+	bodyBytes, err := json.Marshal(body)
+
+	if err != nil {
+		return err
+	}
+
+	err = sendMail(config.ZEPTO_MAIL_HTML_API_URL, z.Headers, bodyBytes)
+
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
 // helper
 func sendMail(url string, headers map[string]string, body []byte) error {
 	res, respBody, err := utils.MakeHTTPRequest(http.MethodPost, url, headers, body, http.DefaultClient)
@@ -1,6 +1,7 @@
 package notes
 
 import (
+	"context"
 	"encoding/json"
 	"errors"
 	"net/http"
@@ -8,6 +9,7 @@ import (
 	"strings"
 
 	"github.com/kljensen/snowball"
+	"github.com/manishMandal02/tabsflow-backend/pkg/errs"
 	"github.com/manishMandal02/tabsflow-backend/pkg/events"
 	"github.com/manishMandal02/tabsflow-backend/pkg/http_api"
 	"github.com/manishMandal02/tabsflow-backend/pkg/logger"
@@ -28,35 +30,26 @@ func newNoteHandler(nr noteRepository, q *events.Queue) *noteHandler {
 func (h noteHandler) create(w http.ResponseWriter, r *http.Request) {
 	userId := r.PathValue("userId")
 
-	note := &Note{}
-
-	err := json.NewDecoder(r.Body).Decode(note)
-
-	if err != nil {
-		logger.Errorf("error decoding note: %v. [Error]: %v", note, err)
-		http_api.ErrorRes(w, err.Error(), http.StatusBadRequest)
-		return
-	}
-
-	err = note.validate()
+	note, err := http_api.DecodeAndValidate[Note](w, r)
 
 	if err != nil {
-		http_api.ErrorRes(w, err.Error(), http.StatusBadRequest)
+		logger.ErrorContext(r.Context(), "error decoding note", err, "userId", userId)
+		http_api.ErrorRes(w, err)
 		return
 	}
 
 	noteText, err := getNotesTextFromNoteJSON(note.Text)
 
 	if err != nil {
-		logger.Errorf("error getting note text from note json: %v. [Error]: %v", note, err)
-		http_api.ErrorRes(w, err.Error(), http.StatusBadRequest)
+		logger.ErrorContext(r.Context(), "error getting note text from note json", err, "noteId", note.Id)
+		http_api.ErrorRes(w, errs.BadRequest.WithMessage(err.Error()))
 		return
 	}
 
-	err = h.r.createNote(userId, note)
+	err = h.r.createNote(r.Context(), userId, note)
 
 	if err != nil {
-		http_api.ErrorRes(w, err.Error(), http.StatusInternalServerError)
+		http_api.ErrorRes(w, err)
 		return
 	}
 
@@ -64,12 +57,12 @@ func (h noteHandler) create(w http.ResponseWriter, r *http.Request) {
 	terms :=
 		extractSearchTerms(note.Title, noteText, note.Domain)
 
-	logger.Dev("num of search terms: %v", len(terms))
+	logger.DebugContext(r.Context(), "note search terms", "count", len(terms))
 
-	err = h.r.indexSearchTerms(userId, note.Id, terms)
+	err = h.r.indexSearchTerms(r.Context(), userId, note.Id, terms)
 
 	if err != nil {
-		logger.Errorf("error indexing search terms for note: %v. [Error]: %v", note, err)
+		logger.ErrorContext(r.Context(), "error indexing search terms", err, "noteId", note.Id)
 	}
 
 	//  if remainder is set, create a schedule to send reminder
@@ -80,10 +73,10 @@ func (h noteHandler) create(w http.ResponseWriter, r *http.Request) {
 			SubEvent:  events.SubEventCreate,
 			TriggerAt: note.RemainderAt,
 		})
-		err = h.notificationQueue.AddMessage(event)
+		err = h.notificationQueue.AddMessage(r.Context(), event)
 
 		if err != nil {
-			http_api.ErrorRes(w, errMsg.noteCreate, http.StatusBadGateway)
+			http_api.ErrorRes(w, errs.BadGateway.WithMessage(errMsg.noteCreate))
 			return
 		}
 	}
@@ -97,18 +90,18 @@ func (h noteHandler) get(w http.ResponseWriter, r *http.Request) {
 	noteId := r.PathValue("noteId")
 
 	if noteId == "" {
-		http_api.ErrorRes(w, errMsg.noteId, http.StatusBadRequest)
+		http_api.ErrorRes(w, errs.BadRequest.WithMessage(errMsg.noteId))
 		return
 	}
 
-	notes, err := h.r.GetNote(userId, noteId)
+	notes, err := h.r.GetNote(r.Context(), userId, noteId)
 
 	if err != nil {
-		if err.Error() == errMsg.notesGetEmpty {
-			http_api.ErrorRes(w, errMsg.notesGetEmpty, http.StatusNotFound)
+		if errors.Is(err, errNoteNotFound) {
+			http_api.ErrorRes(w, errNoteNotFound)
 			return
 		}
-		http_api.ErrorRes(w, err.Error(), http.StatusInternalServerError)
+		http_api.ErrorRes(w, err)
 		return
 	}
 
@@ -127,19 +120,19 @@ func (h noteHandler) getAllByUser(w http.ResponseWriter, r *http.Request) {
 		lastNoteId, err = strconv.ParseInt(lastNoteIdStr, 10, 64)
 
 		if err != nil {
-			logger.Error("Couldn't parse noteId", err)
-			http_api.ErrorRes(w, errMsg.noteGet, http.StatusBadRequest)
+			logger.ErrorContext(r.Context(), "Couldn't parse noteId", err)
+			http_api.ErrorRes(w, errs.BadRequest.WithMessage(errMsg.noteGet))
 			return
 		}
 	}
-	note, err := h.r.getNotesByUser(userId, lastNoteId)
+	note, err := h.r.getNotesByUser(r.Context(), userId, lastNoteId)
 
 	if err != nil {
-		if err.Error() == errMsg.notesGetEmpty {
-			http_api.ErrorRes(w, errMsg.notesGetEmpty, http.StatusNotFound)
+		if errors.Is(err, errNoteNotFound) {
+			http_api.ErrorRes(w, errNoteNotFound)
 			return
 		}
-		http_api.ErrorRes(w, errMsg.notesGet, http.StatusInternalServerError)
+		http_api.ErrorRes(w, errs.Internal.WithMessage(errMsg.notesGet))
 		return
 	}
 
@@ -153,7 +146,7 @@ func (h noteHandler) search(w http.ResponseWriter, r *http.Request) {
 	maxSearchLimit := r.URL.Query().Get("limit")
 
 	if query == "" {
-		http_api.ErrorRes(w, "search query required", http.StatusBadRequest)
+		http_api.ErrorRes(w, errs.BadRequest.WithMessage("search query required"))
 		return
 	}
 
@@ -164,8 +157,8 @@ func (h noteHandler) search(w http.ResponseWriter, r *http.Request) {
 	if maxSearchLimit != "" {
 		n, err := strconv.ParseInt(maxSearchLimit, 10, 32)
 		if err != nil {
-			logger.Error("Couldn't parse search limit query", err)
-			http_api.ErrorRes(w, errMsg.notesSearch, http.StatusBadRequest)
+			logger.ErrorContext(r.Context(), "Couldn't parse search limit query", err)
+			http_api.ErrorRes(w, errs.BadRequest.WithMessage(errMsg.notesSearch))
 			return
 		}
 
@@ -175,31 +168,31 @@ func (h noteHandler) search(w http.ResponseWriter, r *http.Request) {
 			limit = int(n)
 		}
 	}
-	logger.Dev("searchTerms: %v", searchTerms)
+	logger.DebugContext(r.Context(), "notes search", "terms", searchTerms)
 
-	notesIds, err := getNoteIdsBySearchTerms(userId, searchTerms, limit, h.r)
+	notesIds, err := getNoteIdsBySearchTerms(r.Context(), userId, searchTerms, limit, h.r)
 
 	if err != nil {
-		if err.Error() == errMsg.notesSearchEmpty {
-			http_api.ErrorRes(w, errMsg.notesSearchEmpty, http.StatusNotFound)
+		if errors.Is(err, errNotesSearchEmpty) {
+			http_api.ErrorRes(w, errNotesSearchEmpty)
 			return
 		}
-		http_api.ErrorRes(w, errMsg.notesSearch, http.StatusInternalServerError)
+		http_api.ErrorRes(w, errs.Internal.WithMessage(errMsg.notesSearch))
 		return
 	}
 
-	logger.Dev("final notesIds: %v", notesIds)
+	logger.DebugContext(r.Context(), "notes search matched", "noteIds", notesIds)
 
 	// get notes that matched the search query
-	notes, err := h.r.getNotesByIds(userId, &notesIds)
+	notes, err := h.r.getNotesByIds(r.Context(), userId, &notesIds)
 
 	if err != nil {
-		http_api.ErrorRes(w, errMsg.notesSearch, http.StatusBadGateway)
+		http_api.ErrorRes(w, errs.BadGateway.WithMessage(errMsg.notesSearch))
 		return
 	}
 
 	if len(*notes) == 0 {
-		http_api.ErrorRes(w, errMsg.notesSearchEmpty, http.StatusNotFound)
+		http_api.ErrorRes(w, errNotesSearchEmpty)
 		return
 	}
 
@@ -209,39 +202,35 @@ func (h noteHandler) search(w http.ResponseWriter, r *http.Request) {
 func (h noteHandler) update(w http.ResponseWriter, r *http.Request) {
 	userId := r.PathValue("userId")
 
-	body := struct {
-		*Note
-	}{}
-
-	err := json.NewDecoder(r.Body).Decode(&body)
+	body, err := http_api.DecodeAndValidate[noteUpdateReq](w, r)
 
 	if err != nil {
-		http_api.ErrorRes(w, errMsg.noteUpdate, http.StatusBadRequest)
+		http_api.ErrorRes(w, err)
 		return
 	}
 
 	if body.Note.Id == "" {
-		http_api.ErrorRes(w, errMsg.noteId, http.StatusBadRequest)
+		http_api.ErrorRes(w, errs.BadRequest.WithMessage(errMsg.noteId))
 		return
 	}
 
 	// get old note
-	oldNote, err := h.r.GetNote(userId, body.Note.Id)
+	oldNote, err := h.r.GetNote(r.Context(), userId, body.Note.Id)
 
 	if err != nil {
-		if err.Error() == errMsg.notesGetEmpty {
-			http_api.ErrorRes(w, errMsg.notesGetEmpty, http.StatusNotFound)
+		if errors.Is(err, errNoteNotFound) {
+			http_api.ErrorRes(w, errNoteNotFound)
 			return
 		}
-		http_api.ErrorRes(w, errMsg.noteUpdate, http.StatusInternalServerError)
+		http_api.ErrorRes(w, errs.Internal.WithMessage(errMsg.noteUpdate))
 		return
 
 	}
 
-	err = h.r.updateNote(userId, body.Note)
+	err = h.r.updateNote(r.Context(), userId, &body.Note)
 
 	if err != nil {
-		http_api.ErrorRes(w, err.Error(), http.StatusInternalServerError)
+		http_api.ErrorRes(w, err)
 		return
 	}
 
@@ -254,7 +243,7 @@ func (h noteHandler) update(w http.ResponseWriter, r *http.Request) {
 				SubEvent:  events.SubEventUpdate,
 				TriggerAt: body.Note.RemainderAt,
 			})
-			err = h.notificationQueue.AddMessage(event)
+			err = h.notificationQueue.AddMessage(r.Context(), event)
 		}
 
 		if body.Note.RemainderAt == 0 {
@@ -263,12 +252,12 @@ func (h noteHandler) update(w http.ResponseWriter, r *http.Request) {
 				NoteId:   body.Note.Id,
 				SubEvent: events.SubEventDelete,
 			})
-			err = h.notificationQueue.AddMessage(event)
+			err = h.notificationQueue.AddMessage(r.Context(), event)
 		}
 
 		if err != nil {
-			logger.Errorf("error scheduling note  noteId: %v. \n[Error]: %v", body.Note.Id, err)
-			http_api.ErrorRes(w, errMsg.noteUpdate, http.StatusBadRequest)
+			logger.ErrorContext(r.Context(), "error scheduling note remainder", err, "noteId", body.Note.Id)
+			http_api.ErrorRes(w, errs.BadRequest.WithMessage(errMsg.noteUpdate))
 			return
 		}
 
@@ -280,29 +269,29 @@ func (h noteHandler) update(w http.ResponseWriter, r *http.Request) {
 		noteText, err := getNotesTextFromNoteJSON(oldNote.Text)
 
 		if err != nil {
-			logger.Errorf("error getting note text from note json: %v. \n[Error]: %v", body.Note.Id, err)
-			http_api.ErrorRes(w, errMsg.noteUpdate, http.StatusBadRequest)
+			logger.ErrorContext(r.Context(), "error getting note text from note json", err, "noteId", body.Note.Id)
+			http_api.ErrorRes(w, errs.BadRequest.WithMessage(errMsg.noteUpdate))
 			return
 		}
 
 		// delete previous search terms
 		oldTerms := extractSearchTerms(oldNote.Title, noteText, oldNote.Domain)
 
-		err = h.r.deleteSearchTerms(userId, oldNote.Id, oldTerms)
+		err = h.r.deleteSearchTerms(r.Context(), userId, oldNote.Id, oldTerms)
 
 		if err != nil {
-			logger.Errorf("error deleting search terms for noteId: %v. \n[Error]: %v", body.Note.Id, err)
-			http_api.ErrorRes(w, errMsg.noteUpdate, http.StatusBadGateway)
+			logger.ErrorContext(r.Context(), "error deleting search terms", err, "noteId", body.Note.Id)
+			http_api.ErrorRes(w, errs.BadGateway.WithMessage(errMsg.noteUpdate))
 			return
 		}
 
 		// index new search terms for note
 		terms := extractSearchTerms(body.Note.Title, noteText, body.Note.Domain)
-		err = h.r.indexSearchTerms(userId, body.Note.Id, terms)
+		err = h.r.indexSearchTerms(r.Context(), userId, body.Note.Id, terms)
 
 		if err != nil {
-			logger.Errorf("error indexing search terms for noteId: %v. \n[Error]: %v", body.Note.Id, err)
-			http_api.ErrorRes(w, errMsg.noteUpdate, http.StatusBadGateway)
+			logger.ErrorContext(r.Context(), "error indexing search terms", err, "noteId", body.Note.Id)
+			http_api.ErrorRes(w, errs.BadGateway.WithMessage(errMsg.noteUpdate))
 			return
 		}
 
@@ -317,27 +306,27 @@ func (h noteHandler) delete(w http.ResponseWriter, r *http.Request) {
 	noteId := r.PathValue("noteId")
 
 	if noteId == "" {
-		http_api.ErrorRes(w, errMsg.noteId, http.StatusBadRequest)
+		http_api.ErrorRes(w, errs.BadRequest.WithMessage(errMsg.noteId))
 		return
 	}
 
 	// get old note
-	noteToDelete, err := h.r.GetNote(userId, noteId)
+	noteToDelete, err := h.r.GetNote(r.Context(), userId, noteId)
 
 	if err != nil {
-		if err.Error() == errMsg.notesGetEmpty {
-			http_api.ErrorRes(w, errMsg.notesGetEmpty, http.StatusNotFound)
+		if errors.Is(err, errNoteNotFound) {
+			http_api.ErrorRes(w, errNoteNotFound)
 			return
 		}
-		http_api.ErrorRes(w, errMsg.noteUpdate, http.StatusInternalServerError)
+		http_api.ErrorRes(w, errs.Internal.WithMessage(errMsg.noteUpdate))
 		return
 
 	}
 
-	err = h.r.deleteNote(userId, noteId)
+	err = h.r.deleteNote(r.Context(), userId, noteId)
 
 	if err != nil {
-		http_api.ErrorRes(w, err.Error(), http.StatusInternalServerError)
+		http_api.ErrorRes(w, err)
 		return
 	}
 
@@ -347,9 +336,9 @@ func (h noteHandler) delete(w http.ResponseWriter, r *http.Request) {
 			NoteId:   noteToDelete.Id,
 			SubEvent: events.SubEventDelete,
 		})
-		err = h.notificationQueue.AddMessage(event)
+		err = h.notificationQueue.AddMessage(r.Context(), event)
 		if err != nil {
-			logger.Errorf("error sending delete schedule for  noteId: %v. \n[Error]: %v", noteToDelete.Id, err)
+			logger.ErrorContext(r.Context(), "error deleting note remainder schedule", err, "noteId", noteToDelete.Id)
 		}
 	}
 
@@ -357,21 +346,21 @@ func (h noteHandler) delete(w http.ResponseWriter, r *http.Request) {
 	noteText, err := getNotesTextFromNoteJSON(noteToDelete.Text)
 
 	if err != nil {
-		logger.Errorf("error getting note text from note json for noteId: %v. \n[Error]: %v", noteToDelete.Id, err)
+		logger.ErrorContext(r.Context(), "error getting note text from note json", err, "noteId", noteToDelete.Id)
 	}
 
 	terms := extractSearchTerms(noteToDelete.Title, noteText, noteToDelete.Domain)
 
 	if len(terms) < 1 {
-		logger.Errorf("error getting search terms for noteId: %v. \n[Error]: %v", noteToDelete.Id, err)
+		logger.ErrorContext(r.Context(), "error getting search terms", err, "noteId", noteToDelete.Id)
 	}
 
-	logger.Dev("num of search terms: %v", len(terms))
+	logger.DebugContext(r.Context(), "note search terms", "count", len(terms))
 
-	err = h.r.deleteSearchTerms(userId, noteId, terms)
+	err = h.r.deleteSearchTerms(r.Context(), userId, noteId, terms)
 
 	if err != nil {
-		logger.Errorf("error deleting search terms for noteId: %v. \n[Error]: %v", noteId, err)
+		logger.ErrorContext(r.Context(), "error deleting search terms", err, "noteId", noteId)
 	}
 
 	http_api.SuccessResMsg(w, "Note deleted successfully")
@@ -479,19 +468,19 @@ func isCommonWord(word string) bool {
 	return commonWords[word]
 }
 
-func getNoteIdsBySearchTerms(userId string, searchTerms []string, limit int, r noteRepository) ([]string, error) {
+func getNoteIdsBySearchTerms(ctx context.Context, userId string, searchTerms []string, limit int, r noteRepository) ([]string, error) {
 
 	noteIdSets := []map[string]bool{}
 
 	for _, term := range searchTerms {
 		stemmed, _ := snowball.Stem(term, "english", true)
 
-		logger.Dev("stemmed term: %v", stemmed)
+		logger.DebugContext(ctx, "notes search stemmed term", "term", stemmed)
 
-		noteIds, err := r.noteIdsBySearchTerm(userId, stemmed, limit)
+		noteIds, err := r.noteIdsBySearchTerm(ctx, userId, stemmed, limit)
 
 		if err != nil {
-			if err.Error() == errMsg.notesSearchEmpty {
+			if errors.Is(err, errNotesSearchEmpty) {
 				continue
 			} else {
 				return nil, err
@@ -506,10 +495,10 @@ func getNoteIdsBySearchTerms(userId string, searchTerms []string, limit int, r n
 		noteIdSets = append(noteIdSets, noteIdSet)
 	}
 
-	logger.Dev("num noteIdSets: %v", len(noteIdSets))
+	logger.DebugContext(ctx, "notes search sets", "count", len(noteIdSets))
 
 	if len(noteIdSets) < 1 {
-		return nil, errors.New(errMsg.notesSearchEmpty)
+		return nil, errNotesSearchEmpty
 	}
 
 	// Find intersection of note IDs
@@ -531,7 +520,7 @@ func getNoteIdsBySearchTerms(userId string, searchTerms []string, limit int, r n
 		notesIdsMatched = append(notesIdsMatched, id)
 	}
 
-	logger.Dev("notesIdsMatched: %v", notesIdsMatched)
+	logger.DebugContext(ctx, "notes search intersection", "noteIds", notesIdsMatched)
 
 	if len(notesIdsMatched) > limit {
 		notesIdsMatched = notesIdsMatched[:limit]
@@ -541,18 +530,22 @@ func getNoteIdsBySearchTerms(userId string, searchTerms []string, limit int, r n
 }
 
 // middleware to get userId from jwt token present in req cookies
-func newUserIdMiddleware() http_api.Handler {
-	return func(w http.ResponseWriter, r *http.Request) {
+func newUserIdMiddleware() http_api.Middleware {
+	return func(next http_api.Handler) http_api.Handler {
+		return func(w http.ResponseWriter, r *http.Request) {
 
-		// get userId from jwt token
+			// get userId from jwt token
 
-		userId := r.Header.Get("UserId")
+			userId := r.Header.Get("UserId")
 
-		if userId == "" {
-			http.Redirect(w, r, "/logout", http.StatusTemporaryRedirect)
-			return
-		}
+			if userId == "" {
+				http.Redirect(w, r, "/logout", http.StatusTemporaryRedirect)
+				return
+			}
+
+			r.SetPathValue("userId", userId)
 
-		r.SetPathValue("userId", userId)
+			next(w, r)
+		}
 	}
 }
@@ -0,0 +1,209 @@
+package notes
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// lexical text format flags
+const (
+	textFormatBold          = 1
+	textFormatItalic        = 1 << 1
+	textFormatStrikethrough = 1 << 2
+	textFormatCode          = 1 << 4
+)
+
+// lexical editor node, only the fields used for markdown
+type noteNode struct {
+	Type     string     `json:"type"`
+	Text     string     `json:"text"`
+	Format   any        `json:"format"`
+	Tag      string     `json:"tag"`
+	ListType string     `json:"listType"`
+	Checked  bool       `json:"checked"`
+	URL      string     `json:"url"`
+	Children []noteNode `json:"children"`
+}
+
+// ToMarkdown converts the note to a markdown document, with the note's details as front matter
+func ToMarkdown(n *Note) string {
+	var b strings.Builder
+
+	b.WriteString("---\n")
+	fmt.Fprintf(&b, "id: %s\n", n.Id)
+
+	if n.Domain != "" {
+		fmt.Fprintf(&b, "domain: %s\n", n.Domain)
+	}
+
+	if n.SpaceId != "" {
+		fmt.Fprintf(&b, "spaceId: %s\n", n.SpaceId)
+	}
+
+	if n.RemainderAt != 0 {
+		fmt.Fprintf(&b, "remainderAt: %s\n", time.Unix(n.RemainderAt, 0).UTC().Format(time.RFC3339))
+	}
+
+	if n.UpdatedAt != 0 {
+		fmt.Fprintf(&b, "updatedAt: %s\n", time.UnixMilli(n.UpdatedAt).UTC().Format(time.RFC3339))
+	}
+
+	b.WriteString("---\n\n")
+	fmt.Fprintf(&b, "# %s\n\n", n.Title)
+	b.WriteString(noteTextToMarkdown(n.Text))
+
+	return strings.TrimRight(b.String(), "\n") + "\n"
+}
+
+// converts the lexical note json to markdown, the text is returned as is if not a valid note json
+func noteTextToMarkdown(jsonStr string) string {
+	var note struct {
+		Root *noteNode `json:"root"`
+	}
+
+	err := json.Unmarshal([]byte(jsonStr), &note)
+
+	if err != nil || note.Root == nil {
+		return jsonStr
+	}
+
+	var b strings.Builder
+
+	writeBlocks(&b, note.Root.Children)
+
+	return b.String()
+}
+
+// writes block nodes, each followed by a blank line
+func writeBlocks(b *strings.Builder, nodes []noteNode) {
+	for _, n := range nodes {
+		switch n.Type {
+		case "heading":
+			level := 1
+
+			if len(n.Tag) == 2 && n.Tag[0] == 'h' && n.Tag[1] >= '1' && n.Tag[1] <= '6' {
+				level = int(n.Tag[1] - '0')
+			}
+
+			fmt.Fprintf(b, "%s %s\n\n", strings.Repeat("#", level), inlineText(n.Children))
+		case "quote":
+			fmt.Fprintf(b, "> %s\n\n", inlineText(n.Children))
+		case "code":
+			fmt.Fprintf(b, "```\n%s\n```\n\n", plainText(n.Children))
+		case "list":
+			writeList(b, n, "")
+			b.WriteString("\n")
+		case "horizontalrule":
+			b.WriteString("---\n\n")
+		default:
+			if text := inlineText(n.Children); text != "" || n.Type == "paragraph" {
+				fmt.Fprintf(b, "%s\n\n", text)
+			}
+		}
+	}
+}
+
+func writeList(b *strings.Builder, list noteNode, indent string) {
+	for i, item := range list.Children {
+		// nested lists are children of a list item
+		var nested []noteNode
+		var inline []noteNode
+
+		for _, c := range item.Children {
+			if c.Type == "list" {
+				nested = append(nested, c)
+				continue
+			}
+			inline = append(inline, c)
+		}
+
+		if len(inline) > 0 || len(nested) == 0 {
+			marker := "-"
+
+			switch list.ListType {
+			case "number":
+				marker = fmt.Sprintf("%d.", i+1)
+			case "check":
+				marker = "- [ ]"
+				if item.Checked {
+					marker = "- [x]"
+				}
+			}
+
+			fmt.Fprintf(b, "%s%s %s\n", indent, marker, inlineText(inline))
+		}
+
+		for _, l := range nested {
+			writeList(b, l, indent+"  ")
+		}
+	}
+}
+
+func inlineText(nodes []noteNode) string {
+	var b strings.Builder
+
+	for _, n := range nodes {
+		switch n.Type {
+		case "text":
+			b.WriteString(formatText(n.Text, textFormat(n.Format)))
+		case "linebreak":
+			b.WriteString("  \n")
+		case "link", "autolink":
+			fmt.Fprintf(&b, "[%s](%s)", inlineText(n.Children), n.URL)
+		default:
+			b.WriteString(inlineText(n.Children))
+		}
+	}
+
+	return b.String()
+}
+
+func plainText(nodes []noteNode) string {
+	var b strings.Builder
+
+	for _, n := range nodes {
+		switch n.Type {
+		case "linebreak":
+			b.WriteString("\n")
+		default:
+			b.WriteString(n.Text)
+			b.WriteString(plainText(n.Children))
+		}
+	}
+
+	return b.String()
+}
+
+func formatText(text string, format int) string {
+	if text == "" {
+		return text
+	}
+
+	if format&textFormatCode != 0 {
+		return "`" + text + "`"
+	}
+
+	if format&textFormatBold != 0 {
+		text = "**" + text + "**"
+	}
+
+	if format&textFormatItalic != 0 {
+		text = "_" + text + "_"
+	}
+
+	if format&textFormatStrikethrough != 0 {
+		text = "~~" + text + "~~"
+	}
+
+	return text
+}
+
+// format is a bitmask for text nodes, but a string (alignment) for block nodes
+func textFormat(f any) int {
+	if v, ok := f.(float64); ok {
+		return int(v)
+	}
+	return 0
+}
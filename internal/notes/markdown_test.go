@@ -0,0 +1,70 @@
+package notes
+
+import (
+	"testing"
+)
+
+func TestNoteTextToMarkdown(t *testing.T) {
+
+	tests := []struct {
+		name string
+		text string
+		want string
+	}{
+		{
+			name: "paragraphs with formatting",
+			text: `{"root":{"type":"root","children":[
+				{"type":"heading","tag":"h2","children":[{"type":"text","text":"Title","format":0}]},
+				{"type":"paragraph","format":"","children":[
+					{"type":"text","text":"bold","format":1},
+					{"type":"text","text":" and ","format":0},
+					{"type":"text","text":"code","format":16},
+					{"type":"link","url":"https://tabsflow.com","children":[{"type":"text","text":"link","format":0}]}
+				]}
+			]}}`,
+			want: "## Title\n\n**bold** and `code`[link](https://tabsflow.com)\n\n",
+		},
+		{
+			name: "lists",
+			text: `{"root":{"type":"root","children":[
+				{"type":"list","listType":"number","children":[
+					{"type":"listitem","children":[{"type":"text","text":"one"}]},
+					{"type":"listitem","children":[{"type":"list","listType":"check","children":[
+						{"type":"listitem","checked":true,"children":[{"type":"text","text":"done"}]}
+					]}]}
+				]}
+			]}}`,
+			want: "1. one\n  - [x] done\n\n",
+		},
+		{
+			name: "invalid json",
+			text: "plain text note",
+			want: "plain text note",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := noteTextToMarkdown(tt.text)
+
+			if got != tt.want {
+				t.Errorf("noteTextToMarkdown() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestToMarkdown(t *testing.T) {
+	n := &Note{
+		Id:     "1730000000000",
+		Title:  "My note",
+		Text:   `{"root":{"type":"root","children":[{"type":"paragraph","children":[{"type":"text","text":"hello"}]}]}}`,
+		Domain: "tabsflow.com",
+	}
+
+	want := "---\nid: 1730000000000\ndomain: tabsflow.com\n---\n\n# My note\n\nhello\n"
+
+	if got := ToMarkdown(n); got != want {
+		t.Errorf("ToMarkdown() = %q, want %q", got, want)
+	}
+}
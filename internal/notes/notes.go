@@ -1,6 +1,9 @@
 package notes
 
-import "github.com/go-playground/validator/v10"
+import (
+	"github.com/manishMandal02/tabsflow-backend/pkg/errs"
+	"github.com/manishMandal02/tabsflow-backend/pkg/http_api"
+)
 
 type Note struct {
 	Id          string `json:"id" validate:"required"`
@@ -12,34 +15,34 @@ type Note struct {
 	UpdatedAt   int64  `json:"updatedAt,omitempty"`
 }
 
-func (n *Note) validate() error {
-	validate := validator.New(validator.WithRequiredStructEnabled())
-	err := validate.Struct(n)
-	if err != nil {
-		return err
-	}
+// partial update, only the id is required
+type noteUpdateReq struct {
+	Note `validate:"-"`
+}
 
-	return nil
+func (n *Note) validate() error {
+	return http_api.Validate(n)
 }
 
+var (
+	errNoteNotFound     = errs.NotFound.New("note_not_found", "notes not found")
+	errNotesSearchEmpty = errs.NotFound.New("notes_search_empty", "no notes found")
+)
+
 var errMsg = struct {
-	noteCreate       string
-	noteUpdate       string
-	noteGet          string
-	noteId           string
-	notesGet         string
-	notesGetEmpty    string
-	noteDelete       string
-	notesSearch      string
-	notesSearchEmpty string
+	noteCreate  string
+	noteUpdate  string
+	noteGet     string
+	noteId      string
+	notesGet    string
+	noteDelete  string
+	notesSearch string
 }{
-	noteCreate:       "error creating note",
-	noteUpdate:       "error updating note",
-	noteId:           "note id is required",
-	noteGet:          "error getting note",
-	notesGetEmpty:    "notes not found",
-	notesGet:         "error getting notes",
-	noteDelete:       "error deleting note",
-	notesSearch:      "error searching notes",
-	notesSearchEmpty: "no notes found",
+	noteCreate:  "error creating note",
+	noteUpdate:  "error updating note",
+	noteId:      "note id is required",
+	noteGet:     "error getting note",
+	notesGet:    "error getting notes",
+	noteDelete:  "error deleting note",
+	notesSearch: "error searching notes",
 }
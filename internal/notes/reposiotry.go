@@ -19,17 +19,17 @@ import (
 )
 
 type noteRepository interface {
-	createNote(userId string, n *Note) error
-	GetNote(userId string, noteId string) (*Note, error)
-	getNotesByIds(userId string, noteIds *[]string) (*[]Note, error)
-	getNotesByUser(userId string, lastNoteId int64) (*[]Note, error)
-	updateNote(userId string, n *Note) error
-	deleteNote(userId, noteId string) error
-	RemoveNoteRemainder(userId, noteId string) error
+	createNote(ctx context.Context, userId string, n *Note) error
+	GetNote(ctx context.Context, userId string, noteId string) (*Note, error)
+	getNotesByIds(ctx context.Context, userId string, noteIds *[]string) (*[]Note, error)
+	getNotesByUser(ctx context.Context, userId string, lastNoteId int64) (*[]Note, error)
+	updateNote(ctx context.Context, userId string, n *Note) error
+	deleteNote(ctx context.Context, userId, noteId string) error
+	RemoveNoteRemainder(ctx context.Context, userId, noteId string) error
 	// search
-	indexSearchTerms(userId, noteId string, terms []string) error
-	noteIdsBySearchTerm(userId string, query string, limit int) ([]string, error)
-	deleteSearchTerms(userId, noteId string, terms []string) error
+	indexSearchTerms(ctx context.Context, userId, noteId string, terms []string) error
+	noteIdsBySearchTerm(ctx context.Context, userId string, query string, limit int) ([]string, error)
+	deleteSearchTerms(ctx context.Context, userId, noteId string, terms []string) error
 }
 
 type noteRepo struct {
@@ -44,7 +44,7 @@ func NewNoteRepository(db *db.DDB, searchIndexTable *db.DDB) noteRepository {
 	}
 }
 
-func (r noteRepo) createNote(userId string, n *Note) error {
+func (r noteRepo) createNote(ctx context.Context, userId string, n *Note) error {
 	av, err := attributevalue.MarshalMap(n)
 
 	if err != nil {
@@ -56,7 +56,7 @@ func (r noteRepo) createNote(userId string, n *Note) error {
 
 	av[db.SK_NAME] = &types.AttributeValueMemberS{Value: db.SORT_KEY.Notes(n.Id)}
 
-	_, err = r.db.Client.PutItem(context.TODO(), &dynamodb.PutItemInput{
+	_, err = r.db.Client.PutItem(ctx, &dynamodb.PutItemInput{
 		TableName: &r.db.TableName,
 		Item:      av,
 	})
@@ -69,7 +69,7 @@ func (r noteRepo) createNote(userId string, n *Note) error {
 	return nil
 }
 
-func (r noteRepo) updateNote(userId string, n *Note) error {
+func (r noteRepo) updateNote(ctx context.Context, userId string, n *Note) error {
 
 	key := map[string]types.AttributeValue{
 		"PK": &types.AttributeValueMemberS{Value: userId},
@@ -105,7 +105,7 @@ func (r noteRepo) updateNote(userId string, n *Note) error {
 		return err
 	}
 
-	_, err = r.db.Client.UpdateItem(context.TODO(), &dynamodb.UpdateItemInput{
+	_, err = r.db.Client.UpdateItem(ctx, &dynamodb.UpdateItemInput{
 		TableName:                 &r.db.TableName,
 		Key:                       key,
 		ExpressionAttributeNames:  expr.Names(),
@@ -121,13 +121,13 @@ func (r noteRepo) updateNote(userId string, n *Note) error {
 	return nil
 }
 
-func (r noteRepo) deleteNote(userId string, noteId string) error {
+func (r noteRepo) deleteNote(ctx context.Context, userId string, noteId string) error {
 	key := map[string]types.AttributeValue{
 		db.PK_NAME: &types.AttributeValueMemberS{Value: userId},
 		db.SK_NAME: &types.AttributeValueMemberS{Value: db.SORT_KEY.Notes(noteId)},
 	}
 
-	_, err := r.db.Client.DeleteItem(context.TODO(), &dynamodb.DeleteItemInput{
+	_, err := r.db.Client.DeleteItem(ctx, &dynamodb.DeleteItemInput{
 		TableName:    &r.db.TableName,
 		Key:          key,
 		ReturnValues: types.ReturnValueAllOld,
@@ -141,7 +141,7 @@ func (r noteRepo) deleteNote(userId string, noteId string) error {
 	return nil
 }
 
-func (r noteRepo) RemoveNoteRemainder(userId, noteId string) error {
+func (r noteRepo) RemoveNoteRemainder(ctx context.Context, userId, noteId string) error {
 	key := map[string]types.AttributeValue{
 		db.PK_NAME: &types.AttributeValueMemberS{Value: userId},
 		db.SK_NAME: &types.AttributeValueMemberS{Value: db.SORT_KEY.Notes(noteId)},
@@ -157,7 +157,7 @@ func (r noteRepo) RemoveNoteRemainder(userId, noteId string) error {
 		return err
 	}
 
-	_, err = r.db.Client.UpdateItem(context.TODO(), &dynamodb.UpdateItemInput{
+	_, err = r.db.Client.UpdateItem(ctx, &dynamodb.UpdateItemInput{
 		TableName:                 &r.db.TableName,
 		Key:                       key,
 		UpdateExpression:          expr.Condition(),
@@ -173,14 +173,14 @@ func (r noteRepo) RemoveNoteRemainder(userId, noteId string) error {
 	return nil
 }
 
-func (r noteRepo) GetNote(userId string, noteId string) (*Note, error) {
+func (r noteRepo) GetNote(ctx context.Context, userId string, noteId string) (*Note, error) {
 
 	key := map[string]types.AttributeValue{
 		db.PK_NAME: &types.AttributeValueMemberS{Value: userId},
 		db.SK_NAME: &types.AttributeValueMemberS{Value: db.SORT_KEY.Notes(noteId)},
 	}
 
-	response, err := r.db.Client.GetItem(context.TODO(), &dynamodb.GetItemInput{
+	response, err := r.db.Client.GetItem(ctx, &dynamodb.GetItemInput{
 		TableName: &r.db.TableName,
 		Key:       key,
 	})
@@ -191,7 +191,7 @@ func (r noteRepo) GetNote(userId string, noteId string) (*Note, error) {
 	}
 
 	if len(response.Item) == 0 {
-		return nil, errors.New(errMsg.notesGetEmpty)
+		return nil, errNoteNotFound
 	}
 
 	note := &Note{}
@@ -205,7 +205,7 @@ func (r noteRepo) GetNote(userId string, noteId string) (*Note, error) {
 	return note, nil
 }
 
-func (r noteRepo) getNotesByIds(userId string, noteIds *[]string) (*[]Note, error) {
+func (r noteRepo) getNotesByIds(ctx context.Context, userId string, noteIds *[]string) (*[]Note, error) {
 
 	keys := []map[string]types.AttributeValue{}
 
@@ -216,7 +216,7 @@ func (r noteRepo) getNotesByIds(userId string, noteIds *[]string) (*[]Note, erro
 		})
 	}
 
-	response, err := r.db.Client.BatchGetItem(context.TODO(), &dynamodb.BatchGetItemInput{
+	response, err := r.db.Client.BatchGetItem(ctx, &dynamodb.BatchGetItemInput{
 		RequestItems: map[string]types.KeysAndAttributes{
 			r.db.TableName: {
 				Keys: keys,
@@ -240,7 +240,7 @@ func (r noteRepo) getNotesByIds(userId string, noteIds *[]string) (*[]Note, erro
 	}
 
 	if len(response.Responses[r.db.TableName]) < 1 {
-		return nil, errors.New(errMsg.notesGetEmpty)
+		return nil, errNoteNotFound
 	}
 
 	notes := []Note{}
@@ -256,7 +256,7 @@ func (r noteRepo) getNotesByIds(userId string, noteIds *[]string) (*[]Note, erro
 
 }
 
-func (r noteRepo) getNotesByUser(userId string, lastNoteId int64) (*[]Note, error) {
+func (r noteRepo) getNotesByUser(ctx context.Context, userId string, lastNoteId int64) (*[]Note, error) {
 
 	key := expression.KeyAnd(expression.Key(db.PK_NAME).Equal(expression.Value(userId)), expression.Key(db.SK_NAME).BeginsWith(db.SORT_KEY.Notes("")))
 
@@ -276,7 +276,7 @@ func (r noteRepo) getNotesByUser(userId string, lastNoteId int64) (*[]Note, erro
 		}
 	}
 
-	response, err := r.db.Client.Query(context.TODO(), &dynamodb.QueryInput{
+	response, err := r.db.Client.Query(ctx, &dynamodb.QueryInput{
 		TableName:                 &r.db.TableName,
 		ExpressionAttributeNames:  expr.Names(),
 		ExpressionAttributeValues: expr.Values(),
@@ -291,7 +291,7 @@ func (r noteRepo) getNotesByUser(userId string, lastNoteId int64) (*[]Note, erro
 	}
 
 	if len(response.Items) < 1 {
-		return nil, errors.New(errMsg.notesGetEmpty)
+		return nil, errNoteNotFound
 	}
 
 	notes := []Note{}
@@ -307,7 +307,7 @@ func (r noteRepo) getNotesByUser(userId string, lastNoteId int64) (*[]Note, erro
 }
 
 // search index table
-func (r noteRepo) indexSearchTerms(userId, noteId string, terms []string) error {
+func (r noteRepo) indexSearchTerms(ctx context.Context, userId, noteId string, terms []string) error {
 
 	// channel to collect errors from goroutines
 	errChan := make(chan error, len(terms)/db.DDB_MAX_BATCH_SIZE+1)
@@ -315,7 +315,7 @@ func (r noteRepo) indexSearchTerms(userId, noteId string, terms []string) error
 	var wg sync.WaitGroup
 
 	// context with timeout
-	ctx, cancel := context.WithTimeout(context.Background(), 15*time.Second)
+	ctx, cancel := context.WithTimeout(ctx, 15*time.Second)
 	defer cancel()
 
 	reqs := []types.WriteRequest{}
@@ -360,7 +360,7 @@ func (r noteRepo) indexSearchTerms(userId, noteId string, terms []string) error
 	return nil
 }
 
-func (r noteRepo) noteIdsBySearchTerm(userId string, query string, limit int) ([]string, error) {
+func (r noteRepo) noteIdsBySearchTerm(ctx context.Context, userId string, query string, limit int) ([]string, error) {
 
 	key := expression.KeyAnd(expression.Key(db.PK_NAME).Equal(expression.Value(createSearchTermPK(userId, query))), expression.Key(db.SK_NAME).BeginsWith(db.SORT_KEY_SEARCH_INDEX.Note("")))
 
@@ -371,7 +371,7 @@ func (r noteRepo) noteIdsBySearchTerm(userId string, query string, limit int) ([
 		return nil, err
 	}
 
-	response, err := r.searchIndexTable.Client.Query(context.TODO(), &dynamodb.QueryInput{
+	response, err := r.searchIndexTable.Client.Query(ctx, &dynamodb.QueryInput{
 		TableName:                 &r.searchIndexTable.TableName,
 		ExpressionAttributeNames:  expr.Names(),
 		ExpressionAttributeValues: expr.Values(),
@@ -385,7 +385,7 @@ func (r noteRepo) noteIdsBySearchTerm(userId string, query string, limit int) ([
 	}
 
 	if len(response.Items) < 1 {
-		return nil, errors.New(errMsg.notesSearchEmpty)
+		return nil, errNotesSearchEmpty
 	}
 
 	noteIdsSK := []struct {
@@ -409,7 +409,7 @@ func (r noteRepo) noteIdsBySearchTerm(userId string, query string, limit int) ([
 	return noteIds, nil
 }
 
-func (r noteRepo) deleteSearchTerms(userId, noteId string, terms []string) error {
+func (r noteRepo) deleteSearchTerms(ctx context.Context, userId, noteId string, terms []string) error {
 
 	// channel to collect errors from goroutines
 	errChan := make(chan error, len(terms)/db.DDB_MAX_BATCH_SIZE+1)
@@ -417,7 +417,7 @@ func (r noteRepo) deleteSearchTerms(userId, noteId string, terms []string) error
 	var wg sync.WaitGroup
 
 	// context with timeout
-	ctx, cancel := context.WithTimeout(context.Background(), 15*time.Second)
+	ctx, cancel := context.WithTimeout(ctx, 15*time.Second)
 	defer cancel()
 
 	reqs := []types.WriteRequest{}
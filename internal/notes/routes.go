@@ -3,6 +3,7 @@ package notes
 import (
 	"github.com/manishMandal02/tabsflow-backend/pkg/db"
 	"github.com/manishMandal02/tabsflow-backend/pkg/events"
+	"github.com/manishMandal02/tabsflow-backend/pkg/health"
 	"github.com/manishMandal02/tabsflow-backend/pkg/http_api"
 )
 
@@ -14,24 +15,59 @@ func Router(mainTable, searchIndexTable *db.DDB, q *events.Queue) http_api.IRout
 	// middleware to get userId from jwt token
 	userIdMiddleware := newUserIdMiddleware()
 
-	notesRouter := http_api.NewRouter("/notes")
+	router := http_api.NewRouter("/notes")
+
+	checks := []health.Check{
+		health.Table(mainTable),
+		health.Table(searchIndexTable),
+		health.Queue("notifications", q),
+	}
+
+	// health routes, outside the service's middleware
+	health.Routes(router, checks...)
+	health.DiagnosticsRoute(router, checks...)
+
+	notesRouter := router.Group("/")
 
 	notesRouter.Use(http_api.SetAllowOriginHeader())
 
+	// api token requests need the read/write scope
+	notesRouter.Use(http_api.RequireTokenScope("notes"))
+
 	notesRouter.Use(userIdMiddleware)
 
-	notesRouter.POST("/", nh.create)
-	// query: lastNoteId={lastNoteId}
-	notesRouter.GET("/my", nh.getAllByUser)
-	// query: query={searchTerm}, limit={maxLimit}
-	notesRouter.GET("/search", nh.search)
+	notesRouter.POST("/", nh.create).Doc(http_api.RouteDoc{
+		Summary: "Create a note",
+		Body:    Note{},
+	})
+
+	notesRouter.GET("/my", nh.getAllByUser).Doc(http_api.RouteDoc{
+		Summary:  "Notes of the user, paginated",
+		Query:    []http_api.QueryParam{{Name: "lastNoteId", Description: "id of the last note of the previous page"}},
+		Response: []Note{},
+	})
+
+	notesRouter.GET("/search", nh.search).Doc(http_api.RouteDoc{
+		Summary: "Search the notes of the user",
+		Query: []http_api.QueryParam{
+			{Name: "query", Description: "search terms", Required: true},
+			{Name: "limit", Description: "max notes, 2 to 10 (default 8)"},
+		},
+		Response: []Note{},
+	})
 
-	notesRouter.GET("/:noteId", nh.get)
+	notesRouter.GET("/:noteId", nh.get).Doc(http_api.RouteDoc{
+		Summary:  "Get a note",
+		Response: Note{},
+	})
 
-	notesRouter.PATCH("/", nh.update)
+	notesRouter.PATCH("/", nh.update).Doc(http_api.RouteDoc{
+		Summary: "Update a note, only the id is required",
+		Body:    noteUpdateReq{},
+	})
 
-	notesRouter.DELETE("/:noteId", nh.delete)
+	notesRouter.DELETE("/:noteId", nh.delete).Doc(http_api.RouteDoc{Summary: "Delete a note"})
 
 	// serve API routes
-	return notesRouter
+	return router
 }
@@ -0,0 +1,289 @@
+package notes
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/feature/dynamodb/attributevalue"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+	"github.com/manishMandal02/tabsflow-backend/pkg/db"
+	"github.com/manishMandal02/tabsflow-backend/pkg/events"
+	"github.com/manishMandal02/tabsflow-backend/pkg/logger"
+)
+
+// ExportUserData returns all the notes of the user, as exported with the user's account data
+func ExportUserData(ctx context.Context, db *db.DDB, userId string) ([]Note, error) {
+	r := &noteRepo{
+		db: db,
+	}
+
+	notes := []Note{}
+
+	var lastNoteId int64
+
+	for {
+		page, err := r.getNotesByUser(ctx, userId, lastNoteId)
+
+		if err != nil {
+			if errors.Is(err, errNoteNotFound) {
+				break
+			}
+			return nil, err
+		}
+
+		notes = append(notes, *page...)
+
+		lastNoteId, err = strconv.ParseInt((*page)[len(*page)-1].Id, 10, 64)
+
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return notes, nil
+}
+
+// result of the notes import from the user's account data
+type UserDataImport struct {
+	Imported int `json:"imported"`
+	Skipped  int `json:"skipped"`
+	Failed   int `json:"failed"`
+}
+
+// ImportUserData restores the notes from the user's account data export, re-indexing their search terms & remainders;
+// notes with an existing id are skipped, overwritten or imported with a new id as per the conflict strategy.
+// spaceIds maps the ids of spaces imported with a new id, to update the notes' spaceId
+func ImportUserData(ctx context.Context, ddb, searchIndexTable *db.DDB, q *events.Queue, userId string, notes []Note, spaceIds map[string]string, strategy db.ConflictStrategy) (*UserDataImport, error) {
+	r := &noteRepo{
+		db:               ddb,
+		searchIndexTable: searchIndexTable,
+	}
+
+	res := &UserDataImport{}
+
+	existingNotes, err := ExportUserData(ctx, ddb, userId)
+
+	if err != nil {
+		return nil, err
+	}
+
+	existing := map[string]*Note{}
+
+	for i := range existingNotes {
+		existing[existingNotes[i].Id] = &existingNotes[i]
+	}
+
+	// notes repeated in the import are handled as conflicts
+	imported := map[string]bool{}
+
+	var importedNotes []Note
+	var reqs []types.WriteRequest
+
+	// note ids are timestamps, new ids are the next free timestamps
+	nextNoteId := time.Now().UnixMilli()
+
+	for _, n := range notes {
+		if n.validate() != nil {
+			res.Failed++
+			continue
+		}
+
+		if newId, ok := spaceIds[n.SpaceId]; ok {
+			n.SpaceId = newId
+		}
+
+		if existing[n.Id] != nil || imported[n.Id] {
+			if strategy == db.ConflictSkip || (imported[n.Id] && strategy == db.ConflictOverwrite) {
+				res.Skipped++
+				continue
+			}
+
+			if strategy == db.ConflictDuplicate {
+				for existing[strconv.FormatInt(nextNoteId, 10)] != nil || imported[strconv.FormatInt(nextNoteId, 10)] {
+					nextNoteId++
+				}
+
+				n.Id = strconv.FormatInt(nextNoteId, 10)
+			}
+		}
+
+		imported[n.Id] = true
+
+		av, err := attributevalue.MarshalMap(n)
+
+		if err != nil {
+			logger.Errorf("Couldn't marshal imported note: %v, \n[Error]: %v", n.Id, err)
+			res.Failed++
+			continue
+		}
+
+		av[db.PK_NAME] = &types.AttributeValueMemberS{Value: userId}
+		av[db.SK_NAME] = &types.AttributeValueMemberS{Value: db.SORT_KEY.Notes(n.Id)}
+
+		reqs = append(reqs, types.WriteRequest{PutRequest: &types.PutRequest{Item: av}})
+
+		importedNotes = append(importedNotes, n)
+
+		res.Imported++
+	}
+
+	if len(reqs) < 1 {
+		return res, nil
+	}
+
+	// remove the search terms of the overwritten notes
+	for _, n := range importedNotes {
+		old := existing[n.Id]
+
+		if old == nil {
+			continue
+		}
+
+		err = r.deleteSearchTerms(ctx, userId, old.Id, extractSearchTerms(old.Title, noteSearchText(old.Text), old.Domain))
+
+		if err != nil {
+			logger.Errorf("Couldn't delete search terms for overwritten noteId: %v. \n[Error]: %v", old.Id, err)
+		}
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, db.BatchTimeout)
+	defer cancel()
+
+	errChan := make(chan error, len(reqs)/db.DDB_MAX_BATCH_SIZE+1)
+
+	var wg sync.WaitGroup
+
+	ddb.BatchWriter(ctx, ddb.TableName, &wg, errChan, reqs)
+
+	// Wait for all goroutines to complete
+	go func() {
+		wg.Wait()
+		close(errChan)
+	}()
+
+	// Collect errors
+	var errs []error
+	for err := range errChan {
+		errs = append(errs, err)
+	}
+
+	if len(errs) > 0 {
+		logger.Errorf("Couldn't import notes for userId: %v. \n[Error]: %v", userId, errs)
+		return res, errs[0]
+	}
+
+	now := time.Now().Unix()
+
+	for _, n := range importedNotes {
+		err = r.indexSearchTerms(ctx, userId, n.Id, extractSearchTerms(n.Title, noteSearchText(n.Text), n.Domain))
+
+		if err != nil {
+			logger.Errorf("Couldn't index search terms for imported noteId: %v. \n[Error]: %v", n.Id, err)
+		}
+
+		// create, update or delete the remainder schedule
+		subEvent := events.SubEventCreate
+		hasSchedule := existing[n.Id] != nil && existing[n.Id].RemainderAt != 0
+
+		if hasSchedule {
+			subEvent = events.SubEventUpdate
+		}
+
+		if n.RemainderAt <= now {
+			if !hasSchedule {
+				continue
+			}
+			subEvent = events.SubEventDelete
+		}
+
+		event := events.New(events.EventTypeScheduleNoteRemainder, &events.ScheduleNoteRemainderPayload{
+			UserId:    userId,
+			NoteId:    n.Id,
+			SubEvent:  subEvent,
+			TriggerAt: n.RemainderAt,
+		})
+
+		err = q.AddMessage(ctx, event)
+
+		if err != nil {
+			logger.Errorf("Couldn't schedule remainder for imported noteId: %v. \n[Error]: %v", n.Id, err)
+		}
+	}
+
+	return res, nil
+}
+
+// plain text of the lexical note json for search terms, the text is used as is if not a valid note json
+func noteSearchText(jsonStr string) string {
+	var note struct {
+		Root *noteNode `json:"root"`
+	}
+
+	err := json.Unmarshal([]byte(jsonStr), &note)
+
+	if err != nil || note.Root == nil {
+		return jsonStr
+	}
+
+	return plainText(note.Root.Children)
+}
+
+// CancelUserSchedules deletes the remainder schedules of the user's notes, returns the number of schedules cancelled
+func CancelUserSchedules(ctx context.Context, ddb *db.DDB, q *events.Queue, userId string) (int, error) {
+	notes, err := ExportUserData(ctx, ddb, userId)
+
+	if err != nil {
+		return 0, err
+	}
+
+	count := 0
+
+	// schedules are deleted after they are triggered
+	now := time.Now().Unix()
+
+	for _, n := range notes {
+		if n.RemainderAt <= now {
+			continue
+		}
+
+		event := events.New(events.EventTypeScheduleNoteRemainder, &events.ScheduleNoteRemainderPayload{
+			NoteId:   n.Id,
+			SubEvent: events.SubEventDelete,
+		})
+
+		err = q.AddMessage(ctx, event)
+
+		if err != nil {
+			logger.Errorf("Couldn't cancel note remainder schedule for userId: %v. \n[Error]: %v", userId, err)
+			return count, err
+		}
+
+		count++
+	}
+
+	return count, nil
+}
+
+// DeleteUserSearchIndex deletes all the search terms indexed for the user's notes, returns the number of entries deleted
+func DeleteUserSearchIndex(ctx context.Context, searchIndexTable *db.DDB, userId string) (int, error) {
+	// scanned by the user's prefix, to also remove the terms of deleted/updated notes left behind
+	keys, err := searchIndexTable.GetAllKeysByPKPrefix(ctx, createSearchTermPK(userId, ""))
+
+	if err != nil {
+		logger.Errorf("Couldn't get search index entries for userId: %v. \n[Error]: %v", userId, err)
+		return 0, err
+	}
+
+	err = searchIndexTable.DeleteKeys(ctx, keys)
+
+	if err != nil {
+		logger.Errorf("Couldn't delete search index entries for userId: %v. \n[Error]: %v", userId, err)
+		return 0, err
+	}
+
+	return len(keys), nil
+}
@@ -2,8 +2,10 @@ package notifications
 
 import (
 	"encoding/json"
+	"errors"
 	"net/http"
 
+	"github.com/manishMandal02/tabsflow-backend/pkg/errs"
 	"github.com/manishMandal02/tabsflow-backend/pkg/http_api"
 	"github.com/manishMandal02/tabsflow-backend/pkg/logger"
 )
@@ -23,17 +25,17 @@ func (h *notificationHandler) get(w http.ResponseWriter, r *http.Request) {
 	notificationId := r.PathValue("id")
 
 	if notificationId == "" {
-		http_api.ErrorRes(w, errMsg.notificationGet, http.StatusBadRequest)
+		http_api.ErrorRes(w, errs.BadRequest.WithMessage(errMsg.notificationGet))
 		return
 	}
 
-	n, err := h.r.get(userId, notificationId)
+	n, err := h.r.get(r.Context(), userId, notificationId)
 	if err != nil {
-		if err.Error() == errMsg.notificationsEmpty {
-			http_api.SuccessResData(w, []notification{})
+		if errors.Is(err, errNotificationNotFound) {
+			http_api.ErrorRes(w, errNotificationNotFound)
 			return
 		}
-		http_api.ErrorRes(w, errMsg.notificationGet, http.StatusBadGateway)
+		http_api.ErrorRes(w, errs.BadGateway.WithMessage(errMsg.notificationGet))
 		return
 	}
 
@@ -43,14 +45,14 @@ func (h *notificationHandler) get(w http.ResponseWriter, r *http.Request) {
 func (h *notificationHandler) getUserNotifications(w http.ResponseWriter, r *http.Request) {
 	userId := r.PathValue("userId")
 
-	notifications, err := h.r.getUserNotifications(userId)
+	notifications, err := h.r.getUserNotifications(r.Context(), userId)
 
 	if err != nil {
-		if err.Error() == errMsg.notificationsEmpty {
+		if errors.Is(err, errNotificationNotFound) {
 			http_api.SuccessResData(w, []notification{})
 			return
 		}
-		http_api.ErrorRes(w, errMsg.notificationGet, http.StatusBadGateway)
+		http_api.ErrorRes(w, errs.BadGateway.WithMessage(errMsg.notificationGet))
 		return
 	}
 
@@ -66,14 +68,14 @@ func (h *notificationHandler) publishEvent(w http.ResponseWriter, r *http.Reques
 
 	if err != nil {
 		logger.Error("error decoding notification", err)
-		http_api.ErrorRes(w, errMsg.notificationPublishEvent, http.StatusBadRequest)
+		http_api.ErrorRes(w, errs.BadRequest.WithMessage(errMsg.notificationPublishEvent))
 		return
 	}
 
-	err = event.send(userId, h.r)
+	err = event.send(r.Context(), userId, h.r)
 
 	if err != nil {
-		http_api.ErrorRes(w, errMsg.notificationPublishEvent, http.StatusBadGateway)
+		http_api.ErrorRes(w, errs.BadGateway.WithMessage(errMsg.notificationPublishEvent))
 		return
 	}
 
@@ -84,27 +86,18 @@ func (h *notificationHandler) publishEvent(w http.ResponseWriter, r *http.Reques
 func (h *notificationHandler) subscribe(w http.ResponseWriter, r *http.Request) {
 	userId := r.PathValue("userId")
 
-	var subscription PushSubscription
-
-	err := json.NewDecoder(r.Body).Decode(&subscription)
+	subscription, err := http_api.DecodeAndValidate[PushSubscription](w, r)
 
 	if err != nil {
 		logger.Errorf("error decoding notification subscription for user_id: %v. \n[Error]: %v", userId, err)
-		http_api.ErrorRes(w, errMsg.notificationsSubscribe, http.StatusBadRequest)
-		return
-	}
-
-	err = subscription.validate()
-	if err != nil {
-		logger.Errorf("error validating notification subscription for user_id: %v. \n[Error]: %v", userId, err)
-		http_api.ErrorRes(w, errMsg.notificationsSubscribe, http.StatusBadRequest)
+		http_api.ErrorRes(w, err)
 		return
 	}
 
-	err = h.r.subscribe(userId, &subscription)
+	err = h.r.subscribe(r.Context(), userId, subscription)
 
 	if err != nil {
-		http_api.ErrorRes(w, errMsg.notificationsSubscribe, http.StatusBadGateway)
+		http_api.ErrorRes(w, errs.BadGateway.WithMessage(errMsg.notificationsSubscribe))
 		return
 	}
 
@@ -114,14 +107,14 @@ func (h *notificationHandler) subscribe(w http.ResponseWriter, r *http.Request)
 func (h *notificationHandler) getNotificationSubscription(w http.ResponseWriter, r *http.Request) {
 	userId := r.PathValue("userId")
 
-	subscription, err := h.r.getNotificationSubscription(userId)
+	subscription, err := h.r.getNotificationSubscription(r.Context(), userId)
 
 	if err != nil {
-		if err.Error() == errMsg.notificationsSubscribeEmpty {
+		if errors.Is(err, errNotSubscribed) {
 			http_api.SuccessResData(w, PushSubscription{})
 			return
 		}
-		http_api.ErrorRes(w, errMsg.notificationsSubscribeEmpty, http.StatusBadGateway)
+		http_api.ErrorRes(w, errs.BadGateway.WithMessage(errMsg.notificationsSubscriptionGet))
 		return
 	}
 
@@ -132,10 +125,10 @@ func (h *notificationHandler) getNotificationSubscription(w http.ResponseWriter,
 func (h *notificationHandler) unsubscribe(w http.ResponseWriter, r *http.Request) {
 	userId := r.PathValue("userId")
 
-	err := h.r.deleteNotificationSubscription(userId)
+	err := h.r.deleteNotificationSubscription(r.Context(), userId)
 
 	if err != nil {
-		http_api.ErrorRes(w, errMsg.notificationsUnsubscribe, http.StatusBadGateway)
+		http_api.ErrorRes(w, errs.BadGateway.WithMessage(errMsg.notificationsUnsubscribe))
 		return
 	}
 
@@ -149,15 +142,15 @@ func (h *notificationHandler) delete(w http.ResponseWriter, r *http.Request) {
 	notificationId := r.PathValue("id")
 
 	if notificationId == "" {
-		http_api.ErrorRes(w, errMsg.notificationDelete, http.StatusBadRequest)
+		http_api.ErrorRes(w, errs.BadRequest.WithMessage(errMsg.notificationDelete))
 		return
 	}
 
-	err := h.r.delete(userId, notificationId)
+	err := h.r.delete(r.Context(), userId, notificationId)
 
 	if err != nil {
 		logger.Error("error deleting notification", err)
-		http_api.ErrorRes(w, errMsg.notificationDelete, http.StatusBadGateway)
+		http_api.ErrorRes(w, errs.BadGateway.WithMessage(errMsg.notificationDelete))
 		return
 	}
 
@@ -170,13 +163,13 @@ func (h *notificationHandler) delete(w http.ResponseWriter, r *http.Request) {
 // 	err := json.NewDecoder(r.Body).Decode(&n)
 // 	if err != nil {
 // 		logger.Error("error decoding notification", err)
-// 		http_api.ErrorRes(w, errMsg.notificationCreate, http.StatusBadRequest)
+// 		http_api.ErrorRes(w, errs.BadRequest.WithMessage(errMsg.notificationCreate))
 // 		return
 // 	}
 // 	err = h.r.createNotification(userId, &n)
 // 	if err != nil {
 // 		logger.Error("error creating notification", err)
-// 		http_api.ErrorRes(w, errMsg.notificationCreate, http.StatusBadGateway)
+// 		http_api.ErrorRes(w, errs.BadGateway.WithMessage(errMsg.notificationCreate))
 // 		return
 // 	}
 // 	http_api.SuccessResMsg(w, "notification created successfully")
@@ -185,18 +178,22 @@ func (h *notificationHandler) delete(w http.ResponseWriter, r *http.Request) {
 //* helpers
 
 // middleware to get userId from jwt token present in req cookies
-func newUserIdMiddleware() http_api.Handler {
-	return func(w http.ResponseWriter, r *http.Request) {
+func newUserIdMiddleware() http_api.Middleware {
+	return func(next http_api.Handler) http_api.Handler {
+		return func(w http.ResponseWriter, r *http.Request) {
 
-		// get userId from jwt token
+			// get userId from jwt token
 
-		userId := r.Header.Get("UserId")
+			userId := r.Header.Get("UserId")
 
-		if userId == "" {
-			http.Redirect(w, r, "/logout", http.StatusTemporaryRedirect)
-			return
-		}
+			if userId == "" {
+				http.Redirect(w, r, "/logout", http.StatusTemporaryRedirect)
+				return
+			}
 
-		r.SetPathValue("userId", userId)
+			r.SetPathValue("userId", userId)
+
+			next(w, r)
+		}
 	}
 }
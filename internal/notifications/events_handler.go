@@ -1,9 +1,11 @@
 package notifications
 
 import (
+	"context"
 	"errors"
 	"fmt"
 	"strconv"
+	"strings"
 	"time"
 
 	lambda_events "github.com/aws/aws-lambda-go/events"
@@ -17,7 +19,7 @@ import (
 )
 
 func SQSMessagesHandler(q *events.Queue) http_api.SQSHandler {
-	return func(messages []lambda_events.SQSMessage) (interface{}, error) {
+	return func(ctx context.Context, messages []lambda_events.SQSMessage) (interface{}, error) {
 		if len(messages) < 1 {
 			errMsg := "no events to process"
 			logger.Errorf("%v", errMsg)
@@ -27,8 +29,10 @@ func SQSMessagesHandler(q *events.Queue) http_api.SQSHandler {
 
 		//  process batch of events
 		for _, msg := range messages {
+			// the logs & the events queued by the message have its request id
+			ctx := events.MessageContext(ctx, msg)
 
-			logger.Info("processing msg: %v", msg.Body)
+			logger.DebugContext(ctx, "processing msg", "body", msg.Body)
 
 			eventType := ""
 
@@ -46,15 +50,17 @@ func SQSMessagesHandler(q *events.Queue) http_api.SQSHandler {
 
 			}
 
-			err := processEvent(eventType, msg.Body)
+			logger.InfoContext(ctx, "processing event", "event_type", eventType)
+
+			err := processEvent(ctx, eventType, msg.Body, q)
 
 			if err != nil {
-				logger.Errorf("error processing event: %v", err)
+				logger.ErrorContext(ctx, "error processing event", err, "event_type", eventType)
 				continue
 			}
 
 			// remove message from sqs
-			err = q.DeleteMessage(msg.ReceiptHandle)
+			err = q.DeleteMessage(ctx, msg.ReceiptHandle)
 
 			if err != nil {
 				return nil, err
@@ -67,7 +73,7 @@ func SQSMessagesHandler(q *events.Queue) http_api.SQSHandler {
 	}
 }
 
-func processEvent(eventType string, body string) error {
+func processEvent(ctx context.Context, eventType string, body string, q *events.Queue) error {
 	switch events.EventType(eventType) {
 	case events.EventTypeScheduleNoteRemainder:
 
@@ -78,7 +84,7 @@ func processEvent(eventType string, body string) error {
 			return err
 		}
 
-		return scheduleNoteRemainder(ev.Payload)
+		return scheduleNoteRemainder(ctx, ev.Payload)
 
 	case events.EventTypeScheduleSnoozedTab:
 
@@ -89,7 +95,7 @@ func processEvent(eventType string, body string) error {
 			return err
 		}
 
-		return scheduleSnoozedTab(ev.Payload)
+		return scheduleSnoozedTab(ctx, ev.Payload)
 
 	case events.EventTypeTriggerNoteRemainder:
 
@@ -100,7 +106,7 @@ func processEvent(eventType string, body string) error {
 			return err
 		}
 
-		return triggerNoteRemainder(ev.Payload)
+		return triggerNoteRemainder(ctx, ev.Payload)
 
 	case events.EventTypeTriggerSnoozedTab:
 		ev, err := events.NewFromJSON[events.ScheduleSnoozedTabPayload](body)
@@ -110,14 +116,28 @@ func processEvent(eventType string, body string) error {
 			return err
 		}
 
-		return triggerSnoozedTab(ev.Payload)
+		return triggerSnoozedTab(ctx, ev.Payload)
+
+	case events.EventTypeCleanupUnsavedSpaces:
+		ev, err := events.NewFromJSON[events.CleanupUnsavedSpacesPayload](body)
+
+		if err != nil {
+			logger.Errorf("error un_marshalling event: %v", err)
+			return err
+		}
+
+		if ev.Payload == nil || ev.Payload.UserId == "" {
+			return queueUnsavedSpacesCleanup(ctx, q)
+		}
+
+		return cleanupUnsavedSpaces(ctx, ev.Payload)
 	}
 
 	return nil
 }
 
 // set a schedule to trigger a note remainder notification
-func scheduleNoteRemainder(p *events.ScheduleNoteRemainderPayload) error {
+func scheduleNoteRemainder(ctx context.Context, p *events.ScheduleNoteRemainderPayload) error {
 	var err error
 
 	scheduler := events.NewScheduler()
@@ -131,23 +151,21 @@ func scheduleNoteRemainder(p *events.ScheduleNoteRemainderPayload) error {
 			NoteId: p.NoteId,
 		})
 
-		evStr := triggerEvent.ToJSON()
-
 		t := time.Unix(p.TriggerAt, 0).UTC().Format(config.DATE_TIME_FORMAT)
 
-		err = scheduler.CreateSchedule(sId, t, &evStr)
+		err = scheduler.CreateSchedule(ctx, sId, t, triggerEvent)
 	case events.SubEventUpdate:
 		t := time.Unix(p.TriggerAt, 0).UTC().Format(config.DATE_TIME_FORMAT)
-		err = scheduler.UpdateSchedule(sId, t)
+		err = scheduler.UpdateSchedule(ctx, sId, t)
 	case events.SubEventDelete:
-		err = scheduler.DeleteSchedule(sId)
+		err = scheduler.DeleteSchedule(ctx, sId)
 	}
 
 	return err
 }
 
 // set a schedule to trigger a snoozed tab notification
-func scheduleSnoozedTab(p *events.ScheduleSnoozedTabPayload) error {
+func scheduleSnoozedTab(ctx context.Context, p *events.ScheduleSnoozedTabPayload) error {
 
 	scheduler := events.NewScheduler()
 	var err error
@@ -162,28 +180,26 @@ func scheduleSnoozedTab(p *events.ScheduleSnoozedTabPayload) error {
 			SnoozedTabId: p.SnoozedTabId,
 		})
 
-		evStr := triggerEvent.ToJSON()
-
 		t := time.Unix(p.TriggerAt, 0).UTC().Format(config.DATE_TIME_FORMAT)
 
-		err = scheduler.CreateSchedule(sId, t, &evStr)
+		err = scheduler.CreateSchedule(ctx, sId, t, triggerEvent)
 	case events.SubEventUpdate:
 		t := time.Unix(p.TriggerAt, 0).UTC().Format(config.DATE_TIME_FORMAT)
 
-		err = scheduler.UpdateSchedule(sId, t)
+		err = scheduler.UpdateSchedule(ctx, sId, t)
 	case events.SubEventDelete:
-		err = scheduler.DeleteSchedule(sId)
+		err = scheduler.DeleteSchedule(ctx, sId)
 	}
 
 	return err
 }
 
 // send note notification to user
-func triggerNoteRemainder(p *events.ScheduleNoteRemainderPayload) error {
+func triggerNoteRemainder(ctx context.Context, p *events.ScheduleNoteRemainderPayload) error {
 	db := db.New()
 	r := newRepository(db)
 
-	note, err := getNote(db, p.UserId, p.NoteId)
+	note, err := getNote(ctx, db, p.UserId, p.NoteId)
 
 	if err != nil {
 		return err
@@ -202,7 +218,7 @@ func triggerNoteRemainder(p *events.ScheduleNoteRemainderPayload) error {
 		},
 	}
 
-	err = r.create(p.UserId, n)
+	err = r.create(ctx, p.UserId, n)
 
 	if err != nil {
 		return err
@@ -213,14 +229,14 @@ func triggerNoteRemainder(p *events.ScheduleNoteRemainderPayload) error {
 		Payload: n,
 	}
 
-	err = pushEvent.send(p.UserId, r)
+	err = pushEvent.send(ctx, p.UserId, r)
 
 	if err != nil {
 		return err
 	}
 
 	// remove remainder at
-	err = removeNoteRemainder(db, p.UserId, p.NoteId)
+	err = removeNoteRemainder(ctx, db, p.UserId, p.NoteId)
 
 	if err != nil {
 		return err
@@ -231,11 +247,11 @@ func triggerNoteRemainder(p *events.ScheduleNoteRemainderPayload) error {
 }
 
 // send snoozed tab notification to user
-func triggerSnoozedTab(p *events.ScheduleSnoozedTabPayload) error {
+func triggerSnoozedTab(ctx context.Context, p *events.ScheduleSnoozedTabPayload) error {
 	db := db.New()
 	r := newRepository(db)
 
-	snoozedTab, err := getSnoozedTab(db, p.UserId, p.SpaceId, p.SnoozedTabId)
+	snoozedTab, err := getSnoozedTab(ctx, db, p.UserId, p.SpaceId, p.SnoozedTabId)
 
 	if err != nil {
 		return err
@@ -256,7 +272,7 @@ func triggerSnoozedTab(p *events.ScheduleSnoozedTabPayload) error {
 		},
 	}
 
-	err = r.create(p.UserId, n)
+	err = r.create(ctx, p.UserId, n)
 
 	if err != nil {
 		return err
@@ -267,14 +283,14 @@ func triggerSnoozedTab(p *events.ScheduleSnoozedTabPayload) error {
 		Payload: n,
 	}
 
-	err = pushEvent.send(p.UserId, r)
+	err = pushEvent.send(ctx, p.UserId, r)
 
 	if err != nil {
 		return err
 	}
 
 	// delete snoozed tab
-	err = deleteSnoozedTab(db, p.UserId, p.SpaceId, p.SnoozedTabId)
+	err = deleteSnoozedTab(ctx, db, p.UserId, p.SpaceId, p.SnoozedTabId)
 
 	if err != nil {
 		return err
@@ -283,12 +299,119 @@ func triggerSnoozedTab(p *events.ScheduleSnoozedTabPayload) error {
 
 }
 
+// queue a cleanup event for each user with the cleanup preference, triggered by the recurring schedule;
+// the failed users are logged & not retried (the schedule runs daily), a retry would queue all the users again
+func queueUnsavedSpacesCleanup(ctx context.Context, q *events.Queue) error {
+	ddb := db.New()
+
+	evs := []events.IEvent{}
+
+	for _, pref := range spaces.UnsavedSpacesCleanupPrefs {
+		userIds, err := ddb.GetUserIdsByDeleteUnsavedSpacesPref(ctx, pref)
+
+		if err != nil {
+			logger.Errorf("error getting user ids with the %v preference for unsaved spaces cleanup: %v", pref, err)
+		}
+
+		for _, userId := range userIds {
+			evs = append(evs, events.New(events.EventTypeCleanupUnsavedSpaces, &events.CleanupUnsavedSpacesPayload{
+				UserId: userId,
+			}))
+		}
+	}
+
+	sent, err := q.AddMessages(ctx, evs)
+
+	if err != nil {
+		logger.Errorf("error queueing unsaved spaces cleanup for %v of %v users. \n[Error]: %v", len(evs)-sent, len(evs), err)
+	}
+
+	return nil
+}
+
+// delete user's stale unsaved spaces, then archive the inactive ones
+func cleanupUnsavedSpaces(ctx context.Context, p *events.CleanupUnsavedSpacesPayload) error {
+	db := db.New()
+
+	err := deleteUnsavedSpaces(ctx, db, p.UserId)
+
+	// separate step, a failed archive doesn't block the deletion
+	if aErr := spaces.ArchiveStaleUnsavedSpaces(ctx, db, p.UserId); aErr != nil {
+		logger.Errorf("error archiving unsaved spaces for userId: %v. \n[Error]: %v", p.UserId, aErr)
+	}
+
+	return err
+}
+
+// delete user's stale unsaved spaces and notify them about it
+func deleteUnsavedSpaces(ctx context.Context, db *db.DDB, userId string) error {
+	r := newRepository(db)
+
+	summary, err := spaces.DeleteStaleUnsavedSpaces(ctx, db, userId)
+
+	if err != nil {
+		logger.Errorf("error deleting unsaved spaces for userId: %v. \n[Error]: %v", userId, err)
+
+		// notify the user about the spaces removed before the error
+		if summary == nil || len(summary.SpaceTitles) < 1 {
+			return err
+		}
+	}
+
+	if len(summary.SpaceTitles) < 1 {
+		return nil
+	}
+
+	n := &notification{
+		Id:        strconv.FormatInt(time.Now().UTC().Unix(), 10),
+		Type:      NotificationTypeSpacesCleanup,
+		IsRead:    false,
+		Timestamp: time.Now().UTC().Unix(),
+		Message:   unsavedSpacesCleanupMsg(summary),
+	}
+
+	nErr := r.create(ctx, userId, n)
+
+	if nErr != nil {
+		return nErr
+	}
+
+	pushEvent := &WebPushEvent[notification]{
+		Event:   PushNotificationEventTypeNotification,
+		Payload: n,
+	}
+
+	nErr = pushEvent.send(ctx, userId, r)
+
+	if nErr != nil {
+		return nErr
+	}
+
+	return err
+}
+
 // * helpers
-func getNote(db *db.DDB, userId, noteId string) (*notes.Note, error) {
+func unsavedSpacesCleanupMsg(s *spaces.UnsavedSpacesCleanup) string {
+	spacesLabel := "spaces"
+
+	if len(s.SpaceTitles) == 1 {
+		spacesLabel = "space"
+	}
+
+	tabsLabel := "tabs"
+
+	if s.TabsCount == 1 {
+		tabsLabel = "tab"
+	}
+
+	return fmt.Sprintf("Deleted %d unsaved %s with %d %s: %s", len(s.SpaceTitles), spacesLabel, s.TabsCount, tabsLabel, strings.Join(s.SpaceTitles, ", "))
+}
+
+func getNote(ctx context.Context, db *db.DDB, userId, noteId string) (*notes.Note, error) {
 
 	r := notes.NewNoteRepository(db, nil)
 
-	note, err := r.GetNote(userId, noteId)
+	note, err := r.GetNote(ctx, userId, noteId)
 
 	if err != nil {
 		return nil, err
@@ -297,7 +420,7 @@ func getNote(db *db.DDB, userId, noteId string) (*notes.Note, error) {
 	return note, nil
 }
 
-func getSnoozedTab(db *db.DDB, userId, spaceId, snoozedTabId string) (*spaces.SnoozedTab, error) {
+func getSnoozedTab(ctx context.Context, db *db.DDB, userId, spaceId, snoozedTabId string) (*spaces.SnoozedTab, error) {
 
 	r := spaces.NewSpaceRepository(db)
 
@@ -309,7 +432,7 @@ func getSnoozedTab(db *db.DDB, userId, spaceId, snoozedTabId string) (*spaces.Sn
 
 	}
 
-	snoozedTab, err := r.GetSnoozedTab(userId, spaceId, snoozedTabIdInt)
+	snoozedTab, err := r.GetSnoozedTab(ctx, userId, spaceId, snoozedTabIdInt)
 
 	if err != nil {
 		return nil, err
@@ -318,10 +441,10 @@ func getSnoozedTab(db *db.DDB, userId, spaceId, snoozedTabId string) (*spaces.Sn
 	return snoozedTab, nil
 }
 
-func removeNoteRemainder(db *db.DDB, userId, noteId string) error {
+func removeNoteRemainder(ctx context.Context, db *db.DDB, userId, noteId string) error {
 	r := notes.NewNoteRepository(db, nil)
 
-	err := r.RemoveNoteRemainder(userId, noteId)
+	err := r.RemoveNoteRemainder(ctx, userId, noteId)
 
 	if err != nil {
 		logger.Error("error removing note remainder", err)
@@ -331,7 +454,7 @@ func removeNoteRemainder(db *db.DDB, userId, noteId string) error {
 	return nil
 }
 
-func deleteSnoozedTab(db *db.DDB, userId, spaceId, snoozedTabId string) error {
+func deleteSnoozedTab(ctx context.Context, db *db.DDB, userId, spaceId, snoozedTabId string) error {
 	r := spaces.NewSpaceRepository(db)
 
 	snoozedTabIdInt, err := strconv.ParseInt(snoozedTabId, 10, 64)
@@ -342,7 +465,7 @@ func deleteSnoozedTab(db *db.DDB, userId, spaceId, snoozedTabId string) error {
 
 	}
 
-	err = r.DeleteSnoozedTab(userId, spaceId, snoozedTabIdInt)
+	err = r.DeleteSnoozedTab(ctx, userId, spaceId, snoozedTabIdInt)
 
 	if err != nil {
 		logger.Error("error deleting snoozed tab", err)
@@ -1,11 +1,14 @@
 package notifications
 
 import (
+	"context"
+
 	web_push "github.com/SherClockHolmes/webpush-go"
 	"github.com/manishMandal02/tabsflow-backend/config"
 )
 
-func sendWebPushNotification(userId string, s *PushSubscription, body []byte) error {
+// sends the notification to the push service, returns its response status
+func sendWebPushNotification(ctx context.Context, userId string, s *PushSubscription, body []byte) (int, error) {
 	ws := &web_push.Subscription{
 		Endpoint: s.Endpoint,
 		Keys: web_push.Keys{
@@ -19,12 +22,14 @@ func sendWebPushNotification(userId string, s *PushSubscription, body []byte) er
 		VAPIDPrivateKey: config.VAPID_PRIVATE_KEY,
 		VAPIDPublicKey:  config.VAPID_PUBLIC_KEY,
 	}
-	_, err := web_push.SendNotification(body, ws, o)
+	res, err := web_push.SendNotificationWithContext(ctx, body, ws, o)
 
 	if err != nil {
-		return err
+		return 0, err
 	}
 
-	return nil
+	defer res.Body.Close()
+
+	return res.StatusCode, nil
 
 }
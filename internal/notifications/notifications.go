@@ -1,11 +1,15 @@
 package notifications
 
 import (
+	"context"
 	"encoding/json"
+	"errors"
+	"net/http"
 
-	"github.com/go-playground/validator/v10"
 	"github.com/manishMandal02/tabsflow-backend/pkg/db"
+	"github.com/manishMandal02/tabsflow-backend/pkg/errs"
 	"github.com/manishMandal02/tabsflow-backend/pkg/logger"
+	"github.com/manishMandal02/tabsflow-backend/pkg/telemetry"
 )
 
 type NotificationType string
@@ -14,6 +18,7 @@ const (
 	NotificationTypeAccount       NotificationType = "account"
 	NotificationTypeNoteRemainder NotificationType = "note_remainder"
 	NotificationTypeUnSnoozedType NotificationType = "un_snoozed_tab"
+	NotificationTypeSpacesCleanup NotificationType = "unsaved_spaces_deleted"
 )
 
 type snoozedTabNotification struct {
@@ -42,22 +47,11 @@ type notification struct {
 
 // notification subscription
 type PushSubscription struct {
-	Endpoint  string `json:"endpoint,omitempty" validate:"required"`
+	Endpoint  string `json:"endpoint,omitempty" validate:"required,http_url"`
 	AuthKey   string `json:"authKey,omitempty" validate:"required"`
 	P256dhKey string `json:"p256dhKey,omitempty" validate:"required"`
 }
 
-func (p PushSubscription) validate() error {
-	validate := validator.New(validator.WithRequiredStructEnabled())
-
-	err := validate.Struct(p)
-	if err != nil {
-		return err
-	}
-
-	return nil
-}
-
 // push notification event
 type PushNotificationEventType string
 
@@ -76,20 +70,29 @@ type WebPushEvent[T any] struct {
 	Payload *T
 }
 
-func (n *WebPushEvent[T]) send(userId string, r notificationRepository) error {
+func (n *WebPushEvent[T]) send(ctx context.Context, userId string, r notificationRepository) error {
 	if r == nil {
 		db := db.New()
 		r = newRepository(db)
 	}
 
-	s, err := r.getNotificationSubscription(userId)
+	s, err := r.getNotificationSubscription(ctx, userId)
 
-	if err != nil && err.Error() != errMsg.notificationsSubscribeEmpty {
+	if err != nil && !errors.Is(err, errNotSubscribed) {
 		return err
 	}
 
+	attrs := []telemetry.Attr{telemetry.String("event", string(n.Event))}
+
+	// type of the notification, e.g. the note remainders
+	if p, ok := any(n.Payload).(*notification); ok && p != nil {
+		attrs = append(attrs, telemetry.String("notification_type", string(p.Type)))
+	}
+
 	// user has not subscribed for notifications
 	if s == nil {
+		telemetry.Count(ctx, "notifications.push.deliveries", append(attrs, telemetry.String("status", "not_subscribed"))...)
+
 		logger.Errorf("No notification subscription found for userId: %s", userId)
 		return nil
 	}
@@ -101,7 +104,9 @@ func (n *WebPushEvent[T]) send(userId string, r notificationRepository) error {
 		return err
 	}
 
-	err = sendWebPushNotification(userId, s, b)
+	status, err := sendWebPushNotification(ctx, userId, s, b)
+
+	telemetry.Count(ctx, "notifications.push.deliveries", append(attrs, telemetry.String("status", pushStatus(status, err)))...)
 
 	if err != nil {
 		logger.Error("error sending web push notification", err)
@@ -110,22 +115,37 @@ func (n *WebPushEvent[T]) send(userId string, r notificationRepository) error {
 	return nil
 }
 
+// delivery status of the push notification: ok, expired (subscription no longer valid) or error
+func pushStatus(code int, err error) string {
+	switch {
+	case err != nil:
+		return "error"
+	case code == http.StatusNotFound || code == http.StatusGone:
+		return "expired"
+	case code >= 200 && code < 300:
+		return "ok"
+	}
+
+	return "error"
+}
+
+var (
+	errNotificationNotFound = errs.NotFound.New("notification_not_found", "no notifications found")
+	errNotSubscribed        = errs.NotFound.New("not_subscribed", "Not subscribed to notifications")
+)
+
 var errMsg = struct {
 	notificationGet              string
 	notificationPublishEvent     string
 	notificationDelete           string
-	notificationsEmpty           string
 	notificationsSubscribe       string
-	notificationsSubscribeEmpty  string
 	notificationsUnsubscribe     string
 	notificationsSubscriptionGet string
 }{
 	notificationDelete:           "error deleting notification",
 	notificationGet:              "error getting notifications",
 	notificationPublishEvent:     "error sending notifications",
-	notificationsEmpty:           "no notifications found",
 	notificationsSubscribe:       "error subscribing to notifications",
 	notificationsUnsubscribe:     "error unsubscribing from notifications",
-	notificationsSubscribeEmpty:  "Not subscribed to notifications",
 	notificationsSubscriptionGet: "error getting notification subscription",
 }
@@ -2,7 +2,6 @@ package notifications
 
 import (
 	"context"
-	"errors"
 
 	"github.com/aws/aws-sdk-go-v2/feature/dynamodb/attributevalue"
 	"github.com/aws/aws-sdk-go-v2/feature/dynamodb/expression"
@@ -13,13 +12,13 @@ import (
 )
 
 type notificationRepository interface {
-	create(userId string, notification *notification) error
-	get(userId, notificationId string) (notification, error)
-	delete(userId, notificationId string) error
-	subscribe(userId string, s *PushSubscription) error
-	getNotificationSubscription(userId string) (*PushSubscription, error)
-	getUserNotifications(userId string) ([]notification, error)
-	deleteNotificationSubscription(userId string) error
+	create(ctx context.Context, userId string, notification *notification) error
+	get(ctx context.Context, userId, notificationId string) (notification, error)
+	delete(ctx context.Context, userId, notificationId string) error
+	subscribe(ctx context.Context, userId string, s *PushSubscription) error
+	getNotificationSubscription(ctx context.Context, userId string) (*PushSubscription, error)
+	getUserNotifications(ctx context.Context, userId string) ([]notification, error)
+	deleteNotificationSubscription(ctx context.Context, userId string) error
 }
 
 type noteRepo struct {
@@ -32,7 +31,7 @@ func newRepository(db *db.DDB) notificationRepository {
 	}
 }
 
-func (nr *noteRepo) create(userId string, notification *notification) error {
+func (nr *noteRepo) create(ctx context.Context, userId string, notification *notification) error {
 
 	item, err := attributevalue.MarshalMap(notification)
 
@@ -48,7 +47,7 @@ func (nr *noteRepo) create(userId string, notification *notification) error {
 		Value: db.SORT_KEY.Notifications(notification.Id),
 	}
 
-	_, err = nr.db.Client.PutItem(context.TODO(), &dynamodb.PutItemInput{
+	_, err = nr.db.Client.PutItem(ctx, &dynamodb.PutItemInput{
 		TableName: &nr.db.TableName,
 		Item:      item,
 	})
@@ -61,7 +60,7 @@ func (nr *noteRepo) create(userId string, notification *notification) error {
 	return nil
 }
 
-func (nr *noteRepo) get(userId, notificationId string) (notification, error) {
+func (nr *noteRepo) get(ctx context.Context, userId, notificationId string) (notification, error) {
 
 	key := map[string]types.AttributeValue{
 		db.PK_NAME: &types.AttributeValueMemberS{
@@ -72,7 +71,7 @@ func (nr *noteRepo) get(userId, notificationId string) (notification, error) {
 		},
 	}
 
-	result, err := nr.db.Client.GetItem(context.TODO(), &dynamodb.GetItemInput{
+	result, err := nr.db.Client.GetItem(ctx, &dynamodb.GetItemInput{
 		TableName: &nr.db.TableName,
 		Key:       key,
 	})
@@ -83,7 +82,7 @@ func (nr *noteRepo) get(userId, notificationId string) (notification, error) {
 	}
 
 	if result.Item == nil {
-		return notification{}, errors.New(errMsg.notificationsEmpty)
+		return notification{}, errNotificationNotFound
 	}
 
 	var n notification
@@ -97,7 +96,7 @@ func (nr *noteRepo) get(userId, notificationId string) (notification, error) {
 	return n, nil
 }
 
-func (nr *noteRepo) getUserNotifications(userId string) ([]notification, error) {
+func (nr *noteRepo) getUserNotifications(ctx context.Context, userId string) ([]notification, error) {
 
 	key := expression.KeyAnd(expression.Key(db.PK_NAME).Equal(expression.Value(userId)), expression.Key(db.SK_NAME).BeginsWith(db.SORT_KEY.Notifications("")))
 	expr, err := expression.NewBuilder().WithKeyCondition(key).Build()
@@ -107,7 +106,7 @@ func (nr *noteRepo) getUserNotifications(userId string) ([]notification, error)
 		return nil, err
 	}
 
-	result, err := nr.db.Client.Query(context.TODO(), &dynamodb.QueryInput{
+	result, err := nr.db.Client.Query(ctx, &dynamodb.QueryInput{
 		TableName:                 &nr.db.TableName,
 		KeyConditionExpression:    expr.KeyCondition(),
 		ExpressionAttributeNames:  expr.Names(),
@@ -120,7 +119,7 @@ func (nr *noteRepo) getUserNotifications(userId string) ([]notification, error)
 	}
 
 	if result.Count < 1 {
-		return nil, errors.New(errMsg.notificationsEmpty)
+		return nil, errNotificationNotFound
 	}
 
 	var notifications []notification
@@ -136,7 +135,7 @@ func (nr *noteRepo) getUserNotifications(userId string) ([]notification, error)
 
 }
 
-func (nr *noteRepo) delete(userId, notificationId string) error {
+func (nr *noteRepo) delete(ctx context.Context, userId, notificationId string) error {
 
 	key := map[string]types.AttributeValue{
 		db.PK_NAME: &types.AttributeValueMemberS{
@@ -147,7 +146,7 @@ func (nr *noteRepo) delete(userId, notificationId string) error {
 		},
 	}
 
-	_, err := nr.db.Client.DeleteItem(context.TODO(), &dynamodb.DeleteItemInput{
+	_, err := nr.db.Client.DeleteItem(ctx, &dynamodb.DeleteItemInput{
 		TableName: &nr.db.TableName,
 		Key:       key,
 	})
@@ -161,7 +160,7 @@ func (nr *noteRepo) delete(userId, notificationId string) error {
 }
 
 // notification subscription
-func (nr *noteRepo) subscribe(userId string, s *PushSubscription) error {
+func (nr *noteRepo) subscribe(ctx context.Context, userId string, s *PushSubscription) error {
 	item, err := attributevalue.MarshalMap(s)
 
 	if err != nil {
@@ -177,7 +176,7 @@ func (nr *noteRepo) subscribe(userId string, s *PushSubscription) error {
 		Value: db.SORT_KEY.NotificationSubscription,
 	}
 
-	_, err = nr.db.Client.PutItem(context.TODO(), &dynamodb.PutItemInput{
+	_, err = nr.db.Client.PutItem(ctx, &dynamodb.PutItemInput{
 		TableName: &nr.db.TableName,
 		Item:      item,
 	})
@@ -190,7 +189,7 @@ func (nr *noteRepo) subscribe(userId string, s *PushSubscription) error {
 	return nil
 }
 
-func (nr *noteRepo) getNotificationSubscription(userId string) (*PushSubscription, error) {
+func (nr *noteRepo) getNotificationSubscription(ctx context.Context, userId string) (*PushSubscription, error) {
 
 	key := map[string]types.AttributeValue{
 		db.PK_NAME: &types.AttributeValueMemberS{
@@ -201,7 +200,7 @@ func (nr *noteRepo) getNotificationSubscription(userId string) (*PushSubscriptio
 		},
 	}
 
-	result, err := nr.db.Client.GetItem(context.TODO(), &dynamodb.GetItemInput{
+	result, err := nr.db.Client.GetItem(ctx, &dynamodb.GetItemInput{
 		TableName: &nr.db.TableName,
 		Key:       key,
 	})
@@ -212,7 +211,7 @@ func (nr *noteRepo) getNotificationSubscription(userId string) (*PushSubscriptio
 	}
 
 	if result.Item == nil {
-		return nil, errors.New(errMsg.notificationsSubscribeEmpty)
+		return nil, errNotSubscribed
 	}
 
 	var s PushSubscription
@@ -228,7 +227,7 @@ func (nr *noteRepo) getNotificationSubscription(userId string) (*PushSubscriptio
 
 }
 
-func (nr *noteRepo) deleteNotificationSubscription(userId string) error {
+func (nr *noteRepo) deleteNotificationSubscription(ctx context.Context, userId string) error {
 
 	key := map[string]types.AttributeValue{
 		db.PK_NAME: &types.AttributeValueMemberS{
@@ -239,7 +238,7 @@ func (nr *noteRepo) deleteNotificationSubscription(userId string) error {
 		},
 	}
 
-	_, err := nr.db.Client.DeleteItem(context.TODO(), &dynamodb.DeleteItemInput{
+	_, err := nr.db.Client.DeleteItem(ctx, &dynamodb.DeleteItemInput{
 		TableName: &nr.db.TableName,
 		Key:       key,
 	})
@@ -1,7 +1,9 @@
 package notifications
 
 import (
+	"github.com/manishMandal02/tabsflow-backend/config"
 	"github.com/manishMandal02/tabsflow-backend/pkg/db"
+	"github.com/manishMandal02/tabsflow-backend/pkg/health"
 	"github.com/manishMandal02/tabsflow-backend/pkg/http_api"
 )
 
@@ -13,22 +15,53 @@ func Router(db *db.DDB) http_api.IRouter {
 	// middleware to get userId from jwt token
 	userIdMiddleware := newUserIdMiddleware()
 
-	notificationsRouter := http_api.NewRouter("/notifications")
+	router := http_api.NewRouter("/notifications")
+
+	checks := []health.Check{
+		health.Table(db),
+		health.Config("vapid", map[string]string{"VAPID_PRIVATE_KEY": config.VAPID_PRIVATE_KEY, "VAPID_PUBLIC_KEY": config.VAPID_PUBLIC_KEY}),
+		// the note remainders are scheduled to the notifications queue
+		health.Config("scheduler", map[string]string{"NOTIFICATIONS_QUEUE_ARN": config.NOTIFICATIONS_QUEUE_ARN, "SCHEDULER_ROLE_ARN": config.SCHEDULER_ROLE_ARN}),
+	}
+
+	// health routes, outside the service's middleware
+	health.Routes(router, checks...)
+	health.DiagnosticsRoute(router, checks...)
+
+	notificationsRouter := router.Group("/")
 
 	notificationsRouter.Use(http_api.SetAllowOriginHeader())
 
+	// api token requests need the read/write scope
+	notificationsRouter.Use(http_api.RequireTokenScope("notifications"))
+
 	notificationsRouter.Use(userIdMiddleware)
 
 	// notifications subscription
-	notificationsRouter.GET("/subscription", h.getNotificationSubscription)
-	notificationsRouter.POST("/subscription", h.subscribe)
-	notificationsRouter.DELETE("/subscription", h.unsubscribe)
+	notificationsRouter.GET("/subscription", h.getNotificationSubscription).Doc(http_api.RouteDoc{
+		Summary:  "Web push subscription of the user, empty if not subscribed",
+		Response: PushSubscription{},
+	})
+	notificationsRouter.POST("/subscription", h.subscribe).Doc(http_api.RouteDoc{
+		Summary: "Subscribe to web push notifications",
+		Body:    PushSubscription{},
+	})
+	notificationsRouter.DELETE("/subscription", h.unsubscribe).Doc(http_api.RouteDoc{Summary: "Unsubscribe from web push notifications"})
 
-	notificationsRouter.GET("/my", h.getUserNotifications)
-	notificationsRouter.GET("/:id", h.get)
-	notificationsRouter.POST("/publish-event", h.publishEvent)
-	notificationsRouter.DELETE("/:id", h.delete)
+	notificationsRouter.GET("/my", h.getUserNotifications).Doc(http_api.RouteDoc{
+		Summary:  "Notifications of the user",
+		Response: []notification{},
+	})
+	notificationsRouter.GET("/:id", h.get).Doc(http_api.RouteDoc{
+		Summary:  "Get a notification",
+		Response: notification{},
+	})
+	notificationsRouter.POST("/publish-event", h.publishEvent).Doc(http_api.RouteDoc{
+		Summary: "Send a web push event to the user",
+		Body:    WebPushEvent[map[string]string]{},
+	})
+	notificationsRouter.DELETE("/:id", h.delete).Doc(http_api.RouteDoc{Summary: "Delete a notification"})
 
 	// serve API routes
-	return notificationsRouter
+	return router
 }
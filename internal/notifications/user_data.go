@@ -0,0 +1,26 @@
+package notifications
+
+import (
+	"context"
+	"errors"
+	"github.com/manishMandal02/tabsflow-backend/pkg/db"
+)
+
+// notification as exported with the user's account data
+type Notification = notification
+
+// ExportUserData returns all the notifications of the user
+func ExportUserData(ctx context.Context, db *db.DDB, userId string) ([]Notification, error) {
+	r := newRepository(db)
+
+	notifications, err := r.getUserNotifications(ctx, userId)
+
+	if err != nil {
+		if errors.Is(err, errNotificationNotFound) {
+			return []Notification{}, nil
+		}
+		return nil, err
+	}
+
+	return notifications, nil
+}
@@ -1,3 +0,0 @@
-package ping
-
-// ping services lambda every 4 minutes (triggered by eb scheduler)
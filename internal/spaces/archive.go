@@ -0,0 +1,72 @@
+package spaces
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/manishMandal02/tabsflow-backend/pkg/db"
+)
+
+// ArchiveStaleUnsavedSpaces archives the user's unsaved spaces not updated within half of their
+// general.deleteUnsavedSpaces preference, hiding them from /spaces/my before they are deleted at the full period
+func ArchiveStaleUnsavedSpaces(ctx context.Context, ddb *db.DDB, userId string) error {
+	r := &spaceRepo{
+		db: ddb,
+	}
+
+	pref, err := r.getDeleteUnsavedSpacesPref(ctx, userId)
+
+	if err != nil {
+		return err
+	}
+
+	maxAge, ok := unsavedSpaceMaxAge(pref)
+
+	if !ok {
+		return nil
+	}
+
+	spaces, err := r.getSpacesByUser(ctx, userId)
+
+	if err != nil {
+		if errors.Is(err, errSpaceNotFound) {
+			return nil
+		}
+		return err
+	}
+
+	toArchive := inactiveUnsavedSpaces(spaces, time.Now().Add(-maxAge/2).UnixMilli())
+
+	for start := 0; start < len(toArchive); start += db.DDB_MAX_TRANSACTION_SIZE {
+		chunk := toArchive[start:min(start+db.DDB_MAX_TRANSACTION_SIZE, len(toArchive))]
+
+		ids := make([]string, 0, len(chunk))
+
+		for _, s := range chunk {
+			ids = append(ids, s.Id)
+		}
+
+		// the archive doesn't change UpdatedAt, it doesn't delay the deletion
+		err = r.setSpacesArchived(ctx, userId, ids, true)
+
+		if err != nil {
+			return errors.New(errMsg.spaceArchive)
+		}
+	}
+
+	return nil
+}
+
+// unsaved spaces not updated since the cutoff, that aren't archived yet
+func inactiveUnsavedSpaces(spaces []space, cutoff int64) []space {
+	var inactive []space
+
+	for _, s := range spaces {
+		if !s.IsSaved && !s.IsArchived && s.UpdatedAt != 0 && s.UpdatedAt < cutoff {
+			inactive = append(inactive, s)
+		}
+	}
+
+	return inactive
+}
@@ -0,0 +1,26 @@
+package spaces
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestInactiveUnsavedSpaces(t *testing.T) {
+	spaces := []space{
+		{Id: "saved", IsSaved: true, UpdatedAt: 10},
+		{Id: "recent", UpdatedAt: 2000},
+		{Id: "inactive", UpdatedAt: 20},
+		{Id: "archived", UpdatedAt: 10, IsArchived: true, ArchivedAt: 500},
+		{Id: "no-updated-at"},
+	}
+
+	var got []string
+
+	for _, s := range inactiveUnsavedSpaces(spaces, 1000) {
+		got = append(got, s.Id)
+	}
+
+	if want := []string{"inactive"}; !slices.Equal(got, want) {
+		t.Errorf("inactiveUnsavedSpaces() = %v, want %v", got, want)
+	}
+}
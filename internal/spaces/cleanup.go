@@ -0,0 +1,108 @@
+package spaces
+
+import (
+	"cmp"
+	"context"
+	"errors"
+	"slices"
+	"time"
+
+	"github.com/manishMandal02/tabsflow-backend/pkg/db"
+	"github.com/manishMandal02/tabsflow-backend/pkg/logger"
+)
+
+// summary of the unsaved spaces removed for a user by the cleanup job
+type UnsavedSpacesCleanup struct {
+	SpaceTitles []string
+	TabsCount   int
+}
+
+// DeleteStaleUnsavedSpaces deletes the user's unsaved spaces older than their general.deleteUnsavedSpaces preference,
+// along with their tabs, groups & active tab; snoozed tabs are moved to one of the remaining spaces
+func DeleteStaleUnsavedSpaces(ctx context.Context, ddb *db.DDB, userId string) (*UnsavedSpacesCleanup, error) {
+	r := &spaceRepo{
+		db: ddb,
+	}
+
+	summary := &UnsavedSpacesCleanup{}
+
+	pref, err := r.getDeleteUnsavedSpacesPref(ctx, userId)
+
+	if err != nil {
+		return nil, err
+	}
+
+	maxAge, ok := unsavedSpaceMaxAge(pref)
+
+	if !ok {
+		return summary, nil
+	}
+
+	spaces, err := r.getSpacesByUser(ctx, userId)
+
+	if err != nil {
+		if errors.Is(err, errSpaceNotFound) {
+			return summary, nil
+		}
+		return nil, err
+	}
+
+	stale, remaining := staleUnsavedSpaces(spaces, time.Now().Add(-maxAge).UnixMilli())
+
+	if len(stale) < 1 {
+		return summary, nil
+	}
+
+	// keep the most recently used stale space, if there is no other space left for the snoozed tabs
+	if len(remaining) < 1 {
+		remaining = append(remaining, stale[len(stale)-1])
+		stale = stale[:len(stale)-1]
+	}
+
+	sortSpaces(remaining)
+
+	backupSpaceId := remaining[0].Id
+
+	// prefer an active space over an archived one
+	if i := slices.IndexFunc(remaining, func(s space) bool { return !s.IsArchived }); i != -1 {
+		backupSpaceId = remaining[i].Id
+	}
+
+	for _, s := range stale {
+		tabs, _, err := r.getTabsForSpace(ctx, userId, s.Id)
+
+		if err != nil && !errors.Is(err, errTabsNotFound) {
+			logger.Errorf("Couldn't get tabs for unsaved spaceId: %v. \n[Error]: %v", s.Id, err)
+		}
+
+		err = r.deleteSpace(ctx, userId, s.Id, backupSpaceId)
+
+		if err != nil {
+			return summary, errors.New(errMsg.spaceDelete)
+		}
+
+		summary.SpaceTitles = append(summary.SpaceTitles, s.Title)
+		summary.TabsCount += len(tabs)
+	}
+
+	return summary, nil
+}
+
+// splits the spaces into unsaved spaces (archived or not) last updated before the cutoff (oldest first) and the rest
+func staleUnsavedSpaces(spaces []space, cutoff int64) ([]space, []space) {
+	var stale, remaining []space
+
+	for _, s := range spaces {
+		if !s.IsSaved && s.UpdatedAt != 0 && s.UpdatedAt < cutoff {
+			stale = append(stale, s)
+			continue
+		}
+		remaining = append(remaining, s)
+	}
+
+	slices.SortFunc(stale, func(a, b space) int {
+		return cmp.Compare(a.UpdatedAt, b.UpdatedAt)
+	})
+
+	return stale, remaining
+}
@@ -0,0 +1,39 @@
+package spaces
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestStaleUnsavedSpaces(t *testing.T) {
+	const cutoff = 1000
+
+	spaces := []space{
+		{Id: "saved", IsSaved: true, UpdatedAt: 10},
+		{Id: "recent", UpdatedAt: 2000},
+		{Id: "stale", UpdatedAt: 20},
+		{Id: "stale-older", UpdatedAt: 10},
+		{Id: "stale-archived", UpdatedAt: 15, IsArchived: true, ArchivedAt: 2000},
+		{Id: "recent-archived", UpdatedAt: 2000, IsArchived: true, ArchivedAt: 2000},
+		{Id: "saved-archived", IsSaved: true, UpdatedAt: 10, IsArchived: true, ArchivedAt: 500},
+	}
+
+	ids := func(spaces []space) []string {
+		ids := []string{}
+		for _, s := range spaces {
+			ids = append(ids, s.Id)
+		}
+		return ids
+	}
+
+	stale, remaining := staleUnsavedSpaces(spaces, cutoff)
+
+	// deleted once past the threshold, whether archived or not (oldest first)
+	if got, want := ids(stale), []string{"stale-older", "stale-archived", "stale"}; !slices.Equal(got, want) {
+		t.Errorf("staleUnsavedSpaces() = %v, want %v", got, want)
+	}
+
+	if got, want := ids(remaining), []string{"saved", "recent", "recent-archived", "saved-archived"}; !slices.Equal(got, want) {
+		t.Errorf("staleUnsavedSpaces() remaining = %v, want %v", got, want)
+	}
+}
@@ -1,12 +1,13 @@
 package spaces
 
 import (
-	"encoding/json"
 	"errors"
 	"net/http"
+	"slices"
 	"strconv"
 	"time"
 
+	"github.com/manishMandal02/tabsflow-backend/pkg/errs"
 	"github.com/manishMandal02/tabsflow-backend/pkg/events"
 	"github.com/manishMandal02/tabsflow-backend/pkg/http_api"
 	"github.com/manishMandal02/tabsflow-backend/pkg/logger"
@@ -29,20 +30,20 @@ func (h *spaceHandler) get(w http.ResponseWriter, r *http.Request) {
 	spaceId := r.PathValue("id")
 
 	if spaceId == "" {
-		http_api.ErrorRes(w, errMsg.spaceId, http.StatusBadRequest)
+		http_api.ErrorRes(w, errs.BadRequest.WithMessage(errMsg.spaceId))
 		return
 	}
 
-	space, err := h.r.getSpaceById(userId, spaceId)
+	space, err := h.r.getSpaceById(r.Context(), userId, spaceId)
 
 	if err != nil {
 
-		if err.Error() == errMsg.spaceNotFound {
+		if errors.Is(err, errSpaceNotFound) {
 			//  space not found
-			http_api.ErrorRes(w, errMsg.spaceNotFound, http.StatusNotFound)
+			http_api.ErrorRes(w, errSpaceNotFound)
 			return
 		}
-		http_api.ErrorRes(w, errMsg.spaceGet, http.StatusBadGateway)
+		http_api.ErrorRes(w, errs.BadGateway.WithMessage(errMsg.spaceGet))
 		return
 	}
 
@@ -53,22 +54,31 @@ func (h *spaceHandler) spacesByUser(w http.ResponseWriter, r *http.Request) {
 	userId := r.PathValue("userId")
 
 	if userId == "" {
-		http_api.ErrorRes(w, errMsg.groupsSet, http.StatusBadRequest)
+		http_api.ErrorRes(w, errs.BadRequest.WithMessage(errMsg.groupsSet))
 		return
 	}
 
-	spaces, err := h.r.getSpacesByUser(userId)
+	spaces, err := h.r.getSpacesByUser(r.Context(), userId)
 
 	if err != nil {
-		if err.Error() == errMsg.spaceNotFound {
-			http_api.SuccessResData(w, []string{})
+		if errors.Is(err, errSpaceNotFound) {
+			http_api.SuccessResData(w, []space{})
 			return
 		}
 		logger.Error("error getting spaces", err)
-		http_api.ErrorRes(w, errMsg.spaceGet, http.StatusBadGateway)
+		http_api.ErrorRes(w, errs.BadGateway.WithMessage(errMsg.spaceGet))
 		return
 	}
 
+	// archived spaces are hidden, unless requested with query: include=archived
+	if r.URL.Query().Get("include") != "archived" {
+		spaces = slices.DeleteFunc(spaces, func(s space) bool {
+			return s.IsArchived
+		})
+	}
+
+	sortSpaces(spaces)
+
 	http_api.SuccessResData(w, spaces)
 
 }
@@ -77,30 +87,19 @@ func (h *spaceHandler) create(w http.ResponseWriter, r *http.Request) {
 
 	userId := r.PathValue("userId")
 
-	s := space{}
-
-	err := json.NewDecoder(r.Body).Decode(&s)
-
-	if err != nil {
-		logger.Error("error un_marshalling body", err)
-		http_api.ErrorRes(w, errMsg.spaceCreate, http.StatusBadRequest)
-		return
-	}
-
-	// validate space
-	err = s.validate()
+	s, err := http_api.DecodeAndValidate[space](w, r)
 
 	if err != nil {
-		logger.Error("error validating space", err)
-		http_api.ErrorRes(w, errMsg.spaceCreate, http.StatusBadRequest)
+		logger.Error("error decoding space", err)
+		http_api.ErrorRes(w, err)
 		return
 	}
 
-	err = h.r.createSpace(userId, &s)
+	err = h.r.createSpace(r.Context(), userId, s)
 
 	if err != nil {
 		logger.Error("error creating space", err)
-		http_api.ErrorRes(w, errMsg.spaceCreate, http.StatusBadRequest)
+		http_api.ErrorRes(w, errs.BadRequest.WithMessage(errMsg.spaceCreate))
 		return
 	}
 
@@ -111,38 +110,37 @@ func (h *spaceHandler) update(w http.ResponseWriter, r *http.Request) {
 
 	userId := r.PathValue("userId")
 
-	s := space{}
-
-	err := json.NewDecoder(r.Body).Decode(&s)
+	s, err := http_api.DecodeAndValidate[space](w, r)
 
 	if err != nil {
 		logger.Error("error decoding space", err)
-		http_api.ErrorRes(w, errMsg.spaceUpdate, http.StatusBadRequest)
-		return
-	}
-
-	if s.Id == "" {
-		http_api.ErrorRes(w, errMsg.spaceId, http.StatusBadRequest)
+		http_api.ErrorRes(w, err)
 		return
 	}
 
-	_, err = h.r.getSpaceById(userId, s.Id)
+	oldSpace, err := h.r.getSpaceById(r.Context(), userId, s.Id)
 
 	if err != nil {
-		if err.Error() == errMsg.spaceNotFound {
+		if errors.Is(err, errSpaceNotFound) {
 			//  space not found
-			http_api.ErrorRes(w, errMsg.spaceNotFound, http.StatusBadRequest)
+			http_api.ErrorRes(w, errSpaceNotFound)
 			return
 		}
-		http_api.ErrorRes(w, errMsg.spaceUpdate, http.StatusBadGateway)
+		http_api.ErrorRes(w, errs.BadGateway.WithMessage(errMsg.spaceUpdate))
 		return
 	}
 
-	err = h.r.createSpace(userId, &s)
+	// order, pin & archive state are managed by their own endpoints
+	s.Order = oldSpace.Order
+	s.IsPinned = oldSpace.IsPinned
+	s.IsArchived = oldSpace.IsArchived
+	s.ArchivedAt = oldSpace.ArchivedAt
+
+	err = h.r.createSpace(r.Context(), userId, s)
 
 	if err != nil {
 		logger.Error("error updating space", err)
-		http_api.ErrorRes(w, errMsg.spaceUpdate, http.StatusBadGateway)
+		http_api.ErrorRes(w, errs.BadGateway.WithMessage(errMsg.spaceUpdate))
 		return
 	}
 
@@ -157,32 +155,216 @@ func (h *spaceHandler) delete(w http.ResponseWriter, r *http.Request) {
 	userId := r.PathValue("userId")
 
 	if spaceId == "" {
-		http_api.ErrorRes(w, errMsg.spaceId, http.StatusBadRequest)
+		http_api.ErrorRes(w, errs.BadRequest.WithMessage(errMsg.spaceId))
 		return
 	}
 	// if backup space  id is not provided, then move snoozed tabs to a random space
 	if backupSpaceId == "" {
 
-		spaces, err := h.r.getSpacesByUser(userId)
+		spaces, err := h.r.getSpacesByUser(r.Context(), userId)
 
 		if err != nil {
-			http_api.ErrorRes(w, errMsg.spaceGet, http.StatusBadGateway)
+			http_api.ErrorRes(w, errs.BadGateway.WithMessage(errMsg.spaceGet))
 			return
 		}
 		backupSpaceId = spaces[0].Id
 	}
 
-	err := h.r.deleteSpace(userId, spaceId, backupSpaceId)
+	err := h.r.deleteSpace(r.Context(), userId, spaceId, backupSpaceId)
 
 	if err != nil {
 		logger.Error("error deleting space", err)
-		http_api.ErrorRes(w, errMsg.spaceDelete, http.StatusBadGateway)
+		http_api.ErrorRes(w, errs.BadGateway.WithMessage(errMsg.spaceDelete))
 		return
 	}
 
 	http_api.SuccessResMsg(w, "space deleted successfully")
 }
 
+// spaces order, pin & archive
+func (h *spaceHandler) setOrder(w http.ResponseWriter, r *http.Request) {
+	userId := r.PathValue("userId")
+
+	data, err := http_api.DecodeAndValidate[spacesOrderReq](w, r)
+
+	if err != nil {
+		logger.Error("error decoding spaces order", err)
+		http_api.ErrorRes(w, err)
+		return
+	}
+
+	err = h.r.setSpacesOrder(r.Context(), userId, data.SpaceIds)
+
+	if err != nil {
+		if errors.Is(err, errSpaceNotFound) {
+			http_api.ErrorRes(w, errSpaceNotFound)
+			return
+		}
+		logger.Error("error setting spaces order", err)
+		http_api.ErrorRes(w, errs.BadGateway.WithMessage(errMsg.spacesOrder))
+		return
+	}
+
+	http_api.SuccessResMsg(w, "spaces order set successfully")
+}
+
+func (h *spaceHandler) pin(w http.ResponseWriter, r *http.Request) {
+	h.setPinned(w, r, true)
+}
+
+func (h *spaceHandler) unpin(w http.ResponseWriter, r *http.Request) {
+	h.setPinned(w, r, false)
+}
+
+func (h *spaceHandler) setPinned(w http.ResponseWriter, r *http.Request, isPinned bool) {
+	userId := r.PathValue("userId")
+	spaceId := r.PathValue("spaceId")
+
+	if spaceId == "" {
+		http_api.ErrorRes(w, errs.BadRequest.WithMessage(errMsg.spaceId))
+		return
+	}
+
+	err := h.r.setSpacePinned(r.Context(), userId, spaceId, isPinned)
+
+	if err != nil {
+		if errors.Is(err, errSpaceNotFound) {
+			http_api.ErrorRes(w, errSpaceNotFound)
+			return
+		}
+		logger.Error("error setting space pinned", err)
+		http_api.ErrorRes(w, errs.BadGateway.WithMessage(errMsg.spacePin))
+		return
+	}
+
+	if isPinned {
+		http_api.SuccessResMsg(w, "space pinned successfully")
+		return
+	}
+
+	http_api.SuccessResMsg(w, "space unpinned successfully")
+}
+
+func (h *spaceHandler) archive(w http.ResponseWriter, r *http.Request) {
+	h.setArchived(w, r, true)
+}
+
+func (h *spaceHandler) unarchive(w http.ResponseWriter, r *http.Request) {
+	h.setArchived(w, r, false)
+}
+
+func (h *spaceHandler) setArchived(w http.ResponseWriter, r *http.Request, isArchived bool) {
+	userId := r.PathValue("userId")
+	spaceId := r.PathValue("spaceId")
+
+	if spaceId == "" {
+		http_api.ErrorRes(w, errs.BadRequest.WithMessage(errMsg.spaceId))
+		return
+	}
+
+	_, err := h.r.getSpaceById(r.Context(), userId, spaceId)
+
+	if err != nil {
+		if errors.Is(err, errSpaceNotFound) {
+			http_api.ErrorRes(w, errSpaceNotFound)
+			return
+		}
+		http_api.ErrorRes(w, errs.BadGateway.WithMessage(errMsg.spaceArchive))
+		return
+	}
+
+	err = h.r.setSpacesArchived(r.Context(), userId, []string{spaceId}, isArchived)
+
+	if err != nil {
+		if errors.Is(err, errSpaceNotFound) {
+			http_api.ErrorRes(w, errSpaceNotFound)
+			return
+		}
+		logger.Error("error setting space archived", err)
+		http_api.ErrorRes(w, errs.BadGateway.WithMessage(errMsg.spaceArchive))
+		return
+	}
+
+	if isArchived {
+		http_api.SuccessResMsg(w, "space archived successfully")
+		return
+	}
+
+	http_api.SuccessResMsg(w, "space unarchived successfully")
+}
+
+// import spaces from other tools, dryRun returns the parsed spaces without creating them
+func (h *spaceHandler) importSpaces(w http.ResponseWriter, r *http.Request) {
+	userId := r.PathValue("userId")
+
+	body, err := http_api.DecodeAndValidateLimit[importReq](w, r, maxImportBodySize)
+
+	if err != nil {
+		logger.Error("error decoding import body", err)
+		http_api.ErrorRes(w, err)
+		return
+	}
+
+	spaces, err := parseImport(body.Format, body.Data)
+
+	if err != nil {
+		logger.Error("error parsing import data", err)
+
+		if errors.Is(err, errImportEmpty) || errors.Is(err, errImportLimit) {
+			http_api.ErrorRes(w, err)
+			return
+		}
+
+		http_api.ErrorRes(w, errImportParse)
+		return
+	}
+
+	report := &importReport{
+		DryRun: body.DryRun,
+		Total:  len(spaces),
+	}
+
+	for _, s := range spaces {
+		res := importSpaceResult{
+			Id:          s.Space.Id,
+			Title:       s.Space.Title,
+			Tabs:        len(s.Tabs),
+			Groups:      len(s.Groups),
+			SplitFrom:   s.SplitFrom,
+			SkippedTabs: s.SkippedTabs,
+			Status:      importStatusPreview,
+		}
+
+		if !body.DryRun {
+			err = h.r.importSpace(r.Context(), userId, &s)
+
+			if err != nil {
+				logger.Errorf("error importing space: %v for userId: %v. \n[Error]: %v", s.Space.Title, userId, err)
+				res.Status = importStatusFailed
+				report.Failed++
+			} else {
+				res.Status = importStatusImported
+				report.Imported++
+			}
+		}
+
+		report.Spaces = append(report.Spaces, res)
+	}
+
+	if body.DryRun {
+		report.Preview = spaces
+		http_api.SuccessResData(w, report)
+		return
+	}
+
+	if report.Imported < 1 {
+		http_api.ErrorRes(w, errs.BadGateway.WithMessage(errMsg.spacesImport))
+		return
+	}
+
+	http_api.SuccessResData(w, report)
+}
+
 // space active tab index
 
 func (h *spaceHandler) setActiveTab(w http.ResponseWriter, r *http.Request) {
@@ -190,19 +372,15 @@ func (h *spaceHandler) setActiveTab(w http.ResponseWriter, r *http.Request) {
 	spaceId := r.PathValue("spaceId")
 
 	if spaceId == "" {
-		http_api.ErrorRes(w, errMsg.spaceId, http.StatusBadRequest)
+		http_api.ErrorRes(w, errs.BadRequest.WithMessage(errMsg.spaceId))
 		return
 	}
 
-	data := struct {
-		TabIndex int64 `json:"tabIndex"`
-	}{}
-
-	err := json.NewDecoder(r.Body).Decode(&data)
+	data, err := http_api.DecodeAndValidate[activeTabIndexReq](w, r)
 
 	if err != nil {
 		logger.Error("error decoding body", err)
-		http_api.ErrorRes(w, errMsg.spaceActiveTabIndexSet, http.StatusBadRequest)
+		http_api.ErrorRes(w, err)
 		return
 	}
 
@@ -210,11 +388,11 @@ func (h *spaceHandler) setActiveTab(w http.ResponseWriter, r *http.Request) {
 		data.TabIndex = 0
 	}
 
-	err = h.r.setActiveTabIndex(userId, spaceId, data.TabIndex)
+	err = h.r.setActiveTabIndex(r.Context(), userId, spaceId, data.TabIndex)
 
 	if err != nil {
 		logger.Error("error setting active tab index", err)
-		http_api.ErrorRes(w, errMsg.spaceActiveTabIndexSet, http.StatusBadGateway)
+		http_api.ErrorRes(w, errs.BadGateway.WithMessage(errMsg.spaceActiveTabIndexSet))
 		return
 	}
 
@@ -226,14 +404,14 @@ func (h *spaceHandler) getActiveTab(w http.ResponseWriter, r *http.Request) {
 	spaceId := r.PathValue("spaceId")
 
 	if spaceId == "" {
-		http_api.ErrorRes(w, errMsg.spaceId, http.StatusBadRequest)
+		http_api.ErrorRes(w, errs.BadRequest.WithMessage(errMsg.spaceId))
 		return
 	}
-	activeTabIndex, err := h.r.getActiveTabIndex(userId, spaceId)
+	activeTabIndex, err := h.r.getActiveTabIndex(r.Context(), userId, spaceId)
 
 	if err != nil {
 		logger.Error("error getting active tab index", err)
-		http_api.ErrorRes(w, errMsg.spaceActiveTabIndexGet, http.StatusBadGateway)
+		http_api.ErrorRes(w, errs.BadGateway.WithMessage(errMsg.spaceActiveTabIndexGet))
 		return
 	}
 	http_api.SuccessResData(w, activeTabIndex)
@@ -245,11 +423,11 @@ func (h *spaceHandler) getTabsInSpace(w http.ResponseWriter, r *http.Request) {
 	userId := r.PathValue("userId")
 	spaceId := r.PathValue("spaceId")
 
-	tabs, m, err := h.r.getTabsForSpace(userId, spaceId)
+	tabs, m, err := h.r.getTabsForSpace(r.Context(), userId, spaceId)
 
 	if err != nil {
 		logger.Error("error getting tabs for space", err)
-		http_api.ErrorRes(w, errMsg.tabsGet, http.StatusBadGateway)
+		http_api.ErrorRes(w, errs.BadGateway.WithMessage(errMsg.tabsGet))
 		return
 	}
 
@@ -261,34 +439,24 @@ func (h *spaceHandler) setTabsInSpace(w http.ResponseWriter, r *http.Request) {
 	spaceId := r.PathValue("spaceId")
 
 	if spaceId == "" {
-		http_api.ErrorRes(w, errMsg.spaceId, http.StatusBadRequest)
+		http_api.ErrorRes(w, errs.BadRequest.WithMessage(errMsg.spaceId))
 		return
 	}
 
-	data := struct {
-		Tabs          []tab `json:"tabs"`
-		LastUpdatedAt int64 `json:"lastUpdatedAt"`
-	}{}
-
-	err := json.NewDecoder(r.Body).Decode(&data)
+	data, err := http_api.DecodeAndValidate[tabsReq](w, r)
 
 	if err != nil {
 		logger.Error("error decoding tabs", err)
-		http_api.ErrorRes(w, errMsg.tabsSet, http.StatusBadRequest)
-		return
-	}
-
-	if len(data.Tabs) < 1 {
-		http_api.ErrorRes(w, errMsg.tabsSet, http.StatusBadRequest)
+		http_api.ErrorRes(w, err)
 		return
 	}
 
 	// check for data conflict
-	currentTabs, metadata, err := h.r.getTabsForSpace(userId, spaceId)
+	currentTabs, metadata, err := h.r.getTabsForSpace(r.Context(), userId, spaceId)
 
 	if err != nil {
 		logger.Error("error getting tabs for space", err)
-		http_api.ErrorRes(w, errMsg.tabsGet, http.StatusBadGateway)
+		http_api.ErrorRes(w, errs.BadGateway.WithMessage(errMsg.tabsGet))
 		return
 	}
 
@@ -299,12 +467,12 @@ func (h *spaceHandler) setTabsInSpace(w http.ResponseWriter, r *http.Request) {
 		if err != nil {
 			logger.Error("error checking for data conflict", err)
 
-			if err.Error() == errMsg.dataConflict {
-				http_api.ErrorRes(w, errMsg.dataConflict, http.StatusBadRequest)
+			if errors.Is(err, errDataConflict) {
+				http_api.ErrorRes(w, errDataConflict)
 				return
 			}
 
-			http_api.ErrorRes(w, errMsg.tabsSet, http.StatusBadRequest)
+			http_api.ErrorRes(w, errs.BadRequest.WithMessage(errMsg.tabsSet))
 			return
 		}
 	}
@@ -313,11 +481,11 @@ func (h *spaceHandler) setTabsInSpace(w http.ResponseWriter, r *http.Request) {
 		UpdatedAt: time.Now().UnixMilli(),
 	}
 
-	err = h.r.setTabsForSpace(userId, spaceId, data.Tabs, m)
+	err = h.r.setTabsForSpace(r.Context(), userId, spaceId, data.Tabs, m)
 
 	if err != nil {
 		logger.Error("error setting tabs for space", err)
-		http_api.ErrorRes(w, errMsg.tabsSet, http.StatusBadGateway)
+		http_api.ErrorRes(w, errs.BadGateway.WithMessage(errMsg.tabsSet))
 		return
 	}
 
@@ -330,15 +498,15 @@ func (h *spaceHandler) getGroupsInSpace(w http.ResponseWriter, r *http.Request)
 	spaceId := r.PathValue("spaceId")
 
 	if spaceId == "" {
-		http_api.ErrorRes(w, errMsg.spaceId, http.StatusBadRequest)
+		http_api.ErrorRes(w, errs.BadRequest.WithMessage(errMsg.spaceId))
 		return
 	}
 
-	groups, m, err := h.r.getGroupsForSpace(userId, spaceId)
+	groups, m, err := h.r.getGroupsForSpace(r.Context(), userId, spaceId)
 
 	if err != nil {
 		logger.Error("error getting groups for space", err)
-		http_api.ErrorRes(w, errMsg.groupsGet, http.StatusBadGateway)
+		http_api.ErrorRes(w, errs.BadGateway.WithMessage(errMsg.groupsGet))
 		return
 	}
 
@@ -350,39 +518,29 @@ func (h *spaceHandler) setGroupsInSpace(w http.ResponseWriter, r *http.Request)
 	spaceId := r.PathValue("spaceId")
 
 	if spaceId == "" {
-		http_api.ErrorRes(w, errMsg.spaceId, http.StatusBadRequest)
+		http_api.ErrorRes(w, errs.BadRequest.WithMessage(errMsg.spaceId))
 		return
 	}
-	data := struct {
-		Groups        []group `json:"groups"`
-		LastUpdatedAt int64   `json:"lastUpdatedAt"`
-	}{}
-
-	err := json.NewDecoder(r.Body).Decode(&data)
+	data, err := http_api.DecodeAndValidate[groupsReq](w, r)
 
 	if err != nil {
 		logger.Error("error decoding groups", err)
-		http_api.ErrorRes(w, errMsg.groupsSet, http.StatusBadRequest)
-		return
-	}
-
-	if len(data.Groups) < 1 {
-		http_api.ErrorRes(w, errMsg.groupsSet, http.StatusBadRequest)
+		http_api.ErrorRes(w, err)
 		return
 	}
 
 	// check for data conflict
-	_, metadata, err := h.r.getGroupsForSpace(userId, spaceId)
+	_, metadata, err := h.r.getGroupsForSpace(r.Context(), userId, spaceId)
 
 	if err != nil {
 		logger.Error("error getting groups for space", err)
-		http_api.ErrorRes(w, errMsg.groupsGet, http.StatusBadGateway)
+		http_api.ErrorRes(w, errs.BadGateway.WithMessage(errMsg.groupsGet))
 		return
 	}
 
 	if metadata.UpdatedAt != data.LastUpdatedAt {
 		// different data
-		http_api.ErrorRes(w, errMsg.dataConflict, http.StatusBadRequest)
+		http_api.ErrorRes(w, errDataConflict)
 		return
 	}
 
@@ -390,11 +548,11 @@ func (h *spaceHandler) setGroupsInSpace(w http.ResponseWriter, r *http.Request)
 		UpdatedAt: time.Now().UnixMilli(),
 	}
 
-	err = h.r.setGroupsForSpace(userId, spaceId, data.Groups, m)
+	err = h.r.setGroupsForSpace(r.Context(), userId, spaceId, data.Groups, m)
 
 	if err != nil {
 		logger.Error("error setting groups for space", err)
-		http_api.ErrorRes(w, errMsg.groupsSet, http.StatusBadGateway)
+		http_api.ErrorRes(w, errs.BadGateway.WithMessage(errMsg.groupsSet))
 		return
 	}
 
@@ -407,21 +565,19 @@ func (h *spaceHandler) createSnoozedTab(w http.ResponseWriter, r *http.Request)
 	userId := r.PathValue("userId")
 	spaceId := r.PathValue("spaceId")
 
-	sT := SnoozedTab{}
-
-	err := json.NewDecoder(r.Body).Decode(&sT)
+	sT, err := http_api.DecodeAndValidate[SnoozedTab](w, r)
 
 	if err != nil {
 		logger.Error("error decoding snoozed tab", err)
-		http_api.ErrorRes(w, errMsg.snoozedTabsCreate, http.StatusBadRequest)
+		http_api.ErrorRes(w, err)
 		return
 	}
 
-	err = h.r.addSnoozedTab(userId, spaceId, &sT)
+	err = h.r.addSnoozedTab(r.Context(), userId, spaceId, sT)
 
 	if err != nil {
 		logger.Error("error snoozing tab", err)
-		http_api.ErrorRes(w, errMsg.snoozedTabsCreate, http.StatusBadGateway)
+		http_api.ErrorRes(w, errs.BadGateway.WithMessage(errMsg.snoozedTabsCreate))
 		return
 	}
 
@@ -434,10 +590,10 @@ func (h *spaceHandler) createSnoozedTab(w http.ResponseWriter, r *http.Request)
 		TriggerAt:    sT.SnoozedUntil,
 	})
 
-	err = h.notificationQueue.AddMessage(event)
+	err = h.notificationQueue.AddMessage(r.Context(), event)
 
 	if err != nil {
-		http_api.ErrorRes(w, errMsg.snoozedTabsCreate, http.StatusBadGateway)
+		http_api.ErrorRes(w, errs.BadGateway.WithMessage(errMsg.snoozedTabsCreate))
 		return
 	}
 
@@ -450,7 +606,7 @@ func (h *spaceHandler) getSnoozedTab(w http.ResponseWriter, r *http.Request) {
 	snoozedTabId := r.PathValue("id")
 
 	if spaceId == "" || snoozedTabId == "" {
-		http_api.ErrorRes(w, errMsg.spaceId, http.StatusBadRequest)
+		http_api.ErrorRes(w, errs.BadRequest.WithMessage(errMsg.spaceId))
 		return
 	}
 
@@ -458,19 +614,19 @@ func (h *spaceHandler) getSnoozedTab(w http.ResponseWriter, r *http.Request) {
 
 	if err != nil {
 		logger.Error("error parsing snoozedTabId to int", err)
-		http_api.ErrorRes(w, errMsg.snoozedTabsGet, http.StatusBadGateway)
+		http_api.ErrorRes(w, errs.BadGateway.WithMessage(errMsg.snoozedTabsGet))
 		return
 	}
 
-	sT, err := h.r.GetSnoozedTab(userId, spaceId, intId)
+	sT, err := h.r.GetSnoozedTab(r.Context(), userId, spaceId, intId)
 
 	if err != nil {
-		if err.Error() == errMsg.snoozedTabsNotFound {
-			http_api.ErrorRes(w, errMsg.snoozedTabsNotFound, http.StatusNotFound)
+		if errors.Is(err, errSnoozedTabNotFound) {
+			http_api.ErrorRes(w, errSnoozedTabNotFound)
 			return
 		}
 		logger.Error("error getting snoozed tab", err)
-		http_api.ErrorRes(w, errMsg.snoozedTabsGet, http.StatusBadGateway)
+		http_api.ErrorRes(w, errs.BadGateway.WithMessage(errMsg.snoozedTabsGet))
 		return
 	}
 
@@ -482,7 +638,7 @@ func (h *spaceHandler) getSnoozedTabsBySpace(w http.ResponseWriter, r *http.Requ
 	spaceId := r.PathValue("spaceId")
 
 	if spaceId == "" {
-		http_api.ErrorRes(w, errMsg.spaceId, http.StatusBadRequest)
+		http_api.ErrorRes(w, errs.BadRequest.WithMessage(errMsg.spaceId))
 		return
 	}
 
@@ -496,16 +652,16 @@ func (h *spaceHandler) getSnoozedTabsBySpace(w http.ResponseWriter, r *http.Requ
 
 	if err != nil {
 		logger.Error("error parsing lastSnoozedTabId", err)
-		http_api.ErrorRes(w, errMsg.snoozedTabsGet, http.StatusBadRequest)
+		http_api.ErrorRes(w, errs.BadRequest.WithMessage(errMsg.snoozedTabsGet))
 		return
 	}
 
 	// return all snoozed tabs for space
-	sT, m, err := h.r.geSnoozedTabsInSpace(userId, spaceId, 12, lastSnoozedTabId)
+	sT, m, err := h.r.geSnoozedTabsInSpace(r.Context(), userId, spaceId, 12, lastSnoozedTabId)
 
 	if err != nil {
 		logger.Error("error getting snoozed tabs for space", err)
-		http_api.ErrorRes(w, errMsg.snoozedTabsGet, http.StatusBadGateway)
+		http_api.ErrorRes(w, errs.BadGateway.WithMessage(errMsg.snoozedTabsGet))
 		return
 	}
 	http_api.SuccessResDataWithMetadata(w, sT, m)
@@ -524,20 +680,20 @@ func (h spaceHandler) getSnoozedTabByUser(w http.ResponseWriter, r *http.Request
 
 	if err != nil {
 		logger.Error("error parsing lastSnoozedTabId", err)
-		http_api.ErrorRes(w, errMsg.snoozedTabsGet, http.StatusBadRequest)
+		http_api.ErrorRes(w, errs.BadRequest.WithMessage(errMsg.snoozedTabsGet))
 		return
 	}
 
-	sT, m, err := h.r.getAllSnoozedTabsByUser(userId, lastSnoozedTabId)
+	sT, m, err := h.r.getAllSnoozedTabsByUser(r.Context(), userId, lastSnoozedTabId)
 
 	if err != nil {
-		if err.Error() == errMsg.snoozedTabsNotFound {
+		if errors.Is(err, errSnoozedTabNotFound) {
 			http_api.SuccessResData(w, []SnoozedTab{})
 
 			return
 		}
 		logger.Error("error getting snoozed tabs for user", err)
-		http_api.ErrorRes(w, errMsg.snoozedTabsGet, http.StatusBadGateway)
+		http_api.ErrorRes(w, errs.BadGateway.WithMessage(errMsg.snoozedTabsGet))
 		return
 	}
 
@@ -549,30 +705,27 @@ func (h *spaceHandler) switchSnoozedTabSpace(w http.ResponseWriter, r *http.Requ
 	spaceId := r.PathValue("spaceId")
 
 	if spaceId == "" {
-		http_api.ErrorRes(w, errMsg.spaceId, http.StatusBadRequest)
+		http_api.ErrorRes(w, errs.BadRequest.WithMessage(errMsg.spaceId))
 		return
 	}
 
-	var data struct {
-		NewSpaceId string `json:"newSpaceId"`
-	}
-
-	err := json.NewDecoder(r.Body).Decode(&data)
+	data, err := http_api.DecodeAndValidate[switchSpaceReq](w, r)
 
 	if err != nil {
 		logger.Error("error decoding data", err)
-		http_api.ErrorRes(w, errMsg.snoozedTabsSwitchSpace, http.StatusBadRequest)
+		http_api.ErrorRes(w, err)
 		return
 	}
 
-	err = h.r.switchSnoozedTabSpace(userId, spaceId, data.NewSpaceId)
+	err = h.r.switchSnoozedTabSpace(r.Context(), userId, spaceId, data.NewSpaceId)
 
 	if err != nil {
 		logger.Error("error switching snoozed tab space", err)
-		http_api.ErrorRes(w, errMsg.snoozedTabsSwitchSpace, http.StatusBadGateway)
+		http_api.ErrorRes(w, errs.BadGateway.WithMessage(errMsg.snoozedTabsSwitchSpace))
 		return
 	}
 
+	http_api.SuccessResMsg(w, "snoozed tab space switched successfully")
 }
 
 func (h *spaceHandler) DeleteSnoozedTab(w http.ResponseWriter, r *http.Request) {
@@ -581,7 +734,7 @@ func (h *spaceHandler) DeleteSnoozedTab(w http.ResponseWriter, r *http.Request)
 	snoozedAt := r.PathValue("id")
 
 	if spaceId == "" || snoozedAt == "" {
-		http_api.ErrorRes(w, errMsg.spaceId, http.StatusBadRequest)
+		http_api.ErrorRes(w, errs.BadRequest.WithMessage(errMsg.spaceId))
 		return
 	}
 
@@ -589,15 +742,15 @@ func (h *spaceHandler) DeleteSnoozedTab(w http.ResponseWriter, r *http.Request)
 
 	if err != nil {
 		logger.Error("error parsing snoozedAt", err)
-		http_api.ErrorRes(w, errMsg.snoozedTabsDelete, http.StatusBadRequest)
+		http_api.ErrorRes(w, errs.BadRequest.WithMessage(errMsg.snoozedTabsDelete))
 		return
 	}
 
-	err = h.r.DeleteSnoozedTab(userId, spaceId, snoozedAtInt)
+	err = h.r.DeleteSnoozedTab(r.Context(), userId, spaceId, snoozedAtInt)
 
 	if err != nil {
 		logger.Error("error deleting snoozed tab", err)
-		http_api.ErrorRes(w, errMsg.snoozedTabsDelete, http.StatusBadGateway)
+		http_api.ErrorRes(w, errs.BadGateway.WithMessage(errMsg.snoozedTabsDelete))
 		return
 	}
 
@@ -607,10 +760,10 @@ func (h *spaceHandler) DeleteSnoozedTab(w http.ResponseWriter, r *http.Request)
 		SubEvent:     events.SubEventDelete,
 	})
 
-	err = h.notificationQueue.AddMessage(event)
+	err = h.notificationQueue.AddMessage(r.Context(), event)
 
 	if err != nil {
-		http_api.ErrorRes(w, errMsg.snoozedTabsCreate, http.StatusBadGateway)
+		http_api.ErrorRes(w, errs.BadGateway.WithMessage(errMsg.snoozedTabsCreate))
 		return
 	}
 
@@ -620,19 +773,23 @@ func (h *spaceHandler) DeleteSnoozedTab(w http.ResponseWriter, r *http.Request)
 //* helpers
 
 // middleware to get userId from jwt token present in req cookies
-func newUserIdMiddleware() http_api.Handler {
-	return func(w http.ResponseWriter, r *http.Request) {
+func newUserIdMiddleware() http_api.Middleware {
+	return func(next http_api.Handler) http_api.Handler {
+		return func(w http.ResponseWriter, r *http.Request) {
 
-		// get userId from jwt token
-		userId := r.Header.Get("UserId")
+			// get userId from jwt token
+			userId := r.Header.Get("UserId")
 
-		if userId == "" {
-			http.Redirect(w, r, "/logout", http.StatusTemporaryRedirect)
-			w.Header().Add("Error", "userId not found")
-			return
-		}
+			if userId == "" {
+				w.Header().Add("Error", "userId not found")
+				http.Redirect(w, r, "/logout", http.StatusTemporaryRedirect)
+				return
+			}
 
-		r.SetPathValue("userId", userId)
+			r.SetPathValue("userId", userId)
+
+			next(w, r)
+		}
 	}
 }
 
@@ -643,7 +800,7 @@ func checkForDataConflict(currentTabs []tab, tabs []tab) error {
 
 	if len(tabs) < len(currentTabs) {
 		// some tabs were removed, raise conflict
-		return errors.New(errMsg.dataConflict)
+		return errDataConflict
 	}
 
 	// auto resolve conflict if new tabs were added and without removing any other
@@ -659,7 +816,7 @@ func checkForDataConflict(currentTabs []tab, tabs []tab) error {
 		}
 		if !found {
 			// tab not found
-			return errors.New(errMsg.dataConflict)
+			return errDataConflict
 		}
 	}
 
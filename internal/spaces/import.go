@@ -0,0 +1,601 @@
+package spaces
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/manishMandal02/tabsflow-backend/pkg/utils"
+	"golang.org/x/net/html"
+)
+
+type importFormat string
+
+const (
+	importFormatBookmarksHTML importFormat = "bookmarks_html"
+	importFormatOneTab        importFormat = "onetab"
+	importFormatSessionBuddy  importFormat = "session_buddy"
+	importFormatToby          importFormat = "toby"
+)
+
+// max spaces created by a single import
+const maxImportSpaces = 100
+
+// exports of the other tools can be large, e.g. bookmarks html
+const maxImportBodySize = 5 << 20
+
+// favicons (e.g. data URIs) larger than this are dropped, to keep the tabs item small
+const maxImportIconSize = 2048
+
+// tabs with a larger url are skipped & longer titles are truncated, to keep the tabs item small
+const (
+	maxImportURLSize   = 8 << 10
+	maxImportTitleSize = 512
+)
+
+// the tabs of a space are stored in a single item (max 400KB), the spaces with larger tabs
+// (e.g. a big bookmarks folder) are split into several spaces
+const maxImportTabsSize = 300 << 10
+
+// size of a tab's attribute names, index & group id in the tabs item
+const importTabOverhead = 64
+
+type importReq struct {
+	Format importFormat `json:"format" validate:"required,oneof=bookmarks_html onetab session_buddy toby"`
+	Data   string       `json:"data" validate:"required"`
+	DryRun bool         `json:"dryRun"`
+}
+
+// space parsed from an import, with its tabs & groups
+type importedSpace struct {
+	Space  space   `json:"space"`
+	Tabs   []tab   `json:"tabs"`
+	Groups []group `json:"groups"`
+	// title of the folder/group, if it was split into several spaces
+	SplitFrom string `json:"splitFrom,omitempty"`
+	// tabs skipped for their url size
+	SkippedTabs int `json:"skippedTabs,omitempty"`
+}
+
+type importStatus string
+
+const (
+	importStatusPreview  importStatus = "preview"
+	importStatusImported importStatus = "imported"
+	importStatusFailed   importStatus = "failed"
+)
+
+type importSpaceResult struct {
+	Id          string       `json:"id"`
+	Title       string       `json:"title"`
+	Tabs        int          `json:"tabs"`
+	Groups      int          `json:"groups"`
+	SplitFrom   string       `json:"splitFrom,omitempty"`
+	SkippedTabs int          `json:"skippedTabs,omitempty"`
+	Status      importStatus `json:"status"`
+}
+
+// import progress, returned after the import (or preview for dry run)
+type importReport struct {
+	DryRun   bool                `json:"dryRun"`
+	Total    int                 `json:"total"`
+	Imported int                 `json:"imported"`
+	Failed   int                 `json:"failed"`
+	Spaces   []importSpaceResult `json:"spaces"`
+	Preview  []importedSpace     `json:"preview,omitempty"`
+}
+
+// parses the exported data of the given format into spaces with their tabs & groups
+func parseImport(format importFormat, data string) ([]importedSpace, error) {
+	var builders []*spaceBuilder
+	var err error
+
+	switch format {
+	case importFormatBookmarksHTML:
+		builders, err = parseBookmarksHTML(data)
+	case importFormatOneTab:
+		builders, err = parseOneTab(data)
+	case importFormatSessionBuddy:
+		builders, err = parseSessionBuddy(data)
+	case importFormatToby:
+		builders, err = parseToby(data)
+	default:
+		return nil, errImportFormat
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	var spaces []importedSpace
+
+	for _, b := range builders {
+		if len(b.tabs) < 1 {
+			continue
+		}
+		spaces = append(spaces, b.build()...)
+	}
+
+	if len(spaces) < 1 {
+		return nil, errImportEmpty
+	}
+
+	if len(spaces) > maxImportSpaces {
+		return nil, errImportLimit
+	}
+
+	return spaces, nil
+}
+
+// * parsers
+
+// Netscape bookmark file (exported by all major browsers)
+// top level folders are imported as spaces and their sub folders as groups,
+// bookmarks outside of any folder are added to a separate space
+func parseBookmarksHTML(data string) ([]*spaceBuilder, error) {
+	l := newSpaceBuilderList()
+
+	z := html.NewTokenizer(strings.NewReader(data))
+
+	// names of the open folders, the first one is the root list
+	var folders []string
+
+	folderName := ""
+	inFolderName := false
+
+	var link *importedTab
+
+	for {
+		tt := z.Next()
+
+		switch tt {
+		case html.ErrorToken:
+			if z.Err() == io.EOF {
+				return l.builders, nil
+			}
+			return nil, fmt.Errorf("%w: %v", errImportParse, z.Err())
+
+		case html.StartTagToken:
+			name, hasAttr := z.TagName()
+
+			switch string(name) {
+			case "dl":
+				folders = append(folders, strings.TrimSpace(folderName))
+				folderName = ""
+			case "h3":
+				inFolderName = true
+				folderName = ""
+			case "a":
+				link = &importedTab{}
+
+				var iconURI, icon string
+
+				for hasAttr {
+					var k, v []byte
+					k, v, hasAttr = z.TagAttr()
+
+					switch string(k) {
+					case "href":
+						link.url = string(v)
+					case "icon_uri":
+						iconURI = string(v)
+					case "icon":
+						icon = string(v)
+					}
+				}
+
+				link.icon = iconURI
+
+				if link.icon == "" {
+					link.icon = icon
+				}
+			}
+
+		case html.TextToken:
+			if inFolderName {
+				folderName += string(z.Text())
+			}
+			if link != nil {
+				link.title += string(z.Text())
+			}
+
+		case html.EndTagToken:
+			name, _ := z.TagName()
+
+			switch string(name) {
+			case "dl":
+				if len(folders) > 0 {
+					folders = folders[:len(folders)-1]
+				}
+			case "h3":
+				inFolderName = false
+			case "a":
+				if link == nil {
+					continue
+				}
+
+				spaceTitle := "Bookmarks"
+				groupName := ""
+
+				if len(folders) > 1 {
+					spaceTitle = folders[1]
+				}
+
+				if len(folders) > 2 {
+					groupName = folders[2]
+				}
+
+				l.get(spaceTitle).addTab(link, groupName)
+
+				link = nil
+			}
+		}
+	}
+}
+
+// OneTab export, a `url | title` per line with the tab groups separated by a blank line
+// each tab group is imported as a space
+func parseOneTab(data string) ([]*spaceBuilder, error) {
+	var builders []*spaceBuilder
+
+	var b *spaceBuilder
+
+	s := bufio.NewScanner(strings.NewReader(data))
+
+	// long lines with data URLs
+	s.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+
+	for s.Scan() {
+		line := strings.TrimSpace(s.Text())
+
+		if line == "" {
+			b = nil
+			continue
+		}
+
+		if b == nil {
+			b = newSpaceBuilder(fmt.Sprintf("OneTab %d", len(builders)+1))
+			builders = append(builders, b)
+		}
+
+		url, title, _ := strings.Cut(line, " | ")
+
+		b.addTab(&importedTab{
+			url:   strings.TrimSpace(url),
+			title: strings.TrimSpace(title),
+		}, "")
+	}
+
+	if err := s.Err(); err != nil {
+		return nil, fmt.Errorf("%w: %v", errImportParse, err)
+	}
+
+	return builders, nil
+}
+
+type sessionBuddyTab struct {
+	URL        string `json:"url"`
+	Title      string `json:"title"`
+	FavIconURL string `json:"favIconUrl"`
+}
+
+type sessionBuddyExport struct {
+	// current export format
+	Collections []struct {
+		Title   string `json:"title"`
+		Folders []struct {
+			Title string            `json:"title"`
+			Links []sessionBuddyTab `json:"links"`
+		} `json:"folders"`
+	} `json:"collections"`
+	// legacy export format
+	Sessions []struct {
+		Name    string `json:"name"`
+		Windows []struct {
+			Tabs []sessionBuddyTab `json:"tabs"`
+		} `json:"windows"`
+	} `json:"sessions"`
+}
+
+// Session Buddy JSON export, collections (or sessions) are imported as spaces,
+// their folders (or windows) as groups if there are more than one
+func parseSessionBuddy(data string) ([]*spaceBuilder, error) {
+	var e sessionBuddyExport
+
+	err := json.Unmarshal([]byte(data), &e)
+
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", errImportParse, err)
+	}
+
+	var builders []*spaceBuilder
+
+	for i, c := range e.Collections {
+		b := newSpaceBuilder(titleOrDefault(c.Title, fmt.Sprintf("Collection %d", i+1)))
+
+		for j, f := range c.Folders {
+			groupName := ""
+
+			if len(c.Folders) > 1 {
+				groupName = titleOrDefault(f.Title, fmt.Sprintf("Folder %d", j+1))
+			}
+
+			for _, t := range f.Links {
+				b.addTab(&importedTab{url: t.URL, title: t.Title, icon: t.FavIconURL}, groupName)
+			}
+		}
+
+		builders = append(builders, b)
+	}
+
+	for i, s := range e.Sessions {
+		b := newSpaceBuilder(titleOrDefault(s.Name, fmt.Sprintf("Session %d", i+1)))
+
+		for j, w := range s.Windows {
+			groupName := ""
+
+			if len(s.Windows) > 1 {
+				groupName = fmt.Sprintf("Window %d", j+1)
+			}
+
+			for _, t := range w.Tabs {
+				b.addTab(&importedTab{url: t.URL, title: t.Title, icon: t.FavIconURL}, groupName)
+			}
+		}
+
+		builders = append(builders, b)
+	}
+
+	return builders, nil
+}
+
+type tobyExport struct {
+	Lists []struct {
+		Title string `json:"title"`
+		Cards []struct {
+			Title       string `json:"title"`
+			CustomTitle string `json:"customTitle"`
+			URL         string `json:"url"`
+			FavIconURL  string `json:"favIconUrl"`
+		} `json:"cards"`
+	} `json:"lists"`
+}
+
+// Toby JSON export, each list is imported as a space
+func parseToby(data string) ([]*spaceBuilder, error) {
+	var e tobyExport
+
+	err := json.Unmarshal([]byte(data), &e)
+
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", errImportParse, err)
+	}
+
+	var builders []*spaceBuilder
+
+	for i, l := range e.Lists {
+		b := newSpaceBuilder(titleOrDefault(l.Title, fmt.Sprintf("List %d", i+1)))
+
+		for _, c := range l.Cards {
+			b.addTab(&importedTab{
+				url:   c.URL,
+				title: titleOrDefault(c.CustomTitle, c.Title),
+				icon:  c.FavIconURL,
+			}, "")
+		}
+
+		builders = append(builders, b)
+	}
+
+	return builders, nil
+}
+
+// * builders
+
+type importedTab struct {
+	url   string
+	title string
+	icon  string
+}
+
+// colors supported for tab groups
+var groupThemes = []string{"grey", "blue", "red", "yellow", "green", "pink", "purple", "cyan", "orange"}
+
+type spaceBuilder struct {
+	title    string
+	tabs     []tab
+	groups   []group
+	groupIds map[string]int
+	// tabs skipped for their url size
+	skipped int
+}
+
+func newSpaceBuilder(title string) *spaceBuilder {
+	return &spaceBuilder{
+		title:    title,
+		groupIds: map[string]int{},
+	}
+}
+
+// adds the tab to the space, creating the group if not present (ungrouped if empty group name)
+func (b *spaceBuilder) addTab(t *importedTab, groupName string) {
+	url := strings.TrimSpace(t.url)
+
+	if !isImportableURL(url) {
+		return
+	}
+
+	if len(url) > maxImportURLSize {
+		b.skipped++
+		return
+	}
+
+	title := strings.TrimSpace(t.title)
+
+	if title == "" {
+		title = url
+	}
+
+	if r := []rune(title); len(r) > maxImportTitleSize {
+		title = string(r[:maxImportTitleSize])
+	}
+
+	icon := t.icon
+
+	if len(icon) > maxImportIconSize {
+		icon = ""
+	}
+
+	groupId := 0
+
+	if groupName = strings.TrimSpace(groupName); groupName != "" {
+		id, ok := b.groupIds[groupName]
+
+		if !ok {
+			id = len(b.groups) + 1
+
+			b.groups = append(b.groups, group{
+				Id:        id,
+				Name:      groupName,
+				Theme:     groupThemes[len(b.groups)%len(groupThemes)],
+				Collapsed: true,
+			})
+
+			b.groupIds[groupName] = id
+		}
+
+		groupId = id
+	}
+
+	b.tabs = append(b.tabs, tab{
+		URL:     url,
+		Title:   title,
+		Index:   len(b.tabs),
+		Icon:    icon,
+		GroupId: groupId,
+	})
+}
+
+// the space with its tabs & groups, split into parts if the tabs don't fit in an item
+func (b *spaceBuilder) build() []importedSpace {
+	parts := splitTabs(b.tabs, maxImportTabsSize)
+
+	spaces := make([]importedSpace, 0, len(parts))
+
+	for i, tabs := range parts {
+		s := importedSpace{
+			Space: space{
+				Id:        utils.GenerateID(),
+				Title:     b.title,
+				Theme:     defaultSpace.Theme,
+				IsSaved:   true,
+				Emoji:     defaultSpace.Emoji,
+				UpdatedAt: time.Now().UnixMilli(),
+			},
+			Tabs:   tabs,
+			Groups: []group{},
+		}
+
+		if len(parts) > 1 {
+			s.Space.Title = fmt.Sprintf("%v (%d/%d)", b.title, i+1, len(parts))
+			s.SplitFrom = b.title
+		}
+
+		// only the groups of the part's tabs
+		for _, g := range b.groups {
+			if slices.ContainsFunc(tabs, func(t tab) bool { return t.GroupId == g.Id }) {
+				s.Groups = append(s.Groups, g)
+			}
+		}
+
+		spaces = append(spaces, s)
+	}
+
+	// reported on the first part
+	if len(spaces) > 0 {
+		spaces[0].SkippedTabs = b.skipped
+	}
+
+	return spaces
+}
+
+// splits the tabs into parts of at most maxSize (estimated item size), re-indexed in each part
+func splitTabs(tabs []tab, maxSize int) [][]tab {
+	var parts [][]tab
+
+	var part []tab
+	size := 0
+
+	for _, t := range tabs {
+		tSize := len(t.URL) + len(t.Title) + len(t.Icon) + importTabOverhead
+
+		if len(part) > 0 && size+tSize > maxSize {
+			parts = append(parts, part)
+			part, size = nil, 0
+		}
+
+		t.Index = len(part)
+		part = append(part, t)
+		size += tSize
+	}
+
+	if len(part) > 0 {
+		parts = append(parts, part)
+	}
+
+	return parts
+}
+
+// spaces by title, in the order they were first added
+type spaceBuilderList struct {
+	builders []*spaceBuilder
+	byTitle  map[string]*spaceBuilder
+}
+
+func newSpaceBuilderList() *spaceBuilderList {
+	return &spaceBuilderList{
+		byTitle: map[string]*spaceBuilder{},
+	}
+}
+
+func (l *spaceBuilderList) get(title string) *spaceBuilder {
+	if b, ok := l.byTitle[title]; ok {
+		return b
+	}
+
+	b := newSpaceBuilder(titleOrDefault(title, "Bookmarks"))
+
+	l.byTitle[title] = b
+	l.builders = append(l.builders, b)
+
+	return b
+}
+
+// * helpers
+
+// skips bookmarklets & browser internal urls
+func isImportableURL(url string) bool {
+	scheme, _, ok := strings.Cut(url, ":")
+
+	if !ok || scheme == "" {
+		return false
+	}
+
+	switch strings.ToLower(scheme) {
+	case "javascript", "place", "data":
+		return false
+	}
+
+	return true
+}
+
+func titleOrDefault(title, defaultTitle string) string {
+	if t := strings.TrimSpace(title); t != "" {
+		return t
+	}
+	return defaultTitle
+}
@@ -0,0 +1,225 @@
+package spaces
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+type wantSpace struct {
+	title  string
+	tabs   []string
+	groups []string
+}
+
+func TestParseImport(t *testing.T) {
+
+	tests := []struct {
+		name    string
+		format  importFormat
+		data    string
+		want    []wantSpace
+		wantErr error
+	}{
+		{
+			name:   "bookmarks html",
+			format: importFormatBookmarksHTML,
+			data: `<!DOCTYPE NETSCAPE-Bookmark-file-1>
+<META HTTP-EQUIV="Content-Type" CONTENT="text/html; charset=UTF-8">
+<TITLE>Bookmarks</TITLE>
+<H1>Bookmarks</H1>
+<DL><p>
+    <DT><A HREF="https://loose.com" ADD_DATE="1">Loose</A>
+    <DT><H3 ADD_DATE="1">Work</H3>
+    <DL><p>
+        <DT><A HREF="https://work.com" ICON="data:image/png;base64,AAA">Work &amp; Co</A>
+        <DT><H3>Docs</H3>
+        <DL><p>
+            <DT><A HREF="https://docs.com">Docs</A>
+            <DT><H3>Nested</H3>
+            <DL><p>
+                <DT><A HREF="https://nested.com">Nested</A>
+            </DL><p>
+        </DL><p>
+        <DT><A HREF="javascript:void(0)">Bookmarklet</A>
+    </DL><p>
+    <DT><H3>Empty</H3>
+    <DL><p>
+    </DL><p>
+</DL><p>`,
+			want: []wantSpace{
+				{title: "Bookmarks", tabs: []string{"https://loose.com"}},
+				{title: "Work", tabs: []string{"https://work.com", "https://docs.com", "https://nested.com"}, groups: []string{"Docs"}},
+			},
+		},
+		{
+			name:   "onetab",
+			format: importFormatOneTab,
+			data: `https://a.com | A
+https://b.com | B | with pipe
+
+https://c.com
+`,
+			want: []wantSpace{
+				{title: "OneTab 1", tabs: []string{"https://a.com", "https://b.com"}},
+				{title: "OneTab 2", tabs: []string{"https://c.com"}},
+			},
+		},
+		{
+			name:   "session buddy collections",
+			format: importFormatSessionBuddy,
+			data: `{"collections":[{"title":"Research","folders":[
+				{"title":"","links":[{"url":"https://a.com","title":"A","favIconUrl":"https://a.com/favicon.ico"}]},
+				{"title":"Papers","links":[{"url":"https://b.com","title":"B"}]}]}]}`,
+			want: []wantSpace{
+				{title: "Research", tabs: []string{"https://a.com", "https://b.com"}, groups: []string{"Folder 1", "Papers"}},
+			},
+		},
+		{
+			name:   "session buddy legacy sessions",
+			format: importFormatSessionBuddy,
+			data:   `{"sessions":[{"windows":[{"tabs":[{"url":"https://a.com","title":"A"}]}]}]}`,
+			want: []wantSpace{
+				{title: "Session 1", tabs: []string{"https://a.com"}},
+			},
+		},
+		{
+			name:   "toby",
+			format: importFormatToby,
+			data:   `{"version":3,"lists":[{"title":"Reading","cards":[{"title":"A","customTitle":"My A","url":"https://a.com"}]},{"title":"Empty","cards":[]}]}`,
+			want: []wantSpace{
+				{title: "Reading", tabs: []string{"https://a.com"}},
+			},
+		},
+		{
+			name:    "invalid json",
+			format:  importFormatToby,
+			data:    `{"lists":`,
+			wantErr: errImportParse,
+		},
+		{
+			name:    "no tabs",
+			format:  importFormatOneTab,
+			data:    "\n\n",
+			wantErr: errImportEmpty,
+		},
+		{
+			name:    "invalid format",
+			format:  "pocket",
+			data:    "https://a.com",
+			wantErr: errImportFormat,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := parseImport(tt.format, tt.data)
+
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) {
+					t.Fatalf("parseImport() error = %v, wantErr %v", err, tt.wantErr)
+				}
+				return
+			}
+
+			if err != nil {
+				t.Fatalf("parseImport() unexpected error = %v", err)
+			}
+
+			if len(got) != len(tt.want) {
+				t.Fatalf("parseImport() got %v spaces, want %v", len(got), len(tt.want))
+			}
+
+			for i, s := range got {
+				var tabs, groups []string
+
+				for _, t := range s.Tabs {
+					tabs = append(tabs, t.URL)
+				}
+
+				for _, g := range s.Groups {
+					groups = append(groups, g.Name)
+				}
+
+				if s.Space.Title != tt.want[i].title {
+					t.Errorf("space[%d] title = %v, want %v", i, s.Space.Title, tt.want[i].title)
+				}
+
+				if !reflect.DeepEqual(tabs, tt.want[i].tabs) {
+					t.Errorf("space[%d] tabs = %v, want %v", i, tabs, tt.want[i].tabs)
+				}
+
+				if !reflect.DeepEqual(groups, tt.want[i].groups) {
+					t.Errorf("space[%d] groups = %v, want %v", i, groups, tt.want[i].groups)
+				}
+
+				if s.Space.Id == "" || !s.Space.IsSaved {
+					t.Errorf("space[%d] should have an id and be saved", i)
+				}
+			}
+		})
+	}
+}
+
+func TestSpaceBuilderAddTab(t *testing.T) {
+	b := newSpaceBuilder("test")
+
+	b.addTab(&importedTab{url: "https://a.com", title: " ", icon: "https://a.com/favicon.ico"}, "G1")
+	b.addTab(&importedTab{url: "https://b.com", title: "B"}, "G1")
+	b.addTab(&importedTab{url: "https://c.com", title: "C"}, "")
+	b.addTab(&importedTab{url: "not a url", title: "skipped"}, "")
+
+	if len(b.tabs) != 3 || len(b.groups) != 1 {
+		t.Fatalf("got %v tabs & %v groups, want 3 tabs & 1 group", len(b.tabs), len(b.groups))
+	}
+
+	if b.tabs[0].Title != "https://a.com" {
+		t.Errorf("empty title should fallback to url, got %v", b.tabs[0].Title)
+	}
+
+	if b.tabs[0].GroupId != b.groups[0].Id || b.tabs[1].GroupId != b.groups[0].Id || b.tabs[2].GroupId != 0 {
+		t.Errorf("tabs group ids = %v, %v, %v", b.tabs[0].GroupId, b.tabs[1].GroupId, b.tabs[2].GroupId)
+	}
+
+	if b.tabs[2].Index != 2 {
+		t.Errorf("tab index = %v, want 2", b.tabs[2].Index)
+	}
+}
+
+func TestSpaceBuilderSplit(t *testing.T) {
+	b := newSpaceBuilder("Big folder")
+
+	b.addTab(&importedTab{url: "https://a.com/" + strings.Repeat("a", maxImportURLSize), title: "too long"}, "")
+
+	const url = "https://a.com/"
+
+	path := strings.Repeat("a", 8000-len(url))
+
+	perPart := maxImportTabsSize / (8000 + 1 + importTabOverhead)
+
+	for i := range perPart + 2 {
+		b.addTab(&importedTab{url: url + path, title: "T"}, fmt.Sprintf("G%d", i%2))
+	}
+
+	spaces := b.build()
+
+	if len(spaces) != 2 || len(spaces[0].Tabs) != perPart || len(spaces[1].Tabs) != 2 {
+		t.Fatalf("build() got %v spaces, want 2 spaces with %v & 2 tabs", len(spaces), perPart)
+	}
+
+	for i, s := range spaces {
+		if want := fmt.Sprintf("Big folder (%d/2)", i+1); s.Space.Title != want || s.SplitFrom != "Big folder" {
+			t.Errorf("space[%d] title = %v split from %v, want %v", i, s.Space.Title, s.SplitFrom, want)
+		}
+
+		if s.Tabs[0].Index != 0 || len(s.Groups) != 2 {
+			t.Errorf("space[%d] first tab index = %v & %v groups, want re-indexed tabs & their groups", i, s.Tabs[0].Index, len(s.Groups))
+		}
+	}
+
+	if spaces[0].SkippedTabs != 1 || spaces[1].SkippedTabs != 0 {
+		t.Errorf("skipped tabs = %v, %v, want 1 on the first part", spaces[0].SkippedTabs, spaces[1].SkippedTabs)
+	}
+}
@@ -4,6 +4,7 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"slices"
 	"strconv"
 	"sync"
 	"time"
@@ -19,22 +20,27 @@ import (
 )
 
 type spaceRepository interface {
-	createSpace(userId string, s *space) error
-	getSpaceById(userId, spaceId string) (*space, error)
-	getSpacesByUser(userId string) ([]space, error)
-	deleteSpace(userId, spaceId, backupSpaceId string) error
-	setActiveTabIndex(userId, spaceId string, tabIndex int64) error
-	getActiveTabIndex(userId, spaceId string) (int64, error)
-	setTabsForSpace(userId, spaceId string, t []tab, m *http_api.Metadata) error
-	setGroupsForSpace(userId, spaceId string, g []group, m *http_api.Metadata) error
-	getTabsForSpace(userId, spaceId string) ([]tab, *http_api.Metadata, error)
-	getGroupsForSpace(userId, spaceId string) ([]group, *http_api.Metadata, error)
-	addSnoozedTab(userId, spaceId string, t *SnoozedTab) error
-	getAllSnoozedTabsByUser(userId string, lastSnoozedTabID int64) ([]SnoozedTab, *http_api.Metadata, error)
-	geSnoozedTabsInSpace(userId, spaceId string, limit int32, lastSnoozedTabId int64) ([]SnoozedTab, *http_api.Metadata, error)
-	GetSnoozedTab(userId, spaceId string, snoozedAt int64) (*SnoozedTab, error)
-	switchSnoozedTabSpace(userId, spaceId, newSpaceId string) error
-	DeleteSnoozedTab(userId, spaceId string, snoozedAt int64) error
+	createSpace(ctx context.Context, userId string, s *space) error
+	getSpaceById(ctx context.Context, userId, spaceId string) (*space, error)
+	getSpacesByUser(ctx context.Context, userId string) ([]space, error)
+	deleteSpace(ctx context.Context, userId, spaceId, backupSpaceId string) error
+	setSpacesOrder(ctx context.Context, userId string, spaceIds []string) error
+	setSpacePinned(ctx context.Context, userId, spaceId string, isPinned bool) error
+	setSpacesArchived(ctx context.Context, userId string, spaceIds []string, isArchived bool) error
+	getDeleteUnsavedSpacesPref(ctx context.Context, userId string) (string, error)
+	importSpace(ctx context.Context, userId string, s *importedSpace) error
+	setActiveTabIndex(ctx context.Context, userId, spaceId string, tabIndex int64) error
+	getActiveTabIndex(ctx context.Context, userId, spaceId string) (int64, error)
+	setTabsForSpace(ctx context.Context, userId, spaceId string, t []tab, m *http_api.Metadata) error
+	setGroupsForSpace(ctx context.Context, userId, spaceId string, g []group, m *http_api.Metadata) error
+	getTabsForSpace(ctx context.Context, userId, spaceId string) ([]tab, *http_api.Metadata, error)
+	getGroupsForSpace(ctx context.Context, userId, spaceId string) ([]group, *http_api.Metadata, error)
+	addSnoozedTab(ctx context.Context, userId, spaceId string, t *SnoozedTab) error
+	getAllSnoozedTabsByUser(ctx context.Context, userId string, lastSnoozedTabID int64) ([]SnoozedTab, *http_api.Metadata, error)
+	geSnoozedTabsInSpace(ctx context.Context, userId, spaceId string, limit int32, lastSnoozedTabId int64) ([]SnoozedTab, *http_api.Metadata, error)
+	GetSnoozedTab(ctx context.Context, userId, spaceId string, snoozedAt int64) (*SnoozedTab, error)
+	switchSnoozedTabSpace(ctx context.Context, userId, spaceId, newSpaceId string) error
+	DeleteSnoozedTab(ctx context.Context, userId, spaceId string, snoozedAt int64) error
 }
 
 type spaceRepo struct {
@@ -47,7 +53,7 @@ func NewSpaceRepository(db *db.DDB) spaceRepository {
 	}
 }
 
-func (r spaceRepo) createSpace(userId string, s *space) error {
+func (r spaceRepo) createSpace(ctx context.Context, userId string, s *space) error {
 	av, err := attributevalue.MarshalMap(s)
 
 	if err != nil {
@@ -59,7 +65,7 @@ func (r spaceRepo) createSpace(userId string, s *space) error {
 	av[db.SK_NAME] = &types.AttributeValueMemberS{Value: db.SORT_KEY.Space(s.Id)}
 	av["UpdatedAt"] = &types.AttributeValueMemberN{Value: strconv.FormatInt(s.UpdatedAt, 10)}
 
-	_, err = r.db.Client.PutItem(context.TODO(), &dynamodb.PutItemInput{
+	_, err = r.db.Client.PutItem(ctx, &dynamodb.PutItemInput{
 		TableName: &r.db.TableName,
 		Item:      av,
 	})
@@ -72,14 +78,14 @@ func (r spaceRepo) createSpace(userId string, s *space) error {
 	return nil
 }
 
-func (r *spaceRepo) getSpaceById(userId, spaceId string) (*space, error) {
+func (r *spaceRepo) getSpaceById(ctx context.Context, userId, spaceId string) (*space, error) {
 
 	key := map[string]types.AttributeValue{
 		db.PK_NAME: &types.AttributeValueMemberS{Value: userId},
 		db.SK_NAME: &types.AttributeValueMemberS{Value: db.SORT_KEY.Space(spaceId)},
 	}
 
-	response, err := r.db.Client.GetItem(context.TODO(), &dynamodb.GetItemInput{
+	response, err := r.db.Client.GetItem(ctx, &dynamodb.GetItemInput{
 		TableName: &r.db.TableName,
 		Key:       key,
 	})
@@ -90,7 +96,7 @@ func (r *spaceRepo) getSpaceById(userId, spaceId string) (*space, error) {
 	}
 
 	if len(response.Item) == 0 {
-		return nil, errors.New(errMsg.spaceNotFound)
+		return nil, errSpaceNotFound
 	}
 
 	s := &space{}
@@ -109,7 +115,7 @@ func (r *spaceRepo) getSpaceById(userId, spaceId string) (*space, error) {
 	return s, nil
 }
 
-func (r *spaceRepo) getSpacesByUser(userId string) ([]space, error) {
+func (r *spaceRepo) getSpacesByUser(ctx context.Context, userId string) ([]space, error) {
 
 	key := expression.KeyAnd(expression.Key(db.PK_NAME).Equal(expression.Value(userId)), expression.Key(db.SK_NAME).BeginsWith(db.SORT_KEY.Space("")))
 
@@ -119,7 +125,7 @@ func (r *spaceRepo) getSpacesByUser(userId string) ([]space, error) {
 		logger.Errorf("Couldn't build getSpacesByUser expression for userId: %v. \n[Error]: %v", userId, err)
 		return nil, err
 	}
-	response, err := r.db.Client.Query(context.TODO(), &dynamodb.QueryInput{
+	response, err := r.db.Client.Query(ctx, &dynamodb.QueryInput{
 		TableName:                 &r.db.TableName,
 		ExpressionAttributeNames:  expr.Names(),
 		ExpressionAttributeValues: expr.Values(),
@@ -132,7 +138,7 @@ func (r *spaceRepo) getSpacesByUser(userId string) ([]space, error) {
 	}
 
 	if len(response.Items) < 1 {
-		return nil, errors.New(errMsg.spaceNotFound)
+		return nil, errSpaceNotFound
 	}
 
 	spaces := []space{}
@@ -147,7 +153,7 @@ func (r *spaceRepo) getSpacesByUser(userId string) ([]space, error) {
 	return spaces, nil
 }
 
-func (r *spaceRepo) deleteSpace(userId, spaceId, backupSpaceId string) error {
+func (r *spaceRepo) deleteSpace(ctx context.Context, userId, spaceId, backupSpaceId string) error {
 
 	var transactItems []types.TransactWriteItem
 
@@ -179,7 +185,7 @@ func (r *spaceRepo) deleteSpace(userId, spaceId, backupSpaceId string) error {
 		})
 	}
 
-	err := r.db.TransactionWriter(transactItems)
+	err := r.db.TransactionWriter(ctx, transactItems)
 
 	if err != nil {
 		logger.Errorf("Couldn't delete space for userId: %v. \n[Error]: %v", userId, err)
@@ -187,9 +193,9 @@ func (r *spaceRepo) deleteSpace(userId, spaceId, backupSpaceId string) error {
 	}
 
 	// move snoozed tabs to backup space
-	err = r.switchSnoozedTabSpace(userId, spaceId, backupSpaceId)
+	err = r.switchSnoozedTabSpace(ctx, userId, spaceId, backupSpaceId)
 
-	if err != nil {
+	if err != nil && !errors.Is(err, errSnoozedTabNotFound) {
 		logger.Errorf("Couldn't delete space for userId: %v. \n[Error]: %v", userId, err)
 		return err
 	}
@@ -197,13 +203,186 @@ func (r *spaceRepo) deleteSpace(userId, spaceId, backupSpaceId string) error {
 	return nil
 }
 
+// creates the imported space with its tabs & groups in a single transaction
+func (r *spaceRepo) importSpace(ctx context.Context, userId string, s *importedSpace) error {
+	items, err := spaceDataItems(userId, &s.Space, s.Tabs, s.Groups)
+
+	if err != nil {
+		logger.Errorf("Couldn't marshal imported space for userId: %v. \n[Error]: %v", userId, err)
+		return err
+	}
+
+	var transactItems []types.TransactWriteItem
+
+	for _, item := range items {
+		transactItems = append(transactItems, types.TransactWriteItem{
+			Put: &types.Put{
+				TableName: &r.db.TableName,
+				Item:      item,
+			},
+		})
+	}
+
+	err = r.db.TransactionWriter(ctx, transactItems)
+
+	if err != nil {
+		logger.Errorf("Couldn't import space for userId: %v. \n[Error]: %v", userId, err)
+		return err
+	}
+
+	return nil
+}
+
+// sets the order of spaces as per their position in spaceIds (1-based), in a single transaction
+// (max db.DDB_MAX_TRANSACTION_SIZE spaces); errSpaceNotFound if any of the spaces doesn't exist
+func (r *spaceRepo) setSpacesOrder(ctx context.Context, userId string, spaceIds []string) error {
+
+	var transactItems []types.TransactWriteItem
+
+	for i, spaceId := range spaceIds {
+		item, err := r.updateSpaceItem(userId, spaceId, expression.Set(expression.Name("Order"), expression.Value(i+1)))
+
+		if err != nil {
+			logger.Errorf("Couldn't build order update for spaceId: %v. \n[Error]: %v", spaceId, err)
+			return err
+		}
+
+		transactItems = append(transactItems, *item)
+	}
+
+	err := r.db.TransactionWriter(ctx, transactItems)
+
+	if err != nil {
+		if isConditionCheckFailed(err) {
+			return errSpaceNotFound
+		}
+		logger.Errorf("Couldn't set spaces order for userId: %v. \n[Error]: %v", userId, err)
+		return err
+	}
+
+	return nil
+}
+
+func (r *spaceRepo) setSpacePinned(ctx context.Context, userId, spaceId string, isPinned bool) error {
+
+	item, err := r.updateSpaceItem(userId, spaceId, expression.Set(expression.Name("IsPinned"), expression.Value(isPinned)))
+
+	if err != nil {
+		logger.Errorf("Couldn't build pin update for spaceId: %v. \n[Error]: %v", spaceId, err)
+		return err
+	}
+
+	_, err = r.db.Client.UpdateItem(ctx, &dynamodb.UpdateItemInput{
+		TableName:                 item.Update.TableName,
+		Key:                       item.Update.Key,
+		ConditionExpression:       item.Update.ConditionExpression,
+		ExpressionAttributeNames:  item.Update.ExpressionAttributeNames,
+		ExpressionAttributeValues: item.Update.ExpressionAttributeValues,
+		UpdateExpression:          item.Update.UpdateExpression,
+	})
+
+	if err != nil {
+		var conditionErr *types.ConditionalCheckFailedException
+		if errors.As(err, &conditionErr) {
+			return errSpaceNotFound
+		}
+		logger.Errorf("Couldn't set pinned for spaceId: %v. \n[Error]: %v", spaceId, err)
+		return err
+	}
+
+	return nil
+}
+
+// archives/un-archives spaces in a single transaction (max db.DDB_MAX_TRANSACTION_SIZE spaces),
+// archived spaces are hidden from the user's spaces list by default
+func (r *spaceRepo) setSpacesArchived(ctx context.Context, userId string, spaceIds []string, isArchived bool) error {
+
+	update := expression.Set(expression.Name("IsArchived"), expression.Value(isArchived))
+
+	if isArchived {
+		update = update.Set(expression.Name("ArchivedAt"), expression.Value(time.Now().UTC().UnixMilli()))
+	} else {
+		update = update.Remove(expression.Name("ArchivedAt"))
+	}
+
+	var transactItems []types.TransactWriteItem
+
+	for _, spaceId := range spaceIds {
+		item, err := r.updateSpaceItem(userId, spaceId, update)
+
+		if err != nil {
+			logger.Errorf("Couldn't build archive update for spaceId: %v. \n[Error]: %v", spaceId, err)
+			return err
+		}
+
+		transactItems = append(transactItems, *item)
+	}
+
+	err := r.db.TransactionWriter(ctx, transactItems)
+
+	if err != nil {
+		if isConditionCheckFailed(err) {
+			return errSpaceNotFound
+		}
+		logger.Errorf("Couldn't set archived for spaces of userId: %v. \n[Error]: %v", userId, err)
+		return err
+	}
+
+	return nil
+}
+
+// transaction cancelled by the condition of an item, e.g. the space doesn't exist
+func isConditionCheckFailed(err error) bool {
+	var txErr *types.TransactionCanceledException
+
+	if !errors.As(err, &txErr) {
+		return false
+	}
+
+	return slices.ContainsFunc(txErr.CancellationReasons, func(r types.CancellationReason) bool {
+		return aws.ToString(r.Code) == "ConditionalCheckFailed"
+	})
+}
+
+// reads the general.deleteUnsavedSpaces preference of the user from P#General item
+func (r *spaceRepo) getDeleteUnsavedSpacesPref(ctx context.Context, userId string) (string, error) {
+	key := map[string]types.AttributeValue{
+		db.PK_NAME: &types.AttributeValueMemberS{Value: userId},
+		db.SK_NAME: &types.AttributeValueMemberS{Value: db.SORT_KEY.P_General},
+	}
+
+	response, err := r.db.Client.GetItem(ctx, &dynamodb.GetItemInput{
+		TableName:            &r.db.TableName,
+		Key:                  key,
+		ProjectionExpression: aws.String("DeleteUnsavedSpaces"),
+	})
+
+	if err != nil {
+		logger.Errorf("Couldn't get general preferences for userId: %v. \n[Error]: %v", userId, err)
+		return "", err
+	}
+
+	var p struct {
+		DeleteUnsavedSpaces string
+	}
+
+	err = attributevalue.UnmarshalMap(response.Item, &p)
+
+	if err != nil {
+		logger.Errorf("Couldn't unmarshal general preferences for userId: %v. \n[Error]: %v", userId, err)
+		return "", err
+	}
+
+	return p.DeleteUnsavedSpaces, nil
+}
+
 // space active tab index
-func (r *spaceRepo) getActiveTabIndex(userId, spaceId string) (int64, error) {
+func (r *spaceRepo) getActiveTabIndex(ctx context.Context, userId, spaceId string) (int64, error) {
 	key := map[string]types.AttributeValue{
 		db.PK_NAME: &types.AttributeValueMemberS{Value: userId},
 		db.SK_NAME: &types.AttributeValueMemberS{Value: db.SORT_KEY.SpaceActiveTab(spaceId)},
 	}
-	response, err := r.db.Client.GetItem(context.TODO(), &dynamodb.GetItemInput{
+	response, err := r.db.Client.GetItem(ctx, &dynamodb.GetItemInput{
 		TableName: &r.db.TableName,
 		Key:       key,
 	})
@@ -214,7 +393,7 @@ func (r *spaceRepo) getActiveTabIndex(userId, spaceId string) (int64, error) {
 	}
 
 	if len(response.Item) == 0 {
-		return 0, errors.New(errMsg.spaceActiveTabIndexGet)
+		return 0, errActiveTabIndexNotFound
 	}
 
 	var activeTabIndex int64
@@ -227,14 +406,14 @@ func (r *spaceRepo) getActiveTabIndex(userId, spaceId string) (int64, error) {
 	return activeTabIndex, nil
 }
 
-func (r *spaceRepo) setActiveTabIndex(userId, spaceId string, activeTabIndex int64) error {
+func (r *spaceRepo) setActiveTabIndex(ctx context.Context, userId, spaceId string, activeTabIndex int64) error {
 	item := map[string]types.AttributeValue{
 		db.PK_NAME:       &types.AttributeValueMemberS{Value: userId},
 		db.SK_NAME:       &types.AttributeValueMemberS{Value: db.SORT_KEY.SpaceActiveTab(spaceId)},
 		"ActiveTabIndex": &types.AttributeValueMemberN{Value: strconv.FormatInt(activeTabIndex, 10)},
 	}
 
-	_, err := r.db.Client.PutItem(context.TODO(), &dynamodb.PutItemInput{
+	_, err := r.db.Client.PutItem(ctx, &dynamodb.PutItemInput{
 		TableName: &r.db.TableName,
 		Item:      item,
 	})
@@ -248,7 +427,7 @@ func (r *spaceRepo) setActiveTabIndex(userId, spaceId string, activeTabIndex int
 }
 
 // groups
-func (r *spaceRepo) setGroupsForSpace(userId, spaceId string, g []group, m *http_api.Metadata) error {
+func (r *spaceRepo) setGroupsForSpace(ctx context.Context, userId, spaceId string, g []group, m *http_api.Metadata) error {
 	groups, err := attributevalue.MarshalList(g)
 
 	if err != nil {
@@ -262,7 +441,7 @@ func (r *spaceRepo) setGroupsForSpace(userId, spaceId string, g []group, m *http
 		"Groups":    &types.AttributeValueMemberL{Value: groups},
 		"UpdatedAt": &types.AttributeValueMemberN{Value: strconv.FormatInt(m.UpdatedAt, 10)},
 	}
-	_, err = r.db.Client.PutItem(context.TODO(), &dynamodb.PutItemInput{
+	_, err = r.db.Client.PutItem(ctx, &dynamodb.PutItemInput{
 		TableName: &r.db.TableName,
 		Item:      item,
 	})
@@ -276,13 +455,13 @@ func (r *spaceRepo) setGroupsForSpace(userId, spaceId string, g []group, m *http
 
 }
 
-func (r *spaceRepo) getGroupsForSpace(userId, spaceId string) ([]group, *http_api.Metadata, error) {
+func (r *spaceRepo) getGroupsForSpace(ctx context.Context, userId, spaceId string) ([]group, *http_api.Metadata, error) {
 	key := map[string]types.AttributeValue{
 		db.PK_NAME: &types.AttributeValueMemberS{Value: userId},
 		db.SK_NAME: &types.AttributeValueMemberS{Value: db.SORT_KEY.GroupsInSpace(spaceId)},
 	}
 
-	response, err := r.db.Client.GetItem(context.TODO(), &dynamodb.GetItemInput{
+	response, err := r.db.Client.GetItem(ctx, &dynamodb.GetItemInput{
 		TableName: &r.db.TableName,
 		Key:       key,
 	})
@@ -293,7 +472,7 @@ func (r *spaceRepo) getGroupsForSpace(userId, spaceId string) ([]group, *http_ap
 	}
 
 	if len(response.Item) == 0 {
-		return nil, nil, errors.New(errMsg.groupsGet)
+		return nil, nil, errGroupsNotFound
 	}
 
 	groupsAttr, ok := response.Item["Groups"]
@@ -330,7 +509,7 @@ func (r *spaceRepo) getGroupsForSpace(userId, spaceId string) ([]group, *http_ap
 }
 
 // tabs
-func (r *spaceRepo) setTabsForSpace(userId, spaceId string, t []tab, m *http_api.Metadata) error {
+func (r *spaceRepo) setTabsForSpace(ctx context.Context, userId, spaceId string, t []tab, m *http_api.Metadata) error {
 
 	tabs, err := attributevalue.MarshalListWithOptions(t)
 
@@ -346,7 +525,7 @@ func (r *spaceRepo) setTabsForSpace(userId, spaceId string, t []tab, m *http_api
 		"UpdatedAt": &types.AttributeValueMemberN{Value: strconv.FormatInt(m.UpdatedAt, 10)},
 	}
 
-	_, err = r.db.Client.PutItem(context.TODO(), &dynamodb.PutItemInput{
+	_, err = r.db.Client.PutItem(ctx, &dynamodb.PutItemInput{
 		TableName: &r.db.TableName,
 		Item:      item,
 	})
@@ -359,13 +538,13 @@ func (r *spaceRepo) setTabsForSpace(userId, spaceId string, t []tab, m *http_api
 	return nil
 }
 
-func (r *spaceRepo) getTabsForSpace(userId, spaceId string) ([]tab, *http_api.Metadata, error) {
+func (r *spaceRepo) getTabsForSpace(ctx context.Context, userId, spaceId string) ([]tab, *http_api.Metadata, error) {
 	key := map[string]types.AttributeValue{
 		db.PK_NAME: &types.AttributeValueMemberS{Value: userId},
 		db.SK_NAME: &types.AttributeValueMemberS{Value: db.SORT_KEY.TabsInSpace(spaceId)},
 	}
 
-	response, err := r.db.Client.GetItem(context.TODO(), &dynamodb.GetItemInput{
+	response, err := r.db.Client.GetItem(ctx, &dynamodb.GetItemInput{
 		TableName: &r.db.TableName,
 		Key:       key,
 	})
@@ -375,7 +554,7 @@ func (r *spaceRepo) getTabsForSpace(userId, spaceId string) ([]tab, *http_api.Me
 		return nil, nil, err
 	}
 	if len(response.Item) == 0 {
-		return nil, nil, errors.New(errMsg.tabsGet)
+		return nil, nil, errTabsNotFound
 	}
 
 	// tabs
@@ -412,21 +591,16 @@ func (r *spaceRepo) getTabsForSpace(userId, spaceId string) ([]tab, *http_api.Me
 }
 
 // snoozed tabs
-func (r *spaceRepo) addSnoozedTab(userId, spaceId string, t *SnoozedTab) error {
+func (r *spaceRepo) addSnoozedTab(ctx context.Context, userId, spaceId string, t *SnoozedTab) error {
 
-	snoozedTab, err := attributevalue.MarshalMap(*t)
+	snoozedTab, err := snoozedTabItem(userId, spaceId, t)
 
 	if err != nil {
 		logger.Errorf("Couldn't marshal snoozed tabs: %v. \n[Error]: %v", t, err)
 		return err
 	}
 
-	sk := fmt.Sprintf("%s#%v", db.SORT_KEY.SnoozedTab(spaceId), t.SnoozedAt)
-
-	snoozedTab[db.PK_NAME] = &types.AttributeValueMemberS{Value: userId}
-	snoozedTab[db.SK_NAME] = &types.AttributeValueMemberS{Value: sk}
-
-	_, err = r.db.Client.PutItem(context.TODO(), &dynamodb.PutItemInput{
+	_, err = r.db.Client.PutItem(ctx, &dynamodb.PutItemInput{
 		TableName: &r.db.TableName,
 		Item:      snoozedTab,
 	})
@@ -438,7 +612,7 @@ func (r *spaceRepo) addSnoozedTab(userId, spaceId string, t *SnoozedTab) error {
 	return nil
 }
 
-func (r *spaceRepo) GetSnoozedTab(userId, spaceId string, snoozedAt int64) (*SnoozedTab, error) {
+func (r *spaceRepo) GetSnoozedTab(ctx context.Context, userId, spaceId string, snoozedAt int64) (*SnoozedTab, error) {
 
 	skSuffix := fmt.Sprintf("%s#%v", spaceId, snoozedAt)
 
@@ -447,7 +621,7 @@ func (r *spaceRepo) GetSnoozedTab(userId, spaceId string, snoozedAt int64) (*Sno
 		db.SK_NAME: &types.AttributeValueMemberS{Value: db.SORT_KEY.SnoozedTab(skSuffix)},
 	}
 
-	response, err := r.db.Client.GetItem(context.TODO(), &dynamodb.GetItemInput{
+	response, err := r.db.Client.GetItem(ctx, &dynamodb.GetItemInput{
 		TableName: &r.db.TableName,
 		Key:       key,
 	})
@@ -458,7 +632,7 @@ func (r *spaceRepo) GetSnoozedTab(userId, spaceId string, snoozedAt int64) (*Sno
 	}
 
 	if len(response.Item) == 0 {
-		return nil, errors.New(errMsg.snoozedTabsNotFound)
+		return nil, errSnoozedTabNotFound
 	}
 	snoozedTab := &SnoozedTab{}
 
@@ -473,7 +647,7 @@ func (r *spaceRepo) GetSnoozedTab(userId, spaceId string, snoozedAt int64) (*Sno
 
 }
 
-func (r *spaceRepo) getAllSnoozedTabsByUser(userId string, lastSnoozedTabId int64) ([]SnoozedTab, *http_api.Metadata, error) {
+func (r *spaceRepo) getAllSnoozedTabsByUser(ctx context.Context, userId string, lastSnoozedTabId int64) ([]SnoozedTab, *http_api.Metadata, error) {
 
 	key := expression.KeyAnd(expression.Key(db.PK_NAME).Equal(expression.Value(userId)), expression.Key(db.SK_NAME).BeginsWith(db.SORT_KEY.SnoozedTab("")))
 
@@ -493,7 +667,7 @@ func (r *spaceRepo) getAllSnoozedTabsByUser(userId string, lastSnoozedTabId int6
 		}
 	}
 
-	response, err := r.db.Client.Query(context.TODO(), &dynamodb.QueryInput{
+	response, err := r.db.Client.Query(ctx, &dynamodb.QueryInput{
 		TableName:                 &r.db.TableName,
 		ExpressionAttributeNames:  expr.Names(),
 		ExpressionAttributeValues: expr.Values(),
@@ -508,7 +682,7 @@ func (r *spaceRepo) getAllSnoozedTabsByUser(userId string, lastSnoozedTabId int6
 	}
 
 	if len(response.Items) < 1 {
-		return nil, nil, errors.New(errMsg.snoozedTabsNotFound)
+		return nil, nil, errSnoozedTabNotFound
 	}
 
 	snoozedTabs := []SnoozedTab{}
@@ -533,7 +707,7 @@ func (r *spaceRepo) getAllSnoozedTabsByUser(userId string, lastSnoozedTabId int6
 	return snoozedTabs, m, nil
 }
 
-func (r *spaceRepo) geSnoozedTabsInSpace(userId, spaceId string, limit int32, lastSnoozedTabId int64) ([]SnoozedTab, *http_api.Metadata, error) {
+func (r *spaceRepo) geSnoozedTabsInSpace(ctx context.Context, userId, spaceId string, limit int32, lastSnoozedTabId int64) ([]SnoozedTab, *http_api.Metadata, error) {
 
 	key := expression.KeyAnd(expression.Key("PK").Equal(expression.Value(userId)), expression.Key("SK").BeginsWith(db.SORT_KEY.SnoozedTab(spaceId)))
 
@@ -553,7 +727,7 @@ func (r *spaceRepo) geSnoozedTabsInSpace(userId, spaceId string, limit int32, la
 		}
 	}
 
-	response, err := r.db.Client.Query(context.TODO(), &dynamodb.QueryInput{
+	response, err := r.db.Client.Query(ctx, &dynamodb.QueryInput{
 		TableName:                 &r.db.TableName,
 		ExpressionAttributeNames:  expr.Names(),
 		ExpressionAttributeValues: expr.Values(),
@@ -568,7 +742,7 @@ func (r *spaceRepo) geSnoozedTabsInSpace(userId, spaceId string, limit int32, la
 	}
 
 	if len(response.Items) < 1 {
-		return nil, nil, errors.New(errMsg.snoozedTabsGet)
+		return nil, nil, errSnoozedTabNotFound
 	}
 	snoozedTabs := []SnoozedTab{}
 
@@ -592,65 +766,52 @@ func (r *spaceRepo) geSnoozedTabsInSpace(userId, spaceId string, limit int32, la
 	return snoozedTabs, m, nil
 }
 
-func (r *spaceRepo) switchSnoozedTabSpace(userId, spaceId, newSpaceId string) error {
+// all snoozed tabs in a space, across pages
+func (r *spaceRepo) getAllSnoozedTabsInSpace(ctx context.Context, userId, spaceId string) ([]SnoozedTab, error) {
+	var snoozedTabs []SnoozedTab
 
-	var errs []error
-	var updatedSnoozedTabs []SnoozedTab
+	var lastSnoozedTabId int64
 
 	for {
-		tabs, m, err := r.geSnoozedTabsInSpace(userId, spaceId, 200, 0)
+		tabs, m, err := r.geSnoozedTabsInSpace(ctx, userId, spaceId, 200, lastSnoozedTabId)
 
 		if err != nil {
-			return err
+			if errors.Is(err, errSnoozedTabNotFound) && len(snoozedTabs) > 0 {
+				break
+			}
+			return nil, err
 		}
 
-		updatedSnoozedTabs = append(updatedSnoozedTabs, tabs...)
+		snoozedTabs = append(snoozedTabs, tabs...)
 
 		// if there are no more tabs to fetch, stop the loop
 		if m.LastKey == "" {
 			break
 		}
-	}
 
-	if len(updatedSnoozedTabs) < 1 {
-		return errors.New(errMsg.snoozedTabsNotFound)
+		lastSnoozedTabId = tabs[len(tabs)-1].SnoozedAt
 	}
 
-	// context with timeout
-	ctx, cancel := context.WithTimeout(context.Background(), 15*time.Second)
-	defer cancel()
-
-	var wg sync.WaitGroup
-	// channel to collect errors from goroutines
-	errChan := make(chan error, (len(updatedSnoozedTabs) * 2))
+	if len(snoozedTabs) < 1 {
+		return nil, errSnoozedTabNotFound
+	}
 
-	// delete the snoozed tabs from old space
+	return snoozedTabs, nil
+}
 
-	delReqs := []types.WriteRequest{}
+func (r *spaceRepo) switchSnoozedTabSpace(ctx context.Context, userId, spaceId, newSpaceId string) error {
 
-	for _, s := range updatedSnoozedTabs {
-		delReqs = append(delReqs, types.WriteRequest{
-			DeleteRequest: &types.DeleteRequest{
-				Key: map[string]types.AttributeValue{
-					"PK": &types.AttributeValueMemberS{Value: userId},
-					"SK": &types.AttributeValueMemberS{Value: db.SORT_KEY.SnoozedTab(fmt.Sprintf("%s#%v", spaceId, s.SnoozedAt))},
-				},
-			},
-		})
-	}
+	var errs []error
 
-	// execute batch delete requests
-	r.db.BatchWriter(ctx, r.db.TableName, &wg, errChan, delReqs)
+	updatedSnoozedTabs, err := r.getAllSnoozedTabsInSpace(ctx, userId, spaceId)
 
-	// collect errors from goroutines
-	for err := range errChan {
-		errs = append(errs, err)
+	if err != nil {
+		return err
 	}
 
-	if len(errs) > 0 {
-		logger.Errorf("Couldn't delete snoozed tabs from old space for userId: %v. \n[Error]: %v", userId, errs)
-		return errs[0]
-	}
+	// context with timeout
+	ctx, cancel := context.WithTimeout(ctx, 15*time.Second)
+	defer cancel()
 
 	// add snoozed tabs to new space id
 	putReqs := []types.WriteRequest{}
@@ -659,16 +820,16 @@ func (r *spaceRepo) switchSnoozedTabSpace(userId, spaceId, newSpaceId string) er
 
 		snoozedTab, err := attributevalue.MarshalMap(s)
 
-		sk := fmt.Sprintf("%s#%v", db.SORT_KEY.SnoozedTab(spaceId), s.SnoozedAt)
-
-		snoozedTab[db.PK_NAME] = &types.AttributeValueMemberS{Value: userId}
-		snoozedTab[db.SK_NAME] = &types.AttributeValueMemberS{Value: sk}
-
 		if err != nil {
 			logger.Errorf("Couldn't marshal snoozed tab for userId: %v. \n[Error]: %v", userId, err)
 			return err
 		}
 
+		sk := fmt.Sprintf("%s#%v", db.SORT_KEY.SnoozedTab(newSpaceId), s.SnoozedAt)
+
+		snoozedTab[db.PK_NAME] = &types.AttributeValueMemberS{Value: userId}
+		snoozedTab[db.SK_NAME] = &types.AttributeValueMemberS{Value: sk}
+
 		putReqs = append(putReqs, types.WriteRequest{
 			PutRequest: &types.PutRequest{
 				Item: snoozedTab,
@@ -676,28 +837,37 @@ func (r *spaceRepo) switchSnoozedTabSpace(userId, spaceId, newSpaceId string) er
 		})
 	}
 
-	r.db.BatchWriter(ctx, r.db.TableName, &wg, errChan, putReqs)
+	errs = r.batchWrite(ctx, putReqs)
 
-	// Wait for all goroutines to complete
-	go func() {
-		wg.Wait()
-		close(errChan)
-	}()
+	if len(errs) > 0 {
+		return fmt.Errorf("Couldn't move snoozed tabs to new space  for userId: %v. \n[Error]: %v", userId, errs)
+	}
 
-	// collect errors from goroutines
-	for err := range errChan {
-		errs = append(errs, err)
+	// delete the snoozed tabs from old space, once they are saved in the new space
+	delReqs := []types.WriteRequest{}
+
+	for _, s := range updatedSnoozedTabs {
+		delReqs = append(delReqs, types.WriteRequest{
+			DeleteRequest: &types.DeleteRequest{
+				Key: map[string]types.AttributeValue{
+					"PK": &types.AttributeValueMemberS{Value: userId},
+					"SK": &types.AttributeValueMemberS{Value: fmt.Sprintf("%s#%v", db.SORT_KEY.SnoozedTab(spaceId), s.SnoozedAt)},
+				},
+			},
+		})
 	}
 
-	// Return combined errors if any
+	errs = r.batchWrite(ctx, delReqs)
+
 	if len(errs) > 0 {
-		return fmt.Errorf("Couldn't move snoozed tabs to new space  for userId: %v. \n[Error]: %v", userId, errs)
+		logger.Errorf("Couldn't delete snoozed tabs from old space for userId: %v. \n[Error]: %v", userId, errs)
+		return errs[0]
 	}
 
 	return nil
 }
 
-func (r *spaceRepo) DeleteSnoozedTab(userId, spaceId string, snoozedAt int64) error {
+func (r *spaceRepo) DeleteSnoozedTab(ctx context.Context, userId, spaceId string, snoozedAt int64) error {
 	sk := fmt.Sprintf("%s#%s", db.SORT_KEY.SnoozedTab(spaceId), strconv.FormatInt(snoozedAt, 10))
 
 	key := map[string]types.AttributeValue{
@@ -705,7 +875,7 @@ func (r *spaceRepo) DeleteSnoozedTab(userId, spaceId string, snoozedAt int64) er
 		"SK": &types.AttributeValueMemberS{Value: sk},
 	}
 
-	_, err := r.db.Client.DeleteItem(context.TODO(), &dynamodb.DeleteItemInput{
+	_, err := r.db.Client.DeleteItem(ctx, &dynamodb.DeleteItemInput{
 		TableName: &r.db.TableName,
 		Key:       key,
 	})
@@ -719,3 +889,68 @@ func (r *spaceRepo) DeleteSnoozedTab(userId, spaceId string, snoozedAt int64) er
 }
 
 //* helpers
+
+// builds an update transaction item for a space, only if the space exists
+func (r *spaceRepo) updateSpaceItem(userId, spaceId string, update expression.UpdateBuilder) (*types.TransactWriteItem, error) {
+	key := map[string]types.AttributeValue{
+		db.PK_NAME: &types.AttributeValueMemberS{Value: userId},
+		db.SK_NAME: &types.AttributeValueMemberS{Value: db.SORT_KEY.Space(spaceId)},
+	}
+
+	condition := expression.AttributeExists(expression.Name(db.PK_NAME))
+
+	expr, err := expression.NewBuilder().WithUpdate(update).WithCondition(condition).Build()
+
+	if err != nil {
+		return nil, err
+	}
+
+	return &types.TransactWriteItem{
+		Update: &types.Update{
+			TableName:                 &r.db.TableName,
+			Key:                       key,
+			ConditionExpression:       expr.Condition(),
+			ExpressionAttributeNames:  expr.Names(),
+			ExpressionAttributeValues: expr.Values(),
+			UpdateExpression:          expr.Update(),
+		},
+	}, nil
+}
+
+// snoozed tab as dynamodb item, stored under its space & snoozedAt (id)
+func snoozedTabItem(userId, spaceId string, t *SnoozedTab) (map[string]types.AttributeValue, error) {
+	item, err := attributevalue.MarshalMap(*t)
+
+	if err != nil {
+		return nil, err
+	}
+
+	item[db.PK_NAME] = &types.AttributeValueMemberS{Value: userId}
+	item[db.SK_NAME] = &types.AttributeValueMemberS{Value: fmt.Sprintf("%s#%v", db.SORT_KEY.SnoozedTab(spaceId), t.SnoozedAt)}
+
+	return item, nil
+}
+
+// runs the batch write requests and waits for them to complete, returns the errors if any
+func (r *spaceRepo) batchWrite(ctx context.Context, reqs []types.WriteRequest) []error {
+	var errs []error
+	var wg sync.WaitGroup
+
+	// channel to collect errors from goroutines
+	errChan := make(chan error, len(reqs))
+
+	r.db.BatchWriter(ctx, r.db.TableName, &wg, errChan, reqs)
+
+	// Wait for all goroutines to complete
+	go func() {
+		wg.Wait()
+		close(errChan)
+	}()
+
+	// collect errors from goroutines
+	for err := range errChan {
+		errs = append(errs, err)
+	}
+
+	return errs
+}
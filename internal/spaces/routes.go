@@ -3,9 +3,13 @@ package spaces
 import (
 	"github.com/manishMandal02/tabsflow-backend/pkg/db"
 	"github.com/manishMandal02/tabsflow-backend/pkg/events"
+	"github.com/manishMandal02/tabsflow-backend/pkg/health"
 	"github.com/manishMandal02/tabsflow-backend/pkg/http_api"
 )
 
+// pagination of the snoozed tabs
+var lastSnoozedTabIdQuery = http_api.QueryParam{Name: "lastSnoozedTabId", Description: "snoozedAt of the last snoozed tab of the previous page"}
+
 func Router(db *db.DDB, q *events.Queue) http_api.IRouter {
 
 	sr := NewSpaceRepository(db)
@@ -14,41 +18,123 @@ func Router(db *db.DDB, q *events.Queue) http_api.IRouter {
 	// middleware to get userId from jwt token
 	userIdMiddleware := newUserIdMiddleware()
 
-	spacesRouter := http_api.NewRouter("/spaces")
+	router := http_api.NewRouter("/spaces")
+
+	checks := []health.Check{
+		health.Table(db),
+		health.Queue("notifications", q),
+	}
+
+	// health routes, outside the service's middleware
+	health.Routes(router, checks...)
+	health.DiagnosticsRoute(router, checks...)
+
+	spacesRouter := router.Group("/")
 
 	spacesRouter.Use(http_api.SetAllowOriginHeader())
 
+	// api token requests need the read/write scope
+	spacesRouter.Use(http_api.RequireTokenScope("spaces"))
+
 	spacesRouter.Use(userIdMiddleware)
 
 	// spaces
-	spacesRouter.POST("/", sh.create)
-	spacesRouter.GET("/my", sh.spacesByUser)
-	spacesRouter.GET("/:id", sh.get)
-	spacesRouter.PATCH("/", sh.update)
-	spacesRouter.DELETE("/:spaceId", sh.delete)
+	spacesRouter.POST("/", sh.create).Doc(http_api.RouteDoc{
+		Summary: "Create a space",
+		Body:    space{},
+	})
+	spacesRouter.GET("/my", sh.spacesByUser).Doc(http_api.RouteDoc{
+		Summary:  "Spaces of the user, sorted by order",
+		Query:    []http_api.QueryParam{{Name: "include", Description: "archived: include the archived spaces"}},
+		Response: []space{},
+	})
+	spacesRouter.GET("/:id", sh.get).Doc(http_api.RouteDoc{
+		Summary:  "Get a space",
+		Response: space{},
+	})
+	spacesRouter.PATCH("/", sh.update).Doc(http_api.RouteDoc{
+		Summary: "Update a space",
+		Body:    space{},
+	})
+	spacesRouter.DELETE("/:spaceId", sh.delete).Doc(http_api.RouteDoc{
+		Summary: "Delete a space, its snoozed tabs are moved to the backup space",
+		Query:   []http_api.QueryParam{{Name: "backupSpaceId", Description: "space for the snoozed tabs, defaults to the first space"}},
+	})
+
+	// order, pin & archive
+	spacesRouter.PATCH("/order", sh.setOrder).Doc(http_api.RouteDoc{
+		Summary: "Set the order of the spaces",
+		Body:    spacesOrderReq{},
+	})
+	spacesRouter.PATCH("/:spaceId/pin", sh.pin).Doc(http_api.RouteDoc{Summary: "Pin a space"})
+	spacesRouter.PATCH("/:spaceId/unpin", sh.unpin).Doc(http_api.RouteDoc{Summary: "Unpin a space"})
+	spacesRouter.PATCH("/:spaceId/archive", sh.archive).Doc(http_api.RouteDoc{Summary: "Archive a space"})
+	spacesRouter.PATCH("/:spaceId/unarchive", sh.unarchive).Doc(http_api.RouteDoc{Summary: "Unarchive a space"})
+
+	// import from bookmarks html, OneTab, Session Buddy & Toby exports
+	spacesRouter.POST("/import", sh.importSpaces).Doc(http_api.RouteDoc{
+		Summary:  "Import spaces from another tool, dryRun previews the parsed spaces",
+		Body:     importReq{},
+		Response: importReport{},
+	})
 
 	// active tab index
-	spacesRouter.GET("/:spaceId/active-tab-index", sh.getActiveTab)
-	spacesRouter.POST("/:spaceId/active-tab-index", sh.setActiveTab)
+	spacesRouter.GET("/:spaceId/active-tab-index", sh.getActiveTab).Doc(http_api.RouteDoc{
+		Summary:  "Get the active tab index of a space",
+		Response: int64(0),
+	})
+	spacesRouter.POST("/:spaceId/active-tab-index", sh.setActiveTab).Doc(http_api.RouteDoc{
+		Summary: "Set the active tab index of a space",
+		Body:    activeTabIndexReq{},
+	})
 
 	// tabs
-	spacesRouter.GET("/:spaceId/tabs", sh.getTabsInSpace)
-	spacesRouter.POST("/:spaceId/tabs", sh.setTabsInSpace)
+	spacesRouter.GET("/:spaceId/tabs", sh.getTabsInSpace).Doc(http_api.RouteDoc{
+		Summary:  "Tabs of a space, with the last updated time in metadata",
+		Response: []tab{},
+	})
+	spacesRouter.POST("/:spaceId/tabs", sh.setTabsInSpace).Doc(http_api.RouteDoc{
+		Summary: "Set the tabs of a space",
+		Body:    tabsReq{},
+	})
 
 	// groups
-	spacesRouter.GET("/:spaceId/groups", sh.getGroupsInSpace)
-	spacesRouter.POST("/:spaceId/groups", sh.setGroupsInSpace)
+	spacesRouter.GET("/:spaceId/groups", sh.getGroupsInSpace).Doc(http_api.RouteDoc{
+		Summary:  "Tab groups of a space, with the last updated time in metadata",
+		Response: []group{},
+	})
+	spacesRouter.POST("/:spaceId/groups", sh.setGroupsInSpace).Doc(http_api.RouteDoc{
+		Summary: "Set the tab groups of a space",
+		Body:    groupsReq{},
+	})
 
 	// snoozed tabs
-	spacesRouter.POST("/:spaceId/snoozed-tabs", sh.createSnoozedTab)
-	spacesRouter.GET("/:spaceId/snoozed-tabs/:id", sh.getSnoozedTab)
-	spacesRouter.PATCH("/:spaceId/snoozed-tabs/switch-space", sh.switchSnoozedTabSpace)
-	// query param: snoozedAt={timestamp}
-	spacesRouter.GET("/snoozed-tabs/my", sh.getSnoozedTabByUser)
-	// query param: snoozedAt={timestamp}
-	spacesRouter.GET("/:spaceId/snoozed-tabs", sh.getSnoozedTabsBySpace)
-	spacesRouter.DELETE("/:spaceId/snoozed-tabs/:id", sh.DeleteSnoozedTab)
+	spacesRouter.POST("/:spaceId/snoozed-tabs", sh.createSnoozedTab).Doc(http_api.RouteDoc{
+		Summary: "Snooze a tab",
+		Body:    SnoozedTab{},
+	})
+	spacesRouter.GET("/:spaceId/snoozed-tabs/:id", sh.getSnoozedTab).Doc(http_api.RouteDoc{
+		Summary:  "Get a snoozed tab, the id is its snoozedAt timestamp",
+		Response: SnoozedTab{},
+	})
+	spacesRouter.PATCH("/:spaceId/snoozed-tabs/switch-space", sh.switchSnoozedTabSpace).Doc(http_api.RouteDoc{
+		Summary: "Move the snoozed tabs of a space to another space",
+		Body:    switchSpaceReq{},
+	})
+	spacesRouter.GET("/snoozed-tabs/my", sh.getSnoozedTabByUser).Doc(http_api.RouteDoc{
+		Summary:  "Snoozed tabs of the user, paginated",
+		Query:    []http_api.QueryParam{lastSnoozedTabIdQuery},
+		Response: []SnoozedTab{},
+	})
+	spacesRouter.GET("/:spaceId/snoozed-tabs", sh.getSnoozedTabsBySpace).Doc(http_api.RouteDoc{
+		Summary:  "Snoozed tabs of a space, paginated",
+		Query:    []http_api.QueryParam{lastSnoozedTabIdQuery},
+		Response: []SnoozedTab{},
+	})
+	spacesRouter.DELETE("/:spaceId/snoozed-tabs/:id", sh.DeleteSnoozedTab).Doc(http_api.RouteDoc{
+		Summary: "Delete a snoozed tab, the id is its snoozedAt timestamp",
+	})
 
 	// serve API routes
-	return spacesRouter
+	return router
 }
@@ -1,35 +1,65 @@
 package spaces
 
 import (
+	"cmp"
+	"encoding/json"
 	"fmt"
+	"slices"
 	"strconv"
+	"strings"
 	"time"
 
 	"github.com/aws/aws-sdk-go-v2/feature/dynamodb/attributevalue"
 	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
-	"github.com/go-playground/validator/v10"
 	"github.com/manishMandal02/tabsflow-backend/config"
 	"github.com/manishMandal02/tabsflow-backend/pkg/db"
+	"github.com/manishMandal02/tabsflow-backend/pkg/errs"
 )
 
 type space struct {
-	Id        string `json:"id" validate:"required"`
-	Title     string `json:"title" validate:"required"`
-	Theme     string `json:"theme" validate:"required"`
-	IsSaved   bool   `json:"isSaved" validate:"required"`
-	Emoji     string `json:"emoji" validate:"required"`
-	WindowId  int    `json:"windowId" validate:"required,number"`
-	UpdatedAt int64  `json:"updatedAt" validate:"number"`
+	Id         string     `json:"id" validate:"required"`
+	Title      string     `json:"title" validate:"required"`
+	Theme      spaceTheme `json:"theme" validate:"required,hex_color"`
+	IsSaved    bool       `json:"isSaved"`
+	Emoji      string     `json:"emoji" validate:"required,emoji"`
+	WindowId   int        `json:"windowId" validate:"required,number"`
+	UpdatedAt  int64      `json:"updatedAt" validate:"number"`
+	Order      int64      `json:"order"`
+	IsPinned   bool       `json:"isPinned"`
+	IsArchived bool       `json:"isArchived"`
+	ArchivedAt int64      `json:"archivedAt,omitempty"`
 }
 
-func (s space) validate() error {
-	validate := validator.New(validator.WithRequiredStructEnabled())
+// hex color of the space, the named themes of the older clients (e.g. "Green") are mapped to their hex color
+type spaceTheme string
 
-	err := validate.Struct(s)
-	if err != nil {
+// colors of the named themes, by lower case name
+var legacyThemes = map[string]string{
+	"gray":   "#6b7280",
+	"grey":   "#6b7280",
+	"blue":   "#3b82f6",
+	"red":    "#ef4444",
+	"yellow": "#eab308",
+	"green":  "#22c55e",
+	"pink":   "#ec4899",
+	"purple": "#a855f7",
+	"cyan":   "#06b6d4",
+	"orange": "#f97316",
+}
+
+func (t *spaceTheme) UnmarshalJSON(b []byte) error {
+	var theme string
+
+	if err := json.Unmarshal(b, &theme); err != nil {
 		return err
 	}
 
+	if hex, ok := legacyThemes[strings.ToLower(strings.TrimSpace(theme))]; ok {
+		theme = hex
+	}
+
+	*t = spaceTheme(theme)
+
 	return nil
 }
 
@@ -57,6 +87,77 @@ type SnoozedTab struct {
 	SnoozedUntil int64  `json:"snoozedUntil,omitempty"`
 }
 
+// * request bodies
+
+type spacesOrderReq struct {
+	// set in a single transaction
+	SpaceIds []string `json:"spaceIds" validate:"min=1,max=100,unique,dive,required"`
+}
+
+type activeTabIndexReq struct {
+	TabIndex int64 `json:"tabIndex"`
+}
+
+type tabsReq struct {
+	Tabs          []tab `json:"tabs" validate:"min=1"`
+	LastUpdatedAt int64 `json:"lastUpdatedAt"`
+}
+
+type groupsReq struct {
+	Groups        []group `json:"groups" validate:"min=1"`
+	LastUpdatedAt int64   `json:"lastUpdatedAt"`
+}
+
+type switchSpaceReq struct {
+	NewSpaceId string `json:"newSpaceId" validate:"required"`
+}
+
+// general.deleteUnsavedSpaces preferences of the users whose unsaved spaces are cleaned up
+var UnsavedSpacesCleanupPrefs = []string{"day", "week", "month"}
+
+// unsavedSpaceMaxAge maps the user's general.deleteUnsavedSpaces preference
+// to the duration after which an unsaved space is removed (archived at half of it),
+// returns false if unsaved spaces should be kept
+func unsavedSpaceMaxAge(pref string) (time.Duration, bool) {
+	switch pref {
+	case "day":
+		return 24 * time.Hour, true
+	case "week":
+		return 7 * 24 * time.Hour, true
+	case "month":
+		return 30 * 24 * time.Hour, true
+	default:
+		return 0, false
+	}
+}
+
+// sortSpaces orders spaces by pinned first, then by the user defined order,
+// spaces without an order (0) keep their sort-key order after the ordered ones
+func sortSpaces(spaces []space) {
+	slices.SortStableFunc(spaces, func(a, b space) int {
+		if a.IsPinned != b.IsPinned {
+			if a.IsPinned {
+				return -1
+			}
+			return 1
+		}
+
+		if a.Order == b.Order {
+			return 0
+		}
+
+		if a.Order == 0 {
+			return 1
+		}
+
+		if b.Order == 0 {
+			return -1
+		}
+
+		return cmp.Compare(a.Order, b.Order)
+	})
+}
+
 // initial space for new users
 var defaultSpace = &space{
 	Id:        "default2025",
@@ -106,24 +207,30 @@ var defaultTabs = []tab{
 // It generates the initial space, groups, and tabs data for the provided userId
 // and returns them as a Dynamodb Items.
 func GetDefaultSpaceData(userId string) ([]map[string]types.AttributeValue, error) {
+	return spaceDataItems(userId, defaultSpace, defaultTabs, defaultGroups)
+}
+
+// space, groups and tabs of a space as Dynamodb Items
+func spaceDataItems(userId string, s *space, t []tab, g []group) ([]map[string]types.AttributeValue, error) {
 	items := []map[string]types.AttributeValue{}
 
-	// set default space
-	space, err := attributevalue.MarshalMap(defaultSpace)
+	updatedAt := &types.AttributeValueMemberN{Value: strconv.FormatInt(s.UpdatedAt, 10)}
+
+	// set space
+	spaceItem, err := attributevalue.MarshalMap(s)
 
 	if err != nil {
 		return nil, fmt.Errorf("Couldn't marshal space. [Error]: %v", err)
 	}
 
-	space[db.PK_NAME] = &types.AttributeValueMemberS{Value: userId}
-	space[db.SK_NAME] = &types.AttributeValueMemberS{Value: db.SORT_KEY.Space(defaultSpace.Id)}
-	space["UpdatedAt"] = &types.AttributeValueMemberN{Value: strconv.FormatInt(defaultSpace.UpdatedAt, 10)}
-
-	items = append(items, space)
+	spaceItem[db.PK_NAME] = &types.AttributeValueMemberS{Value: userId}
+	spaceItem[db.SK_NAME] = &types.AttributeValueMemberS{Value: db.SORT_KEY.Space(s.Id)}
+	spaceItem["UpdatedAt"] = updatedAt
 
-	// set default groups
+	items = append(items, spaceItem)
 
-	groups, err := attributevalue.MarshalList(defaultGroups)
+	// set groups
+	groups, err := attributevalue.MarshalList(g)
 
 	if err != nil {
 		return nil, fmt.Errorf("Couldn't marshal groups. [Error]: %v", err)
@@ -131,15 +238,15 @@ func GetDefaultSpaceData(userId string) ([]map[string]types.AttributeValue, erro
 
 	groupsItem := map[string]types.AttributeValue{
 		db.PK_NAME:  &types.AttributeValueMemberS{Value: userId},
-		db.SK_NAME:  &types.AttributeValueMemberS{Value: db.SORT_KEY.GroupsInSpace(defaultSpace.Id)},
+		db.SK_NAME:  &types.AttributeValueMemberS{Value: db.SORT_KEY.GroupsInSpace(s.Id)},
 		"Groups":    &types.AttributeValueMemberL{Value: groups},
-		"UpdatedAt": &types.AttributeValueMemberN{Value: strconv.FormatInt(defaultSpace.UpdatedAt, 10)},
+		"UpdatedAt": updatedAt,
 	}
 
 	items = append(items, groupsItem)
 
-	// set default tabs
-	tabs, err := attributevalue.MarshalList(defaultTabs)
+	// set tabs
+	tabs, err := attributevalue.MarshalList(t)
 
 	if err != nil {
 		return nil, fmt.Errorf("Couldn't marshal tabs. [Error]: %v", err)
@@ -147,7 +254,7 @@ func GetDefaultSpaceData(userId string) ([]map[string]types.AttributeValue, erro
 
 	tabsItem := map[string]types.AttributeValue{
 		db.PK_NAME: &types.AttributeValueMemberS{Value: userId},
-		db.SK_NAME: &types.AttributeValueMemberS{Value: db.SORT_KEY.TabsInSpace(defaultSpace.Id)},
+		db.SK_NAME: &types.AttributeValueMemberS{Value: db.SORT_KEY.TabsInSpace(s.Id)},
 		"Tabs":     &types.AttributeValueMemberL{Value: tabs},
 	}
 
@@ -156,10 +263,22 @@ func GetDefaultSpaceData(userId string) ([]map[string]types.AttributeValue, erro
 	return items, nil
 }
 
+var (
+	errSpaceNotFound          = errs.NotFound.New("space_not_found", "Space not found")
+	errTabsNotFound           = errs.NotFound.New("tabs_not_found", "Tabs not found")
+	errGroupsNotFound         = errs.NotFound.New("groups_not_found", "Groups not found")
+	errActiveTabIndexNotFound = errs.NotFound.New("active_tab_index_not_found", "Active tab index not found")
+	errSnoozedTabNotFound     = errs.NotFound.New("snoozed_tab_not_found", "Snoozed tab not found")
+	// tabs or groups were changed by another device, the client must sync before updating
+	errDataConflict = errs.Conflict.New("data_conflict", "Data conflict")
+	errImportFormat = errs.BadRequest.New("invalid_import_format", "Invalid import format")
+	errImportParse  = errs.BadRequest.New("import_parse_failed", "Couldn't parse import data")
+	errImportEmpty  = errs.BadRequest.New("import_empty", "No tabs found to import")
+	errImportLimit  = errs.BadRequest.New("import_limit_exceeded", "Too many spaces to import")
+)
+
 var errMsg = struct {
 	userDefaultSpace       string
-	spaceNotFound          string
-	dataConflict           string
 	spaceGet               string
 	spaceId                string
 	spaceCreate            string
@@ -174,13 +293,14 @@ var errMsg = struct {
 	groupsSet              string
 	snoozedTabsCreate      string
 	snoozedTabsGet         string
-	snoozedTabsNotFound    string
 	snoozedTabsSwitchSpace string
 	snoozedTabsDelete      string
+	spacesOrder            string
+	spacePin               string
+	spaceArchive           string
+	spacesImport           string
 }{
 	userDefaultSpace:       "Error setting default space",
-	spaceNotFound:          "Space not found",
-	dataConflict:           "data_conflict",
 	spaceGet:               "Error getting space",
 	spaceId:                "Invalid space id",
 	spaceCreate:            "Error creating space",
@@ -193,9 +313,12 @@ var errMsg = struct {
 	tabsSet:                "Error setting tabs",
 	groupsGet:              "Error getting groups",
 	groupsSet:              "Error setting groups",
-	snoozedTabsNotFound:    "Snoozed not found",
 	snoozedTabsCreate:      "Error creating snoozed tab",
 	snoozedTabsGet:         "Error getting snoozed tabs",
 	snoozedTabsSwitchSpace: "Error switching snoozed tab space",
 	snoozedTabsDelete:      "Error deleting snoozed tab",
+	spacesOrder:            "Error setting spaces order",
+	spacePin:               "Error pinning space",
+	spaceArchive:           "Error archiving space",
+	spacesImport:           "Error importing spaces",
 }
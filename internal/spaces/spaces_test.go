@@ -0,0 +1,41 @@
+package spaces
+
+import (
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/manishMandal02/tabsflow-backend/pkg/http_api"
+)
+
+func TestSpaceTheme(t *testing.T) {
+	tests := []struct {
+		name    string
+		theme   string
+		want    spaceTheme
+		wantErr bool
+	}{
+		{name: "hex color", theme: "#38bdf8", want: "#38bdf8"},
+		{name: "named theme of the older clients", theme: "Green", want: "#22c55e"},
+		{name: "lower case named theme", theme: "gray", want: "#6b7280"},
+		{name: "unknown named theme", theme: "sepia", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			body := `{"id":"1","title":"Work","theme":"` + tt.theme + `","emoji":"💼","isSaved":true,"windowId":1}`
+
+			r := httptest.NewRequest("POST", "/spaces/", strings.NewReader(body))
+
+			s, err := http_api.DecodeAndValidate[space](httptest.NewRecorder(), r)
+
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("DecodeAndValidate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+
+			if !tt.wantErr && s.Theme != tt.want {
+				t.Errorf("theme = %v, want %v", s.Theme, tt.want)
+			}
+		})
+	}
+}
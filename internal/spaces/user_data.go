@@ -0,0 +1,323 @@
+package spaces
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+	"github.com/manishMandal02/tabsflow-backend/pkg/db"
+	"github.com/manishMandal02/tabsflow-backend/pkg/events"
+	"github.com/manishMandal02/tabsflow-backend/pkg/logger"
+	"github.com/manishMandal02/tabsflow-backend/pkg/utils"
+)
+
+// space with all its data, as exported with the user's account data
+type SpaceData struct {
+	Space          space        `json:"space"`
+	Tabs           []tab        `json:"tabs"`
+	Groups         []group      `json:"groups"`
+	ActiveTabIndex int64        `json:"activeTabIndex"`
+	SnoozedTabs    []SnoozedTab `json:"snoozedTabs"`
+}
+
+// ExportUserData returns all the spaces of the user with their tabs, groups & snoozed tabs
+func ExportUserData(ctx context.Context, db *db.DDB, userId string) ([]SpaceData, error) {
+	r := &spaceRepo{
+		db: db,
+	}
+
+	spaces, err := r.getSpacesByUser(ctx, userId)
+
+	if err != nil {
+		if errors.Is(err, errSpaceNotFound) {
+			return []SpaceData{}, nil
+		}
+		return nil, err
+	}
+
+	sortSpaces(spaces)
+
+	data := []SpaceData{}
+
+	for _, s := range spaces {
+		d := SpaceData{
+			Space:       s,
+			Tabs:        []tab{},
+			Groups:      []group{},
+			SnoozedTabs: []SnoozedTab{},
+		}
+
+		tabs, _, err := r.getTabsForSpace(ctx, userId, s.Id)
+
+		if err != nil && !errors.Is(err, errTabsNotFound) {
+			return nil, err
+		}
+
+		if tabs != nil {
+			d.Tabs = tabs
+		}
+
+		groups, _, err := r.getGroupsForSpace(ctx, userId, s.Id)
+
+		if err != nil && !errors.Is(err, errGroupsNotFound) {
+			return nil, err
+		}
+
+		if groups != nil {
+			d.Groups = groups
+		}
+
+		activeTabIndex, err := r.getActiveTabIndex(ctx, userId, s.Id)
+
+		if err != nil && !errors.Is(err, errActiveTabIndexNotFound) {
+			return nil, err
+		}
+
+		d.ActiveTabIndex = activeTabIndex
+
+		snoozedTabs, err := r.getAllSnoozedTabsInSpace(ctx, userId, s.Id)
+
+		if err != nil && !errors.Is(err, errSnoozedTabNotFound) {
+			return nil, err
+		}
+
+		if snoozedTabs != nil {
+			d.SnoozedTabs = snoozedTabs
+		}
+
+		data = append(data, d)
+	}
+
+	return data, nil
+}
+
+// result of the spaces import from the user's account data
+type UserDataImport struct {
+	Imported int `json:"imported"`
+	Skipped  int `json:"skipped"`
+	Failed   int `json:"failed"`
+	// spaces imported with a new id, as their id already exists (old id: new id)
+	RemappedIds map[string]string `json:"-"`
+}
+
+// snoozed tab to schedule after import
+type importedSnoozedTab struct {
+	spaceId  string
+	tab      SnoozedTab
+	subEvent events.SubEvent
+}
+
+// ImportUserData restores the spaces from the user's account data export with their tabs, groups & snoozed tabs,
+// spaces with an existing id are skipped, overwritten or imported with a new id as per the conflict strategy
+func ImportUserData(ctx context.Context, ddb *db.DDB, q *events.Queue, userId string, data []SpaceData, strategy db.ConflictStrategy) (*UserDataImport, error) {
+	r := &spaceRepo{
+		db: ddb,
+	}
+
+	res := &UserDataImport{
+		RemappedIds: map[string]string{},
+	}
+
+	existingSpaces, err := r.getSpacesByUser(ctx, userId)
+
+	if err != nil && !errors.Is(err, errSpaceNotFound) {
+		return nil, err
+	}
+
+	existing := map[string]bool{}
+
+	for _, s := range existingSpaces {
+		existing[s.Id] = true
+	}
+
+	// spaces repeated in the import are handled as conflicts
+	imported := map[string]bool{}
+
+	var reqs []types.WriteRequest
+	var snoozedTabs []importedSnoozedTab
+
+	// snoozedAt is the snoozed tab id, new ids are unique timestamps
+	nextSnoozedTabId := time.Now().UnixMilli()
+
+	for _, d := range data {
+		s := d.Space
+
+		if s.Id == "" || s.Title == "" {
+			res.Failed++
+			continue
+		}
+
+		isDuplicate := false
+
+		if existing[s.Id] || imported[s.Id] {
+			if strategy == db.ConflictSkip || (imported[s.Id] && strategy == db.ConflictOverwrite) {
+				res.Skipped++
+				continue
+			}
+
+			if strategy == db.ConflictDuplicate {
+				newId := utils.GenerateID()
+				res.RemappedIds[s.Id] = newId
+				s.Id = newId
+				isDuplicate = true
+			}
+		}
+
+		imported[s.Id] = true
+
+		// snoozed tabs already in the overwritten space, to update their schedules
+		existingSnoozedTabs := map[int64]bool{}
+
+		if existing[s.Id] {
+			tabs, err := r.getAllSnoozedTabsInSpace(ctx, userId, s.Id)
+
+			if err != nil && !errors.Is(err, errSnoozedTabNotFound) {
+				return nil, err
+			}
+
+			for _, t := range tabs {
+				existingSnoozedTabs[t.SnoozedAt] = true
+			}
+		}
+
+		items, err := spaceDataItems(userId, &s, d.Tabs, d.Groups)
+
+		if err != nil {
+			logger.Errorf("Couldn't marshal imported spaceId: %v. \n[Error]: %v", s.Id, err)
+			res.Failed++
+			continue
+		}
+
+		items = append(items, map[string]types.AttributeValue{
+			db.PK_NAME:       &types.AttributeValueMemberS{Value: userId},
+			db.SK_NAME:       &types.AttributeValueMemberS{Value: db.SORT_KEY.SpaceActiveTab(s.Id)},
+			"ActiveTabIndex": &types.AttributeValueMemberN{Value: strconv.FormatInt(d.ActiveTabIndex, 10)},
+		})
+
+		for _, t := range d.SnoozedTabs {
+			subEvent := events.SubEventCreate
+
+			if existingSnoozedTabs[t.SnoozedAt] {
+				subEvent = events.SubEventUpdate
+			}
+
+			// the schedules are named by the snoozed tab id, so duplicates need new ids
+			if isDuplicate {
+				nextSnoozedTabId++
+				t.SnoozedAt = nextSnoozedTabId
+			}
+
+			item, err := snoozedTabItem(userId, s.Id, &t)
+
+			if err != nil {
+				logger.Errorf("Couldn't marshal imported snoozed tab for spaceId: %v. \n[Error]: %v", s.Id, err)
+				continue
+			}
+
+			items = append(items, item)
+
+			snoozedTabs = append(snoozedTabs, importedSnoozedTab{spaceId: s.Id, tab: t, subEvent: subEvent})
+		}
+
+		for _, item := range items {
+			reqs = append(reqs, types.WriteRequest{PutRequest: &types.PutRequest{Item: item}})
+		}
+
+		res.Imported++
+	}
+
+	if len(reqs) < 1 {
+		return res, nil
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, db.BatchTimeout)
+	defer cancel()
+
+	errs := r.batchWrite(ctx, reqs)
+
+	if len(errs) > 0 {
+		logger.Errorf("Couldn't import spaces for userId: %v. \n[Error]: %v", userId, errs)
+		return res, errs[0]
+	}
+
+	// schedule the snoozed tabs yet to be un-snoozed
+	now := time.Now().Unix()
+
+	for _, t := range snoozedTabs {
+		if t.tab.SnoozedUntil <= now {
+			continue
+		}
+
+		event := events.New(events.EventTypeScheduleSnoozedTab, &events.ScheduleSnoozedTabPayload{
+			UserId:       userId,
+			SpaceId:      t.spaceId,
+			SnoozedTabId: strconv.FormatInt(t.tab.SnoozedAt, 10),
+			SubEvent:     t.subEvent,
+			TriggerAt:    t.tab.SnoozedUntil,
+		})
+
+		err = q.AddMessage(ctx, event)
+
+		if err != nil {
+			logger.Errorf("Couldn't schedule imported snoozed tab for userId: %v. \n[Error]: %v", userId, err)
+		}
+	}
+
+	return res, nil
+}
+
+// CancelUserSchedules deletes the un-snooze schedules of the user's snoozed tabs, returns the number of schedules cancelled
+func CancelUserSchedules(ctx context.Context, ddb *db.DDB, q *events.Queue, userId string) (int, error) {
+	r := &spaceRepo{
+		db: ddb,
+	}
+
+	spaces, err := r.getSpacesByUser(ctx, userId)
+
+	if err != nil {
+		if errors.Is(err, errSpaceNotFound) {
+			return 0, nil
+		}
+		return 0, err
+	}
+
+	count := 0
+
+	// schedules are deleted after they are triggered
+	now := time.Now().Unix()
+
+	for _, s := range spaces {
+		tabs, err := r.getAllSnoozedTabsInSpace(ctx, userId, s.Id)
+
+		if err != nil {
+			if errors.Is(err, errSnoozedTabNotFound) {
+				continue
+			}
+			return count, err
+		}
+
+		for _, t := range tabs {
+			if t.SnoozedUntil <= now {
+				continue
+			}
+
+			event := events.New(events.EventTypeScheduleSnoozedTab, &events.ScheduleSnoozedTabPayload{
+				SnoozedTabId: strconv.FormatInt(t.SnoozedAt, 10),
+				SubEvent:     events.SubEventDelete,
+			})
+
+			err = q.AddMessage(ctx, event)
+
+			if err != nil {
+				logger.Errorf("Couldn't cancel snoozed tab schedule for userId: %v. \n[Error]: %v", userId, err)
+				return count, err
+			}
+
+			count++
+		}
+	}
+
+	return count, nil
+}
@@ -0,0 +1,162 @@
+package users
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"slices"
+	"strings"
+	"time"
+
+	paddle "github.com/PaddleHQ/paddle-go-sdk"
+	"github.com/manishMandal02/tabsflow-backend/pkg/events"
+	"github.com/manishMandal02/tabsflow-backend/pkg/logger"
+	"github.com/manishMandal02/tabsflow-backend/pkg/utils"
+)
+
+// auditable record of an account deletion, kept after the account data is removed
+type deletionReceipt struct {
+	Id     string `json:"id" dynamodbav:"Id"`
+	UserId string `json:"userId" dynamodbav:"UserId"`
+	// hash of the email, to verify the deletion for a user without keeping their email
+	EmailHash   string `json:"emailHash" dynamodbav:"EmailHash"`
+	RequestedAt int64  `json:"requestedAt" dynamodbav:"RequestedAt"`
+	CompletedAt int64  `json:"completedAt" dynamodbav:"CompletedAt"`
+	// number of items deleted from each table
+	MainItems            int    `json:"mainItems" dynamodbav:"MainItems"`
+	SessionItems         int    `json:"sessionItems" dynamodbav:"SessionItems"`
+	SearchIndexItems     int    `json:"searchIndexItems" dynamodbav:"SearchIndexItems"`
+	SchedulesCancelled   int    `json:"schedulesCancelled" dynamodbav:"SchedulesCancelled"`
+	SubscriptionId       string `json:"subscriptionId,omitempty" dynamodbav:"SubscriptionId,omitempty"`
+	SubscriptionCanceled bool   `json:"subscriptionCanceled" dynamodbav:"SubscriptionCanceled"`
+}
+
+// deletes the user's account from all the tables, cancels the schedules & the paid subscription,
+// and saves a deletion receipt; safe to retry, as the profile is deleted last & an already cancelled
+// subscription isn't cancelled again
+func deleteAccount(ctx context.Context, r repository, pc paddleClientInterface, notificationQueue, emailQueue *events.Queue, p *events.DeleteAccountPayload) error {
+	user, err := r.getUserByID(ctx, p.UserId)
+
+	if err != nil {
+		if !errors.Is(err, ErrUserNotFound) {
+			return err
+		}
+
+		// event re-delivered after the account was deleted
+		_, err = r.getDeletionReceipt(ctx, p.UserId)
+
+		if err == nil {
+			return nil
+		}
+
+		logger.Errorf("Couldn't delete account, user not found. userId: %v", p.UserId)
+		return nil
+	}
+
+	receipt := &deletionReceipt{
+		Id:          utils.GenerateID(),
+		UserId:      user.Id,
+		EmailHash:   hashEmail(user.Email),
+		RequestedAt: p.RequestedAt,
+	}
+
+	// cancel the paid subscription first, so the user isn't charged again if the deletion fails midway
+	receipt.SubscriptionId, receipt.SubscriptionCanceled, err = cancelPaidSubscription(ctx, r, pc, user.Id)
+
+	if err != nil {
+		return err
+	}
+
+	receipt.SchedulesCancelled, err = r.cancelSchedules(ctx, user.Id, notificationQueue)
+
+	if err != nil {
+		return err
+	}
+
+	err = r.deleteAccount(ctx, user, receipt)
+
+	if err != nil {
+		return err
+	}
+
+	logger.Info("account deleted, userId: %v, receiptId: %v", user.Id, receipt.Id)
+
+	event := events.New(events.EventTypeAccountDeleted, &events.AccountDeletedPayload{
+		Email:     user.Email,
+		Name:      user.FirstName,
+		ReceiptId: receipt.Id,
+		DeletedAt: time.UnixMilli(receipt.CompletedAt).UTC().Format(time.DateOnly),
+	})
+
+	err = emailQueue.AddMessage(ctx, event)
+
+	// the account is deleted, so the event isn't retried for the email
+	if err != nil {
+		logger.Errorf("Couldn't queue account deleted email for userId: %v. \n[Error]: %v", user.Id, err)
+	}
+
+	return nil
+}
+
+// subscription statuses with pending payments
+var cancellableSubscriptionStatus = []SubscriptionStatus{
+	SubscriptionStatusActive,
+	SubscriptionStatusPastDue,
+	SubscriptionStatusPaused,
+	SubscriptionStatusTrialing,
+}
+
+// cancels the user's paddle subscription immediately, returns the subscription id & if it was cancelled
+func cancelPaidSubscription(ctx context.Context, r repository, pc paddleClientInterface, userId string) (string, bool, error) {
+	s, err := r.getSubscription(ctx, userId)
+
+	if err != nil {
+		if errors.Is(err, ErrSubscriptionNotFound) {
+			return "", false, nil
+		}
+		return "", false, err
+	}
+
+	// trial & lifetime plans have no recurring payments
+	if s.Id == "" || s.Plan != SubscriptionPlanYearly || !slices.Contains(cancellableSubscriptionStatus, s.Status) {
+		return s.Id, false, nil
+	}
+
+	_, err = pc.CancelSubscription(ctx, &paddle.CancelSubscriptionRequest{
+		SubscriptionID: s.Id,
+		EffectiveFrom:  paddle.PtrTo(paddle.EffectiveFromImmediately),
+	})
+
+	if err != nil {
+		// cancelled by a previous attempt that failed later, paddle rejects cancelling it again
+		if isSubscriptionCanceled(ctx, pc, s.Id) {
+			return s.Id, true, nil
+		}
+
+		logger.Errorf("Couldn't cancel paddle subscription for userId: %v. \n[Error]: %v", userId, err)
+		return s.Id, false, err
+	}
+
+	return s.Id, true, nil
+}
+
+// checks the subscription status on paddle, the stored status is only updated by the webhook
+func isSubscriptionCanceled(ctx context.Context, pc paddleClientInterface, subscriptionId string) bool {
+	s, err := pc.GetSubscription(ctx, &paddle.GetSubscriptionRequest{
+		SubscriptionID: subscriptionId,
+	})
+
+	if err != nil {
+		logger.Errorf("Couldn't get paddle subscription: %v. \n[Error]: %v", subscriptionId, err)
+		return false
+	}
+
+	return s.Status == paddle.SubscriptionStatusCanceled
+}
+
+func hashEmail(email string) string {
+	h := sha256.Sum256([]byte(strings.ToLower(strings.TrimSpace(email))))
+
+	return hex.EncodeToString(h[:])
+}
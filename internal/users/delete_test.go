@@ -0,0 +1,109 @@
+package users
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	paddle "github.com/PaddleHQ/paddle-go-sdk"
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/sqs"
+
+	"github.com/manishMandal02/tabsflow-backend/pkg/events"
+)
+
+// account of a user with a yearly subscription, the schedules can fail once
+type deleteRepoMock struct {
+	repository
+	user            *User
+	receipt         *deletionReceipt
+	failSchedules   bool
+	schedulesCalled int
+}
+
+func (m *deleteRepoMock) getUserByID(_ context.Context, _ string) (*User, error) {
+	if m.user == nil {
+		return nil, ErrUserNotFound
+	}
+	return m.user, nil
+}
+
+func (m *deleteRepoMock) getSubscription(_ context.Context, _ string) (*subscription, error) {
+	// the stored status isn't updated by the cancellation, only by the paddle webhook
+	return &subscription{Id: "sub-1", Plan: SubscriptionPlanYearly, Status: SubscriptionStatusActive}, nil
+}
+
+func (m *deleteRepoMock) cancelSchedules(_ context.Context, _ string, _ *events.Queue) (int, error) {
+	m.schedulesCalled++
+
+	if m.failSchedules {
+		m.failSchedules = false
+		return 0, errors.New("scheduler error")
+	}
+
+	return 2, nil
+}
+
+func (m *deleteRepoMock) deleteAccount(_ context.Context, _ *User, receipt *deletionReceipt) error {
+	m.receipt = receipt
+	m.user = nil
+	return nil
+}
+
+// paddle rejects cancelling a canceled subscription
+type paddleMock struct {
+	status paddle.SubscriptionStatus
+}
+
+func (m *paddleMock) GetSubscription(_ context.Context, _ *paddle.GetSubscriptionRequest) (*paddle.Subscription, error) {
+	return &paddle.Subscription{Status: m.status}, nil
+}
+
+func (m *paddleMock) CancelSubscription(_ context.Context, _ *paddle.CancelSubscriptionRequest) (*paddle.Subscription, error) {
+	if m.status == paddle.SubscriptionStatusCanceled {
+		return nil, errors.New("subscription is already canceled")
+	}
+
+	m.status = paddle.SubscriptionStatusCanceled
+
+	return &paddle.Subscription{Status: m.status}, nil
+}
+
+type sqsMock struct {
+	events.SQSClientInterface
+}
+
+func (m *sqsMock) SendMessage(_ context.Context, _ *sqs.SendMessageInput, _ ...func(*sqs.Options)) (*sqs.SendMessageOutput, error) {
+	return &sqs.SendMessageOutput{MessageId: aws.String("1")}, nil
+}
+
+func TestDeleteAccountRetry(t *testing.T) {
+	r := &deleteRepoMock{
+		user:          &User{Id: "user-1", FirstName: "Test", Email: "test@test.com"},
+		failSchedules: true,
+	}
+
+	pc := &paddleMock{status: paddle.SubscriptionStatusActive}
+
+	q := &events.Queue{Client: &sqsMock{}}
+
+	p := &events.DeleteAccountPayload{UserId: "user-1"}
+
+	// fails after the subscription was cancelled
+	if err := deleteAccount(context.Background(), r, pc, q, q, p); err == nil {
+		t.Fatal("deleteAccount() expected the schedules error")
+	}
+
+	if pc.status != paddle.SubscriptionStatusCanceled || r.receipt != nil {
+		t.Fatalf("deleteAccount() subscription = %v, receipt = %v, want canceled & no receipt", pc.status, r.receipt)
+	}
+
+	// retry of the message
+	if err := deleteAccount(context.Background(), r, pc, q, q, p); err != nil {
+		t.Fatalf("deleteAccount() retry error = %v", err)
+	}
+
+	if r.receipt == nil || !r.receipt.SubscriptionCanceled || r.receipt.SubscriptionId != "sub-1" || r.schedulesCalled != 2 {
+		t.Errorf("deleteAccount() retry receipt = %+v, want the canceled subscription", r.receipt)
+	}
+}
@@ -0,0 +1,129 @@
+package users
+
+import (
+	"context"
+	"errors"
+
+	lambda_events "github.com/aws/aws-lambda-go/events"
+	"github.com/manishMandal02/tabsflow-backend/pkg/db"
+	"github.com/manishMandal02/tabsflow-backend/pkg/events"
+	"github.com/manishMandal02/tabsflow-backend/pkg/http_api"
+	"github.com/manishMandal02/tabsflow-backend/pkg/logger"
+)
+
+func SQSMessagesHandler(q, emailQueue *events.Queue) http_api.SQSHandler {
+	return func(ctx context.Context, messages []lambda_events.SQSMessage) (interface{}, error) {
+		if len(messages) < 1 {
+			errMsg := "no events to process"
+			logger.Errorf("%v", errMsg)
+
+			return nil, errors.New(errMsg)
+		}
+
+		//  process batch of events
+		for _, msg := range messages {
+			// the logs & the events queued by the message have its request id
+			ctx := events.MessageContext(ctx, msg)
+
+			logger.DebugContext(ctx, "processing msg", "body", msg.Body)
+
+			eventType := ""
+
+			if _, ok := msg.MessageAttributes["event_type"]; ok {
+				eventType = *msg.MessageAttributes["event_type"].StringValue
+			} else {
+
+				e, err := events.NewFromJSON[any](msg.Body)
+
+				if err != nil {
+					logger.Errorf("error un_marshalling event from json: %v", err)
+				}
+
+				eventType = string(e.EventType)
+
+			}
+
+			logger.InfoContext(ctx, "processing event", "event_type", eventType)
+
+			err := processEvent(ctx, eventType, msg.Body, emailQueue)
+
+			if err != nil {
+				logger.ErrorContext(ctx, "error processing event", err, "event_type", eventType)
+				continue
+			}
+
+			// remove message from sqs
+			err = q.DeleteMessage(ctx, msg.ReceiptHandle)
+
+			if err != nil {
+				return nil, err
+			}
+		}
+
+		return nil, nil
+	}
+}
+
+func processEvent(ctx context.Context, eventType string, body string, emailQueue *events.Queue) error {
+	switch events.EventType(eventType) {
+	case events.EventTypeExportUserData:
+		ev, err := events.NewFromJSON[events.ExportUserDataPayload](body)
+
+		if err != nil {
+			logger.Errorf("error un_marshalling event: %v", err)
+			return err
+		}
+
+		return exportUserData(ctx, ev.Payload, emailQueue)
+
+	case events.EventTypeDeleteAccount:
+		ev, err := events.NewFromJSON[events.DeleteAccountPayload](body)
+
+		if err != nil {
+			logger.Errorf("error un_marshalling event: %v", err)
+			return err
+		}
+
+		return deleteUserAccount(ctx, ev.Payload, emailQueue)
+	}
+
+	return nil
+}
+
+// builds the account data export & sends the download link to the user's email
+func exportUserData(ctx context.Context, p *events.ExportUserDataPayload, emailQueue *events.Queue) error {
+	r := newRepository(db.New(), db.NewSearchIndexTable(), db.NewSessionTable())
+
+	format := dataExportFormat(p.Format)
+
+	if !format.isValid() {
+		format = dataExportFormatZIP
+	}
+
+	d, err := r.getAccountData(ctx, p.UserId)
+
+	if err != nil {
+		return err
+	}
+
+	f, err := encodeDataExport(d, format)
+
+	if err != nil {
+		logger.Errorf("error encoding data export for userId: %v. \n[Error]: %v", p.UserId, err)
+		return err
+	}
+
+	return sendDataExport(ctx, r, emailQueue, d.Profile, f)
+}
+
+func deleteUserAccount(ctx context.Context, p *events.DeleteAccountPayload, emailQueue *events.Queue) error {
+	r := newRepository(db.New(), db.NewSearchIndexTable(), db.NewSessionTable())
+
+	paddle, err := NewPaddleSubscriptionClient()
+
+	if err != nil {
+		return err
+	}
+
+	return deleteAccount(ctx, r, paddle, events.NewNotificationQueue(), emailQueue, p)
+}
@@ -0,0 +1,235 @@
+package users
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/manishMandal02/tabsflow-backend/config"
+	"github.com/manishMandal02/tabsflow-backend/internal/notes"
+	"github.com/manishMandal02/tabsflow-backend/internal/notifications"
+	"github.com/manishMandal02/tabsflow-backend/internal/spaces"
+	"github.com/manishMandal02/tabsflow-backend/pkg/events"
+	"github.com/manishMandal02/tabsflow-backend/pkg/logger"
+	"github.com/manishMandal02/tabsflow-backend/pkg/utils"
+)
+
+// version of the account data export schema, bumped on breaking changes
+const dataExportVersion = 1
+
+// accounts with more items are exported asynchronously, with the download link sent by email
+const maxSyncExportItems = 500
+
+// exports larger than this (lambda response limit) are stored & sent as a download link
+const maxSyncExportSize = 4 * 1024 * 1024
+
+// size of the export chunks stored in dynamodb (item size limit is 400KB)
+const dataExportChunkSize = 350 * 1024
+
+type dataExportFormat string
+
+const (
+	dataExportFormatJSON dataExportFormat = "json"
+	dataExportFormatZIP  dataExportFormat = "zip"
+)
+
+func (f dataExportFormat) isValid() bool {
+	return f == dataExportFormatJSON || f == dataExportFormatZIP
+}
+
+// all the user's account data
+type accountData struct {
+	Version       int                          `json:"version"`
+	ExportedAt    int64                        `json:"exportedAt"`
+	Profile       *User                        `json:"profile"`
+	Preferences   *Preferences                 `json:"preferences,omitempty"`
+	Subscription  *subscription                `json:"subscription,omitempty"`
+	Spaces        []spaces.SpaceData           `json:"spaces"`
+	Notes         []notes.Note                 `json:"notes"`
+	Notifications []notifications.Notification `json:"notifications"`
+}
+
+type dataExportFile struct {
+	Data        []byte
+	ContentType string
+	FileName    string
+}
+
+// encodes the account data as json, or zip with the json & notes as markdown files
+func encodeDataExport(d *accountData, format dataExportFormat) (*dataExportFile, error) {
+	data, err := json.MarshalIndent(d, "", "  ")
+
+	if err != nil {
+		return nil, err
+	}
+
+	fileName := fmt.Sprintf("tabsflow-export-%s", time.UnixMilli(d.ExportedAt).UTC().Format(time.DateOnly))
+
+	if format == dataExportFormatJSON {
+		return &dataExportFile{
+			Data:        data,
+			ContentType: "application/json",
+			FileName:    fileName + ".json",
+		}, nil
+	}
+
+	buf := new(bytes.Buffer)
+
+	zw := zip.NewWriter(buf)
+
+	f, err := zw.Create("tabsflow-export.json")
+
+	if err != nil {
+		return nil, err
+	}
+
+	_, err = f.Write(data)
+
+	if err != nil {
+		return nil, err
+	}
+
+	for _, n := range d.Notes {
+		f, err := zw.Create(fmt.Sprintf("notes/%s.md", noteFileName(&n)))
+
+		if err != nil {
+			return nil, err
+		}
+
+		_, err = f.Write([]byte(notes.ToMarkdown(&n)))
+
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	err = zw.Close()
+
+	if err != nil {
+		return nil, err
+	}
+
+	return &dataExportFile{
+		Data:        buf.Bytes(),
+		ContentType: "application/zip",
+		FileName:    fileName + ".zip",
+	}, nil
+}
+
+var nonFileNameChars = regexp.MustCompile(`[^a-z0-9]+`)
+
+// unique & file system safe name for the note
+func noteFileName(n *notes.Note) string {
+	slug := strings.Trim(nonFileNameChars.ReplaceAllString(strings.ToLower(n.Title), "-"), "-")
+
+	if len(slug) > 50 {
+		slug = strings.Trim(slug[:50], "-")
+	}
+
+	if slug == "" {
+		return n.Id
+	}
+
+	return fmt.Sprintf("%s-%s", slug, n.Id)
+}
+
+// stores the export & sends the download link to the user's email
+func sendDataExport(ctx context.Context, r repository, q *events.Queue, u *User, f *dataExportFile) error {
+	exportId := utils.GenerateID()
+
+	expiresAt := time.Now().AddDate(0, 0, config.DATA_EXPORT_EXPIRY_DAYS)
+
+	err := r.saveDataExport(ctx, u.Id, exportId, f, expiresAt.Unix())
+
+	if err != nil {
+		return err
+	}
+
+	event := events.New(events.EventTypeSendDataExport, &events.SendDataExportPayload{
+		Email:       u.Email,
+		Name:        u.FirstName,
+		DownloadURL: dataExportDownloadURL(signDataExportToken(u.Id, exportId, expiresAt.Unix())),
+		ExpiresAt:   expiresAt.UTC().Format(time.DateOnly),
+	})
+
+	err = q.AddMessage(ctx, event)
+
+	if err != nil {
+		logger.Errorf("Couldn't queue data export email for userId: %v. \n[Error]: %v", u.Id, err)
+		return err
+	}
+
+	return nil
+}
+
+// * signed download link
+
+// token to download the stored export, signed with the app secret
+func signDataExportToken(userId, exportId string, expiresAt int64) string {
+	payload := fmt.Sprintf("%s:%s:%d", userId, exportId, expiresAt)
+
+	return fmt.Sprintf("%s.%s", base64.RawURLEncoding.EncodeToString([]byte(payload)), dataExportSignature(payload))
+}
+
+// verifies the token and returns the userId & exportId
+func verifyDataExportToken(token string) (string, string, error) {
+	encodedPayload, signature, ok := strings.Cut(token, ".")
+
+	if !ok {
+		return "", "", ErrDataExportLink
+	}
+
+	payloadBytes, err := base64.RawURLEncoding.DecodeString(encodedPayload)
+
+	if err != nil {
+		return "", "", ErrDataExportLink
+	}
+
+	payload := string(payloadBytes)
+
+	if !hmac.Equal([]byte(signature), []byte(dataExportSignature(payload))) {
+		return "", "", ErrDataExportLink
+	}
+
+	parts := strings.Split(payload, ":")
+
+	if len(parts) != 3 {
+		return "", "", ErrDataExportLink
+	}
+
+	expiresAt, err := strconv.ParseInt(parts[2], 10, 64)
+
+	if err != nil || expiresAt < time.Now().Unix() {
+		return "", "", ErrDataExportExpired
+	}
+
+	return parts[0], parts[1], nil
+}
+
+// the key also signs the sessions & magic links, the purpose prefix keeps the signatures apart
+const dataExportTokenPurpose = "data-export:"
+
+func dataExportSignature(payload string) string {
+	mac := hmac.New(sha256.New, []byte(config.JWT_SECRET_KEY))
+	mac.Write([]byte(dataExportTokenPurpose + payload))
+
+	return base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
+
+func dataExportDownloadURL(token string) string {
+	p := "https"
+	if config.LOCAL_DEV_ENV {
+		p = "http"
+	}
+
+	return fmt.Sprintf("%s://%s/users/export/download?token=%s", p, config.API_DOMAIN_NAME, token)
+}
@@ -0,0 +1,134 @@
+package users
+
+import (
+	"archive/zip"
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/manishMandal02/tabsflow-backend/config"
+	"github.com/manishMandal02/tabsflow-backend/internal/notes"
+)
+
+func TestDataExportToken(t *testing.T) {
+	expiresAt := time.Now().Add(time.Hour).Unix()
+
+	token := signDataExportToken("user-1", "export-1", expiresAt)
+
+	userId, exportId, err := verifyDataExportToken(token)
+
+	if err != nil {
+		t.Fatalf("verifyDataExportToken() unexpected error = %v", err)
+	}
+
+	if userId != "user-1" || exportId != "export-1" {
+		t.Errorf("verifyDataExportToken() = %v, %v, want user-1, export-1", userId, exportId)
+	}
+
+	encodedPayload, _, _ := strings.Cut(token, ".")
+	payload, _ := base64.RawURLEncoding.DecodeString(encodedPayload)
+
+	// signature of the same key, without the purpose prefix
+	mac := hmac.New(sha256.New, []byte(config.JWT_SECRET_KEY))
+	mac.Write(payload)
+
+	tests := []struct {
+		name    string
+		token   string
+		wantErr error
+	}{
+		{
+			name:    "expired",
+			token:   signDataExportToken("user-1", "export-1", time.Now().Add(-time.Hour).Unix()),
+			wantErr: ErrDataExportExpired,
+		},
+		{
+			name:    "tampered signature",
+			token:   token[:len(token)-2] + "xx",
+			wantErr: ErrDataExportLink,
+		},
+		{
+			name:    "tampered payload",
+			token:   "dXNlci0yOmV4cG9ydC0xOjk5OTk5OTk5OTk" + token[strings.Index(token, "."):],
+			wantErr: ErrDataExportLink,
+		},
+		{
+			name:    "signed without the purpose",
+			token:   encodedPayload + "." + base64.RawURLEncoding.EncodeToString(mac.Sum(nil)),
+			wantErr: ErrDataExportLink,
+		},
+		{
+			name:    "empty",
+			token:   "",
+			wantErr: ErrDataExportLink,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, _, err := verifyDataExportToken(tt.token)
+
+			if !errors.Is(err, tt.wantErr) {
+				t.Errorf("verifyDataExportToken() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestEncodeDataExport(t *testing.T) {
+	d := &accountData{
+		Version:    dataExportVersion,
+		ExportedAt: time.Date(2024, 10, 1, 0, 0, 0, 0, time.UTC).UnixMilli(),
+		Profile:    &User{Id: "user-1", Email: "test@tabsflow.com"},
+		Notes: []notes.Note{
+			{Id: "123", Title: "My Note: Ideas!", Text: "plain text"},
+			{Id: "456"},
+		},
+	}
+
+	f, err := encodeDataExport(d, dataExportFormatJSON)
+
+	if err != nil {
+		t.Fatalf("encodeDataExport() unexpected error = %v", err)
+	}
+
+	if f.FileName != "tabsflow-export-2024-10-01.json" || f.ContentType != "application/json" {
+		t.Errorf("encodeDataExport() json file = %v, %v", f.FileName, f.ContentType)
+	}
+
+	var got accountData
+
+	if err := json.Unmarshal(f.Data, &got); err != nil || got.Profile.Id != "user-1" || len(got.Notes) != 2 {
+		t.Errorf("encodeDataExport() json data = %s, err = %v", f.Data, err)
+	}
+
+	f, err = encodeDataExport(d, dataExportFormatZIP)
+
+	if err != nil {
+		t.Fatalf("encodeDataExport() unexpected error = %v", err)
+	}
+
+	zr, err := zip.NewReader(bytes.NewReader(f.Data), int64(len(f.Data)))
+
+	if err != nil {
+		t.Fatalf("invalid zip: %v", err)
+	}
+
+	var files []string
+
+	for _, zf := range zr.File {
+		files = append(files, zf.Name)
+	}
+
+	want := []string{"tabsflow-export.json", "notes/my-note-ideas-123.md", "notes/456.md"}
+
+	if strings.Join(files, ",") != strings.Join(want, ",") {
+		t.Errorf("encodeDataExport() zip files = %v, want %v", files, want)
+	}
+}
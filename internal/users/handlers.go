@@ -3,12 +3,16 @@ package users
 import (
 	"context"
 	"encoding/json"
+	"errors"
+	"io"
 	"net/http"
 	"time"
 
 	paddle "github.com/PaddleHQ/paddle-go-sdk"
 	"github.com/PaddleHQ/paddle-go-sdk/pkg/paddlenotification"
 	"github.com/manishMandal02/tabsflow-backend/config"
+	"github.com/manishMandal02/tabsflow-backend/pkg/db"
+	"github.com/manishMandal02/tabsflow-backend/pkg/errs"
 	"github.com/manishMandal02/tabsflow-backend/pkg/events"
 	"github.com/manishMandal02/tabsflow-backend/pkg/http_api"
 	"github.com/manishMandal02/tabsflow-backend/pkg/logger"
@@ -16,21 +20,24 @@ import (
 
 type paddleClientInterface interface {
 	GetSubscription(ctx context.Context, req *paddle.GetSubscriptionRequest) (res *paddle.Subscription, err error)
+	CancelSubscription(ctx context.Context, req *paddle.CancelSubscriptionRequest) (res *paddle.Subscription, err error)
 }
 
 type handler struct {
-	r          repository
-	paddle     paddleClientInterface
-	emailQueue *events.Queue
-	httpClient http_api.Client
+	r                 repository
+	paddle            paddleClientInterface
+	emailQueue        *events.Queue
+	usersQueue        *events.Queue
+	notificationQueue *events.Queue
 }
 
-func newHandler(r repository, q *events.Queue, c http_api.Client, p paddleClientInterface) *handler {
+func newHandler(r repository, q, uq, nq *events.Queue, p paddleClientInterface) *handler {
 	return &handler{
-		r:          r,
-		paddle:     p,
-		emailQueue: q,
-		httpClient: c,
+		r:                 r,
+		paddle:            p,
+		emailQueue:        q,
+		usersQueue:        uq,
+		notificationQueue: nq,
 	}
 }
 
@@ -40,17 +47,17 @@ func (h handler) userById(w http.ResponseWriter, r *http.Request) {
 	id := r.Header.Get("UserId")
 
 	if id == "" {
-		http_api.ErrorRes(w, ErrMsg.InvalidUserId, http.StatusBadRequest)
+		http_api.ErrorRes(w, errs.BadRequest.WithMessage(ErrMsg.InvalidUserId))
 		return
 	}
 
-	user, err := h.r.getUserByID(id)
+	user, err := h.r.getUserByID(r.Context(), id)
 
 	if err != nil {
-		if err.Error() == ErrMsg.UserNotFound {
-			http_api.ErrorRes(w, ErrMsg.UserNotFound, http.StatusNotFound)
+		if errors.Is(err, ErrUserNotFound) {
+			http_api.ErrorRes(w, ErrUserNotFound)
 		} else {
-			http_api.ErrorRes(w, ErrMsg.GetUser, http.StatusInternalServerError)
+			http_api.ErrorRes(w, errs.Internal.WithMessage(ErrMsg.GetUser))
 		}
 		return
 	}
@@ -60,43 +67,35 @@ func (h handler) userById(w http.ResponseWriter, r *http.Request) {
 
 func (h handler) createUser(w http.ResponseWriter, r *http.Request) {
 
-	user, err := userFromJSON(r.Body)
+	user, err := http_api.DecodeAndValidate[User](w, r)
 
 	if err != nil {
 		logger.Error("decoding user from body at createUser()", err)
-		http_api.ErrorRes(w, ErrMsg.CreateUser, http.StatusBadRequest)
-		return
-	}
-
-	err = user.validate()
-
-	if err != nil {
-		logger.Error("error validating user at createUser()", err)
-		http_api.ErrorRes(w, ErrMsg.CreateUser, http.StatusBadRequest)
+		http_api.ErrorRes(w, err)
 		return
 	}
 
 	//  check if the user with this id exits
-	userExists, err := h.r.getUserByID(user.Id)
+	userExists, err := h.r.getUserByID(r.Context(), user.Id)
 
-	if err != nil && err.Error() != ErrMsg.UserNotFound {
+	if err != nil && !errors.Is(err, ErrUserNotFound) {
 		logger.Errorf("error getting user by id, userId: %v, \n[Error]: %v", user.Id, err)
-		http_api.ErrorRes(w, ErrMsg.GetUser, http.StatusBadGateway)
+		http_api.ErrorRes(w, errs.BadGateway.WithMessage(ErrMsg.GetUser))
 		return
 	}
 
 	//  if user exists, return error
 	if userExists != nil {
-		http_api.ErrorRes(w, ErrMsg.UserExists, http.StatusBadRequest)
+		http_api.ErrorRes(w, errs.BadRequest.WithMessage(ErrMsg.UserExists))
 		return
 	}
 
-	//  verify if this user's id is valid from auth/session service
-	shouldLogout, err := verifyUserIdFromAuthService(user, r.Host, h.httpClient)
+	//  verify if this user's id is the one assigned by the auth service on login
+	shouldLogout, err := verifyUserIdWithAuth(r.Context(), user, h.r)
 
 	if err != nil && !shouldLogout {
-		logger.Errorf("error verifying userId from auth service, userId: %v, \n[Error]: %v", user.Id, err)
-		http_api.ErrorRes(w, ErrMsg.CreateUser, http.StatusInternalServerError)
+		logger.Errorf("error verifying userId with auth, userId: %v, \n[Error]: %v", user.Id, err)
+		http_api.ErrorRes(w, errs.Internal.WithMessage(ErrMsg.CreateUser))
 		return
 	}
 
@@ -119,10 +118,10 @@ func (h handler) createUser(w http.ResponseWriter, r *http.Request) {
 		time.UTC,
 	)
 
-	err = h.r.createUserWithDefaults(user, trialEndTime.Unix())
+	err = h.r.createUserWithDefaults(r.Context(), user, trialEndTime.Unix())
 
 	if err != nil {
-		http_api.ErrorRes(w, ErrMsg.CreateUser, http.StatusBadGateway)
+		http_api.ErrorRes(w, errs.BadGateway.WithMessage(ErrMsg.CreateUser))
 		return
 	}
 
@@ -133,10 +132,10 @@ func (h handler) createUser(w http.ResponseWriter, r *http.Request) {
 		TrailEndDate: trialEndTime.Format(time.DateOnly),
 	})
 
-	err = h.emailQueue.AddMessage(event)
+	err = h.emailQueue.AddMessage(r.Context(), event)
 
 	if err != nil {
-		http_api.ErrorRes(w, ErrMsg.CreateUser, http.StatusBadGateway)
+		http_api.ErrorRes(w, errs.BadGateway.WithMessage(ErrMsg.CreateUser))
 		return
 	}
 
@@ -145,26 +144,26 @@ func (h handler) createUser(w http.ResponseWriter, r *http.Request) {
 	http_api.SuccessResMsg(w, "user created")
 }
 
+type updateUserReq struct {
+	FirstName string `json:"firstName" validate:"required"`
+	LastName  string `json:"lastName" validate:"required"`
+}
+
 func (h handler) updateUser(w http.ResponseWriter, r *http.Request) {
 	id := r.PathValue("id")
 
-	var n struct {
-		FirstName string `json:"firstName" validate:"required"`
-		LastName  string `json:"lastName" validate:"required"`
-	}
-
-	err := json.NewDecoder(r.Body).Decode(&n)
+	n, err := http_api.DecodeAndValidate[updateUserReq](w, r)
 
 	if err != nil {
 		logger.Error("error un_marshaling name from JSON at updateUser()", err)
-		http_api.ErrorRes(w, ErrMsg.UpdateUser, http.StatusBadRequest)
+		http_api.ErrorRes(w, err)
 		return
 	}
 
-	err = h.r.updateUser(id, n.FirstName, n.LastName)
+	err = h.r.updateUser(r.Context(), id, n.FirstName, n.LastName)
 
 	if err != nil {
-		http_api.ErrorRes(w, ErrMsg.UpdateUser, http.StatusBadGateway)
+		http_api.ErrorRes(w, errs.BadGateway.WithMessage(ErrMsg.UpdateUser))
 		return
 	}
 
@@ -175,14 +174,20 @@ func (h handler) deleteUser(w http.ResponseWriter, r *http.Request) {
 
 	id := r.PathValue("id")
 
-	err := h.r.deleteAccount(id)
+	// data is deleted from all the services in background
+	event := events.New(events.EventTypeDeleteAccount, &events.DeleteAccountPayload{
+		UserId:      id,
+		RequestedAt: time.Now().UnixMilli(),
+	})
+
+	err := h.usersQueue.AddMessage(r.Context(), event)
 
 	if err != nil {
-		http_api.ErrorRes(w, ErrMsg.DeleteUser, http.StatusBadRequest)
+		http_api.ErrorRes(w, errs.BadGateway.WithMessage(ErrMsg.DeleteUserRequest))
 		return
 	}
 
-	http_api.SuccessResMsg(w, "user deleted")
+	http_api.SuccessResMsg(w, "account deletion requested")
 }
 
 // preferences handlers
@@ -190,10 +195,10 @@ func (h handler) getPreferences(w http.ResponseWriter, r *http.Request) {
 
 	id := r.PathValue("id")
 
-	preferences, err := h.r.getAllPreferences(id)
+	preferences, err := h.r.getAllPreferences(r.Context(), id)
 
 	if err != nil {
-		http_api.ErrorRes(w, ErrMsg.PreferencesGet, http.StatusBadRequest)
+		http_api.ErrorRes(w, errs.BadRequest.WithMessage(ErrMsg.PreferencesGet))
 		return
 	}
 
@@ -215,7 +220,7 @@ func (h handler) updatePreferences(w http.ResponseWriter, r *http.Request) {
 
 	if err != nil {
 		logger.Error("error un_marshaling preferences from req body at updatePreferences()", err)
-		http_api.ErrorRes(w, ErrMsg.PreferencesUpdate, http.StatusBadRequest)
+		http_api.ErrorRes(w, errs.BadRequest.WithMessage(ErrMsg.PreferencesUpdate))
 		return
 	}
 
@@ -223,13 +228,13 @@ func (h handler) updatePreferences(w http.ResponseWriter, r *http.Request) {
 		sk, subPref, err := parseSubPreferencesData(key, pref)
 
 		if err != nil {
-			http_api.ErrorRes(w, ErrMsg.PreferencesUpdate, http.StatusBadRequest)
+			http_api.ErrorRes(w, errs.BadRequest.WithMessage(ErrMsg.PreferencesUpdate))
 			return
 		}
 
-		err = h.r.updatePreferences(id, sk, *subPref)
+		err = h.r.updatePreferences(r.Context(), id, sk, *subPref)
 		if err != nil {
-			http_api.ErrorRes(w, ErrMsg.PreferencesUpdate, http.StatusBadRequest)
+			http_api.ErrorRes(w, errs.BadRequest.WithMessage(ErrMsg.PreferencesUpdate))
 			return
 		}
 	}
@@ -240,23 +245,27 @@ func (h handler) updatePreferences(w http.ResponseWriter, r *http.Request) {
 func (h handler) getSubscription(w http.ResponseWriter, r *http.Request) {
 	id := r.PathValue("id")
 
-	subscription, err := h.r.getSubscription(id)
+	subscription, err := h.r.getSubscription(r.Context(), id)
 
 	if err != nil {
-		http_api.ErrorRes(w, ErrMsg.SubscriptionGet, http.StatusBadGateway)
+		http_api.ErrorRes(w, errs.BadGateway.WithMessage(ErrMsg.SubscriptionGet))
 		return
 	}
 
 	http_api.SuccessResData(w, subscription)
 }
 
+type subscriptionStatusRes struct {
+	Active bool `json:"active"`
+}
+
 func (h handler) checkSubscriptionStatus(w http.ResponseWriter, r *http.Request) {
 	id := r.PathValue("id")
 
-	s, err := h.r.getSubscription(id)
+	s, err := h.r.getSubscription(r.Context(), id)
 
 	if err != nil {
-		http_api.ErrorRes(w, ErrMsg.SubscriptionGet, http.StatusBadRequest)
+		http_api.ErrorRes(w, errs.BadRequest.WithMessage(ErrMsg.SubscriptionGet))
 		return
 	}
 
@@ -270,7 +279,7 @@ func (h handler) checkSubscriptionStatus(w http.ResponseWriter, r *http.Request)
 		// if subscription is active, check the end date
 		if err != nil {
 			logger.Error("error parsing subscription end date", err)
-			http_api.ErrorRes(w, ErrMsg.SubscriptionGet, http.StatusBadRequest)
+			http_api.ErrorRes(w, errs.BadRequest.WithMessage(ErrMsg.SubscriptionGet))
 			return
 		}
 
@@ -279,40 +288,36 @@ func (h handler) checkSubscriptionStatus(w http.ResponseWriter, r *http.Request)
 		}
 	}
 
-	status := struct {
-		Active bool `json:"active"`
-	}{
-		Active: active,
-	}
+	http_api.SuccessResData(w, subscriptionStatusRes{Active: active})
 
-	http_api.SuccessResData(w, status)
+}
 
+type paddleURLRes struct {
+	CancelURL string `json:"cancelURL,omitempty"`
+	UpdateURL string `json:"updateURL,omitempty"`
 }
 
 func (h handler) getPaddleURL(w http.ResponseWriter, r *http.Request) {
 	id := r.PathValue("id")
 
-	s, err := h.r.getSubscription(id)
+	s, err := h.r.getSubscription(r.Context(), id)
 
 	if err != nil {
-		http_api.ErrorRes(w, ErrMsg.SubscriptionGet, http.StatusBadGateway)
+		http_api.ErrorRes(w, errs.BadGateway.WithMessage(ErrMsg.SubscriptionGet))
 		return
 	}
 
-	res, err := h.paddle.GetSubscription(context.TODO(), &paddle.GetSubscriptionRequest{
+	res, err := h.paddle.GetSubscription(r.Context(), &paddle.GetSubscriptionRequest{
 		SubscriptionID: s.Id,
 	})
 
 	if err != nil {
 		logger.Error("error getting paddle subscription", err)
-		http_api.ErrorRes(w, ErrMsg.SubscriptionPaddleURL, http.StatusBadGateway)
+		http_api.ErrorRes(w, errs.BadGateway.WithMessage(ErrMsg.SubscriptionPaddleURL))
 		return
 	}
 
-	resBody := struct {
-		CancelURL string `json:"cancelURL,omitempty"`
-		UpdateURL string `json:"updateURL,omitempty"`
-	}{}
+	resBody := paddleURLRes{}
 
 	shouldSendCancelURL := r.URL.Query().Get("cancelURL") != ""
 
@@ -323,12 +328,12 @@ func (h handler) getPaddleURL(w http.ResponseWriter, r *http.Request) {
 	}
 
 	if shouldSendCancelURL && resBody.CancelURL == "" {
-		http_api.ErrorRes(w, ErrMsg.SubscriptionPaddleURL, http.StatusBadRequest)
+		http_api.ErrorRes(w, errs.BadRequest.WithMessage(ErrMsg.SubscriptionPaddleURL))
 		return
 	}
 
 	if !shouldSendCancelURL && resBody.UpdateURL == "" {
-		http_api.ErrorRes(w, ErrMsg.SubscriptionPaddleURL, http.StatusBadRequest)
+		http_api.ErrorRes(w, errs.BadRequest.WithMessage(ErrMsg.SubscriptionPaddleURL))
 		return
 	}
 
@@ -336,6 +341,159 @@ func (h handler) getPaddleURL(w http.ResponseWriter, r *http.Request) {
 
 }
 
+// account data export handlers
+
+type dataExportRes struct {
+	Async bool `json:"async"`
+}
+
+const dataExportEmailMsg = "export is being prepared, download link will be sent to your email"
+
+// queries - format:json|zip
+func (h handler) exportData(w http.ResponseWriter, r *http.Request) {
+	id := r.PathValue("id")
+
+	format := dataExportFormat(r.URL.Query().Get("format"))
+
+	if format == "" {
+		format = dataExportFormatJSON
+	}
+
+	if !format.isValid() {
+		http_api.ErrorRes(w, errs.BadRequest.WithMessage(ErrMsg.DataExportFormat))
+		return
+	}
+
+	count, err := h.r.getItemsCount(r.Context(), id)
+
+	if err != nil {
+		http_api.ErrorRes(w, errs.BadGateway.WithMessage(ErrMsg.DataExport))
+		return
+	}
+
+	// large accounts are exported in background
+	if count > maxSyncExportItems {
+		event := events.New(events.EventTypeExportUserData, &events.ExportUserDataPayload{
+			UserId: id,
+			Format: string(format),
+		})
+
+		err = h.usersQueue.AddMessage(r.Context(), event)
+
+		if err != nil {
+			http_api.ErrorRes(w, errs.BadGateway.WithMessage(ErrMsg.DataExport))
+			return
+		}
+
+		http_api.SuccessResMsgWithBody(w, dataExportEmailMsg, dataExportRes{Async: true})
+		return
+	}
+
+	d, err := h.r.getAccountData(r.Context(), id)
+
+	if err != nil {
+		http_api.ErrorRes(w, errs.BadGateway.WithMessage(ErrMsg.DataExport))
+		return
+	}
+
+	f, err := encodeDataExport(d, format)
+
+	if err != nil {
+		logger.Errorf("error encoding data export for userId: %v, \n[Error]: %v", id, err)
+		http_api.ErrorRes(w, errs.Internal.WithMessage(ErrMsg.DataExport))
+		return
+	}
+
+	if len(f.Data) > maxSyncExportSize {
+		err = sendDataExport(r.Context(), h.r, h.emailQueue, d.Profile, f)
+
+		if err != nil {
+			http_api.ErrorRes(w, errs.BadGateway.WithMessage(ErrMsg.DataExport))
+			return
+		}
+
+		http_api.SuccessResMsgWithBody(w, dataExportEmailMsg, dataExportRes{Async: true})
+		return
+	}
+
+	http_api.FileRes(w, f.Data, f.ContentType, f.FileName)
+}
+
+// queries - token:string (from the download link)
+func (h handler) downloadDataExport(w http.ResponseWriter, r *http.Request) {
+	userId, exportId, err := verifyDataExportToken(r.URL.Query().Get("token"))
+
+	if err != nil {
+		if errors.Is(err, ErrDataExportExpired) {
+			http_api.ErrorRes(w, ErrDataExportExpired)
+			return
+		}
+		http_api.ErrorRes(w, ErrDataExportLink)
+		return
+	}
+
+	f, err := h.r.getDataExport(r.Context(), userId, exportId)
+
+	if err != nil {
+		if errors.Is(err, ErrDataExportNotFound) {
+			http_api.ErrorRes(w, ErrDataExportNotFound)
+			return
+		}
+		http_api.ErrorRes(w, errs.BadGateway.WithMessage(ErrMsg.DataExport))
+		return
+	}
+
+	http_api.FileRes(w, f.Data, f.ContentType, f.FileName)
+}
+
+// account data import handler
+
+// queries - strategy:skip|overwrite|duplicate
+// body - export json or zip archive
+func (h handler) importData(w http.ResponseWriter, r *http.Request) {
+	id := r.PathValue("id")
+
+	strategy := db.ConflictStrategy(r.URL.Query().Get("strategy"))
+
+	if strategy == "" {
+		strategy = db.ConflictSkip
+	}
+
+	if !isValidConflictStrategy(strategy) {
+		http_api.ErrorRes(w, errs.BadRequest.WithMessage(ErrMsg.DataImportStrategy))
+		return
+	}
+
+	data, err := io.ReadAll(io.LimitReader(r.Body, maxDataImportSize+1))
+
+	if err != nil {
+		logger.Error("error reading data import body at importData()", err)
+		http_api.ErrorRes(w, errs.BadRequest.WithMessage(ErrMsg.DataImport))
+		return
+	}
+
+	if len(data) > maxDataImportSize {
+		http_api.ErrorRes(w, ErrDataImportSize)
+		return
+	}
+
+	d, err := decodeDataImport(data)
+
+	if err != nil {
+		http_api.ErrorRes(w, err)
+		return
+	}
+
+	report, err := h.r.importAccountData(r.Context(), id, d, strategy, h.notificationQueue)
+
+	if err != nil {
+		http_api.ErrorRes(w, errs.BadGateway.WithMessage(ErrMsg.DataImport))
+		return
+	}
+
+	http_api.SuccessResData(w, report)
+}
+
 // paddle webhook handler
 func (h handler) subscriptionWebhook(w http.ResponseWriter, r *http.Request) {
 	var err error
@@ -348,12 +506,12 @@ func (h handler) subscriptionWebhook(w http.ResponseWriter, r *http.Request) {
 
 		if err != nil {
 			logger.Error("error verifying paddle webhook", err)
-			http_api.ErrorRes(w, "Error", http.StatusInternalServerError)
+			http_api.ErrorRes(w, errs.Internal)
 			return
 		}
 
 		if !ok {
-			http_api.ErrorRes(w, "Error bad_request", http.StatusBadRequest)
+			http_api.ErrorRes(w, errs.BadRequest)
 			return
 		}
 	}
@@ -371,7 +529,7 @@ func (h handler) subscriptionWebhook(w http.ResponseWriter, r *http.Request) {
 
 	if err != nil {
 		logger.Error("error decoding paddle webhook event", err)
-		http_api.ErrorRes(w, "Error", http.StatusBadRequest)
+		http_api.ErrorRes(w, errs.BadRequest)
 		return
 	}
 
@@ -408,7 +566,7 @@ func (h handler) subscriptionWebhook(w http.ResponseWriter, r *http.Request) {
 			nextBillDate:   *c.NextBilledAt,
 		}
 
-		err = subscriptionEventHandler(h.r, subscriptionData, false)
+		err = subscriptionEventHandler(r.Context(), h.r, subscriptionData, false)
 
 		if err != nil {
 			logger.Error("Error processing SubscriptionCreated event as subscriptionWebhook()", err)
@@ -446,7 +604,7 @@ func (h handler) subscriptionWebhook(w http.ResponseWriter, r *http.Request) {
 			nextBillDate:   *u.NextBilledAt,
 		}
 
-		err = subscriptionEventHandler(h.r, subscriptionData, true)
+		err = subscriptionEventHandler(r.Context(), h.r, subscriptionData, true)
 
 		if err != nil {
 			logger.Error("Error processing SubscriptionUpdated event as subscriptionWebhook()", err)
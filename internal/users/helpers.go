@@ -1,11 +1,11 @@
 package users
 
 import (
+	"context"
 	"encoding/json"
 	"errors"
 	"fmt"
 	"net/http"
-	"strings"
 	"time"
 
 	paddle "github.com/PaddleHQ/paddle-go-sdk"
@@ -13,35 +13,44 @@ import (
 	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
 	"github.com/manishMandal02/tabsflow-backend/config"
 	"github.com/manishMandal02/tabsflow-backend/pkg/db"
+	"github.com/manishMandal02/tabsflow-backend/pkg/errs"
 	"github.com/manishMandal02/tabsflow-backend/pkg/http_api"
 	"github.com/manishMandal02/tabsflow-backend/pkg/logger"
-	"github.com/manishMandal02/tabsflow-backend/pkg/utils"
 )
 
 // gets userId from header set by authorizer, also checks if user exits
-func newUserIdMiddleware(ur repository) http_api.Handler {
-	return func(w http.ResponseWriter, r *http.Request) {
-		userId := r.Header.Get("UserId")
+func newUserIdMiddleware(ur repository) http_api.Middleware {
+	return func(next http_api.Handler) http_api.Handler {
+		return func(w http.ResponseWriter, r *http.Request) {
+			userId := r.Header.Get("UserId")
 
-		if userId == "" || !checkUserExits(userId, ur, w) {
-			http.Redirect(w, r, "/logout", http.StatusTemporaryRedirect)
-			return
-		}
+			if userId == "" {
+				http.Redirect(w, r, "/logout", http.StatusTemporaryRedirect)
+				return
+			}
+
+			// error response is written by the check
+			if !checkUserExits(r.Context(), userId, ur, w) {
+				return
+			}
 
-		r.SetPathValue("id", userId)
+			r.SetPathValue("id", userId)
+
+			next(w, r)
+		}
 	}
 }
 
-// maps default user preferences to dbb items
-func getPrefDBItems(userId string) ([]map[string]types.AttributeValue, error) {
+// maps user preferences to dbb items
+func getPrefDBItems(userId string, p *Preferences) ([]map[string]types.AttributeValue, error) {
 
 	pref := make(map[string]interface{})
 
-	pref[db.SORT_KEY.P_General] = &defaultUserPref.General
-	pref[db.SORT_KEY.P_CmdPalette] = &defaultUserPref.CmdPalette
-	pref[db.SORT_KEY.P_Notes] = &defaultUserPref.Notes
-	pref[db.SORT_KEY.P_LinkPreview] = &defaultUserPref.LinkPreview
-	pref[db.SORT_KEY.P_AutoDiscard] = &defaultUserPref.AutoDiscard
+	pref[db.SORT_KEY.P_General] = &p.General
+	pref[db.SORT_KEY.P_CmdPalette] = &p.CmdPalette
+	pref[db.SORT_KEY.P_Notes] = &p.Notes
+	pref[db.SORT_KEY.P_LinkPreview] = &p.LinkPreview
+	pref[db.SORT_KEY.P_AutoDiscard] = &p.AutoDiscard
 
 	var pData []map[string]types.AttributeValue
 
@@ -63,77 +72,47 @@ func getPrefDBItems(userId string) ([]map[string]types.AttributeValue, error) {
 
 }
 
-func checkUserExits(id string, r repository, w http.ResponseWriter) bool {
+func checkUserExits(ctx context.Context, id string, r repository, w http.ResponseWriter) bool {
 
 	if id == "" {
-		http_api.ErrorRes(w, ErrMsg.InvalidUserId, http.StatusBadRequest)
+		http_api.ErrorRes(w, errs.BadRequest.WithMessage(ErrMsg.InvalidUserId))
 		return false
 	}
 
 	//  check if the user with this id
-	userExists, err := r.getUserByID(id)
+	userExists, err := r.getUserByID(ctx, id)
 
 	if err != nil {
-		if err.Error() == ErrMsg.UserNotFound {
-			http_api.ErrorRes(w, ErrMsg.UserNotFound, http.StatusBadRequest)
+		if errors.Is(err, ErrUserNotFound) {
+			http_api.ErrorRes(w, ErrUserNotFound)
 		} else {
-			http_api.ErrorRes(w, ErrMsg.GetUser, http.StatusInternalServerError)
+			http_api.ErrorRes(w, errs.Internal.WithMessage(ErrMsg.GetUser))
 		}
 		return false
 	}
 
 	if userExists == nil {
-		http_api.ErrorRes(w, ErrMsg.UserNotFound, http.StatusNotFound)
+		http_api.ErrorRes(w, ErrUserNotFound)
 		return false
 	}
 	return true
 }
 
-func verifyUserIdFromAuthService(user *User, reqHostUrl string, c http_api.Client) (bool, error) {
-	p := "https"
-	if config.LOCAL_DEV_ENV {
-		p = "http"
-	}
-
-	if strings.Contains(reqHostUrl, "amazonaws.com") {
-		reqHostUrl += "/test"
-	}
-
-	authServiceURL := fmt.Sprintf("%s://%s/auth/user/%s", p, reqHostUrl, user.Email)
-
-	headers := map[string]string{
-		"Referrer": config.AllowedOrigins[1],
-	}
-
-	res, respBody, err := utils.MakeHTTPRequest(http.MethodGet, authServiceURL, headers, nil, c)
+// verifies the user id with the one assigned to the email by the auth service (sessions table),
+// returns true if the user should be logged out
+func verifyUserIdWithAuth(ctx context.Context, user *User, ur repository) (bool, error) {
+	userId, err := ur.userIdByEmail(ctx, user.Email)
 
 	if err != nil {
 		return false, err
 	}
 
-	if res.StatusCode != http.StatusOK {
-		return true, fmt.Errorf("User does not have a valid session profile for email: %v. \n [Error]: %v", user.Email, err)
-	}
-
-	// check user id
-	var userIdData struct {
-		Data struct {
-			UserId string `json:"userId"`
-		} `json:"data"`
+	if userId == "" {
+		return true, fmt.Errorf("User does not have a valid session profile for email: %v", user.Email)
 	}
 
-	err = json.Unmarshal([]byte(respBody), &userIdData)
-
-	if err != nil {
-		return false, err
-	}
-
-	logger.Dev("userIdData: %v", userIdData)
-	logger.Dev("user.Id: %v", user.Id)
-
-	if userIdData.Data.UserId != user.Id {
+	if userId != user.Id {
 		return true, fmt.Errorf("User Id mismatch for email: %v", user.Email)
-
 	}
 
 	return false, nil
@@ -224,7 +203,7 @@ type userSubscriptionData struct {
 }
 
 // process paddle subscription (create/update) event in webhook
-func subscriptionEventHandler(r repository, data *userSubscriptionData, isUpdatedEvent bool) error {
+func subscriptionEventHandler(ctx context.Context, r repository, data *userSubscriptionData, isUpdatedEvent bool) error {
 	// parse date to convert it to unix timestamp for db
 	startDate, err := time.Parse(time.RFC3339, data.startDate)
 	endDate, err2 := time.Parse(time.RFC3339, data.endDate)
@@ -241,6 +220,16 @@ func subscriptionEventHandler(r repository, data *userSubscriptionData, isUpdate
 		return errors.New(ErrMsg)
 	}
 
+	// subscription cancelled on account deletion, don't recreate it for the deleted user
+	if isUpdatedEvent {
+		_, err = r.getUserByID(ctx, data.userId)
+
+		if err != nil && errors.Is(err, ErrUserNotFound) {
+			logger.Info("ignoring subscription update for deleted userId: %v", data.userId)
+			return nil
+		}
+	}
+
 	plan := *parsePaddlePlan(data.priceId)
 
 	s := &subscription{
@@ -257,10 +246,10 @@ func subscriptionEventHandler(r repository, data *userSubscriptionData, isUpdate
 	}
 
 	if isUpdatedEvent {
-		err = r.updateSubscription(data.userId, s)
+		err = r.updateSubscription(ctx, data.userId, s)
 
 	} else {
-		err = r.setSubscription(data.userId, s)
+		err = r.setSubscription(ctx, data.userId, s)
 	}
 
 	if err != nil {
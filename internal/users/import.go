@@ -0,0 +1,95 @@
+package users
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/json"
+	"io"
+
+	"github.com/manishMandal02/tabsflow-backend/internal/notes"
+	"github.com/manishMandal02/tabsflow-backend/internal/spaces"
+	"github.com/manishMandal02/tabsflow-backend/pkg/db"
+)
+
+// max size of the uploaded export (lambda request limit)
+const maxDataImportSize = 6 * 1024 * 1024
+
+// max size of the export json in the zip archive
+const maxDataImportJSONSize = 50 * 1024 * 1024
+
+type dataImportReport struct {
+	Version     int                    `json:"version"`
+	Strategy    db.ConflictStrategy    `json:"strategy"`
+	Preferences bool                   `json:"preferences"`
+	Spaces      *spaces.UserDataImport `json:"spaces"`
+	Notes       *notes.UserDataImport  `json:"notes"`
+}
+
+func isValidConflictStrategy(s db.ConflictStrategy) bool {
+	return s == db.ConflictSkip || s == db.ConflictOverwrite || s == db.ConflictDuplicate
+}
+
+// decodes the account data from the export json or zip archive & validates its schema version
+func decodeDataImport(data []byte) (*accountData, error) {
+	var err error
+
+	// zip archives start with the local file header signature
+	if bytes.HasPrefix(data, []byte("PK\x03\x04")) {
+		data, err = readDataExportZip(data)
+
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	d := &accountData{}
+
+	err = json.Unmarshal(data, d)
+
+	if err != nil {
+		return nil, ErrDataImportParse
+	}
+
+	if d.Version < 1 || d.Version > dataExportVersion {
+		return nil, ErrDataImportVersion
+	}
+
+	return d, nil
+}
+
+// reads the export json from the zip archive
+func readDataExportZip(data []byte) ([]byte, error) {
+	zr, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+
+	if err != nil {
+		return nil, ErrDataImportParse
+	}
+
+	for _, f := range zr.File {
+		if f.Name != "tabsflow-export.json" {
+			continue
+		}
+
+		rc, err := f.Open()
+
+		if err != nil {
+			return nil, ErrDataImportParse
+		}
+
+		defer rc.Close()
+
+		jsonData, err := io.ReadAll(io.LimitReader(rc, maxDataImportJSONSize+1))
+
+		if err != nil {
+			return nil, ErrDataImportParse
+		}
+
+		if len(jsonData) > maxDataImportJSONSize {
+			return nil, ErrDataImportSize
+		}
+
+		return jsonData, nil
+	}
+
+	return nil, ErrDataImportParse
+}
@@ -0,0 +1,86 @@
+package users
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/manishMandal02/tabsflow-backend/internal/notes"
+)
+
+func TestDecodeDataImport(t *testing.T) {
+	d := &accountData{
+		Version:    dataExportVersion,
+		ExportedAt: time.Now().UnixMilli(),
+		Profile:    &User{Id: "user-1"},
+		Notes:      []notes.Note{{Id: "123", Title: "Note", Text: "text"}},
+	}
+
+	jsonFile, err := encodeDataExport(d, dataExportFormatJSON)
+
+	if err != nil {
+		t.Fatalf("encodeDataExport() unexpected error = %v", err)
+	}
+
+	zipFile, err := encodeDataExport(d, dataExportFormatZIP)
+
+	if err != nil {
+		t.Fatalf("encodeDataExport() unexpected error = %v", err)
+	}
+
+	tests := []struct {
+		name    string
+		data    []byte
+		wantErr error
+	}{
+		{
+			name: "json export",
+			data: jsonFile.Data,
+		},
+		{
+			name: "zip export",
+			data: zipFile.Data,
+		},
+		{
+			name:    "unsupported version",
+			data:    []byte(`{"version":99,"spaces":[]}`),
+			wantErr: ErrDataImportVersion,
+		},
+		{
+			name:    "missing version",
+			data:    []byte(`{"spaces":[]}`),
+			wantErr: ErrDataImportVersion,
+		},
+		{
+			name:    "invalid json",
+			data:    []byte(`{"version":`),
+			wantErr: ErrDataImportParse,
+		},
+		{
+			name:    "invalid zip",
+			data:    []byte("PK\x03\x04invalid"),
+			wantErr: ErrDataImportParse,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := decodeDataImport(tt.data)
+
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) {
+					t.Fatalf("decodeDataImport() error = %v, wantErr %v", err, tt.wantErr)
+				}
+				return
+			}
+
+			if err != nil {
+				t.Fatalf("decodeDataImport() unexpected error = %v", err)
+			}
+
+			if got.Version != dataExportVersion || len(got.Notes) != 1 || got.Notes[0].Id != "123" {
+				t.Errorf("decodeDataImport() = %+v", got)
+			}
+		})
+	}
+}
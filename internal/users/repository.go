@@ -1,10 +1,13 @@
 package users
 
 import (
+	"bytes"
 	"context"
 	"errors"
 	"fmt"
 	"reflect"
+	"slices"
+	"strconv"
 	"sync"
 	"time"
 
@@ -12,43 +15,65 @@ import (
 	"github.com/aws/aws-sdk-go-v2/feature/dynamodb/expression"
 	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
 	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+	"github.com/manishMandal02/tabsflow-backend/internal/auth"
+	"github.com/manishMandal02/tabsflow-backend/internal/notes"
+	"github.com/manishMandal02/tabsflow-backend/internal/notifications"
 	"github.com/manishMandal02/tabsflow-backend/internal/spaces"
 	"github.com/manishMandal02/tabsflow-backend/pkg/db"
+	"github.com/manishMandal02/tabsflow-backend/pkg/events"
 	"github.com/manishMandal02/tabsflow-backend/pkg/logger"
 )
 
 type repository interface {
-	getUserByID(id string) (*User, error)
-	createUserWithDefaults(user *User, trialEndTime int64) error
-	updateUser(id, firstName, lastName string) error
-	deleteAccount(id string) error
-	getAllPreferences(id string) (*Preferences, error)
-	setPreferences(userId, sk string, pData interface{}) error
-	updatePreferences(userId, sk string, pData interface{}) error
-	getSubscription(userId string) (*subscription, error)
-	setSubscription(userId string, s *subscription) error
-	updateSubscription(userId string, sData *subscription) error
+	getUserByID(ctx context.Context, id string) (*User, error)
+	userIdByEmail(ctx context.Context, email string) (string, error)
+	createUserWithDefaults(ctx context.Context, user *User, trialEndTime int64) error
+	updateUser(ctx context.Context, id, firstName, lastName string) error
+	deleteAccount(ctx context.Context, user *User, receipt *deletionReceipt) error
+	cancelSchedules(ctx context.Context, userId string, q *events.Queue) (int, error)
+	saveDeletionReceipt(ctx context.Context, d *deletionReceipt) error
+	getDeletionReceipt(ctx context.Context, userId string) (*deletionReceipt, error)
+	getAllPreferences(ctx context.Context, id string) (*Preferences, error)
+	setPreferences(ctx context.Context, userId, sk string, pData interface{}) error
+	updatePreferences(ctx context.Context, userId, sk string, pData interface{}) error
+	getSubscription(ctx context.Context, userId string) (*subscription, error)
+	setSubscription(ctx context.Context, userId string, s *subscription) error
+	updateSubscription(ctx context.Context, userId string, sData *subscription) error
+	getItemsCount(ctx context.Context, userId string) (int, error)
+	getAccountData(ctx context.Context, userId string) (*accountData, error)
+	saveDataExport(ctx context.Context, userId, exportId string, f *dataExportFile, expiresAt int64) error
+	getDataExport(ctx context.Context, userId, exportId string) (*dataExportFile, error)
+	importAccountData(ctx context.Context, userId string, d *accountData, strategy db.ConflictStrategy, q *events.Queue) (*dataImportReport, error)
 }
 
 type userRepo struct {
-	db *db.DDB
+	db               *db.DDB
+	searchIndexTable *db.DDB
+	sessionsTable    *db.DDB
 }
 
-func newRepository(db *db.DDB) repository {
+func newRepository(db, searchIndexTable, sessionsTable *db.DDB) repository {
 	return &userRepo{
-		db: db,
+		db:               db,
+		searchIndexTable: searchIndexTable,
+		sessionsTable:    sessionsTable,
 	}
 }
 
+// user id assigned to the email by the auth service, empty if the email hasn't logged in
+func (r *userRepo) userIdByEmail(ctx context.Context, email string) (string, error) {
+	return auth.UserIdByEmail(ctx, r.sessionsTable, email)
+}
+
 // profile
-func (r *userRepo) getUserByID(id string) (*User, error) {
+func (r *userRepo) getUserByID(ctx context.Context, id string) (*User, error) {
 
 	key := map[string]types.AttributeValue{
 		db.PK_NAME: &types.AttributeValueMemberS{Value: id},
 		db.SK_NAME: &types.AttributeValueMemberS{Value: db.SORT_KEY.Profile},
 	}
 
-	response, err := r.db.Client.GetItem(context.TODO(), &dynamodb.GetItemInput{
+	response, err := r.db.Client.GetItem(ctx, &dynamodb.GetItemInput{
 		TableName: &r.db.TableName,
 		Key:       key,
 	})
@@ -59,11 +84,11 @@ func (r *userRepo) getUserByID(id string) (*User, error) {
 	}
 
 	if response == nil || response.Item == nil {
-		return nil, errors.New(ErrMsg.UserNotFound)
+		return nil, ErrUserNotFound
 	}
 
 	if _, ok := response.Item["PK"]; !ok {
-		return nil, errors.New(ErrMsg.UserNotFound)
+		return nil, ErrUserNotFound
 	}
 
 	user := &User{}
@@ -76,18 +101,18 @@ func (r *userRepo) getUserByID(id string) (*User, error) {
 	}
 
 	if user.Id == "" {
-		return nil, errors.New(ErrMsg.UserNotFound)
+		return nil, ErrUserNotFound
 	}
 
 	return user, nil
 }
 
-func (r *userRepo) createUserWithDefaults(user *User, trailEndTime int64) error {
+func (r *userRepo) createUserWithDefaults(ctx context.Context, user *User, trailEndTime int64) error {
 
 	var transactItems []types.TransactWriteItem
 
 	// default user preferences
-	pref, err := getPrefDBItems(user.Id)
+	pref, err := getPrefDBItems(user.Id, &defaultUserPref)
 
 	if err != nil {
 		return err
@@ -161,7 +186,7 @@ func (r *userRepo) createUserWithDefaults(user *User, trailEndTime int64) error
 		},
 	})
 
-	err = r.db.TransactionWriter(transactItems)
+	err = r.db.TransactionWriter(ctx, transactItems)
 
 	if err != nil {
 		return fmt.Errorf("Couldn't create user with default data,  userId: %v. \n[Error]: %v", user.Id, err)
@@ -170,7 +195,7 @@ func (r *userRepo) createUserWithDefaults(user *User, trailEndTime int64) error
 	return nil
 }
 
-func (r userRepo) updateUser(id, firsName, lastName string) error {
+func (r userRepo) updateUser(ctx context.Context, id, firsName, lastName string) error {
 
 	key := map[string]types.AttributeValue{
 		db.PK_NAME: &types.AttributeValueMemberS{Value: id},
@@ -188,7 +213,7 @@ func (r userRepo) updateUser(id, firsName, lastName string) error {
 	}
 
 	// execute the query
-	_, err = r.db.Client.UpdateItem(context.TODO(), &dynamodb.UpdateItemInput{
+	_, err = r.db.Client.UpdateItem(ctx, &dynamodb.UpdateItemInput{
 		TableName:                 &r.db.TableName,
 		Key:                       key,
 		ExpressionAttributeNames:  expr.Names(),
@@ -205,61 +230,160 @@ func (r userRepo) updateUser(id, firsName, lastName string) error {
 }
 
 // delete user account with all their data
-func (r userRepo) deleteAccount(id string) error {
+// deletes the user's data from all the tables & saves the deletion receipt, the profile is deleted last
+func (r userRepo) deleteAccount(ctx context.Context, user *User, receipt *deletionReceipt) error {
+	// sessions & the email to userId mapping
+	for _, pk := range []string{user.Id, user.Email} {
+		sks, err := r.sessionsTable.GetAllSKs(ctx, pk)
+
+		if err != nil {
+			logger.Errorf("Couldn't get sessions for userId: %v. \n[Error]: %v", user.Id, err)
+			return err
+		}
+
+		err = r.sessionsTable.DeleteItems(ctx, pk, sks)
+
+		if err != nil {
+			logger.Errorf("Couldn't delete sessions for userId: %v. \n[Error]: %v", user.Id, err)
+			return err
+		}
 
-	allSKs, err := r.db.GetAllSKs(id)
+		receipt.SessionItems += len(sks)
+	}
+
+	searchIndexItems, err := notes.DeleteUserSearchIndex(ctx, r.searchIndexTable, user.Id)
 
 	if err != nil {
-		logger.Errorf("Couldn't get all SKs for userId: %v. \n[Error]: %v", id, err)
 		return err
 	}
 
-	// channel to collect errors from goroutines
-	errChan := make(chan error, len(allSKs)/db.DDB_MAX_BATCH_SIZE+1)
+	receipt.SearchIndexItems = searchIndexItems
 
-	var wg sync.WaitGroup
+	sks, err := r.db.GetAllSKs(ctx, user.Id)
 
-	// context with timeout
-	ctx, cancel := context.WithTimeout(context.Background(), 15*time.Second)
-	defer cancel()
+	if err != nil {
+		logger.Errorf("Couldn't get all SKs for userId: %v. \n[Error]: %v", user.Id, err)
+		return err
+	}
 
-	reqs := []types.WriteRequest{}
+	sks = slices.DeleteFunc(sks, func(sk string) bool { return sk == db.SORT_KEY.Profile })
 
-	for _, sk := range allSKs {
-		reqs = append(reqs, types.WriteRequest{
-			DeleteRequest: &types.DeleteRequest{
-				Key: map[string]types.AttributeValue{
-					db.PK_NAME: &types.AttributeValueMemberS{Value: id},
-					db.SK_NAME: &types.AttributeValueMemberS{Value: sk},
-				},
-			},
-		})
+	err = r.db.DeleteItems(ctx, user.Id, sks)
+
+	if err != nil {
+		logger.Errorf("Couldn't delete data for userId: %v. \n[Error]: %v", user.Id, err)
+		return err
 	}
 
-	r.db.BatchWriter(ctx, r.db.TableName, &wg, errChan, reqs)
+	// verify nothing is left behind, except the profile
+	remaining, err := r.db.GetAllSKs(ctx, user.Id)
 
-	// Wait for all goroutines to complete
-	go func() {
-		wg.Wait()
-		close(errChan)
-	}()
+	if err != nil {
+		return err
+	}
 
-	// Collect errors
-	var errs []error
-	for err := range errChan {
-		errs = append(errs, err)
+	if len(remaining) > 1 {
+		logger.Errorf("Couldn't delete all data for userId: %v, remaining items: %v", user.Id, len(remaining))
+		return errors.New(ErrMsg.DeleteUser)
 	}
 
-	// Return combined errors if any
-	if len(errs) > 0 {
-		return fmt.Errorf("delete search index errors: %v", errs)
+	// profile
+	receipt.MainItems = len(sks) + 1
+	receipt.CompletedAt = time.Now().UnixMilli()
+
+	err = r.saveDeletionReceipt(ctx, receipt)
+
+	if err != nil {
+		return err
+	}
+
+	err = r.db.DeleteItems(ctx, user.Id, []string{db.SORT_KEY.Profile})
+
+	if err != nil {
+		logger.Errorf("Couldn't delete profile for userId: %v. \n[Error]: %v", user.Id, err)
+		return err
+	}
+
+	return nil
+}
+
+// cancels the pending note remainder & snoozed tab schedules
+func (r userRepo) cancelSchedules(ctx context.Context, userId string, q *events.Queue) (int, error) {
+	notesCount, err := notes.CancelUserSchedules(ctx, r.db, q, userId)
+
+	if err != nil {
+		logger.Errorf("Couldn't cancel notes schedules for userId: %v. \n[Error]: %v", userId, err)
+		return notesCount, err
+	}
+
+	spacesCount, err := spaces.CancelUserSchedules(ctx, r.db, q, userId)
+
+	if err != nil {
+		logger.Errorf("Couldn't cancel snoozed tabs schedules for userId: %v. \n[Error]: %v", userId, err)
+		return notesCount + spacesCount, err
+	}
+
+	return notesCount + spacesCount, nil
+}
+
+func (r userRepo) saveDeletionReceipt(ctx context.Context, d *deletionReceipt) error {
+	av, err := attributevalue.MarshalMap(d)
+
+	if err != nil {
+		logger.Errorf("Couldn't marshal deletion receipt for userId: %v. \n[Error]: %v", d.UserId, err)
+		return err
+	}
+
+	av[db.PK_NAME] = &types.AttributeValueMemberS{Value: db.PARTITION_KEY.DeletedUser(d.UserId)}
+	av[db.SK_NAME] = &types.AttributeValueMemberS{Value: db.SORT_KEY.DeletionReceipt}
+
+	_, err = r.db.Client.PutItem(ctx, &dynamodb.PutItemInput{
+		TableName: &r.db.TableName,
+		Item:      av,
+	})
+
+	if err != nil {
+		logger.Errorf("Couldn't save deletion receipt for userId: %v. \n[Error]: %v", d.UserId, err)
+		return err
 	}
 
 	return nil
 }
 
+func (r userRepo) getDeletionReceipt(ctx context.Context, userId string) (*deletionReceipt, error) {
+	key := map[string]types.AttributeValue{
+		db.PK_NAME: &types.AttributeValueMemberS{Value: db.PARTITION_KEY.DeletedUser(userId)},
+		db.SK_NAME: &types.AttributeValueMemberS{Value: db.SORT_KEY.DeletionReceipt},
+	}
+
+	response, err := r.db.Client.GetItem(ctx, &dynamodb.GetItemInput{
+		TableName: &r.db.TableName,
+		Key:       key,
+	})
+
+	if err != nil {
+		logger.Errorf("Couldn't get deletion receipt for userId: %v. \n[Error]: %v", userId, err)
+		return nil, err
+	}
+
+	if len(response.Item) == 0 {
+		return nil, ErrDeletionReceiptNotFound
+	}
+
+	d := &deletionReceipt{}
+
+	err = attributevalue.UnmarshalMap(response.Item, d)
+
+	if err != nil {
+		logger.Errorf("Couldn't unmarshal deletion receipt for userId: %v. \n[Error]: %v", userId, err)
+		return nil, err
+	}
+
+	return d, nil
+}
+
 // preferences
-func (r userRepo) getAllPreferences(id string) (*Preferences, error) {
+func (r userRepo) getAllPreferences(ctx context.Context, id string) (*Preferences, error) {
 	// primary key - partition+sort key
 	keyCondition := expression.KeyAnd(expression.Key("PK").Equal(expression.Value(id)), expression.Key("SK").BeginsWith(db.SORT_KEY.PreferencesBase))
 
@@ -270,7 +394,7 @@ func (r userRepo) getAllPreferences(id string) (*Preferences, error) {
 		return nil, err
 	}
 
-	response, err := r.db.Client.Query(context.TODO(), &dynamodb.QueryInput{
+	response, err := r.db.Client.Query(ctx, &dynamodb.QueryInput{
 		TableName:                 &r.db.TableName,
 		ExpressionAttributeNames:  expr.Names(),
 		ExpressionAttributeValues: expr.Values(),
@@ -283,7 +407,7 @@ func (r userRepo) getAllPreferences(id string) (*Preferences, error) {
 	}
 
 	if len(response.Items) < 1 {
-		return nil, errors.New(ErrMsg.PreferencesGet)
+		return nil, ErrPreferencesNotFound
 	}
 	p, err := unMarshalPreferences(response)
 
@@ -294,7 +418,7 @@ func (r userRepo) getAllPreferences(id string) (*Preferences, error) {
 	return p, nil
 }
 
-func (r userRepo) setPreferences(userId, sk string, pData interface{}) error {
+func (r userRepo) setPreferences(ctx context.Context, userId, sk string, pData interface{}) error {
 	av, err := attributevalue.MarshalMap(pData)
 
 	if err != nil {
@@ -304,7 +428,7 @@ func (r userRepo) setPreferences(userId, sk string, pData interface{}) error {
 	av["PK"] = &types.AttributeValueMemberS{Value: userId}
 	av["SK"] = &types.AttributeValueMemberS{Value: sk}
 
-	_, err = r.db.Client.PutItem(context.TODO(), &dynamodb.PutItemInput{
+	_, err = r.db.Client.PutItem(ctx, &dynamodb.PutItemInput{
 		TableName: &r.db.TableName,
 		Item:      av,
 	})
@@ -315,7 +439,7 @@ func (r userRepo) setPreferences(userId, sk string, pData interface{}) error {
 	return nil
 }
 
-func (r userRepo) updatePreferences(userId, sk string, pData interface{}) error {
+func (r userRepo) updatePreferences(ctx context.Context, userId, sk string, pData interface{}) error {
 
 	key := map[string]types.AttributeValue{
 		"PK": &types.AttributeValueMemberS{Value: userId},
@@ -352,7 +476,7 @@ func (r userRepo) updatePreferences(userId, sk string, pData interface{}) error
 		return err
 	}
 
-	_, err = r.db.Client.UpdateItem(context.TODO(), &dynamodb.UpdateItemInput{
+	_, err = r.db.Client.UpdateItem(ctx, &dynamodb.UpdateItemInput{
 		TableName:                 &r.db.TableName,
 		Key:                       key,
 		ExpressionAttributeNames:  expr.Names(),
@@ -369,14 +493,14 @@ func (r userRepo) updatePreferences(userId, sk string, pData interface{}) error
 }
 
 // subscription
-func (r userRepo) getSubscription(userId string) (*subscription, error) {
+func (r userRepo) getSubscription(ctx context.Context, userId string) (*subscription, error) {
 
 	key := map[string]types.AttributeValue{
 		"PK": &types.AttributeValueMemberS{Value: userId},
 		"SK": &types.AttributeValueMemberS{Value: db.SORT_KEY.Subscription},
 	}
 
-	response, err := r.db.Client.GetItem(context.TODO(), &dynamodb.GetItemInput{
+	response, err := r.db.Client.GetItem(ctx, &dynamodb.GetItemInput{
 		TableName: &r.db.TableName,
 		Key:       key,
 	})
@@ -386,7 +510,7 @@ func (r userRepo) getSubscription(userId string) (*subscription, error) {
 		return nil, err
 	}
 	if _, ok := response.Item["PK"]; !ok {
-		return nil, errors.New(ErrMsg.SubscriptionGet)
+		return nil, ErrSubscriptionNotFound
 	}
 	s := &subscription{}
 
@@ -401,7 +525,7 @@ func (r userRepo) getSubscription(userId string) (*subscription, error) {
 
 }
 
-func (r userRepo) setSubscription(userId string, s *subscription) error {
+func (r userRepo) setSubscription(ctx context.Context, userId string, s *subscription) error {
 
 	av, err := attributevalue.MarshalMap(s)
 	if err != nil {
@@ -410,7 +534,7 @@ func (r userRepo) setSubscription(userId string, s *subscription) error {
 	av["PK"] = &types.AttributeValueMemberS{Value: userId}
 	av["SK"] = &types.AttributeValueMemberS{Value: db.SORT_KEY.Subscription}
 
-	_, err = r.db.Client.PutItem(context.TODO(), &dynamodb.PutItemInput{
+	_, err = r.db.Client.PutItem(ctx, &dynamodb.PutItemInput{
 		TableName: &r.db.TableName,
 		Item:      av,
 	})
@@ -423,7 +547,7 @@ func (r userRepo) setSubscription(userId string, s *subscription) error {
 	return nil
 }
 
-func (r userRepo) updateSubscription(userId string, sData *subscription) error {
+func (r userRepo) updateSubscription(ctx context.Context, userId string, sData *subscription) error {
 	key := map[string]types.AttributeValue{
 		"PK": &types.AttributeValueMemberS{Value: userId},
 		"SK": &types.AttributeValueMemberS{Value: db.SORT_KEY.Subscription},
@@ -459,7 +583,7 @@ func (r userRepo) updateSubscription(userId string, sData *subscription) error {
 		return err
 	}
 
-	_, err = r.db.Client.UpdateItem(context.TODO(), &dynamodb.UpdateItemInput{
+	_, err = r.db.Client.UpdateItem(ctx, &dynamodb.UpdateItemInput{
 		TableName:                 &r.db.TableName,
 		Key:                       key,
 		ExpressionAttributeNames:  expr.Names(),
@@ -475,7 +599,292 @@ func (r userRepo) updateSubscription(userId string, sData *subscription) error {
 	return nil
 }
 
+// data export
+
+// number of items stored for the user in main table
+func (r userRepo) getItemsCount(ctx context.Context, userId string) (int, error) {
+	key := expression.Key(db.PK_NAME).Equal(expression.Value(userId))
+
+	expr, err := expression.NewBuilder().WithKeyCondition(key).Build()
+
+	if err != nil {
+		logger.Errorf("Couldn't build getItemsCount expression for userId: %v. \n[Error]: %v", userId, err)
+		return 0, err
+	}
+
+	paginator := dynamodb.NewQueryPaginator(r.db.Client, &dynamodb.QueryInput{
+		TableName:                 &r.db.TableName,
+		ExpressionAttributeNames:  expr.Names(),
+		ExpressionAttributeValues: expr.Values(),
+		KeyConditionExpression:    expr.KeyCondition(),
+		Select:                    types.SelectCount,
+	})
+
+	count := 0
+
+	for paginator.HasMorePages() {
+		page, err := paginator.NextPage(ctx)
+
+		if err != nil {
+			logger.Errorf("Couldn't count items for userId: %v. \n[Error]: %v", userId, err)
+			return 0, err
+		}
+
+		count += int(page.Count)
+	}
+
+	return count, nil
+}
+
+// collects all the user's data across services
+func (r userRepo) getAccountData(ctx context.Context, userId string) (*accountData, error) {
+	user, err := r.getUserByID(ctx, userId)
+
+	if err != nil {
+		return nil, err
+	}
+
+	d := &accountData{
+		Version:    dataExportVersion,
+		ExportedAt: time.Now().UnixMilli(),
+		Profile:    user,
+	}
+
+	d.Preferences, err = r.getAllPreferences(ctx, userId)
+
+	if err != nil && !errors.Is(err, ErrPreferencesNotFound) {
+		return nil, err
+	}
+
+	d.Subscription, err = r.getSubscription(ctx, userId)
+
+	if err != nil && !errors.Is(err, ErrSubscriptionNotFound) {
+		return nil, err
+	}
+
+	d.Spaces, err = spaces.ExportUserData(ctx, r.db, userId)
+
+	if err != nil {
+		logger.Errorf("Couldn't get spaces data for userId: %v. \n[Error]: %v", userId, err)
+		return nil, err
+	}
+
+	d.Notes, err = notes.ExportUserData(ctx, r.db, userId)
+
+	if err != nil {
+		logger.Errorf("Couldn't get notes data for userId: %v. \n[Error]: %v", userId, err)
+		return nil, err
+	}
+
+	d.Notifications, err = notifications.ExportUserData(ctx, r.db, userId)
+
+	if err != nil {
+		logger.Errorf("Couldn't get notifications data for userId: %v. \n[Error]: %v", userId, err)
+		return nil, err
+	}
+
+	return d, nil
+}
+
+// stores the export file in chunks, removed after it expires (TTL)
+func (r userRepo) saveDataExport(ctx context.Context, userId, exportId string, f *dataExportFile, expiresAt int64) error {
+	ttl := &types.AttributeValueMemberN{Value: strconv.FormatInt(expiresAt, 10)}
+
+	chunks := (len(f.Data) + dataExportChunkSize - 1) / dataExportChunkSize
+
+	reqs := []types.WriteRequest{
+		{
+			PutRequest: &types.PutRequest{
+				Item: map[string]types.AttributeValue{
+					db.PK_NAME:      &types.AttributeValueMemberS{Value: userId},
+					db.SK_NAME:      &types.AttributeValueMemberS{Value: db.SORT_KEY.DataExport(exportId)},
+					"ContentType":   &types.AttributeValueMemberS{Value: f.ContentType},
+					"FileName":      &types.AttributeValueMemberS{Value: f.FileName},
+					"Chunks":        &types.AttributeValueMemberN{Value: strconv.Itoa(chunks)},
+					db.TTL_KEY_NAME: ttl,
+				},
+			},
+		},
+	}
+
+	for i := 0; i < chunks; i++ {
+		end := min((i+1)*dataExportChunkSize, len(f.Data))
+
+		reqs = append(reqs, types.WriteRequest{
+			PutRequest: &types.PutRequest{
+				Item: map[string]types.AttributeValue{
+					db.PK_NAME:      &types.AttributeValueMemberS{Value: userId},
+					db.SK_NAME:      &types.AttributeValueMemberS{Value: dataExportChunkSK(exportId, i)},
+					"Data":          &types.AttributeValueMemberB{Value: f.Data[i*dataExportChunkSize : end]},
+					db.TTL_KEY_NAME: ttl,
+				},
+			},
+		})
+	}
+
+	// context with timeout
+	ctx, cancel := context.WithTimeout(ctx, 15*time.Second)
+	defer cancel()
+
+	errChan := make(chan error, len(reqs))
+
+	var wg sync.WaitGroup
+
+	r.db.BatchWriter(ctx, r.db.TableName, &wg, errChan, reqs)
+
+	// Wait for all goroutines to complete
+	go func() {
+		wg.Wait()
+		close(errChan)
+	}()
+
+	// Collect errors
+	var errs []error
+	for err := range errChan {
+		errs = append(errs, err)
+	}
+
+	if len(errs) > 0 {
+		logger.Errorf("Couldn't save data export for userId: %v. \n[Error]: %v", userId, errs)
+		return errs[0]
+	}
+
+	return nil
+}
+
+func (r userRepo) getDataExport(ctx context.Context, userId, exportId string) (*dataExportFile, error) {
+	key := expression.KeyAnd(expression.Key(db.PK_NAME).Equal(expression.Value(userId)), expression.Key(db.SK_NAME).BeginsWith(db.SORT_KEY.DataExport(exportId)))
+
+	expr, err := expression.NewBuilder().WithKeyCondition(key).Build()
+
+	if err != nil {
+		logger.Errorf("Couldn't build getDataExport expression for userId: %v. \n[Error]: %v", userId, err)
+		return nil, err
+	}
+
+	paginator := dynamodb.NewQueryPaginator(r.db.Client, &dynamodb.QueryInput{
+		TableName:                 &r.db.TableName,
+		ExpressionAttributeNames:  expr.Names(),
+		ExpressionAttributeValues: expr.Values(),
+		KeyConditionExpression:    expr.KeyCondition(),
+	})
+
+	var meta struct {
+		ContentType string
+		FileName    string
+		Chunks      int
+		TTL         int64
+	}
+
+	var chunks [][]byte
+
+	for paginator.HasMorePages() {
+		page, err := paginator.NextPage(ctx)
+
+		if err != nil {
+			logger.Errorf("Couldn't get data export for userId: %v. \n[Error]: %v", userId, err)
+			return nil, err
+		}
+
+		for _, item := range page.Items {
+			sk := item[db.SK_NAME].(*types.AttributeValueMemberS).Value
+
+			if sk == db.SORT_KEY.DataExport(exportId) {
+				err = attributevalue.UnmarshalMap(item, &meta)
+
+				if err != nil {
+					logger.Errorf("Couldn't unmarshal data export for userId: %v. \n[Error]: %v", userId, err)
+					return nil, err
+				}
+				continue
+			}
+
+			// chunks are sorted by their zero padded index
+			data, ok := item["Data"].(*types.AttributeValueMemberB)
+
+			if !ok {
+				return nil, ErrDataExportNotFound
+			}
+
+			chunks = append(chunks, data.Value)
+		}
+	}
+
+	// items are removed by TTL after they expire, but not immediately
+	if meta.Chunks == 0 || len(chunks) != meta.Chunks || meta.TTL < time.Now().Unix() {
+		return nil, ErrDataExportNotFound
+	}
+
+	return &dataExportFile{
+		Data:        bytes.Join(chunks, nil),
+		ContentType: meta.ContentType,
+		FileName:    meta.FileName,
+	}, nil
+}
+
+// data import
+
+// restores the account data to the user's account, profile & subscription are not imported
+func (r userRepo) importAccountData(ctx context.Context, userId string, d *accountData, strategy db.ConflictStrategy, q *events.Queue) (*dataImportReport, error) {
+	report := &dataImportReport{
+		Version:  d.Version,
+		Strategy: strategy,
+	}
+
+	// users always have preferences (defaults), so they are replaced only if conflicts are not skipped
+	if d.Preferences != nil && strategy != db.ConflictSkip {
+		items, err := getPrefDBItems(userId, d.Preferences)
+
+		if err != nil {
+			logger.Errorf("Couldn't marshal imported preferences for userId: %v. \n[Error]: %v", userId, err)
+			return nil, err
+		}
+
+		var transactItems []types.TransactWriteItem
+
+		for _, item := range items {
+			transactItems = append(transactItems, types.TransactWriteItem{
+				Put: &types.Put{
+					TableName: &r.db.TableName,
+					Item:      item,
+				},
+			})
+		}
+
+		err = r.db.TransactionWriter(ctx, transactItems)
+
+		if err != nil {
+			logger.Errorf("Couldn't import preferences for userId: %v. \n[Error]: %v", userId, err)
+			return nil, err
+		}
+
+		report.Preferences = true
+	}
+
+	var err error
+
+	report.Spaces, err = spaces.ImportUserData(ctx, r.db, q, userId, d.Spaces, strategy)
+
+	if err != nil {
+		logger.Errorf("Couldn't import spaces for userId: %v. \n[Error]: %v", userId, err)
+		return report, err
+	}
+
+	report.Notes, err = notes.ImportUserData(ctx, r.db, r.searchIndexTable, q, userId, d.Notes, report.Spaces.RemappedIds, strategy)
+
+	if err != nil {
+		logger.Errorf("Couldn't import notes for userId: %v. \n[Error]: %v", userId, err)
+		return report, err
+	}
+
+	return report, nil
+}
+
 // * helper
+func dataExportChunkSK(exportId string, i int) string {
+	return fmt.Sprintf("%s#%03d", db.SORT_KEY.DataExport(exportId), i)
+}
+
 func unMarshalPreferences(res *dynamodb.QueryOutput) (*Preferences, error) {
 
 	unmarshal := func(item map[string]types.AttributeValue, v interface{}) error {
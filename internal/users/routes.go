@@ -1,41 +1,121 @@
 package users
 
 import (
+	"net/http"
+
+	"github.com/manishMandal02/tabsflow-backend/config"
 	"github.com/manishMandal02/tabsflow-backend/pkg/db"
 	"github.com/manishMandal02/tabsflow-backend/pkg/events"
+	"github.com/manishMandal02/tabsflow-backend/pkg/health"
 	"github.com/manishMandal02/tabsflow-backend/pkg/http_api"
 )
 
-func Router(db *db.DDB, q *events.Queue, c http_api.Client, p paddleClientInterface) http_api.IRouter {
+func Router(db, searchIndexTable, sessionsTable *db.DDB, emailQueue, usersQueue, notificationQueue *events.Queue, p paddleClientInterface) http_api.IRouter {
+
+	r := newRepository(db, searchIndexTable, sessionsTable)
+
+	handler := newHandler(r, emailQueue, usersQueue, notificationQueue, p)
+
+	router := http_api.NewRouter("/users")
 
-	r := newRepository(db)
+	checks := []health.Check{
+		health.Table(db),
+		health.Table(searchIndexTable),
+		health.Table(sessionsTable),
+		health.Queue("email", emailQueue),
+		health.Queue("users", usersQueue),
+		health.Queue("notifications", notificationQueue),
+		health.Config("paddle", map[string]string{"PADDLE_API_KEY": config.PADDLE_API_KEY, "PADDLE_WEBHOOK_SECRET_KEY": config.PADDLE_WEBHOOK_SECRET_KEY}),
+		// signs the data export download links
+		health.Config("jwt", map[string]string{"JWT_SECRET_KEY": config.JWT_SECRET_KEY}),
+	}
 
-	handler := newHandler(r, q, c, p)
+	// health routes, outside the service's middleware
+	health.Routes(router, checks...)
+	health.DiagnosticsRoute(router, checks...)
 
-	usersRouter := http_api.NewRouter("/users")
+	usersRouter := router.Group("/")
 
 	usersRouter.Use(http_api.SetAllowOriginHeader())
 
-	checkUserMiddleware := newUserIdMiddleware(r)
+	// api token requests need the read/write scope
+	usersRouter.Use(http_api.RequireTokenScope("users"))
+
+	// routes of an existing user
+	userRouter := usersRouter.Group("/", newUserIdMiddleware(r))
 
 	// profile
-	usersRouter.GET("/me", handler.userById)
-	usersRouter.POST("/", handler.createUser)
-	usersRouter.PATCH("/", checkUserMiddleware, handler.updateUser)
-	// TODO: test delete handler after adding more data
-	usersRouter.DELETE("/", checkUserMiddleware, handler.deleteUser)
+	usersRouter.GET("/me", handler.userById).Doc(http_api.RouteDoc{
+		Summary:  "Profile of the logged in user",
+		Response: User{},
+	})
+	usersRouter.POST("/", handler.createUser).Doc(http_api.RouteDoc{
+		Summary: "Create the user with the default preferences & a trial, after the first login",
+		Body:    User{},
+		// to logout, if the user id isn't the one assigned on login
+		Redirect: http.StatusTemporaryRedirect,
+	})
+	userRouter.PATCH("/", handler.updateUser).Doc(http_api.RouteDoc{
+		Summary: "Update the name of the user",
+		Body:    updateUserReq{},
+	})
+	// deletes the account & all its data in background
+	userRouter.DELETE("/", handler.deleteUser).Doc(http_api.RouteDoc{Summary: "Delete the account & all its data, in background"})
 
 	// preferences
-	usersRouter.GET("/preferences", checkUserMiddleware, handler.getPreferences)
-	usersRouter.PATCH("/preferences", checkUserMiddleware, handler.updatePreferences)
+	userRouter.GET("/preferences", handler.getPreferences).Doc(http_api.RouteDoc{
+		Summary:  "Preferences of the user",
+		Response: &Preferences{},
+	})
+	userRouter.PATCH("/preferences", handler.updatePreferences).Doc(http_api.RouteDoc{
+		Summary: "Update the preferences, by sub preference",
+		Body:    Preferences{},
+	})
 
 	// subscription
-	usersRouter.GET("/subscription", checkUserMiddleware, handler.getSubscription)
-	usersRouter.GET("/subscription/status", checkUserMiddleware, handler.checkSubscriptionStatus)
-	// queries - cancelURL:bool
-	usersRouter.GET("/subscription/paddle-url", checkUserMiddleware, handler.getPaddleURL)
-	usersRouter.POST("/subscription/webhook", handler.subscriptionWebhook)
+	userRouter.GET("/subscription", handler.getSubscription).Doc(http_api.RouteDoc{
+		Summary:  "Subscription of the user",
+		Response: &subscription{},
+	})
+	userRouter.GET("/subscription/status", handler.checkSubscriptionStatus).Doc(http_api.RouteDoc{
+		Summary:  "Whether the subscription is active",
+		Response: subscriptionStatusRes{},
+	})
+	userRouter.GET("/subscription/paddle-url", handler.getPaddleURL).Doc(http_api.RouteDoc{
+		Summary:  "Paddle url to update the payment method, or to cancel the subscription",
+		Query:    []http_api.QueryParam{{Name: "cancelURL", Description: "any value: the cancel url"}},
+		Response: paddleURLRes{},
+	})
+	usersRouter.POST("/subscription/webhook", handler.subscriptionWebhook).Doc(http_api.RouteDoc{
+		Summary: "Paddle subscription events, authorized by the paddle signature",
+		Auth:    http_api.AuthNone,
+	})
+
+	// account data export
+	userRouter.GET("/export", handler.exportData).Doc(http_api.RouteDoc{
+		Summary:  "Export the account data, large exports are sent by email",
+		Query:    []http_api.QueryParam{{Name: "format", Description: "json (default) or zip"}},
+		Response: dataExportRes{},
+		Files:    map[string]any{"application/json": accountData{}, "application/zip": nil},
+	})
+	// public, authorized by the signed token in download link; outside the CORS middleware,
+	// the link is opened from the email (top-level navigation, without an Origin header)
+	router.GET("/export/download", handler.downloadDataExport).Doc(http_api.RouteDoc{
+		Summary: "Download an export sent by email",
+		Query:   []http_api.QueryParam{{Name: "token", Description: "signed token of the download link", Required: true}},
+		Files:   map[string]any{"application/json": accountData{}, "application/zip": nil},
+		Auth:    http_api.AuthNone,
+	})
+
+	// account data import
+	userRouter.POST("/import", handler.importData).Doc(http_api.RouteDoc{
+		Summary:   "Import the account data from an export json or zip archive",
+		Query:     []http_api.QueryParam{{Name: "strategy", Description: "for the existing items: skip (default), overwrite or duplicate"}},
+		Body:      accountData{},
+		BodyFiles: []string{"application/zip"},
+		Response:  dataImportReport{},
+	})
 
 	// serve API routes
-	return usersRouter
+	return router
 }
@@ -1,18 +1,13 @@
 package users
 
-import (
-	"encoding/json"
-	"io"
-
-	"github.com/go-playground/validator/v10"
-)
+import "github.com/manishMandal02/tabsflow-backend/pkg/errs"
 
 type User struct {
 	Id         string `json:"id" dynamodbav:"PK" validate:"required"`
 	FirstName  string `json:"firstName" dynamodbav:"FirstName" validate:"required"`
 	LastName   string `json:"lastName" dynamodbav:"LastName" validate:"required"`
 	Email      string `json:"email" dynamodbav:"Email" validate:"required,email"`
-	ProfilePic string `json:"profilePic,omitempty" dynamodbav:"ProfilePic" validate:"omitempty,url"`
+	ProfilePic string `json:"profilePic,omitempty" dynamodbav:"ProfilePic" validate:"omitempty,http_url"`
 }
 
 type userWithSK struct {
@@ -20,33 +15,6 @@ type userWithSK struct {
 	SK string `json:"sk" dynamodbav:"SK"`
 }
 
-func userFromJSON(body io.Reader) (*User, error) {
-
-	var u *User
-
-	err := json.NewDecoder(body).Decode(&u)
-
-	if err != nil {
-		return nil, err
-	}
-
-	return u, nil
-
-}
-
-func (u *User) validate() error {
-	validate := validator.New(validator.WithRequiredStructEnabled())
-
-	err := validate.Struct(u)
-
-	if err != nil {
-		return err
-	}
-
-	return nil
-
-}
-
 type SubscriptionPlan string
 
 const (
@@ -151,9 +119,21 @@ var defaultUserPref = Preferences{
 	},
 }
 
+var (
+	ErrUserNotFound            = errs.NotFound.New("user_not_found", "User not found")
+	ErrPreferencesNotFound     = errs.NotFound.New("preferences_not_found", "Preferences not found")
+	ErrSubscriptionNotFound    = errs.NotFound.New("subscription_not_found", "Subscription not found")
+	ErrDeletionReceiptNotFound = errs.NotFound.New("deletion_receipt_not_found", "Deletion receipt not found")
+	ErrDataExportNotFound      = errs.NotFound.New("data_export_not_found", "Data export not found")
+	ErrDataExportLink          = errs.Unauthorized.New("invalid_download_link", "Invalid download link")
+	ErrDataExportExpired       = errs.Gone.New("download_link_expired", "Download link expired")
+	ErrDataImportParse         = errs.BadRequest.New("invalid_export_document", "Invalid export document")
+	ErrDataImportVersion       = errs.BadRequest.New("unsupported_export_version", "Unsupported export version")
+	ErrDataImportSize          = errs.PayloadTooLarge.New("export_document_too_large", "Export document too large")
+)
+
 var ErrMsg = struct {
 	GetUser               string
-	UserNotFound          string
 	UserExists            string
 	CreateUser            string
 	UpdateUser            string
@@ -165,9 +145,13 @@ var ErrMsg = struct {
 	SubscriptionUpdate    string
 	SubscriptionCheck     string
 	SubscriptionPaddleURL string
+	DataExport            string
+	DataExportFormat      string
+	DataImport            string
+	DeleteUserRequest     string
+	DataImportStrategy    string
 }{
 	GetUser:               "Error getting user",
-	UserNotFound:          "User not found",
 	UserExists:            "User already exits",
 	CreateUser:            "Error creating user",
 	UpdateUser:            "Error updating user",
@@ -179,4 +163,9 @@ var ErrMsg = struct {
 	SubscriptionUpdate:    "Error updating subscription",
 	SubscriptionCheck:     "Error checking subscription status",
 	SubscriptionPaddleURL: "Error getting paddle url",
+	DataExport:            "Error exporting account data",
+	DataExportFormat:      "Invalid export format",
+	DataImport:            "Error importing account data",
+	DeleteUserRequest:     "Error requesting account deletion",
+	DataImportStrategy:    "Invalid conflict strategy",
 }
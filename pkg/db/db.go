@@ -19,10 +19,30 @@ type DynamoDBClientInterface interface {
 	BatchGetItem(ctx context.Context, params *dynamodb.BatchGetItemInput, optFns ...func(*dynamodb.Options)) (*dynamodb.BatchGetItemOutput, error)
 	BatchWriteItem(ctx context.Context, params *dynamodb.BatchWriteItemInput, optFns ...func(*dynamodb.Options)) (*dynamodb.BatchWriteItemOutput, error)
 	TransactWriteItems(ctx context.Context, params *dynamodb.TransactWriteItemsInput, optFns ...func(*dynamodb.Options)) (*dynamodb.TransactWriteItemsOutput, error)
+	Scan(ctx context.Context, params *dynamodb.ScanInput, optFns ...func(*dynamodb.Options)) (*dynamodb.ScanOutput, error)
 }
 
 const DDB_MAX_BATCH_SIZE int = 25
 
+const DDB_MAX_TRANSACTION_SIZE int = 100
+
+// sparse index of the main table, only the general preferences items have the DeleteUnsavedSpaces attribute;
+// partition key: DeleteUnsavedSpaces, sort key: PK (user id)
+const DELETE_UNSAVED_SPACES_INDEX = "DeleteUnsavedSpaces-index"
+
+// deadline of the batch writes, a request context ending sooner (client disconnect, lambda deadline) still cancels them
+const BatchTimeout = 30 * time.Second
+
+// how imported items are written, if an item with the same id already exists
+type ConflictStrategy string
+
+const (
+	ConflictSkip      ConflictStrategy = "skip"
+	ConflictOverwrite ConflictStrategy = "overwrite"
+	// imported as a new item, with a new id
+	ConflictDuplicate ConflictStrategy = "duplicate"
+)
+
 type DDB struct {
 	Client    DynamoDBClientInterface
 	TableName string
@@ -32,7 +52,7 @@ type DDB struct {
 // new instance of main table
 func New() *DDB {
 	return &DDB{
-		Client:    newDBB(),
+		Client:    newInstrumentedClient(newDBB(), config.DDB_MAIN_TABLE_NAME),
 		TableName: config.DDB_MAIN_TABLE_NAME,
 		Limiter:   newLimiter(),
 	}
@@ -41,15 +61,16 @@ func New() *DDB {
 // new instance of session table
 func NewSessionTable() *DDB {
 	return &DDB{
-		Client:    newDBB(),
+		Client:    newInstrumentedClient(newDBB(), config.DDB_SESSIONS_TABLE_NAME),
 		TableName: config.DDB_SESSIONS_TABLE_NAME,
+		Limiter:   newLimiter(),
 	}
 }
 
 // new instance od search index table
 func NewSearchIndexTable() *DDB {
 	return &DDB{
-		Client:    newDBB(),
+		Client:    newInstrumentedClient(newDBB(), config.DDB_SEARCH_INDEX_TABLE_NAME),
 		TableName: config.DDB_SEARCH_INDEX_TABLE_NAME,
 		Limiter:   newLimiter(),
 	}
@@ -2,12 +2,14 @@ package db
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"math"
 	"math/rand/v2"
 	"sync"
 	"time"
 
+	"github.com/aws/aws-sdk-go-v2/aws"
 	"github.com/aws/aws-sdk-go-v2/feature/dynamodb/attributevalue"
 	"github.com/aws/aws-sdk-go-v2/feature/dynamodb/expression"
 	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
@@ -15,98 +17,217 @@ import (
 	"github.com/manishMandal02/tabsflow-backend/pkg/logger"
 )
 
-func getAllStaticSKs() []string {
-	return []string{
-		SORT_KEY.Profile,
-		SORT_KEY.Subscription,
-		SORT_KEY.UsageAnalytics,
-		SORT_KEY.P_General,
-		SORT_KEY.P_Notes,
-		SORT_KEY.P_CmdPalette,
-		SORT_KEY.P_LinkPreview,
-		SORT_KEY.P_AutoDiscard,
-	}
-}
+// query dynamodb for the sort keys of all the items with the partition key
+func (db *DDB) GetAllSKs(ctx context.Context, pk string) ([]string, error) {
 
-// query dynamodb with sort key prefixes to get all dynamic sort keys
-func (db *DDB) GetAllSKs(pk string) ([]string, error) {
+	sortKeys := []string{}
 
-	sortKeys := getAllStaticSKs()
+	keyEx := expression.Key(PK_NAME).Equal(expression.Value(pk))
 
-	dynamicSKPrefixes := []string{
-		SORT_KEY.Notifications(""),
-		SORT_KEY.Space(""),
-		SORT_KEY.TabsInSpace(""),
-		SORT_KEY.GroupsInSpace(""),
-		SORT_KEY.SnoozedTab(""),
-		SORT_KEY.Notes(""),
-	}
+	expr, err := expression.NewBuilder().WithKeyCondition(keyEx).WithProjection(expression.NamesList(expression.Name(SK_NAME))).Build()
 
-	for _, prefix := range dynamicSKPrefixes {
+	if err != nil {
+		return sortKeys, fmt.Errorf("error building key expression for pk: %v", pk)
+	}
 
-		keyEx :=
-			expression.Key(PK_NAME).Equal(expression.Value(pk))
+	input := &dynamodb.QueryInput{
+		TableName:                 &db.TableName,
+		ExpressionAttributeNames:  expr.Names(),
+		ExpressionAttributeValues: expr.Values(),
+		KeyConditionExpression:    expr.KeyCondition(),
+		ProjectionExpression:      expr.Projection(),
+	}
 
-		keyEx.And(expression.Key(SK_NAME).BeginsWith(prefix))
+	paginator := dynamodb.NewQueryPaginator(db.Client, input)
 
-		expr, err := expression.NewBuilder().WithKeyCondition(keyEx).Build()
+	for paginator.HasMorePages() {
+		page, err := paginator.NextPage(ctx)
 
 		if err != nil {
-			return sortKeys, fmt.Errorf("error building key expression for sort_key: %v", prefix)
+			return sortKeys, fmt.Errorf("error querying for sort keys. err: %v", err)
 		}
 
-		input := &dynamodb.QueryInput{
-			TableName:                 &db.TableName,
-			ExpressionAttributeNames:  expr.Names(),
-			ExpressionAttributeValues: expr.Values(),
-			KeyConditionExpression:    expr.Condition(),
-		}
+		for _, item := range page.Items {
 
-		paginator := dynamodb.NewQueryPaginator(db.Client, input)
+			var sk struct {
+				SK string
+			}
 
-		for paginator.HasMorePages() {
-			page, err := paginator.NextPage(context.TODO())
+			err := attributevalue.UnmarshalMap(item, &sk)
 
 			if err != nil {
-				return sortKeys, fmt.Errorf("error querying for dynamic sort keys. err: %v", err)
+				return sortKeys, fmt.Errorf("error un_marshalling sort key for pk: %v", pk)
 			}
 
-			for _, item := range page.Items {
+			sortKeys = append(sortKeys, sk.SK)
+		}
+	}
 
-				var sk struct {
-					SK string
-				}
+	return sortKeys, nil
+}
 
-				err := attributevalue.UnmarshalMap(item, &sk)
+// scan the table for the keys of all the items with the partition key prefix
+func (db *DDB) GetAllKeysByPKPrefix(ctx context.Context, prefix string) ([]map[string]types.AttributeValue, error) {
 
-				if err != nil {
-					return sortKeys, fmt.Errorf("error un_marshalling item for sort_key: %v", prefix)
-				}
+	filterEx := expression.Name(PK_NAME).BeginsWith(prefix)
+
+	expr, err := expression.NewBuilder().WithFilter(filterEx).WithProjection(expression.NamesList(expression.Name(PK_NAME), expression.Name(SK_NAME))).Build()
+
+	if err != nil {
+		return nil, fmt.Errorf("error building scan expression for pk prefix: %v", prefix)
+	}
+
+	paginator := dynamodb.NewScanPaginator(db.Client, &dynamodb.ScanInput{
+		TableName:                 &db.TableName,
+		ExpressionAttributeNames:  expr.Names(),
+		ExpressionAttributeValues: expr.Values(),
+		FilterExpression:          expr.Filter(),
+		ProjectionExpression:      expr.Projection(),
+	})
+
+	keys := []map[string]types.AttributeValue{}
+
+	for paginator.HasMorePages() {
+		page, err := paginator.NextPage(ctx)
+
+		if err != nil {
+			return nil, fmt.Errorf("error scanning for pk prefix. err: %v", err)
+		}
+
+		keys = append(keys, page.Items...)
+	}
+
+	return keys, nil
+}
+
+// batch delete the items with the partition key & sort keys
+func (db *DDB) DeleteItems(ctx context.Context, pk string, sks []string) error {
+	keys := []map[string]types.AttributeValue{}
+
+	for _, sk := range sks {
+		keys = append(keys, map[string]types.AttributeValue{
+			PK_NAME: &types.AttributeValueMemberS{Value: pk},
+			SK_NAME: &types.AttributeValueMemberS{Value: sk},
+		})
+	}
+
+	return db.DeleteKeys(ctx, keys)
+}
+
+// batch delete the items by their primary keys
+func (db *DDB) DeleteKeys(ctx context.Context, keys []map[string]types.AttributeValue) error {
+	if len(keys) < 1 {
+		return nil
+	}
+
+	reqs := []types.WriteRequest{}
 
-				sortKeys = append(sortKeys, sk.SK)
+	for _, key := range keys {
+		reqs = append(reqs, types.WriteRequest{
+			DeleteRequest: &types.DeleteRequest{
+				Key: key,
+			},
+		})
+	}
+
+	// channel to collect errors from goroutines
+	errChan := make(chan error, len(reqs)/DDB_MAX_BATCH_SIZE+1)
+
+	var wg sync.WaitGroup
+
+	// the batches share the deadline, ends sooner with the request
+	ctx, cancel := context.WithTimeout(ctx, BatchTimeout)
+	defer cancel()
+
+	db.BatchWriter(ctx, db.TableName, &wg, errChan, reqs)
+
+	// Wait for all goroutines to complete
+	go func() {
+		wg.Wait()
+		close(errChan)
+	}()
+
+	// Collect errors
+	var errs []error
+	for err := range errChan {
+		errs = append(errs, err)
+	}
+
+	if len(errs) > 0 {
+		return fmt.Errorf("batch delete errors: %v", errs)
+	}
+
+	return nil
+}
+
+// ids of the users with the general.deleteUnsavedSpaces preference, from the sparse preference index
+func (db *DDB) GetUserIdsByDeleteUnsavedSpacesPref(ctx context.Context, pref string) ([]string, error) {
+
+	keyEx := expression.Key("DeleteUnsavedSpaces").Equal(expression.Value(pref))
+
+	expr, err := expression.NewBuilder().WithKeyCondition(keyEx).WithProjection(expression.NamesList(expression.Name(PK_NAME))).Build()
+
+	if err != nil {
+		return nil, fmt.Errorf("error building key condition for the delete unsaved spaces preference. err: %v", err)
+	}
+
+	input := &dynamodb.QueryInput{
+		TableName:                 &db.TableName,
+		IndexName:                 aws.String(DELETE_UNSAVED_SPACES_INDEX),
+		ExpressionAttributeNames:  expr.Names(),
+		ExpressionAttributeValues: expr.Values(),
+		KeyConditionExpression:    expr.KeyCondition(),
+		ProjectionExpression:      expr.Projection(),
+	}
+
+	userIds := []string{}
+
+	paginator := dynamodb.NewQueryPaginator(db.Client, input)
+
+	for paginator.HasMorePages() {
+		page, err := paginator.NextPage(ctx)
+
+		if err != nil {
+			return userIds, fmt.Errorf("error querying the delete unsaved spaces preference index. err: %v", err)
+		}
+
+		for _, item := range page.Items {
+
+			var pk struct {
+				PK string
 			}
+
+			err := attributevalue.UnmarshalMap(item, &pk)
+
+			if err != nil {
+				return userIds, fmt.Errorf("error un_marshalling preference item. err: %v", err)
+			}
+
+			userIds = append(userIds, pk.PK)
 		}
 	}
 
-	return sortKeys, nil
+	return userIds, nil
 }
 
-func (db *DDB) TransactionWriter(items []types.TransactWriteItem) error {
+func (db *DDB) TransactionWriter(ctx context.Context, items []types.TransactWriteItem) error {
 
 	input := &dynamodb.TransactWriteItemsInput{
 		TransactItems: items,
 	}
 
 	// Execute the transaction
-	_, err := db.Client.TransactWriteItems(context.TODO(), input)
+	_, err := db.Client.TransactWriteItems(ctx, input)
 
 	if err != nil {
-		return fmt.Errorf("[TransactionWriter] error executing transaction [Error]: %v", err)
+		return fmt.Errorf("[TransactionWriter] error executing transaction [Error]: %w", err)
 	}
 	return nil
 }
 
 func (db *DDB) BatchWriter(ctx context.Context, tableName string, wg *sync.WaitGroup, errChan chan error, reqs []types.WriteRequest) {
+	// the batches are written from goroutines
+	ctx = withCaller(ctx)
 
 	for start := 0; start < len(reqs); start += DDB_MAX_BATCH_SIZE {
 		end := start + DDB_MAX_BATCH_SIZE
@@ -140,7 +261,14 @@ func (db *DDB) BatchWriter(ctx context.Context, tableName string, wg *sync.WaitG
 					// Exponential backoff with jitter
 					backoffDuration := time.Duration(math.Pow(2, float64(attempt))) * 100 * time.Millisecond
 					jitter := time.Duration(rand.Float64() * float64(backoffDuration/2))
-					time.Sleep(backoffDuration + jitter)
+
+					// stop retrying once the request is cancelled or past its deadline
+					select {
+					case <-ctx.Done():
+						errChan <- fmt.Errorf("batch write cancelled after %d attempts: %w", attempt, errors.Join(lastErr, ctx.Err()))
+						return
+					case <-time.After(backoffDuration + jitter):
+					}
 				}
 
 				output, err := db.Client.BatchWriteItem(ctx, &dynamodb.BatchWriteItemInput{
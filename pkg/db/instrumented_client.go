@@ -0,0 +1,215 @@
+package db
+
+import (
+	"context"
+	"errors"
+	"regexp"
+	"runtime"
+	"strings"
+
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+	"github.com/manishMandal02/tabsflow-backend/pkg/telemetry"
+)
+
+// dynamodb client that records the latency & consumed capacity of the calls,
+// per table, operation & the repository method (caller) that made the call
+type instrumentedClient struct {
+	client DynamoDBClientInterface
+	table  string
+}
+
+func newInstrumentedClient(client DynamoDBClientInterface, table string) *instrumentedClient {
+	return &instrumentedClient{client: client, table: table}
+}
+
+func (c *instrumentedClient) GetItem(ctx context.Context, params *dynamodb.GetItemInput, optFns ...func(*dynamodb.Options)) (*dynamodb.GetItemOutput, error) {
+	in := *params
+	in.ReturnConsumedCapacity = types.ReturnConsumedCapacityTotal
+
+	return instrument(ctx, c.table, "GetItem", func() (*dynamodb.GetItemOutput, error) {
+		return c.client.GetItem(ctx, &in, optFns...)
+	}, func(o *dynamodb.GetItemOutput) []types.ConsumedCapacity { return capacity(o.ConsumedCapacity) })
+}
+
+func (c *instrumentedClient) PutItem(ctx context.Context, params *dynamodb.PutItemInput, optFns ...func(*dynamodb.Options)) (*dynamodb.PutItemOutput, error) {
+	in := *params
+	in.ReturnConsumedCapacity = types.ReturnConsumedCapacityTotal
+
+	return instrument(ctx, c.table, "PutItem", func() (*dynamodb.PutItemOutput, error) {
+		return c.client.PutItem(ctx, &in, optFns...)
+	}, func(o *dynamodb.PutItemOutput) []types.ConsumedCapacity { return capacity(o.ConsumedCapacity) })
+}
+
+func (c *instrumentedClient) UpdateItem(ctx context.Context, params *dynamodb.UpdateItemInput, optFns ...func(*dynamodb.Options)) (*dynamodb.UpdateItemOutput, error) {
+	in := *params
+	in.ReturnConsumedCapacity = types.ReturnConsumedCapacityTotal
+
+	return instrument(ctx, c.table, "UpdateItem", func() (*dynamodb.UpdateItemOutput, error) {
+		return c.client.UpdateItem(ctx, &in, optFns...)
+	}, func(o *dynamodb.UpdateItemOutput) []types.ConsumedCapacity { return capacity(o.ConsumedCapacity) })
+}
+
+func (c *instrumentedClient) DeleteItem(ctx context.Context, params *dynamodb.DeleteItemInput, optFns ...func(*dynamodb.Options)) (*dynamodb.DeleteItemOutput, error) {
+	in := *params
+	in.ReturnConsumedCapacity = types.ReturnConsumedCapacityTotal
+
+	return instrument(ctx, c.table, "DeleteItem", func() (*dynamodb.DeleteItemOutput, error) {
+		return c.client.DeleteItem(ctx, &in, optFns...)
+	}, func(o *dynamodb.DeleteItemOutput) []types.ConsumedCapacity { return capacity(o.ConsumedCapacity) })
+}
+
+func (c *instrumentedClient) Query(ctx context.Context, params *dynamodb.QueryInput, optFns ...func(*dynamodb.Options)) (*dynamodb.QueryOutput, error) {
+	in := *params
+	in.ReturnConsumedCapacity = types.ReturnConsumedCapacityTotal
+
+	return instrument(ctx, c.table, "Query", func() (*dynamodb.QueryOutput, error) {
+		return c.client.Query(ctx, &in, optFns...)
+	}, func(o *dynamodb.QueryOutput) []types.ConsumedCapacity { return capacity(o.ConsumedCapacity) })
+}
+
+func (c *instrumentedClient) Scan(ctx context.Context, params *dynamodb.ScanInput, optFns ...func(*dynamodb.Options)) (*dynamodb.ScanOutput, error) {
+	in := *params
+	in.ReturnConsumedCapacity = types.ReturnConsumedCapacityTotal
+
+	return instrument(ctx, c.table, "Scan", func() (*dynamodb.ScanOutput, error) {
+		return c.client.Scan(ctx, &in, optFns...)
+	}, func(o *dynamodb.ScanOutput) []types.ConsumedCapacity { return capacity(o.ConsumedCapacity) })
+}
+
+func (c *instrumentedClient) BatchGetItem(ctx context.Context, params *dynamodb.BatchGetItemInput, optFns ...func(*dynamodb.Options)) (*dynamodb.BatchGetItemOutput, error) {
+	in := *params
+	in.ReturnConsumedCapacity = types.ReturnConsumedCapacityTotal
+
+	return instrument(ctx, c.table, "BatchGetItem", func() (*dynamodb.BatchGetItemOutput, error) {
+		return c.client.BatchGetItem(ctx, &in, optFns...)
+	}, func(o *dynamodb.BatchGetItemOutput) []types.ConsumedCapacity { return o.ConsumedCapacity })
+}
+
+func (c *instrumentedClient) BatchWriteItem(ctx context.Context, params *dynamodb.BatchWriteItemInput, optFns ...func(*dynamodb.Options)) (*dynamodb.BatchWriteItemOutput, error) {
+	in := *params
+	in.ReturnConsumedCapacity = types.ReturnConsumedCapacityTotal
+
+	return instrument(ctx, c.table, "BatchWriteItem", func() (*dynamodb.BatchWriteItemOutput, error) {
+		return c.client.BatchWriteItem(ctx, &in, optFns...)
+	}, func(o *dynamodb.BatchWriteItemOutput) []types.ConsumedCapacity { return o.ConsumedCapacity })
+}
+
+func (c *instrumentedClient) TransactWriteItems(ctx context.Context, params *dynamodb.TransactWriteItemsInput, optFns ...func(*dynamodb.Options)) (*dynamodb.TransactWriteItemsOutput, error) {
+	in := *params
+	in.ReturnConsumedCapacity = types.ReturnConsumedCapacityTotal
+
+	return instrument(ctx, c.table, "TransactWriteItems", func() (*dynamodb.TransactWriteItemsOutput, error) {
+		return c.client.TransactWriteItems(ctx, &in, optFns...)
+	}, func(o *dynamodb.TransactWriteItemsOutput) []types.ConsumedCapacity { return o.ConsumedCapacity })
+}
+
+// times the call, records its status (ok, error or throttled) & the consumed capacity
+func instrument[O any](ctx context.Context, table, operation string, call func() (*O, error), consumed func(*O) []types.ConsumedCapacity) (*O, error) {
+	attrs := []telemetry.Attr{
+		telemetry.String("table", table),
+		telemetry.String("operation", operation),
+		telemetry.String("caller", callerOf(ctx)),
+	}
+
+	ctx, span := telemetry.StartSpan(ctx, "db.client.operation", attrs...)
+
+	out, err := call()
+
+	span.SetAttrs(telemetry.String("status", callStatus(err)))
+	span.End()
+
+	if out == nil {
+		return out, err
+	}
+
+	units := 0.0
+
+	for _, c := range consumed(out) {
+		if c.CapacityUnits != nil {
+			units += *c.CapacityUnits
+		}
+	}
+
+	if units > 0 {
+		telemetry.Add(ctx, "db.client.consumed_capacity", telemetry.UnitCapacity, units, attrs...)
+	}
+
+	return out, err
+}
+
+func capacity(c *types.ConsumedCapacity) []types.ConsumedCapacity {
+	if c == nil {
+		return nil
+	}
+
+	return []types.ConsumedCapacity{*c}
+}
+
+func callStatus(err error) string {
+	var (
+		throughputErr *types.ProvisionedThroughputExceededException
+		limitErr      *types.RequestLimitExceeded
+	)
+
+	switch {
+	case err == nil:
+		return "ok"
+	case errors.As(err, &throughputErr), errors.As(err, &limitErr):
+		return "throttled"
+	}
+
+	return "error"
+}
+
+// closures of the caller, e.g. the batch writer's goroutines
+var closureSuffix = regexp.MustCompile(`(\.func\d+)+(\.\d+)*$`)
+
+type callerKey struct{}
+
+// keeps the caller of a helper for the calls made from its goroutines (e.g. the batch writer),
+// their stacks don't have the caller's frames
+func withCaller(ctx context.Context) context.Context {
+	if _, ok := ctx.Value(callerKey{}).(string); ok {
+		return ctx
+	}
+
+	return context.WithValue(ctx, callerKey{}, caller())
+}
+
+func callerOf(ctx context.Context) string {
+	if c, ok := ctx.Value(callerKey{}).(string); ok {
+		return c
+	}
+
+	return caller()
+}
+
+// the function that called the client, outside of the sdk (e.g. paginators) & this package's
+// client & helpers (e.g. BatchWriter, GetAllSKs). package qualified, e.g. notes.(*noteRepository).getNote
+func caller() string {
+	pcs := make([]uintptr, 16)
+
+	frames := runtime.CallersFrames(pcs[:runtime.Callers(3, pcs)])
+
+	for {
+		f, more := frames.Next()
+
+		name := f.Function
+
+		if !strings.HasPrefix(name, "github.com/aws/") && !isDBPackageFrame(f) {
+			name = closureSuffix.ReplaceAllString(name, "")
+
+			return name[strings.LastIndex(name, "/")+1:]
+		}
+
+		if !more {
+			return "unknown"
+		}
+	}
+}
+
+// frames of this package, except its tests
+func isDBPackageFrame(f runtime.Frame) bool {
+	return strings.Contains(f.Function, "/pkg/db.") && !strings.HasSuffix(f.File, "_test.go")
+}
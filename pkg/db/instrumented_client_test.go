@@ -0,0 +1,142 @@
+package db
+
+import (
+	"context"
+	"sync"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+	"github.com/manishMandal02/tabsflow-backend/pkg/telemetry"
+	"golang.org/x/time/rate"
+)
+
+type fakeClient struct {
+	DynamoDBClientInterface
+	input *dynamodb.GetItemInput
+	err   error
+}
+
+func (c *fakeClient) GetItem(_ context.Context, params *dynamodb.GetItemInput, _ ...func(*dynamodb.Options)) (*dynamodb.GetItemOutput, error) {
+	c.input = params
+
+	if c.err != nil {
+		return nil, c.err
+	}
+
+	return &dynamodb.GetItemOutput{ConsumedCapacity: &types.ConsumedCapacity{CapacityUnits: aws.Float64(0.5)}}, nil
+}
+
+func (c *fakeClient) BatchWriteItem(_ context.Context, _ *dynamodb.BatchWriteItemInput, _ ...func(*dynamodb.Options)) (*dynamodb.BatchWriteItemOutput, error) {
+	return &dynamodb.BatchWriteItemOutput{}, nil
+}
+
+func (c *fakeClient) TransactWriteItems(_ context.Context, _ *dynamodb.TransactWriteItemsInput, _ ...func(*dynamodb.Options)) (*dynamodb.TransactWriteItemsOutput, error) {
+	return &dynamodb.TransactWriteItemsOutput{}, nil
+}
+
+type testExporter struct {
+	points []telemetry.Point
+}
+
+func (e *testExporter) Export(_ context.Context, points []telemetry.Point) error {
+	e.points = append(e.points, points...)
+	return nil
+}
+
+func attrs(p telemetry.Point) map[string]string {
+	m := map[string]string{}
+
+	for _, a := range p.Attrs {
+		m[a.Key] = a.Value
+	}
+
+	return m
+}
+
+func TestInstrumentedClient(t *testing.T) {
+	e := &testExporter{}
+
+	telemetry.Init(e)
+	defer telemetry.SetExporter("none")
+
+	ctx := context.Background()
+
+	fake := &fakeClient{}
+	c := newInstrumentedClient(fake, "main")
+
+	params := &dynamodb.GetItemInput{TableName: aws.String("main")}
+
+	if _, err := c.GetItem(ctx, params); err != nil {
+		t.Fatalf("GetItem() error = %v", err)
+	}
+
+	if fake.input.ReturnConsumedCapacity != types.ReturnConsumedCapacityTotal || params.ReturnConsumedCapacity != "" {
+		t.Errorf("GetItem() input = %+v, want the consumed capacity of a copy of the input", fake.input)
+	}
+
+	fake.err = &types.ProvisionedThroughputExceededException{}
+
+	_, _ = c.GetItem(ctx, params)
+
+	telemetry.Flush(ctx)
+
+	if len(e.points) != 3 {
+		t.Fatalf("GetItem() points = %+v, want 3", e.points)
+	}
+
+	want := map[string]string{"table": "main", "operation": "GetItem", "caller": "db.TestInstrumentedClient", "status": "ok"}
+
+	if got := attrs(e.points[0]); e.points[0].Name != "db.client.operation.duration" || len(got) != len(want) || got["caller"] != want["caller"] || got["status"] != "ok" {
+		t.Errorf("GetItem() duration = %v %v, want %v", e.points[0].Name, got, want)
+	}
+
+	if capacity := e.points[1]; capacity.Name != "db.client.consumed_capacity" || capacity.Value != 0.5 {
+		t.Errorf("GetItem() capacity = %+v, want 0.5", capacity)
+	}
+
+	if got := attrs(e.points[2]); got["status"] != "throttled" {
+		t.Errorf("GetItem() status = %v, want throttled", got["status"])
+	}
+}
+
+// the calls made by the helpers are recorded for the function that called the helper
+func TestInstrumentedClientHelperCaller(t *testing.T) {
+	e := &testExporter{}
+
+	telemetry.Init(e)
+	defer telemetry.SetExporter("none")
+
+	ctx := context.Background()
+
+	ddb := &DDB{
+		Client:    newInstrumentedClient(&fakeClient{}, "main"),
+		TableName: "main",
+		Limiter:   rate.NewLimiter(rate.Inf, 1),
+	}
+
+	if err := ddb.TransactionWriter(ctx, []types.TransactWriteItem{{}}); err != nil {
+		t.Fatalf("TransactionWriter() error = %v", err)
+	}
+
+	// the batches are written from goroutines
+	wg := &sync.WaitGroup{}
+	errChan := make(chan error, 1)
+
+	ddb.BatchWriter(ctx, "main", wg, errChan, []types.WriteRequest{{}})
+
+	wg.Wait()
+
+	telemetry.Flush(ctx)
+
+	if len(e.points) < 2 {
+		t.Fatalf("points = %+v, want the TransactWriteItems & BatchWriteItem calls", e.points)
+	}
+
+	for _, p := range e.points {
+		if got := attrs(p); got["caller"] != "db.TestInstrumentedClientHelperCaller" {
+			t.Errorf("%v caller = %v, want db.TestInstrumentedClientHelperCaller", got["operation"], got["caller"])
+		}
+	}
+}
@@ -26,7 +26,9 @@ var SORT_KEY = struct {
 	P_LinkPreview            string
 	P_AutoDiscard            string
 	NotificationSubscription string
+	DeletionReceipt          string
 	Notifications            dynamicKey
+	DataExport               dynamicKey
 	Space                    dynamicKey
 	SpaceActiveTab           dynamicKey
 	TabsInSpace              dynamicKey
@@ -44,7 +46,9 @@ var SORT_KEY = struct {
 	P_LinkPreview:            "P#LinkPreview",
 	P_AutoDiscard:            "P#AutoDiscard",
 	NotificationSubscription: "U#NotificationSubscription",
+	DeletionReceipt:          "U#DeletionReceipt",
 	Notifications:            generateKey("U#Notification#"),
+	DataExport:               generateKey("U#DataExport#"),
 	Space:                    generateKey("S#Info#"),
 	SpaceActiveTab:           generateKey("S#ActiveTab#"),
 	TabsInSpace:              generateKey("S#Tabs#"),
@@ -53,14 +57,37 @@ var SORT_KEY = struct {
 	Notes:                    generateKey("N#"),
 }
 
+// partition keys for items not stored under a user
+var PARTITION_KEY = struct {
+	DeletedUser      dynamicKey
+	ClientIP         dynamicKey
+	PasskeyChallenge dynamicKey
+}{
+	DeletedUser:      generateKey("DeletedUser#"),
+	ClientIP:         generateKey("IP#"),
+	PasskeyChallenge: generateKey("PasskeyChallenge#"),
+}
+
 var SORT_KEY_SESSIONS = struct {
-	Session dynamicKey
-	OTP     dynamicKey
-	UserId  dynamicKey
+	OTPAttempts      string
+	PasskeyChallenge string
+	Passkey          dynamicKey
+	Session          dynamicKey
+	OTP              dynamicKey
+	MagicLink        dynamicKey
+	UserId           dynamicKey
+	RateLimit        dynamicKey
+	APIToken         dynamicKey
 }{
-	Session: generateKey("S#"),
-	OTP:     generateKey("OTP#"),
-	UserId:  generateKey("UserId#"),
+	OTPAttempts:      "OTPAttempts",
+	PasskeyChallenge: "Challenge",
+	Passkey:          generateKey("Passkey#"),
+	Session:          generateKey("S#"),
+	OTP:              generateKey("OTP#"),
+	MagicLink:        generateKey("MagicLink#"),
+	UserId:           generateKey("UserId#"),
+	RateLimit:        generateKey("RateLimit#"),
+	APIToken:         generateKey("APIToken#"),
 }
 
 var SORT_KEY_SEARCH_INDEX = struct {
@@ -0,0 +1,106 @@
+package errs
+
+import (
+	"errors"
+	"net/http"
+)
+
+// * typed api errors
+// an error has a stable code for the clients to handle, a message to display & the response status.
+// an error matches the error it was created from with errors.Is (e.g. a space not found error matches NotFound),
+// errors wrapped with fmt.Errorf("%w") match too
+
+type Error struct {
+	Code    string `json:"code"`
+	Message string `json:"message"`
+	Details any    `json:"details,omitempty"`
+	Status  int    `json:"-"`
+	// error this error was created from, e.g. NotFound for a space not found error
+	parent *Error
+}
+
+// error kinds, by response status
+var (
+	BadRequest       = New("bad_request", "Bad request", http.StatusBadRequest)
+	Unauthorized     = New("unauthorized", "Unauthorized", http.StatusUnauthorized)
+	Forbidden        = New("forbidden", "Forbidden", http.StatusForbidden)
+	NotFound         = New("not_found", "Not found", http.StatusNotFound)
+	MethodNotAllowed = New("method_not_allowed", "Method not allowed", http.StatusMethodNotAllowed)
+	Conflict         = New("conflict", "Conflict", http.StatusConflict)
+	Gone             = New("gone", "Gone", http.StatusGone)
+	PayloadTooLarge  = New("payload_too_large", "Payload too large", http.StatusRequestEntityTooLarge)
+	TooManyRequests  = New("too_many_requests", "Too many requests", http.StatusTooManyRequests)
+	Internal         = New("internal_error", "Internal server error", http.StatusInternalServerError)
+	// a dependency (db, queue, 3rd party api) failed
+	BadGateway = New("bad_gateway", "Bad gateway", http.StatusBadGateway)
+	// the service can't serve requests, e.g. a dependency is not reachable
+	Unavailable = New("service_unavailable", "Service unavailable", http.StatusServiceUnavailable)
+)
+
+func New(code, message string, status int) *Error {
+	return &Error{
+		Code:    code,
+		Message: message,
+		Status:  status,
+	}
+}
+
+func (e *Error) Error() string {
+	return e.Message
+}
+
+// error of the kind with its own code, for the errors the clients handle
+func (e *Error) New(code, message string) *Error {
+	return &Error{
+		Code:    code,
+		Message: message,
+		Status:  e.Status,
+		parent:  e,
+	}
+}
+
+// copy of the error with the message, the code stays the same
+func (e *Error) WithMessage(message string) *Error {
+	c := *e
+	c.Message = message
+	c.parent = e
+
+	return &c
+}
+
+// copy of the error with details for the client, e.g. the invalid fields
+func (e *Error) WithDetails(details any) *Error {
+	c := *e
+	c.Details = details
+	c.parent = e
+
+	return &c
+}
+
+// matches the errors this error was created from
+func (e *Error) Is(target error) bool {
+	t, ok := target.(*Error)
+
+	if !ok {
+		return false
+	}
+
+	for p := e.parent; p != nil; p = p.parent {
+		if p == t {
+			return true
+		}
+	}
+
+	return false
+}
+
+// api error of err, internal error if err isn't one. The message of other errors is not sent to the client
+func From(err error) *Error {
+	var e *Error
+
+	if errors.As(err, &e) {
+		return e
+	}
+
+	return Internal
+}
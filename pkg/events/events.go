@@ -1,23 +1,40 @@
 package events
 
 import (
+	"context"
 	"encoding/json"
+	"strconv"
+	"time"
 
+	lambda_events "github.com/aws/aws-lambda-go/events"
 	"github.com/aws/aws-sdk-go-v2/aws"
 	"github.com/aws/aws-sdk-go-v2/service/sqs/types"
+	"github.com/manishMandal02/tabsflow-backend/pkg/logger"
+	"github.com/manishMandal02/tabsflow-backend/pkg/telemetry"
 )
 
+// sqs message attribute of the event's request id
+const requestIdAttribute = logger.RequestIdKey
+
 type EventType string
 
 // Events
 const (
 	EventTypeSendOTP        EventType = "send_otp"
 	EventTypeUserRegistered EventType = "user_registered"
+	EventTypeSendDataExport EventType = "send_data_export"
+	EventTypeAccountDeleted EventType = "account_deleted"
+	EventTypeSendMagicLink  EventType = "send_magic_link"
+
+	EventTypeExportUserData EventType = "export_user_data"
+	EventTypeDeleteAccount  EventType = "delete_account"
 
 	EventTypeScheduleNoteRemainder EventType = "schedule_note_remainder"
 	EventTypeScheduleSnoozedTab    EventType = "schedule_snoozed_tab"
 	EventTypeTriggerNoteRemainder  EventType = "trigger_note_remainder"
 	EventTypeTriggerSnoozedTab     EventType = "trigger_snoozed_tab"
+
+	EventTypeCleanupUnsavedSpaces EventType = "cleanup_unsaved_spaces"
 )
 
 type SubEvent string
@@ -32,11 +49,14 @@ type IEvent interface {
 	GetEventType() EventType
 	ToMsgAttributes() map[string]types.MessageAttributeValue
 	ToJSON() string
+	// request that queued or scheduled the event, to trace it through the consumers
+	SetRequestId(id string)
 }
 
 type Event[T any] struct {
 	EventType EventType `json:"event_type"`
 	Payload   *T        `json:"payload"`
+	RequestId string    `json:"request_id,omitempty"`
 }
 
 // New creates a new event
@@ -60,15 +80,24 @@ func NewFromJSON[T any](jsonStr string) (*Event[T], error) {
 	return &ev, nil
 }
 
-// convert event_type info as map for sqs message
+// convert event_type & request_id info as map for sqs message
 func (e Event[any]) ToMsgAttributes() map[string]types.MessageAttributeValue {
 
-	return map[string]types.MessageAttributeValue{
+	attrs := map[string]types.MessageAttributeValue{
 		"event_type": {
 			DataType:    aws.String("String"),
 			StringValue: aws.String(string(e.GetEventType())),
 		},
 	}
+
+	if e.RequestId != "" {
+		attrs[requestIdAttribute] = types.MessageAttributeValue{
+			DataType:    aws.String("String"),
+			StringValue: aws.String(e.RequestId),
+		}
+	}
+
+	return attrs
 }
 
 // convert event to json
@@ -86,6 +115,42 @@ func (e Event[any]) GetEventType() EventType {
 	return e.EventType
 }
 
+func (e *Event[any]) SetRequestId(id string) {
+	e.RequestId = id
+}
+
+// context of the sqs message, with the request id of the event (a new one for the events without it, e.g. the recurring schedules).
+// records the received message & its lag (time in the queue) metrics
+func MessageContext(ctx context.Context, msg lambda_events.SQSMessage) context.Context {
+	// scheduled events have no message attributes
+	var ev struct {
+		EventType string `json:"event_type"`
+		RequestId string `json:"request_id"`
+	}
+
+	_ = json.Unmarshal([]byte(msg.Body), &ev)
+
+	if attr, ok := msg.MessageAttributes[requestIdAttribute]; ok && attr.StringValue != nil {
+		ev.RequestId = *attr.StringValue
+	}
+
+	if ev.RequestId == "" {
+		ev.RequestId = logger.NewRequestId()
+	}
+
+	ctx = logger.WithRequestId(ctx, ev.RequestId)
+
+	attrs := []telemetry.Attr{telemetry.String("queue", queueName(msg.EventSourceARN)), telemetry.String("event_type", ev.EventType)}
+
+	telemetry.Count(ctx, "messaging.receive.messages", attrs...)
+
+	if sent, err := strconv.ParseInt(msg.Attributes["SentTimestamp"], 10, 64); err == nil {
+		telemetry.Record(ctx, "messaging.receive.lag", telemetry.UnitMilliseconds, float64(time.Now().UnixMilli()-sent), attrs...)
+	}
+
+	return ctx
+}
+
 //* Event Payloads
 
 type SendOTPPayload struct {
@@ -99,6 +164,36 @@ type UserRegisteredPayload struct {
 	TrailEndDate string `json:"trailEndDate"`
 }
 
+type SendDataExportPayload struct {
+	Email       string `json:"email"`
+	Name        string `json:"name"`
+	DownloadURL string `json:"downloadURL"`
+	ExpiresAt   string `json:"expiresAt"`
+}
+
+type ExportUserDataPayload struct {
+	UserId string `json:"userId"`
+	Format string `json:"format"`
+}
+
+type SendMagicLinkPayload struct {
+	Email     string `json:"email"`
+	Link      string `json:"link"`
+	ExpiresIn string `json:"expiresIn"`
+}
+
+type AccountDeletedPayload struct {
+	Email     string `json:"email"`
+	Name      string `json:"name"`
+	ReceiptId string `json:"receiptId"`
+	DeletedAt string `json:"deletedAt"`
+}
+
+type DeleteAccountPayload struct {
+	UserId      string `json:"userId"`
+	RequestedAt int64  `json:"requestedAt"`
+}
+
 type ScheduleNoteRemainderPayload struct {
 	UserId    string   `json:"userId"`
 	NoteId    string   `json:"noteId"`
@@ -113,3 +208,8 @@ type ScheduleSnoozedTabPayload struct {
 	TriggerAt    int64    `json:"triggerAt,omitempty"`
 	SubEvent     SubEvent `json:"subEvent,omitempty"`
 }
+
+// userId is empty for the scheduled run, which is fanned out as an event per user
+type CleanupUnsavedSpacesPayload struct {
+	UserId string `json:"userId,omitempty"`
+}
@@ -1,9 +1,15 @@
 package events_test
 
 import (
+	"context"
+	"errors"
 	"testing"
 
+	lambda_events "github.com/aws/aws-lambda-go/events"
+	"github.com/aws/aws-sdk-go-v2/service/sqs"
+	sqs_types "github.com/aws/aws-sdk-go-v2/service/sqs/types"
 	"github.com/manishMandal02/tabsflow-backend/pkg/events"
+	"github.com/manishMandal02/tabsflow-backend/pkg/logger"
 )
 
 func TestNewEventFromJSON(t *testing.T) {
@@ -35,3 +41,93 @@ func TestNewEventFromJSON(t *testing.T) {
 		t.Errorf("Expected tp to be 123456, got %s", event.Payload.OTP)
 	}
 }
+
+func TestMessageContext(t *testing.T) {
+	ev := events.New(events.EventTypeScheduleNoteRemainder, &events.ScheduleNoteRemainderPayload{})
+	ev.SetRequestId("req-1")
+
+	attrs := map[string]lambda_events.SQSMessageAttribute{}
+
+	for k, v := range ev.ToMsgAttributes() {
+		attrs[k] = lambda_events.SQSMessageAttribute{DataType: *v.DataType, StringValue: v.StringValue}
+	}
+
+	tests := []struct {
+		name string
+		msg  lambda_events.SQSMessage
+		want string
+	}{
+		{name: "queued event", msg: lambda_events.SQSMessage{Body: "{}", MessageAttributes: attrs}, want: "req-1"},
+		{name: "scheduled event", msg: lambda_events.SQSMessage{Body: ev.ToJSON()}, want: "req-1"},
+		{name: "event without request id", msg: lambda_events.SQSMessage{Body: `{"event_type":"cleanup_unsaved_spaces"}`}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := logger.RequestId(events.MessageContext(context.Background(), tt.msg))
+
+			if tt.want != "" && got != tt.want {
+				t.Errorf("MessageContext() request id = %v, want %v", got, tt.want)
+			}
+
+			if got == "" {
+				t.Errorf("MessageContext() has no request id")
+			}
+		})
+	}
+}
+
+// sqs client that fails the first batch & the first message of the other batches
+type batchSQSClientMock struct {
+	events.SQSClientInterface
+	batches [][]string
+}
+
+func (m *batchSQSClientMock) SendMessageBatch(_ context.Context, params *sqs.SendMessageBatchInput, _ ...func(*sqs.Options)) (*sqs.SendMessageBatchOutput, error) {
+	ids := []string{}
+
+	for _, e := range params.Entries {
+		ids = append(ids, *e.Id)
+	}
+
+	m.batches = append(m.batches, ids)
+
+	if len(m.batches) == 1 {
+		return nil, errors.New("sqs error")
+	}
+
+	out := &sqs.SendMessageBatchOutput{}
+
+	for i, e := range params.Entries {
+		if i == 0 {
+			out.Failed = append(out.Failed, sqs_types.BatchResultErrorEntry{Id: e.Id})
+			continue
+		}
+		out.Successful = append(out.Successful, sqs_types.SendMessageBatchResultEntry{Id: e.Id})
+	}
+
+	return out, nil
+}
+
+func TestQueueAddMessages(t *testing.T) {
+	client := &batchSQSClientMock{}
+
+	q := events.Queue{Client: client, URL: "https://sqs/notifications"}
+
+	evs := []events.IEvent{}
+
+	for range 25 {
+		evs = append(evs, events.New(events.EventTypeCleanupUnsavedSpaces, &events.CleanupUnsavedSpacesPayload{UserId: "u1"}))
+	}
+
+	sent, err := q.AddMessages(context.Background(), evs)
+
+	// all the batches are sent, after a failed batch or message
+	if len(client.batches) != 3 || len(client.batches[2]) != 5 {
+		t.Fatalf("AddMessages() batches = %v, want 3 batches of max 10", client.batches)
+	}
+
+	if sent != 9+4 || err == nil {
+		t.Errorf("AddMessages() = %v, %v, want 13 sent & the errors of the failed ones", sent, err)
+	}
+}
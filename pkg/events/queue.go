@@ -2,15 +2,22 @@ package events
 
 import (
 	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
 
 	"github.com/aws/aws-sdk-go-v2/aws"
 	"github.com/aws/aws-sdk-go-v2/service/sqs"
+	"github.com/aws/aws-sdk-go-v2/service/sqs/types"
 	"github.com/manishMandal02/tabsflow-backend/config"
 	"github.com/manishMandal02/tabsflow-backend/pkg/logger"
+	"github.com/manishMandal02/tabsflow-backend/pkg/telemetry"
 )
 
 type SQSClientInterface interface {
 	SendMessage(ctx context.Context, params *sqs.SendMessageInput, optFns ...func(*sqs.Options)) (*sqs.SendMessageOutput, error)
+	SendMessageBatch(ctx context.Context, params *sqs.SendMessageBatchInput, optFns ...func(*sqs.Options)) (*sqs.SendMessageBatchOutput, error)
 	DeleteMessage(ctx context.Context, params *sqs.DeleteMessageInput, optFns ...func(*sqs.Options)) (*sqs.DeleteMessageOutput, error)
 }
 
@@ -28,6 +35,15 @@ func NewEmailQueue() *Queue {
 	}
 }
 
+func NewUsersQueue() *Queue {
+	client := sqs.NewFromConfig(config.AWS_CONFIG)
+
+	return &Queue{
+		Client: client,
+		URL:    config.USERS_QUEUE_URL,
+	}
+}
+
 func NewNotificationQueue() *Queue {
 	client := sqs.NewFromConfig(config.AWS_CONFIG)
 
@@ -39,34 +55,106 @@ func NewNotificationQueue() *Queue {
 }
 
 // sqs helper fn to send messages
-func (q Queue) AddMessage(ev IEvent) error {
+func (q Queue) AddMessage(ctx context.Context, ev IEvent) error {
+	if id := logger.RequestId(ctx); id != "" {
+		ev.SetRequestId(id)
+	}
 
-	res, err := q.Client.SendMessage(context.TODO(), &sqs.SendMessageInput{
+	res, err := q.Client.SendMessage(ctx, &sqs.SendMessageInput{
 		DelaySeconds:      *aws.Int32(1),
 		QueueUrl:          &q.URL,
 		MessageBody:       aws.String(ev.ToJSON()),
 		MessageAttributes: ev.ToMsgAttributes(),
 	})
 
+	telemetry.Count(ctx, "messaging.send.messages", telemetry.String("queue", queueName(q.URL)), telemetry.String("event_type", string(ev.GetEventType())), telemetry.Status(err))
+
 	if err != nil || res.MessageId == nil {
-		logger.Errorf("Error sending message to SQS queue for event_type: %v. \n [Error]: %v", ev.GetEventType(), err)
+		logger.ErrorContext(ctx, "Error sending message to SQS queue", err, "event_type", ev.GetEventType())
 		return err
 	}
 
 	return nil
 }
 
-func (q Queue) DeleteMessage(r string) error {
+// max messages of a SendMessageBatch request
+const sqsMaxBatchSize = 10
+
+// sends the messages in batches, a failed batch or message doesn't stop the others;
+// returns the number of messages sent & the errors of the failed ones
+func (q Queue) AddMessages(ctx context.Context, evs []IEvent) (int, error) {
+	requestId := logger.RequestId(ctx)
+
+	sent := 0
+
+	var errs []error
+
+	for start := 0; start < len(evs); start += sqsMaxBatchSize {
+		batch := evs[start:min(start+sqsMaxBatchSize, len(evs))]
+
+		entries := make([]types.SendMessageBatchRequestEntry, 0, len(batch))
+
+		for i, ev := range batch {
+			if requestId != "" {
+				ev.SetRequestId(requestId)
+			}
 
-	_, err := q.Client.DeleteMessage(context.TODO(), &sqs.DeleteMessageInput{
+			entries = append(entries, types.SendMessageBatchRequestEntry{
+				Id:                aws.String(strconv.Itoa(i)),
+				DelaySeconds:      *aws.Int32(1),
+				MessageBody:       aws.String(ev.ToJSON()),
+				MessageAttributes: ev.ToMsgAttributes(),
+			})
+		}
+
+		res, err := q.Client.SendMessageBatch(ctx, &sqs.SendMessageBatchInput{
+			QueueUrl: &q.URL,
+			Entries:  entries,
+		})
+
+		attrs := []telemetry.Attr{telemetry.String("queue", queueName(q.URL)), telemetry.String("event_type", string(batch[0].GetEventType()))}
+
+		if err != nil {
+			telemetry.Add(ctx, "messaging.send.messages", telemetry.UnitCount, float64(len(batch)), append(attrs, telemetry.Status(err))...)
+			logger.ErrorContext(ctx, "Error sending message batch to SQS queue", err, "event_type", batch[0].GetEventType(), "messages", len(batch))
+			errs = append(errs, err)
+			continue
+		}
+
+		sent += len(res.Successful)
+
+		for _, f := range res.Failed {
+			err := fmt.Errorf("message %v of the batch failed: %v %v", aws.ToString(f.Id), aws.ToString(f.Code), aws.ToString(f.Message))
+			logger.ErrorContext(ctx, "Error sending message to SQS queue", err, "event_type", batch[0].GetEventType())
+			errs = append(errs, err)
+		}
+
+		telemetry.Add(ctx, "messaging.send.messages", telemetry.UnitCount, float64(len(res.Successful)), append(attrs, telemetry.Status(nil))...)
+
+		if len(res.Failed) > 0 {
+			telemetry.Add(ctx, "messaging.send.messages", telemetry.UnitCount, float64(len(res.Failed)), append(attrs, telemetry.Status(errs[len(errs)-1]))...)
+		}
+	}
+
+	return sent, errors.Join(errs...)
+}
+
+func (q Queue) DeleteMessage(ctx context.Context, r string) error {
+
+	_, err := q.Client.DeleteMessage(ctx, &sqs.DeleteMessageInput{
 		QueueUrl:      &q.URL,
 		ReceiptHandle: aws.String(r),
 	})
 
 	if err != nil {
-		logger.Errorf("Error deleting message from SQS queue for receipt_handle: %v. \n [Error]: %v", r, err)
+		logger.ErrorContext(ctx, "Error deleting message from SQS queue", err, "receipt_handle", r)
 		return err
 	}
 
 	return nil
 }
+
+// name of the queue, the last part of its url or arn
+func queueName(urlOrARN string) string {
+	return urlOrARN[strings.LastIndexAny(urlOrARN, "/:")+1:]
+}
@@ -8,6 +8,8 @@ import (
 	eb_scheduler "github.com/aws/aws-sdk-go-v2/service/scheduler"
 	"github.com/aws/aws-sdk-go-v2/service/scheduler/types"
 	"github.com/manishMandal02/tabsflow-backend/config"
+	"github.com/manishMandal02/tabsflow-backend/pkg/logger"
+	"github.com/manishMandal02/tabsflow-backend/pkg/telemetry"
 )
 
 type scheduler struct {
@@ -25,11 +27,19 @@ func NewScheduler() *scheduler {
 // name - name of the schedule
 //
 // dateTime - date & time to trigger the target. ex: at(yyyy-mm-ddThh:mm:ss)
-func (s scheduler) CreateSchedule(id, dateTime string, event *string) error {
+//
+// event - sent to the notifications queue, with the request id of the context
+func (s scheduler) CreateSchedule(ctx context.Context, id, dateTime string, event IEvent) error {
 
 	scheduleExpression := fmt.Sprintf("at(%s)", dateTime)
 
-	_, err := s.client.CreateSchedule(context.TODO(), &eb_scheduler.CreateScheduleInput{
+	if requestId := logger.RequestId(ctx); requestId != "" {
+		event.SetRequestId(requestId)
+	}
+
+	input := event.ToJSON()
+
+	_, err := s.client.CreateSchedule(ctx, &eb_scheduler.CreateScheduleInput{
 		Name:               &id,
 		ScheduleExpression: &scheduleExpression,
 		FlexibleTimeWindow: &types.FlexibleTimeWindow{
@@ -38,7 +48,7 @@ func (s scheduler) CreateSchedule(id, dateTime string, event *string) error {
 		Target: &types.Target{
 			Arn:     &config.NOTIFICATIONS_QUEUE_ARN,
 			RoleArn: &config.SCHEDULER_ROLE_ARN,
-			Input:   event,
+			Input:   &input,
 			RetryPolicy: &types.RetryPolicy{
 				MaximumRetryAttempts:     aws.Int32(5),
 				MaximumEventAgeInSeconds: aws.Int32(720),
@@ -47,10 +57,14 @@ func (s scheduler) CreateSchedule(id, dateTime string, event *string) error {
 		ActionAfterCompletion: types.ActionAfterCompletionDelete,
 	})
 
+	telemetry.Count(ctx, "scheduler.schedules", telemetry.String("operation", "create"), telemetry.String("event_type", string(event.GetEventType())), telemetry.Status(err))
+
 	if err != nil {
 		return err
 	}
 
+	logger.DebugContext(ctx, "schedule created", "schedule", id, "event_type", event.GetEventType(), "at", dateTime)
+
 	return nil
 }
 
@@ -59,14 +73,17 @@ func (s scheduler) CreateSchedule(id, dateTime string, event *string) error {
 // name - name of the schedule
 //
 // dateTime - date & time to trigger the target. ex: at(yyyy-mm-ddThh:mm:ss)
-func (s scheduler) UpdateSchedule(name, dateTime string) error {
+func (s scheduler) UpdateSchedule(ctx context.Context, name, dateTime string) error {
 
 	scheduleExpression := fmt.Sprintf("at(%s)", dateTime)
 
-	_, err := s.client.UpdateSchedule(context.TODO(), &eb_scheduler.UpdateScheduleInput{
+	_, err := s.client.UpdateSchedule(ctx, &eb_scheduler.UpdateScheduleInput{
 		Name:               &name,
 		ScheduleExpression: &scheduleExpression,
 	})
+
+	telemetry.Count(ctx, "scheduler.schedules", telemetry.String("operation", "update"), telemetry.Status(err))
+
 	if err != nil {
 		return err
 	}
@@ -74,11 +91,13 @@ func (s scheduler) UpdateSchedule(name, dateTime string) error {
 	return nil
 }
 
-func (s scheduler) DeleteSchedule(name string) error {
-	_, err := s.client.DeleteSchedule(context.TODO(), &eb_scheduler.DeleteScheduleInput{
+func (s scheduler) DeleteSchedule(ctx context.Context, name string) error {
+	_, err := s.client.DeleteSchedule(ctx, &eb_scheduler.DeleteScheduleInput{
 		Name: &name,
 	})
 
+	telemetry.Count(ctx, "scheduler.schedules", telemetry.String("operation", "delete"), telemetry.Status(err))
+
 	if err != nil {
 		return err
 	}
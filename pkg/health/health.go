@@ -0,0 +1,314 @@
+package health
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"runtime"
+	"runtime/debug"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/manishMandal02/tabsflow-backend/config"
+	"github.com/manishMandal02/tabsflow-backend/pkg/db"
+	"github.com/manishMandal02/tabsflow-backend/pkg/errs"
+	"github.com/manishMandal02/tabsflow-backend/pkg/events"
+	"github.com/manishMandal02/tabsflow-backend/pkg/http_api"
+	"github.com/manishMandal02/tabsflow-backend/pkg/logger"
+)
+
+// * health, readiness & diagnostics routes of the services
+// /health - the service is up, with its build info (no dependency calls)
+// /ready - the dependencies of the service are reachable & configured, 503 if not.
+// public, the result is cached & the checks are grouped by their kind (no table names or 3rd parties)
+// /diagnostics - admins only, the redacted config & the dependencies status with their errors
+
+// version of the build, set with -ldflags "-X github.com/manishMandal02/tabsflow-backend/pkg/health.Version=v1.0.0"
+var Version = "dev"
+
+// max time of the checks
+const CheckTimeout = 3 * time.Second
+
+// the /ready result is reused for this long, the route is public & each request would call the dependencies
+const ReadyCacheTTL = 5 * time.Second
+
+// Check of a dependency of the service
+type Check struct {
+	// detailed name, only in the diagnostics (e.g. dynamodb:MainTable_prod)
+	Name string
+	// generic name, in the public /ready route (e.g. dynamodb)
+	Kind string
+	Run  func(ctx context.Context) error
+}
+
+type CheckResult struct {
+	Name       string  `json:"name"`
+	Status     string  `json:"status"`
+	DurationMs float64 `json:"durationMs"`
+	// only in the diagnostics
+	Error string `json:"error,omitempty"`
+}
+
+type BuildInfo struct {
+	Version   string `json:"version"`
+	Commit    string `json:"commit,omitempty"`
+	Time      string `json:"time,omitempty"`
+	Modified  bool   `json:"modified,omitempty"`
+	GoVersion string `json:"goVersion"`
+}
+
+type Status struct {
+	Status string        `json:"status"`
+	Build  BuildInfo     `json:"build"`
+	Checks []CheckResult `json:"checks,omitempty"`
+}
+
+type Diagnostics struct {
+	Status string            `json:"status"`
+	Build  BuildInfo         `json:"build"`
+	Config map[string]string `json:"config"`
+	Checks []CheckResult     `json:"checks"`
+}
+
+const (
+	statusOK      = "ok"
+	statusFailing = "failing"
+)
+
+// Routes adds the public /health & /ready routes, outside the service's middleware (e.g. CORS)
+func Routes(r http_api.IRouter, checks ...Check) {
+	r.GET("/health", func(w http.ResponseWriter, r *http.Request) {
+		http_api.SuccessResData(w, Status{Status: statusOK, Build: Build()})
+	}).Doc(http_api.RouteDoc{
+		Summary:  "Health of the service, with its build info",
+		Response: Status{},
+		Auth:     http_api.AuthNone,
+	})
+
+	ready := &readyCache{checks: checks}
+
+	r.GET("/ready", func(w http.ResponseWriter, r *http.Request) {
+		results, ok := ready.get(r.Context())
+
+		if !ok {
+			http_api.ErrorRes(w, errs.Unavailable.WithDetails(results))
+			return
+		}
+
+		http_api.SuccessResData(w, Status{Status: statusOK, Build: Build(), Checks: results})
+	}).Doc(http_api.RouteDoc{
+		Summary:  "Readiness of the service, its dependencies are reachable & configured",
+		Response: Status{},
+		Auth:     http_api.AuthNone,
+	})
+}
+
+// last result of the /ready checks, grouped by kind
+type readyCache struct {
+	checks []Check
+
+	mu        sync.Mutex
+	checkedAt time.Time
+	results   []CheckResult
+	ok        bool
+}
+
+// runs the checks if the cached result is older than the ReadyCacheTTL,
+// the concurrent requests wait for the same run
+func (c *readyCache) get(ctx context.Context) ([]CheckResult, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.results != nil && time.Since(c.checkedAt) < ReadyCacheTTL {
+		return slices.Clone(c.results), c.ok
+	}
+
+	// shared result, not cancelled with the request that ran it
+	results, ok := run(context.WithoutCancel(ctx), c.checks)
+
+	c.results, c.ok, c.checkedAt = byKind(c.checks, results), ok, time.Now()
+
+	return slices.Clone(c.results), c.ok
+}
+
+// one result per kind of check, failing if any of its checks failed (without the errors)
+func byKind(checks []Check, results []CheckResult) []CheckResult {
+	grouped := []CheckResult{}
+
+	for i, r := range results {
+		kind := checks[i].Kind
+
+		if kind == "" {
+			kind = "other"
+		}
+
+		j := slices.IndexFunc(grouped, func(g CheckResult) bool { return g.Name == kind })
+
+		if j == -1 {
+			grouped = append(grouped, CheckResult{Name: kind, Status: statusOK})
+			j = len(grouped) - 1
+		}
+
+		if r.Status != statusOK {
+			grouped[j].Status = statusFailing
+		}
+
+		grouped[j].DurationMs = max(grouped[j].DurationMs, r.DurationMs)
+	}
+
+	return grouped
+}
+
+// DiagnosticsRoute adds the /diagnostics route for the admins, the router must be behind the authorizer
+func DiagnosticsRoute(r http_api.IRouter, checks ...Check) {
+	r.GET("/diagnostics", http_api.RequireAdmin()(func(w http.ResponseWriter, r *http.Request) {
+		results, ok := run(r.Context(), checks)
+
+		status := statusOK
+
+		if !ok {
+			status = statusFailing
+		}
+
+		http_api.SuccessResData(w, Diagnostics{Status: status, Build: Build(), Config: config.Redacted(), Checks: results})
+	})).Doc(http_api.RouteDoc{
+		Summary:  "Config & dependencies status of the service, admins only",
+		Response: Diagnostics{},
+		Auth:     http_api.AuthSession,
+	})
+}
+
+// runs the checks concurrently, ok if all of them passed
+func run(ctx context.Context, checks []Check) ([]CheckResult, bool) {
+	ctx, cancel := context.WithTimeout(ctx, CheckTimeout)
+	defer cancel()
+
+	results := make([]CheckResult, len(checks))
+
+	var wg sync.WaitGroup
+
+	for i, c := range checks {
+		wg.Add(1)
+
+		go func(i int, c Check) {
+			defer wg.Done()
+
+			start := time.Now()
+
+			err := c.Run(ctx)
+
+			results[i] = CheckResult{Name: c.Name, Status: statusOK, DurationMs: float64(time.Since(start).Microseconds()) / 1000}
+
+			if err != nil {
+				results[i].Status = statusFailing
+				results[i].Error = err.Error()
+			}
+		}(i, c)
+	}
+
+	wg.Wait()
+
+	ok := true
+
+	for _, r := range results {
+		if r.Status != statusOK {
+			ok = false
+			logger.WarnContext(ctx, "health check failing", "check", r.Name, "error", r.Error)
+		}
+	}
+
+	return results, ok
+}
+
+// Table checks the table is reachable, with a 1 item scan
+func Table(t *db.DDB) Check {
+	return Check{
+		Name: "dynamodb:" + t.TableName,
+		Kind: "dynamodb",
+		Run: func(ctx context.Context) error {
+			if t.TableName == "" {
+				return errors.New("table name not set")
+			}
+
+			_, err := t.Client.Scan(ctx, &dynamodb.ScanInput{
+				TableName: &t.TableName,
+				Limit:     aws.Int32(1),
+			})
+
+			return err
+		},
+	}
+}
+
+// Queue checks the url of the queue is set
+func Queue(name string, q *events.Queue) Check {
+	return Check{
+		Name: "sqs:" + name,
+		Kind: "queue",
+		Run: func(context.Context) error {
+			if q == nil || q.URL == "" {
+				return errors.New("queue url not set")
+			}
+
+			return nil
+		},
+	}
+}
+
+// Config checks the config values are set, e.g. the keys of a 3rd party api
+func Config(name string, values map[string]string) Check {
+	return Check{
+		Name: "config:" + name,
+		Kind: "config",
+		Run: func(context.Context) error {
+			missing := []string{}
+
+			for k, v := range values {
+				if v == "" {
+					missing = append(missing, k)
+				}
+			}
+
+			slices.Sort(missing)
+
+			if len(missing) > 0 {
+				return fmt.Errorf("not set: %v", strings.Join(missing, ", "))
+			}
+
+			return nil
+		},
+	}
+}
+
+var buildInfo = sync.OnceValue(func() BuildInfo {
+	b := BuildInfo{Version: Version, GoVersion: runtime.Version()}
+
+	info, ok := debug.ReadBuildInfo()
+
+	if !ok {
+		return b
+	}
+
+	for _, s := range info.Settings {
+		switch s.Key {
+		case "vcs.revision":
+			b.Commit = s.Value
+		case "vcs.time":
+			b.Time = s.Value
+		case "vcs.modified":
+			b.Modified = s.Value == "true"
+		}
+	}
+
+	return b
+})
+
+// Build info of the service, the vcs info is set by go build (in a git repo)
+func Build() BuildInfo {
+	return buildInfo()
+}
@@ -0,0 +1,191 @@
+package health_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/manishMandal02/tabsflow-backend/config"
+	"github.com/manishMandal02/tabsflow-backend/pkg/events"
+	"github.com/manishMandal02/tabsflow-backend/pkg/health"
+	"github.com/manishMandal02/tabsflow-backend/pkg/http_api"
+)
+
+func testRouter(failing bool) http_api.IRouter {
+	checks := []health.Check{
+		health.Queue("email", &events.Queue{URL: "https://sqs/emails"}),
+		health.Config("vapid", map[string]string{"VAPID_PUBLIC_KEY": "key"}),
+		{Name: "dynamodb:main", Kind: "dynamodb", Run: func(context.Context) error {
+			if failing {
+				return errors.New("table not found: main")
+			}
+			return nil
+		}},
+	}
+
+	r := http_api.NewRouter("/test")
+
+	health.Routes(r, checks...)
+	health.DiagnosticsRoute(r, checks...)
+
+	return r
+}
+
+func TestRoutes(t *testing.T) {
+	tests := []struct {
+		name       string
+		path       string
+		failing    bool
+		wantStatus int
+		wantChecks int
+	}{
+		{name: "health", path: "/test/health", failing: true, wantStatus: http.StatusOK},
+		{name: "ready", path: "/test/ready", wantStatus: http.StatusOK, wantChecks: 3},
+		{name: "not ready", path: "/test/ready", failing: true, wantStatus: http.StatusServiceUnavailable},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+
+			// no origin, the health routes are outside the CORS middleware
+			testRouter(tt.failing).ServeHTTP(w, httptest.NewRequest("GET", tt.path, nil))
+
+			if w.Code != tt.wantStatus {
+				t.Fatalf("%v status = %v, want %v. body: %v", tt.path, w.Code, tt.wantStatus, w.Body.String())
+			}
+
+			// the check errors & detailed names are only in the diagnostics
+			if strings.Contains(w.Body.String(), "table not found") || strings.Contains(w.Body.String(), "dynamodb:main") || strings.Contains(w.Body.String(), "vapid") {
+				t.Errorf("%v body = %v, has the check error", tt.path, w.Body.String())
+			}
+
+			if tt.wantStatus != http.StatusOK {
+				return
+			}
+
+			var res struct {
+				Data health.Status `json:"data"`
+			}
+
+			_ = json.NewDecoder(w.Body).Decode(&res)
+
+			if res.Data.Status != "ok" || res.Data.Build.GoVersion == "" || len(res.Data.Checks) != tt.wantChecks {
+				t.Errorf("%v = %+v", tt.path, res.Data)
+			}
+		})
+	}
+}
+
+func TestReadyCache(t *testing.T) {
+	runs := 0
+
+	r := http_api.NewRouter("/test")
+
+	health.Routes(r,
+		health.Check{Name: "dynamodb:main", Kind: "dynamodb", Run: func(context.Context) error {
+			runs++
+			return nil
+		}},
+		health.Check{Name: "dynamodb:sessions", Kind: "dynamodb", Run: func(context.Context) error {
+			return errors.New("table not found: sessions")
+		}},
+	)
+
+	for range 3 {
+		w := httptest.NewRecorder()
+
+		r.ServeHTTP(w, httptest.NewRequest("GET", "/test/ready", nil))
+
+		if w.Code != http.StatusServiceUnavailable {
+			t.Fatalf("ready status = %v, want %v", w.Code, http.StatusServiceUnavailable)
+		}
+
+		var res struct {
+			Error struct {
+				Details []health.CheckResult `json:"details"`
+			} `json:"error"`
+		}
+
+		_ = json.NewDecoder(w.Body).Decode(&res)
+
+		// a failing table fails its kind
+		if d := res.Error.Details; len(d) != 1 || d[0].Name != "dynamodb" || d[0].Status != "failing" {
+			t.Errorf("ready checks = %+v, want the failing dynamodb kind", d)
+		}
+	}
+
+	if runs != 1 {
+		t.Errorf("ready checks runs = %v, want 1 within the cache ttl", runs)
+	}
+}
+
+func TestDiagnosticsRoute(t *testing.T) {
+	config.ADMIN_USER_IDS = "admin-1, admin-2"
+	config.JWT_SECRET_KEY = "secret"
+
+	defer func() {
+		config.ADMIN_USER_IDS = ""
+		config.JWT_SECRET_KEY = ""
+	}()
+
+	tests := []struct {
+		name       string
+		userId     string
+		scopes     string
+		wantStatus int
+	}{
+		{name: "admin", userId: "admin-2", wantStatus: http.StatusOK},
+		{name: "not admin", userId: "user-1", wantStatus: http.StatusForbidden},
+		{name: "admin api token", userId: "admin-1", scopes: "spaces:read", wantStatus: http.StatusForbidden},
+		{name: "no user", wantStatus: http.StatusUnauthorized},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest("GET", "/test/diagnostics", nil)
+			req.Header.Set("UserId", tt.userId)
+			req.Header.Set(http_api.TokenScopesHeader, tt.scopes)
+
+			w := httptest.NewRecorder()
+
+			testRouter(true).ServeHTTP(w, req)
+
+			if w.Code != tt.wantStatus {
+				t.Fatalf("diagnostics status = %v, want %v", w.Code, tt.wantStatus)
+			}
+
+			if tt.wantStatus != http.StatusOK {
+				return
+			}
+
+			var res struct {
+				Data health.Diagnostics `json:"data"`
+			}
+
+			_ = json.NewDecoder(w.Body).Decode(&res)
+
+			if res.Data.Status != "failing" || res.Data.Checks[2].Error != "table not found: main" {
+				t.Errorf("diagnostics = %+v, want the failing check error", res.Data)
+			}
+
+			if res.Data.Config["JWT_SECRET_KEY"] != "[redacted]" || res.Data.Config["ADMIN_USER_IDS"] != config.ADMIN_USER_IDS {
+				t.Errorf("diagnostics config = %v, want the secrets redacted", res.Data.Config)
+			}
+		})
+	}
+}
+
+func TestConfig(t *testing.T) {
+	c := health.Config("paddle", map[string]string{"PADDLE_WEBHOOK_SECRET_KEY": "", "PADDLE_API_KEY": "", "OTHER": "set"})
+
+	err := c.Run(context.Background())
+
+	if err == nil || err.Error() != "not set: PADDLE_API_KEY, PADDLE_WEBHOOK_SECRET_KEY" {
+		t.Errorf("Config() error = %v, want the missing values", err)
+	}
+}
@@ -4,13 +4,21 @@ import (
 	"context"
 	"encoding/json"
 	"net/http"
+	"time"
 
 	"github.com/aws/aws-lambda-go/events"
 	lambda_events "github.com/aws/aws-lambda-go/events"
 	"github.com/awslabs/aws-lambda-go-api-proxy/httpadapter"
+	"github.com/manishMandal02/tabsflow-backend/pkg/logger"
 )
 
-type SQSHandler func(messages []lambda_events.SQSMessage) (interface{}, error)
+type SQSHandler func(ctx context.Context, messages []lambda_events.SQSMessage) (interface{}, error)
+
+// time kept from the lambda deadline to respond, the requests are cancelled before the invocation times out
+const responseTimeReserve = 500 * time.Millisecond
+
+// authorizer context key of the rotated session cookie, written to the response
+const SetCookieContextKey = "Set-Cookie"
 
 // API Gateway proxy events handler
 type APIGatewayHandler struct {
@@ -34,16 +42,45 @@ func NewAPIGatewayHandlerWithSQSHandler(baseURL string, handler http.Handler, sH
 	}
 }
 
-// wrapper handler that injects the userId
+// wrapper handler that injects the userId, or removes the header sent by the client (routes without the authorizer)
 func (h *APIGatewayHandler) withUserID(userId string, handler http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		r.Header.Set("UserId", userId)
+		if userId == "" {
+			r.Header.Del("UserId")
+		} else {
+			r.Header.Set("UserId", userId)
+		}
+		handler.ServeHTTP(w, r)
+	})
+}
+
+// wrapper handler that injects the api token scopes, or removes the header sent by the client
+func (h *APIGatewayHandler) withTokenScopes(scopes string, handler http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if scopes == "" {
+			r.Header.Del(TokenScopesHeader)
+		} else {
+			r.Header.Set(TokenScopesHeader, scopes)
+		}
+		handler.ServeHTTP(w, r)
+	})
+}
+
+// wrapper handler that sets the cookie from the authorizer (rotated session) on the response
+func (h *APIGatewayHandler) withSetCookie(cookie string, handler http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if cookie != "" {
+			w.Header().Add("Set-Cookie", cookie)
+		}
 		handler.ServeHTTP(w, r)
 	})
 }
 
 // processes the Lambda api event
 func (h *APIGatewayHandler) Handle(ctx context.Context, event json.RawMessage) (interface{}, error) {
+	ctx, cancel := invocationContext(ctx)
+	defer cancel()
+
 	// Parse API GW event
 	var apiEvent events.APIGatewayProxyRequest
 	err := json.Unmarshal(event, &apiEvent)
@@ -55,25 +92,47 @@ func (h *APIGatewayHandler) Handle(ctx context.Context, event json.RawMessage) (
 			var sqsEvent events.SQSEvent
 			if err := json.Unmarshal(event, &sqsEvent); err == nil && len(sqsEvent.Records) > 0 {
 				// This is an SQS event
-				return h.sqsHandler(sqsEvent.Records)
+				return h.sqsHandler(ctx, sqsEvent.Records)
 			}
 		}
 		return nil, err
 	}
 
+	// logs of the request have the api gateway request id
+	if apiEvent.RequestContext.RequestID != "" {
+		ctx = logger.WithRequestId(ctx, apiEvent.RequestContext.RequestID)
+	}
+
 	// Create mux for this request
 	mux := http.NewServeMux()
 
-	// Extract userId from authorizer context
-	if userId, ok := apiEvent.RequestContext.Authorizer["UserId"].(string); ok {
-		// Wrap the handler with userId injection
-		mux.Handle(h.baseURL, h.withUserID(userId, h.handler))
-	} else {
-		// Use original handler without userId injection
-		mux.Handle(h.baseURL, h.handler)
-	}
+	// scopes are set by the authorizer for api token requests
+	scopes, _ := apiEvent.RequestContext.Authorizer["Scopes"].(string)
+
+	handler := h.withTokenScopes(scopes, h.handler)
+
+	// set by the authorizer when the session was rotated, the client must replace its cookie
+	cookie, _ := apiEvent.RequestContext.Authorizer[SetCookieContextKey].(string)
+
+	handler = h.withSetCookie(cookie, handler)
+
+	// Extract userId from authorizer context, empty for the public routes
+	userId, _ := apiEvent.RequestContext.Authorizer["UserId"].(string)
+
+	mux.Handle(h.baseURL, h.withUserID(userId, handler))
 
 	// serve the request
 	adapter := httpadapter.New(mux)
-	return adapter.Proxy(apiEvent)
+	return adapter.ProxyWithContext(ctx, apiEvent)
+}
+
+// context of the invocation, with a deadline before the lambda's so the handlers can still respond
+func invocationContext(ctx context.Context) (context.Context, context.CancelFunc) {
+	deadline, ok := ctx.Deadline()
+
+	if !ok {
+		return context.WithCancel(ctx)
+	}
+
+	return context.WithDeadline(ctx, deadline.Add(-responseTimeReserve))
 }
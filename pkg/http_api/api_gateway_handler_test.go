@@ -0,0 +1,105 @@
+package http_api_test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"testing"
+	"time"
+
+	lambda_events "github.com/aws/aws-lambda-go/events"
+	"github.com/manishMandal02/tabsflow-backend/pkg/http_api"
+)
+
+func TestAPIGatewayHandlerContext(t *testing.T) {
+	lambdaDeadline := time.Now().Add(10 * time.Second)
+
+	var (
+		reqDeadline time.Time
+		sqsDeadline time.Time
+	)
+
+	r := http_api.NewRouter("/spaces")
+
+	r.GET("/my", func(w http.ResponseWriter, r *http.Request) {
+		reqDeadline, _ = r.Context().Deadline()
+		http_api.SuccessResMsg(w, "ok")
+	})
+
+	sqsHandler := func(ctx context.Context, _ []lambda_events.SQSMessage) (interface{}, error) {
+		sqsDeadline, _ = ctx.Deadline()
+		return nil, nil
+	}
+
+	h := http_api.NewAPIGatewayHandlerWithSQSHandler("/spaces/", r, sqsHandler)
+
+	apiEvent, _ := json.Marshal(lambda_events.APIGatewayProxyRequest{
+		HTTPMethod:     http.MethodGet,
+		Path:           "/spaces/my",
+		RequestContext: lambda_events.APIGatewayProxyRequestContext{APIID: "test"},
+	})
+
+	sqsEvent, _ := json.Marshal(lambda_events.SQSEvent{
+		Records: []lambda_events.SQSMessage{{MessageId: "1", Body: "{}"}},
+	})
+
+	for _, event := range [][]byte{apiEvent, sqsEvent} {
+		ctx, cancel := context.WithDeadline(context.Background(), lambdaDeadline)
+
+		_, err := h.Handle(ctx, event)
+
+		cancel()
+
+		if err != nil {
+			t.Fatalf("Handle() error = %v", err)
+		}
+	}
+
+	for name, deadline := range map[string]time.Time{"request": reqDeadline, "sqs": sqsDeadline} {
+		if deadline.IsZero() || !deadline.Before(lambdaDeadline) {
+			t.Errorf("Handle() %v deadline = %v, want before the lambda deadline %v", name, deadline, lambdaDeadline)
+		}
+	}
+}
+
+func TestAPIGatewayHandlerUserId(t *testing.T) {
+	var got string
+
+	r := http_api.NewRouter("/spaces")
+
+	r.GET("/my", func(w http.ResponseWriter, r *http.Request) {
+		got = r.Header.Get("UserId")
+		http_api.SuccessResMsg(w, "ok")
+	})
+
+	h := http_api.NewAPIGatewayHandler("/spaces/", r)
+
+	tests := []struct {
+		name       string
+		authorizer map[string]interface{}
+		want       string
+	}{
+		{name: "authorizer user", authorizer: map[string]interface{}{"UserId": "user-1"}, want: "user-1"},
+		// public routes, the header sent by the client is removed
+		{name: "no authorizer"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			event, _ := json.Marshal(lambda_events.APIGatewayProxyRequest{
+				HTTPMethod:     http.MethodGet,
+				Path:           "/spaces/my",
+				Headers:        map[string]string{"UserId": "admin-1"},
+				RequestContext: lambda_events.APIGatewayProxyRequestContext{APIID: "test", Authorizer: tt.authorizer},
+			})
+
+			if _, err := h.Handle(context.Background(), event); err != nil {
+				t.Fatalf("Handle() error = %v", err)
+			}
+
+			if got != tt.want {
+				t.Errorf("Handle() UserId = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
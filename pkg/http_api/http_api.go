@@ -2,7 +2,11 @@ package http_api
 
 import (
 	"encoding/json"
+	"fmt"
 	"net/http"
+
+	"github.com/manishMandal02/tabsflow-backend/pkg/errs"
+	"github.com/manishMandal02/tabsflow-backend/pkg/logger"
 )
 
 const (
@@ -14,90 +18,106 @@ const (
 	ErrorUnMarshalling    = "Error un_marshaling "
 )
 
-type ErrorBody struct {
-	ErrorMsg *string `json:"error,omitempty"`
-}
-
 type Metadata struct {
 	UpdatedAt int64  `json:"updatedAt,omitempty"`
 	LastKey   string `json:"lastKey,omitempty"`
 }
 
 type APIResponse struct {
-	Success  bool        `json:"success"`
-	Message  string      `json:"message,omitempty"`
+	Success bool   `json:"success"`
+	Message string `json:"message,omitempty"`
+	// error with a code for the client to handle/display
+	Error    *errs.Error `json:"error,omitempty"`
 	Data     interface{} `json:"data,omitempty"`
 	Metadata *Metadata   `json:"metadata,omitempty"`
 }
 
 func setCommonHeaders(w http.ResponseWriter) {
 	w.Header().Set("Content-Type", "application/json")
-	w.Header().Set("Access-Control-Allow-Methods", "POST, GET, OPTIONS, PUT, DELETE")
+	w.Header().Set("Access-Control-Allow-Methods", "GET, HEAD, POST, PUT, PATCH, DELETE, OPTIONS")
 	w.Header().Set("Access-Control-Allow-Credentials", "true")
 }
 
-func ErrorRes(w http.ResponseWriter, errMsg string, statusCode int) {
-	w.WriteHeader(statusCode)
+// writes the error response with the status of the error,
+// errors that are not api errors are sent as internal errors
+func ErrorRes(w http.ResponseWriter, err error) {
+	e := errs.From(err)
+
 	setCommonHeaders(w)
-	err := json.NewEncoder(w).Encode(APIResponse{Success: false, Message: errMsg})
+	w.WriteHeader(e.Status)
 
-	if err != nil {
-		ErrorRes(w, ErrorMarshalling, http.StatusInternalServerError)
-		return
+	encodeErr := json.NewEncoder(w).Encode(APIResponse{Success: false, Error: e})
+
+	if encodeErr != nil {
+		logger.Errorf("Couldn't encode error response: %#v: \n[Error]: %v", e, encodeErr)
 	}
 }
 
 func SuccessResData(w http.ResponseWriter, data interface{}) {
-	w.WriteHeader(http.StatusOK)
 	setCommonHeaders(w)
+	w.WriteHeader(http.StatusOK)
 	err := json.NewEncoder(w).Encode(APIResponse{Success: true, Data: data})
 
 	if err != nil {
-		ErrorRes(w, ErrorMarshalling, http.StatusInternalServerError)
+		ErrorRes(w, errs.Internal.WithMessage(ErrorMarshalling))
 		return
 	}
 }
 
 func SuccessResDataWithMetadata(w http.ResponseWriter, data interface{}, m *Metadata) {
-	w.WriteHeader(http.StatusOK)
 	setCommonHeaders(w)
+	w.WriteHeader(http.StatusOK)
 	err := json.NewEncoder(w).Encode(APIResponse{Success: true, Data: data, Metadata: m})
 	if err != nil {
-		ErrorRes(w, ErrorMarshalling, http.StatusInternalServerError)
+		ErrorRes(w, errs.Internal.WithMessage(ErrorMarshalling))
 		return
 	}
 }
 
 func SuccessResMsg(w http.ResponseWriter, msg string) {
-	w.WriteHeader(http.StatusOK)
 	setCommonHeaders(w)
+	w.WriteHeader(http.StatusOK)
 	err := json.NewEncoder(w).Encode(APIResponse{Success: true, Message: msg})
 	if err != nil {
-		ErrorRes(w, ErrorMarshalling, http.StatusInternalServerError)
+		ErrorRes(w, errs.Internal.WithMessage(ErrorMarshalling))
 		return
 	}
 }
 
 func SuccessResMsgWithMetadata(w http.ResponseWriter, msg string, m *Metadata) {
-	w.WriteHeader(http.StatusOK)
 	setCommonHeaders(w)
+	w.WriteHeader(http.StatusOK)
 	err := json.NewEncoder(w).Encode(APIResponse{Success: true, Message: msg, Metadata: m})
 	if err != nil {
-		ErrorRes(w, ErrorMarshalling, http.StatusInternalServerError)
+		ErrorRes(w, errs.Internal.WithMessage(ErrorMarshalling))
 		return
 	}
 }
 
 func SuccessResMsgWithBody(w http.ResponseWriter, msg string, data interface{}) {
-	w.WriteHeader(http.StatusOK)
 	setCommonHeaders(w)
+	w.WriteHeader(http.StatusOK)
 	err := json.NewEncoder(w).Encode(APIResponse{Success: true, Message: msg, Data: data})
 	if err != nil {
-		ErrorRes(w, ErrorMarshalling, http.StatusInternalServerError)
+		ErrorRes(w, errs.Internal.WithMessage(ErrorMarshalling))
 		return
 	}
 }
 
+// writes the data as a file download
+func FileRes(w http.ResponseWriter, data []byte, contentType, fileName string) {
+	w.Header().Set("Content-Type", contentType)
+	w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%q", fileName))
+	w.Header().Set("Access-Control-Allow-Credentials", "true")
+	w.WriteHeader(http.StatusOK)
+
+	_, err := w.Write(data)
+
+	if err != nil {
+		logger.Error("error writing file response", err)
+	}
+}
+
 type responseWriterWritten struct {
 	http.ResponseWriter
 	Written bool
@@ -117,3 +137,23 @@ func (w *responseWriterWritten) HasWritten() bool {
 	return w.Written
 
 }
+
+// records the status of the response, for the route metrics
+type responseWriterStatus struct {
+	http.ResponseWriter
+	Status      int
+	wroteHeader bool
+}
+
+func (w *responseWriterStatus) WriteHeader(status int) {
+	if !w.wroteHeader {
+		w.Status = status
+		w.wroteHeader = true
+	}
+	w.ResponseWriter.WriteHeader(status)
+}
+
+func (w *responseWriterStatus) Write(b []byte) (int, error) {
+	w.wroteHeader = true
+	return w.ResponseWriter.Write(b)
+}
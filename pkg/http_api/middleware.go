@@ -2,33 +2,180 @@ package http_api
 
 import (
 	"net/http"
+	"net/url"
 	"slices"
+	"strconv"
 	"strings"
+	"time"
 
 	"github.com/manishMandal02/tabsflow-backend/config"
+	"github.com/manishMandal02/tabsflow-backend/pkg/errs"
 )
 
-func SetAllowOriginHeader() Handler {
-	return func(w http.ResponseWriter, r *http.Request) {
+func SetAllowOriginHeader() Middleware {
+	return CORS(DefaultCORSOptions())
+}
+
+type CORSOptions struct {
+	AllowedOrigins []string
+	AllowedHeaders []string
+	// time the browser caches the preflight response
+	MaxAge time.Duration
+}
+
+func DefaultCORSOptions() CORSOptions {
+	return CORSOptions{
+		AllowedOrigins: config.AllowedOrigins,
+		AllowedHeaders: config.CORSAllowedHeaders,
+		MaxAge:         time.Second * config.CORS_MAX_AGE_SEC,
+	}
+}
+
+// methods for the preflight response, if the router didn't set the Allow header
+const corsDefaultMethods = "GET, HEAD, POST, PUT, PATCH, DELETE, OPTIONS"
+
+// allows requests from the allowed origins & answers their preflight (OPTIONS) requests
+func CORS(o CORSOptions) Middleware {
+	return func(next Handler) Handler {
+		return func(w http.ResponseWriter, r *http.Request) {
+
+			// api tokens are sent by scripts, not browsers
+			if IsTokenRequest(r) {
+				next(w, r)
+				return
+			}
+
+			origin := r.Header.Get("Origin")
+
+			if origin == "" {
+				origin = refererOrigin(r.Header.Get("Referer"))
+
+				if origin == "" {
+					ErrorRes(w, errs.Forbidden.WithMessage("Origin not allowed"))
+					return
+				}
+			}
+
+			origin = strings.TrimSuffix(origin, "/")
+
+			if !slices.Contains(o.AllowedOrigins, origin) {
+				ErrorRes(w, errs.Forbidden.WithMessage("Origin not allowed"))
+				return
+			}
+
+			w.Header().Add("Access-Control-Allow-Origin", origin)
+			w.Header().Add("Vary", "Origin")
+			// for the extension to report the request id of errors
+			w.Header().Set("Access-Control-Expose-Headers", RequestIdHeader)
+
+			if r.Method != http.MethodOptions || r.Header.Get("Access-Control-Request-Method") == "" {
+				next(w, r)
+				return
+			}
+
+			// preflight
+			methods := w.Header().Get("Allow")
+
+			if methods == "" {
+				methods = corsDefaultMethods
+			}
+
+			w.Header().Set("Access-Control-Allow-Methods", methods)
+			w.Header().Set("Access-Control-Allow-Headers", strings.Join(o.AllowedHeaders, ", "))
+			w.Header().Set("Access-Control-Allow-Credentials", "true")
+			w.Header().Set("Access-Control-Max-Age", strconv.Itoa(int(o.MaxAge.Seconds())))
+			w.WriteHeader(http.StatusNoContent)
+		}
+	}
+}
 
-		origin := r.Header.Get("Origin")
-		referrer := r.Header.Get("Referrer")
+// origin (scheme & host) of the Referer header, empty if it's not a url
+func refererOrigin(referer string) string {
+	u, err := url.Parse(referer)
 
-		if origin == "" {
-			if referrer == "" {
-				ErrorRes(w, "Origin not allowed", http.StatusForbidden)
+	if err != nil || u.Scheme == "" || u.Host == "" {
+		return ""
+	}
+
+	return u.Scheme + "://" + u.Host
+}
+
+// * personal api token scopes
+
+// set by the authorizer for requests authenticated with an api token, space separated scopes
+const TokenScopesHeader = "TokenScopes"
+
+// scopes that can be granted to an api token
+var TokenScopes = []string{
+	"read:spaces",
+	"write:spaces",
+	"read:notes",
+	"write:notes",
+	"read:notifications",
+	"write:notifications",
+	"read:users",
+}
+
+var errTokenScope = errs.Forbidden.New("token_scope_required", "Token scope required")
+
+func IsTokenRequest(r *http.Request) bool {
+	return r.Header.Get(TokenScopesHeader) != ""
+}
+
+// allows api token requests with the read (GET) or write scope of the resource,
+// cookie authenticated requests are not restricted
+func RequireTokenScope(resource string) Middleware {
+	return func(next Handler) Handler {
+		return func(w http.ResponseWriter, r *http.Request) {
+			if !IsTokenRequest(r) {
+				next(w, r)
+				return
+			}
+
+			scope := "write:" + resource
+
+			if r.Method == http.MethodGet || r.Method == http.MethodHead {
+				scope = "read:" + resource
+			}
+
+			if !slices.Contains(strings.Fields(r.Header.Get(TokenScopesHeader)), scope) {
+				ErrorRes(w, errTokenScope.WithDetails(map[string]string{"scope": scope}))
 				return
 			}
-			origin = referrer
+
+			next(w, r)
 		}
+	}
+}
+
+// allows the requests of the admins (ADMIN_USER_IDS), by the userId set by the authorizer.
+// api tokens have no admin scope, only the session requests are allowed
+func RequireAdmin() Middleware {
+	return func(next Handler) Handler {
+		return func(w http.ResponseWriter, r *http.Request) {
+			userId := r.Header.Get("UserId")
 
-		origin = strings.TrimSuffix(origin, "/")
+			if userId == "" {
+				ErrorRes(w, errs.Unauthorized)
+				return
+			}
 
-		if !slices.Contains(config.AllowedOrigins, origin) {
-			ErrorRes(w, "Origin not allowed", http.StatusForbidden)
-			return
+			if IsTokenRequest(r) || !isAdmin(userId) {
+				ErrorRes(w, errs.Forbidden.WithMessage("Admin only"))
+				return
+			}
+
+			next(w, r)
 		}
+	}
+}
 
-		w.Header().Add("Access-Control-Allow-Origin", origin)
+func isAdmin(userId string) bool {
+	for _, id := range strings.Split(config.ADMIN_USER_IDS, ",") {
+		if strings.TrimSpace(id) == userId {
+			return true
+		}
 	}
+
+	return false
 }
@@ -0,0 +1,169 @@
+package http_api_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/manishMandal02/tabsflow-backend/pkg/http_api"
+)
+
+func TestRequireTokenScope(t *testing.T) {
+	r := http_api.NewRouter("/spaces")
+
+	r.Use(http_api.SetAllowOriginHeader())
+
+	r.Use(http_api.RequireTokenScope("spaces"))
+
+	r.GET("/my", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	})
+
+	r.POST("/", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	})
+
+	tests := []struct {
+		name           string
+		method         string
+		path           string
+		scopes         string
+		origin         string
+		expectedStatus int
+	}{
+		{"read scope allows GET", http.MethodGet, "/spaces/my", "read:spaces", "", http.StatusOK},
+		{"read scope doesn't allow POST", http.MethodPost, "/spaces/", "read:spaces", "", http.StatusForbidden},
+		{"write scope allows POST", http.MethodPost, "/spaces/", "read:notes write:spaces", "", http.StatusOK},
+		{"other resource scope", http.MethodGet, "/spaces/my", "read:notes", "", http.StatusForbidden},
+		{"cookie request from allowed origin", http.MethodPost, "/spaces/", "", "https://tabsflow.com", http.StatusOK},
+		{"cookie request without origin", http.MethodGet, "/spaces/my", "", "", http.StatusForbidden},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(tt.method, tt.path, nil)
+
+			if tt.scopes != "" {
+				req.Header.Set(http_api.TokenScopesHeader, tt.scopes)
+			}
+
+			if tt.origin != "" {
+				req.Header.Set("Origin", tt.origin)
+			}
+
+			rr := httptest.NewRecorder()
+
+			r.ServeHTTP(rr, req)
+
+			if rr.Code != tt.expectedStatus {
+				t.Errorf("expected status %v, got %v", tt.expectedStatus, rr.Code)
+			}
+		})
+	}
+}
+
+func TestCORS(t *testing.T) {
+	r := http_api.NewRouter("/notes")
+
+	r.Use(http_api.CORS(http_api.CORSOptions{
+		AllowedOrigins: []string{"https://app.tabsflow.com"},
+		AllowedHeaders: []string{"Content-Type", "X-Custom"},
+		MaxAge:         time.Minute,
+	}))
+
+	r.GET("/:id", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	})
+
+	r.PATCH("/:id", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	})
+
+	tests := []struct {
+		name           string
+		method         string
+		origin         string
+		referer        string
+		preflight      bool
+		expectedStatus int
+		expectedHeader map[string]string
+	}{
+		{
+			name:           "preflight from allowed origin",
+			method:         http.MethodOptions,
+			origin:         "https://app.tabsflow.com",
+			preflight:      true,
+			expectedStatus: http.StatusNoContent,
+			expectedHeader: map[string]string{
+				"Access-Control-Allow-Origin":  "https://app.tabsflow.com",
+				"Access-Control-Allow-Methods": "GET, HEAD, PATCH, OPTIONS",
+				"Access-Control-Allow-Headers": "Content-Type, X-Custom",
+				"Access-Control-Max-Age":       "60",
+			},
+		},
+		{
+			name:           "preflight from other origin",
+			method:         http.MethodOptions,
+			origin:         "https://evil.com",
+			preflight:      true,
+			expectedStatus: http.StatusForbidden,
+		},
+		{
+			name:           "request from allowed origin",
+			method:         http.MethodPatch,
+			origin:         "https://app.tabsflow.com/",
+			expectedStatus: http.StatusOK,
+			expectedHeader: map[string]string{
+				"Access-Control-Allow-Origin": "https://app.tabsflow.com",
+				"Vary":                        "Origin",
+			},
+		},
+		{
+			name:           "request without origin, from allowed referer",
+			method:         http.MethodGet,
+			referer:        "https://app.tabsflow.com/spaces?id=1",
+			expectedStatus: http.StatusOK,
+			expectedHeader: map[string]string{
+				"Access-Control-Allow-Origin": "https://app.tabsflow.com",
+			},
+		},
+		{
+			name:           "request without origin & referer",
+			method:         http.MethodGet,
+			expectedStatus: http.StatusForbidden,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(tt.method, "/notes/1", nil)
+
+			if tt.origin != "" {
+				req.Header.Set("Origin", tt.origin)
+			}
+
+			if tt.referer != "" {
+				req.Header.Set("Referer", tt.referer)
+			}
+
+			if tt.preflight {
+				req.Header.Set("Access-Control-Request-Method", http.MethodPatch)
+			}
+
+			rr := httptest.NewRecorder()
+
+			r.ServeHTTP(rr, req)
+
+			if rr.Code != tt.expectedStatus {
+				t.Errorf("expected status %v, got %v", tt.expectedStatus, rr.Code)
+			}
+
+			for k, v := range tt.expectedHeader {
+				if got := rr.Header().Get(k); got != v {
+					t.Errorf("expected header %v = %v, got %v", k, v, got)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,77 @@
+package http_api
+
+import (
+	"cmp"
+	"slices"
+)
+
+// * route docs, for the OpenAPI spec
+
+// how a route is authorized
+type RouteAuth int
+
+const (
+	// session cookie or personal api token, checked by the authorizer
+	AuthDefault RouteAuth = iota
+	// session cookie only, checked by the handler
+	AuthSession
+	// public route
+	AuthNone
+)
+
+type QueryParam struct {
+	Name        string
+	Description string
+	Required    bool
+}
+
+type RouteDoc struct {
+	Summary string
+	Query   []QueryParam
+	// value of the json body type, nil if the route has no json body
+	Body any
+	// content types of the file bodies, e.g. application/zip
+	BodyFiles []string
+	// value of the response data type, nil for the message only responses
+	Response any
+	// file download responses by content type, with the value of the file's json type (nil for binary files)
+	Files map[string]any
+	// redirect status, for the routes that redirect (e.g. to logout)
+	Redirect int
+	// the route always redirects, it has no success response (e.g. the magic link)
+	RedirectOnly bool
+	Auth         RouteAuth
+}
+
+type RouteInfo struct {
+	Method string
+	// full path, e.g. /spaces/:spaceId/tabs
+	Path string
+	// nil if the route isn't documented
+	Doc *RouteDoc
+}
+
+// sets the docs of the route
+func (r *Route) Doc(d RouteDoc) *Route {
+	r.doc = &d
+	return r
+}
+
+// routes of the router & its groups, by path & method
+func (r *Router) Routes() []RouteInfo {
+	routes := []RouteInfo{}
+
+	r.tree.each(func(route *Route) {
+		routes = append(routes, RouteInfo{
+			Method: route.Method,
+			Path:   r.base + route.path(),
+			Doc:    route.doc,
+		})
+	})
+
+	slices.SortFunc(routes, func(a, b RouteInfo) int {
+		return cmp.Or(cmp.Compare(a.Path, b.Path), cmp.Compare(slices.Index(methodsOrder, a.Method), slices.Index(methodsOrder, b.Method)))
+	})
+
+	return routes
+}
@@ -1,138 +1,342 @@
 package http_api
 
 import (
+	"fmt"
 	"net/http"
+	"slices"
+	"strconv"
 	"strings"
 
+	"github.com/manishMandal02/tabsflow-backend/pkg/errs"
 	"github.com/manishMandal02/tabsflow-backend/pkg/logger"
+	"github.com/manishMandal02/tabsflow-backend/pkg/telemetry"
 )
 
 type Handler func(w http.ResponseWriter, r *http.Request)
 
+// wraps the next handler, stops the chain by not calling next
+type Middleware func(next Handler) Handler
+
 type Route struct {
 	Method       string
 	PathSegments []string
 	Handlers     []Handler
+	// names of the :param & *wildcard segments, in order
+	params []string
+	// group the route was registered on, for its middleware
+	router *Router
+	doc    *RouteDoc
 }
 
 type Router struct {
-	base       string
-	routes     []*Route
-	middleware []Handler
+	base string
+	// path prefix of the group, empty for the root router
+	prefix string
+	parent *Router
+	// route tree, shared with the groups
+	tree       *node
+	middleware []Middleware
 }
 
 type IRouter interface {
 	ServeHTTP(w http.ResponseWriter, req *http.Request)
-	Use(handlers Handler)
-	GET(path string, handlers ...Handler)
-	POST(path string, handlers ...Handler)
-	PATCH(path string, handlers ...Handler)
-	DELETE(path string, handlers ...Handler)
+	Use(middleware ...Middleware)
+	Group(prefix string, middleware ...Middleware) IRouter
+	GET(path string, handlers ...Handler) *Route
+	POST(path string, handlers ...Handler) *Route
+	PATCH(path string, handlers ...Handler) *Route
+	DELETE(path string, handlers ...Handler) *Route
+	PUT(path string, handlers ...Handler) *Route
+	HEAD(path string, handlers ...Handler) *Route
+	OPTIONS(path string, handlers ...Handler) *Route
+	// registered routes, with their docs
+	Routes() []RouteInfo
+}
+
+func (r *Route) path() string {
+	return "/" + strings.Join(r.PathSegments, "/")
 }
 
-func (r *Route) Match(method, path string) (bool, map[string]string) {
-	if r.Method != method {
-		return false, nil
+// sets the :param & *wildcard values as the request path values
+func (r *Route) setPathValues(req *http.Request, values []string) {
+	for i, name := range r.params {
+		req.SetPathValue(name, values[i])
 	}
+}
 
-	// split path
-	segments := strings.Split(strings.Trim(path, "/"), "/")
+func NewRouter(base string) IRouter {
+	return &Router{
+		base:       base,
+		tree:       newNode(),
+		middleware: []Middleware{},
+	}
+}
 
-	if len(segments) != len(r.PathSegments) {
-		return false, nil
+// handlers run in order, the chain stops after a handler writes the response (e.g. an error).
+// panics if the route conflicts with a registered route, the routes are added at startup
+func (r *Router) AddRoute(method, path string, handlers []Handler) *Route {
+	route := &Route{
+		Method:       method,
+		PathSegments: splitPath(r.prefix + "/" + strings.Trim(path, "/")),
+		Handlers:     handlers,
+		router:       r,
 	}
 
-	params := make(map[string]string)
+	for _, s := range route.PathSegments {
+		if !isParam(s) && !isWildcard(s) {
+			continue
+		}
 
-	for i, s := range r.PathSegments {
-		if strings.HasPrefix(s, ":") {
-			params[s[1:]] = segments[i]
-		} else if s != segments[i] {
-			return false, nil
+		if slices.Contains(route.params, s[1:]) {
+			panic(fmt.Sprintf("route %v %v: duplicate param %v", method, route.path(), s))
 		}
+
+		route.params = append(route.params, s[1:])
+	}
+
+	if err := r.tree.insert(route); err != nil {
+		panic(err)
 	}
 
-	return true, params
+	return route
 }
 
-func NewRouter(base string) IRouter {
+// middleware of the router & its groups, in the order they are added
+func (r *Router) Use(middleware ...Middleware) {
+	r.middleware = append(r.middleware, middleware...)
+}
+
+// routes under the prefix, with the group's middleware after the parent's
+func (r *Router) Group(prefix string, middleware ...Middleware) IRouter {
 	return &Router{
-		base:       base,
-		routes:     []*Route{},
-		middleware: []Handler{},
+		base:       r.base,
+		prefix:     r.prefix + "/" + strings.Trim(prefix, "/"),
+		parent:     r,
+		tree:       r.tree,
+		middleware: middleware,
 	}
 }
 
-func (r *Router) AddRoute(method, path string, handlers []Handler) {
-
-	segments := strings.Split(strings.Trim(path, "/"), "/")
-	r.routes = append(r.routes, &Route{
-		Method:       method,
-		PathSegments: segments,
-		Handlers:     handlers,
-	})
+func (r *Router) GET(path string, handlers ...Handler) *Route {
+	return r.AddRoute(http.MethodGet, path, handlers)
 }
 
-func (r *Router) Use(middleware Handler) {
-	r.middleware = append(r.middleware, middleware)
+func (r *Router) POST(path string, handlers ...Handler) *Route {
+	return r.AddRoute(http.MethodPost, path, handlers)
 }
 
-func (r *Router) GET(path string, handlers ...Handler) {
-	r.AddRoute(http.MethodGet, path, handlers)
+func (r *Router) PATCH(path string, handlers ...Handler) *Route {
+	return r.AddRoute(http.MethodPatch, path, handlers)
 }
 
-func (r *Router) POST(path string, handlers ...Handler) {
-	r.AddRoute(http.MethodPost, path, handlers)
+func (r *Router) DELETE(path string, handlers ...Handler) *Route {
+	return r.AddRoute(http.MethodDelete, path, handlers)
+}
 
+func (r *Router) PUT(path string, handlers ...Handler) *Route {
+	return r.AddRoute(http.MethodPut, path, handlers)
 }
 
-func (r *Router) PATCH(path string, handlers ...Handler) {
-	r.AddRoute(http.MethodPatch, path, handlers)
+// GET routes also answer HEAD requests, if the path has no HEAD route
+func (r *Router) HEAD(path string, handlers ...Handler) *Route {
+	return r.AddRoute(http.MethodHead, path, handlers)
 }
 
-func (r *Router) DELETE(path string, handlers ...Handler) {
-	r.AddRoute(http.MethodDelete, path, handlers)
+// OPTIONS requests without a route are answered with the allowed methods (and by the CORS middleware)
+func (r *Router) OPTIONS(path string, handlers ...Handler) *Route {
+	return r.AddRoute(http.MethodOptions, path, handlers)
 }
 
 func (r *Router) ServeHTTP(w http.ResponseWriter, req *http.Request) {
-	logger.Info("Router req: [Method]: %v \n[Path]: %v", req.Method, req.URL.Path)
-	for _, route := range r.routes {
+	// groups share the routes of the root router
+	if r.parent != nil {
+		r.parent.ServeHTTP(w, req)
+		return
+	}
 
-		match, params := route.Match(req.Method, strings.TrimPrefix(req.URL.Path, r.base))
-		if match {
+	req = withRequestId(w, req)
 
-			// wrap the original ResponseWriter
-			rw := &responseWriterWritten{ResponseWriter: w}
+	segments := splitPath(strings.TrimPrefix(req.URL.Path, r.base))
 
-			logger.Info("params: %v", params)
+	route, values := r.tree.lookup(req.Method, segments)
 
-			// set path values
-			for key, value := range params {
-				req.SetPathValue(key, value)
-			}
-			// run middleware
-			if len(r.middleware) > 0 {
-				for _, m := range r.middleware {
-					m(rw, req)
-					if rw.HasWritten() {
-						// stop the req, if header was written by a middleware
-						return
-					}
-				}
+	if route == nil && req.Method == http.MethodHead {
+		route, values = r.tree.lookup(http.MethodGet, segments)
+	}
+
+	logger.DebugContext(req.Context(), "router request", "method", req.Method, "path", req.URL.Path, "params", values)
+
+	if route != nil {
+		route.setPathValues(req, values)
+
+		r.serveRoute(route, w, req)
+		return
+	}
+
+	allowed, pathRoute, values := r.allowedMethods(segments)
+
+	if len(allowed) == 0 {
+		ErrorRes(w, errs.NotFound.WithMessage(ErrorRouteNotFound))
+		return
+	}
+
+	w.Header().Set("Allow", strings.Join(allowed, ", "))
+
+	if req.Method != http.MethodOptions {
+		ErrorRes(w, errs.MethodNotAllowed.WithMessage(ErrorMethodNotAllowed))
+		return
+	}
+
+	pathRoute.setPathValues(req, values)
+
+	// run the path's middleware, to answer CORS preflight requests
+	pathRoute.handlerWith(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNoContent)
+	})(w, req)
+}
+
+// runs the route's handlers, recording the latency & response status of the route
+func (r *Router) serveRoute(route *Route, w http.ResponseWriter, req *http.Request) {
+	ctx, span := telemetry.StartSpan(req.Context(), "http.server.request", telemetry.String("route", route.Method+" "+r.base+route.path()))
+
+	sw := &responseWriterStatus{ResponseWriter: w, Status: http.StatusOK}
+
+	route.handler()(sw, req.WithContext(ctx))
+
+	span.SetAttrs(telemetry.String("status", strconv.Itoa(sw.Status)))
+	span.End()
+}
+
+// request id of the request & its response, a new id is set if the client (or api gateway) didn't send one
+const RequestIdHeader = "X-Request-Id"
+
+// adds the request id to the request context & the response headers,
+// the id of the context (e.g. the api gateway request id) is used over the header
+func withRequestId(w http.ResponseWriter, req *http.Request) *http.Request {
+	id := logger.RequestId(req.Context())
+
+	if id == "" {
+		id = req.Header.Get(RequestIdHeader)
+
+		if !isValidRequestId(id) {
+			id = logger.NewRequestId()
+		}
+
+		req = req.WithContext(logger.WithRequestId(req.Context(), id))
+	}
+
+	w.Header().Set(RequestIdHeader, id)
+
+	return req
+}
+
+// ids from the clients are logged, only short ids of letters, digits, - & _
+func isValidRequestId(id string) bool {
+	if id == "" || len(id) > 64 {
+		return false
+	}
+
+	for _, c := range id {
+		if !(c == '-' || c == '_' || (c >= '0' && c <= '9') || (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z')) {
+			return false
+		}
+	}
+
+	return true
+}
+
+var methodsOrder = []string{
+	http.MethodGet,
+	http.MethodHead,
+	http.MethodPost,
+	http.MethodPut,
+	http.MethodPatch,
+	http.MethodDelete,
+	http.MethodOptions,
+}
+
+// methods registered for the path, with a route of the path (by precedence) & its values
+func (r *Router) allowedMethods(segments []string) ([]string, *Route, []string) {
+	var (
+		pathRoute *Route
+		values    []string
+	)
+
+	registered := map[string]bool{}
+
+	r.tree.walk(segments, nil, func(n *node, v []string) bool {
+		for _, m := range methodsOrder {
+			route, ok := n.routes[m]
+
+			if !ok {
+				continue
 			}
 
-			// run handlers
-			if len(route.Handlers) == 1 {
-				route.Handlers[0](w, req)
-			} else {
-				for _, h := range route.Handlers {
-					h(rw, req)
-				}
+			if pathRoute == nil {
+				pathRoute = route
+				// copy, the walk reuses the values
+				values = slices.Clone(v)
 			}
-			return
+
+			registered[m] = true
 		}
 
+		// all the matching nodes
+		return false
+	})
+
+	if pathRoute == nil {
+		return nil, nil, nil
 	}
 
-	ErrorRes(w, ErrorRouteNotFound, http.StatusNotFound)
+	if registered[http.MethodGet] {
+		registered[http.MethodHead] = true
+	}
+
+	registered[http.MethodOptions] = true
+
+	allowed := []string{}
+
+	for _, m := range methodsOrder {
+		if registered[m] {
+			allowed = append(allowed, m)
+		}
+	}
+
+	return allowed, pathRoute, values
+}
+
+// route handlers wrapped with the middleware of its group & the parent groups
+func (route *Route) handler() Handler {
+	return route.handlerWith(chainHandlers(route.Handlers))
+}
+
+func (route *Route) handlerWith(h Handler) Handler {
+	for g := route.router; g != nil; g = g.parent {
+		for i := len(g.middleware) - 1; i >= 0; i-- {
+			h = g.middleware[i](h)
+		}
+	}
+
+	return h
+}
+
+// runs the handlers in order, until one writes the response
+func chainHandlers(handlers []Handler) Handler {
+	if len(handlers) == 1 {
+		return handlers[0]
+	}
+
+	return func(w http.ResponseWriter, r *http.Request) {
+		// wrap the original ResponseWriter
+		rw := &responseWriterWritten{ResponseWriter: w}
+
+		for _, h := range handlers {
+			h(rw, r)
+
+			if rw.HasWritten() {
+				return
+			}
+		}
+	}
 }
@@ -1,31 +1,38 @@
 package http_api_test
 
 import (
+	"context"
 	"fmt"
 	"net/http"
 	"net/http/httptest"
 	"testing"
 
+	"github.com/manishMandal02/tabsflow-backend/pkg/errs"
 	"github.com/manishMandal02/tabsflow-backend/pkg/http_api"
+	"github.com/manishMandal02/tabsflow-backend/pkg/logger"
+	"github.com/manishMandal02/tabsflow-backend/pkg/telemetry"
 )
 
 func Router() http.Handler {
 	r := http_api.NewRouter("/test")
 
-	r.Use(func(w http.ResponseWriter, r *http.Request) {
-		r.SetPathValue("userId", "123")
+	r.Use(func(next http_api.Handler) http_api.Handler {
+		return func(w http.ResponseWriter, r *http.Request) {
+			r.SetPathValue("userId", "123")
+			next(w, r)
+		}
 	})
 
 	r.GET("/hello", func(w http.ResponseWriter, r *http.Request) {
 		if r.Method != http.MethodGet {
-			http_api.ErrorRes(w, "Method not allowed", http.StatusMethodNotAllowed)
+			http_api.ErrorRes(w, errs.MethodNotAllowed)
 			return
 		}
 
 		userId := r.PathValue("userId")
 
 		if userId == "" {
-			http_api.ErrorRes(w, "User ID not found", http.StatusBadRequest)
+			http_api.ErrorRes(w, errs.BadRequest.WithMessage("User ID not found"))
 			return
 		}
 
@@ -34,7 +41,7 @@ func Router() http.Handler {
 
 	r.POST("/hello", func(w http.ResponseWriter, r *http.Request) {
 		if r.Method != http.MethodPost {
-			http_api.ErrorRes(w, "Method not allowed", http.StatusMethodNotAllowed)
+			http_api.ErrorRes(w, errs.MethodNotAllowed)
 			return
 		}
 
@@ -58,10 +65,10 @@ var testCases = []struct {
 		expectedStatus: http.StatusNotFound,
 	},
 	{
-		name:           "Delete /test/hello/extra > not found",
+		name:           "DELETE /test/hello > method not allowed",
 		path:           "/test/hello",
 		method:         "DELETE",
-		expectedStatus: http.StatusNotFound,
+		expectedStatus: http.StatusMethodNotAllowed,
 	},
 	{
 		name:           "GET /test/hello > success",
@@ -104,3 +111,377 @@ func TestRouter(t *testing.T) {
 		})
 	}
 }
+
+// middleware that allows the request only with the header
+func requireHeader(name string) http_api.Middleware {
+	return func(next http_api.Handler) http_api.Handler {
+		return func(w http.ResponseWriter, r *http.Request) {
+			if r.Header.Get(name) == "" {
+				http_api.ErrorRes(w, errs.Unauthorized.WithMessage(name+" required"))
+				return
+			}
+
+			next(w, r)
+		}
+	}
+}
+
+func TestRouterGroups(t *testing.T) {
+	r := http_api.NewRouter("/test")
+
+	order := []string{}
+
+	r.Use(func(next http_api.Handler) http_api.Handler {
+		return func(w http.ResponseWriter, r *http.Request) {
+			order = append(order, "root")
+			next(w, r)
+		}
+	})
+
+	ok := func(w http.ResponseWriter, r *http.Request) {
+		order = append(order, "handler")
+		fmt.Fprint(w, r.URL.Path)
+	}
+
+	r.GET("/public", ok)
+
+	admin := r.Group("/admin", requireHeader("Admin"))
+
+	admin.GET("/stats", ok)
+
+	// nested group, with the parent's middleware
+	reports := admin.Group("reports", requireHeader("Reports"))
+
+	reports.GET("/:id", ok)
+
+	// handler chain stops after the first handler writes an error
+	r.POST("/chain", func(w http.ResponseWriter, r *http.Request) {
+		http_api.ErrorRes(w, errs.BadRequest)
+	}, func(w http.ResponseWriter, r *http.Request) {
+		order = append(order, "not-called")
+	})
+
+	tests := []struct {
+		name           string
+		method         string
+		path           string
+		headers        map[string]string
+		expectedStatus int
+		expectedOrder  []string
+	}{
+		{"public route", "GET", "/test/public", nil, http.StatusOK, []string{"root", "handler"}},
+		{"group middleware blocks", "GET", "/test/admin/stats", nil, http.StatusUnauthorized, []string{"root"}},
+		{"group middleware allows", "GET", "/test/admin/stats", map[string]string{"Admin": "1"}, http.StatusOK, []string{"root", "handler"}},
+		{"nested group needs parent middleware", "GET", "/test/admin/reports/1", map[string]string{"Reports": "1"}, http.StatusUnauthorized, []string{"root"}},
+		{"nested group allows", "GET", "/test/admin/reports/1", map[string]string{"Admin": "1", "Reports": "1"}, http.StatusOK, []string{"root", "handler"}},
+		{"group route not on root", "GET", "/test/stats", nil, http.StatusNotFound, []string{}},
+		{"chain stops on error", "POST", "/test/chain", nil, http.StatusBadRequest, []string{"root"}},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			order = []string{}
+
+			req := httptest.NewRequest(tc.method, tc.path, nil)
+
+			for k, v := range tc.headers {
+				req.Header.Set(k, v)
+			}
+
+			w := httptest.NewRecorder()
+
+			r.ServeHTTP(w, req)
+
+			if w.Code != tc.expectedStatus {
+				t.Errorf("Status [Want] %d | [Actual] %d", tc.expectedStatus, w.Code)
+			}
+
+			if fmt.Sprint(order) != fmt.Sprint(tc.expectedOrder) {
+				t.Errorf("Order [Want] %v | [Actual] %v", tc.expectedOrder, order)
+			}
+		})
+	}
+}
+
+func TestRouterMethods(t *testing.T) {
+	r := http_api.NewRouter("/test")
+
+	ok := func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, r.Method+" "+r.PathValue("id"))
+	}
+
+	r.GET("/items/:id", ok)
+	r.PUT("/items/:id", ok)
+	r.DELETE("/items/:id", ok)
+	r.POST("/items", ok)
+	r.HEAD("/ping", ok)
+	r.OPTIONS("/custom", ok)
+
+	tests := []struct {
+		name           string
+		method         string
+		path           string
+		expectedStatus int
+		expectedBody   string
+		expectedAllow  string
+	}{
+		{"put route", "PUT", "/test/items/1", http.StatusOK, "PUT 1", ""},
+		{"head falls back to get", "HEAD", "/test/items/1", http.StatusOK, "HEAD 1", ""},
+		{"head route", "HEAD", "/test/ping", http.StatusOK, "HEAD ", ""},
+		{"method not allowed", "PATCH", "/test/items/1", http.StatusMethodNotAllowed, "", "GET, HEAD, PUT, DELETE, OPTIONS"},
+		{"get not registered", "GET", "/test/ping", http.StatusMethodNotAllowed, "", "HEAD, OPTIONS"},
+		{"options without route", "OPTIONS", "/test/items", http.StatusNoContent, "", "POST, OPTIONS"},
+		{"options route", "OPTIONS", "/test/custom", http.StatusOK, "OPTIONS ", ""},
+		{"not found", "PATCH", "/test/other", http.StatusNotFound, "", ""},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			req := httptest.NewRequest(tc.method, tc.path, nil)
+
+			w := httptest.NewRecorder()
+
+			r.ServeHTTP(w, req)
+
+			if w.Code != tc.expectedStatus {
+				t.Errorf("Status [Want] %d | [Actual] %d", tc.expectedStatus, w.Code)
+			}
+
+			if tc.expectedBody != "" && w.Body.String() != tc.expectedBody {
+				t.Errorf("Body [Want] %s | [Actual] %s", tc.expectedBody, w.Body.String())
+			}
+
+			if allow := w.Header().Get("Allow"); allow != tc.expectedAllow {
+				t.Errorf("Allow [Want] %s | [Actual] %s", tc.expectedAllow, allow)
+			}
+		})
+	}
+}
+
+func TestRouterPrecedence(t *testing.T) {
+	r := http_api.NewRouter("/spaces")
+
+	// handler writes the matched route & its path values
+	route := func(name string, params ...string) http_api.Handler {
+		return func(w http.ResponseWriter, r *http.Request) {
+			fmt.Fprint(w, name)
+
+			for _, p := range params {
+				fmt.Fprintf(w, " %v=%v", p, r.PathValue(p))
+			}
+		}
+	}
+
+	// param routes registered first, static routes still take precedence
+	r.GET("/:spaceId/snoozed-tabs", route("snoozedBySpace", "spaceId"))
+	r.GET("/snoozed-tabs/my", route("snoozedByUser"))
+	r.GET("/:id", route("space", "id"))
+	r.GET("/my", route("mySpaces"))
+	r.GET("/files/*path", route("files", "path"))
+	r.GET("/files/readme", route("readme"))
+	r.GET("/:spaceId/tabs/:tabId/restore", route("restoreTab", "spaceId", "tabId"))
+
+	tests := []struct {
+		path         string
+		expectedBody string
+	}{
+		{"/spaces/snoozed-tabs/my", "snoozedByUser"},
+		{"/spaces/space-1/snoozed-tabs", "snoozedBySpace spaceId=space-1"},
+		// static segment doesn't match the rest, falls back to the param
+		{"/spaces/snoozed-tabs/snoozed-tabs", "snoozedBySpace spaceId=snoozed-tabs"},
+		{"/spaces/my", "mySpaces"},
+		{"/spaces/my/", "mySpaces"},
+		{"/spaces/space-1/", "space id=space-1"},
+		{"/spaces/files/readme", "readme"},
+		{"/spaces/files/docs/api.md", "files path=docs/api.md"},
+		{"/spaces/files", "files path="},
+		{"/spaces/space-1/tabs/tab-1/restore", "restoreTab spaceId=space-1 tabId=tab-1"},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.path, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, tc.path, nil)
+
+			w := httptest.NewRecorder()
+
+			r.ServeHTTP(w, req)
+
+			if w.Body.String() != tc.expectedBody {
+				t.Errorf("Body [Want] %s | [Actual] %s", tc.expectedBody, w.Body.String())
+			}
+		})
+	}
+
+	// empty segment is not a param value
+	w := httptest.NewRecorder()
+
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/spaces/space-1/tabs//restore", nil))
+
+	if w.Code != http.StatusNotFound {
+		t.Errorf("Status [Want] %d | [Actual] %d", http.StatusNotFound, w.Code)
+	}
+}
+
+func TestRouterConflicts(t *testing.T) {
+	ok := func(w http.ResponseWriter, r *http.Request) {}
+
+	tests := []struct {
+		name     string
+		register func(r http_api.IRouter)
+	}{
+		{"same route", func(r http_api.IRouter) {
+			r.GET("/tabs", ok)
+			r.GET("/tabs/", ok)
+		}},
+		{"param with a different name", func(r http_api.IRouter) {
+			r.DELETE("/:id", ok)
+			r.DELETE("/:spaceId", ok)
+		}},
+		{"group route", func(r http_api.IRouter) {
+			r.GET("/admin/stats", ok)
+			r.Group("/admin").GET("/stats", ok)
+		}},
+		{"wildcard not the last segment", func(r http_api.IRouter) {
+			r.GET("/files/*path/edit", ok)
+		}},
+		{"duplicate param", func(r http_api.IRouter) {
+			r.GET("/:id/tabs/:id", ok)
+		}},
+		{"param without a name", func(r http_api.IRouter) {
+			r.GET("/:/tabs", ok)
+		}},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			defer func() {
+				if recover() == nil {
+					t.Errorf("expected a panic for the conflicting route")
+				}
+			}()
+
+			tc.register(http_api.NewRouter("/test"))
+		})
+	}
+
+	// same path with other methods, or param names, doesn't conflict
+	r := http_api.NewRouter("/test")
+
+	r.GET("/:id", ok)
+	r.DELETE("/:spaceId", ok)
+	r.GET("/:spaceId/tabs", ok)
+}
+
+func TestRouterRoutes(t *testing.T) {
+	ok := func(w http.ResponseWriter, r *http.Request) {}
+
+	r := http_api.NewRouter("/test")
+
+	r.POST("/tabs", ok)
+	r.GET("/tabs", ok).Doc(http_api.RouteDoc{Summary: "Tabs"})
+	r.Group("/admin").DELETE("/:id", ok)
+	r.GET("/", ok)
+
+	got := []string{}
+
+	for _, route := range r.Routes() {
+		got = append(got, route.Method+" "+route.Path)
+	}
+
+	want := []string{"GET /test/", "DELETE /test/admin/:id", "GET /test/tabs", "POST /test/tabs"}
+
+	if fmt.Sprint(got) != fmt.Sprint(want) {
+		t.Errorf("Routes [Want] %v | [Actual] %v", want, got)
+	}
+
+	if doc := r.Routes()[2].Doc; doc == nil || doc.Summary != "Tabs" {
+		t.Errorf("Routes doc [Want] Tabs | [Actual] %+v", doc)
+	}
+}
+
+func TestRouterRequestId(t *testing.T) {
+	var got string
+
+	r := http_api.NewRouter("/test")
+
+	r.GET("/tabs", func(w http.ResponseWriter, r *http.Request) {
+		got = logger.RequestId(r.Context())
+	})
+
+	tests := []struct {
+		name   string
+		header string
+		want   string
+	}{
+		{name: "client id", header: "req-1", want: "req-1"},
+		{name: "invalid client id", header: "req 1\n"},
+		{name: "no client id"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest("GET", "/test/tabs", nil)
+
+			if tt.header != "" {
+				req.Header.Set(http_api.RequestIdHeader, tt.header)
+			}
+
+			w := httptest.NewRecorder()
+
+			r.ServeHTTP(w, req)
+
+			res := w.Header().Get(http_api.RequestIdHeader)
+
+			if res != got {
+				t.Errorf("RequestId header [Want] %q | [Actual] %q", got, res)
+			}
+
+			// a new id, if the client has none or an invalid one
+			if (tt.want != "" && got != tt.want) || (tt.want == "" && (got == "" || got == tt.header)) {
+				t.Errorf("RequestId [Want] %q | [Actual] %q", tt.want, got)
+			}
+		})
+	}
+}
+
+type testExporter struct {
+	points []telemetry.Point
+}
+
+func (e *testExporter) Export(_ context.Context, points []telemetry.Point) error {
+	e.points = append(e.points, points...)
+	return nil
+}
+
+func TestRouterMetrics(t *testing.T) {
+	// metrics of the other tests
+	telemetry.Flush(context.Background())
+
+	e := &testExporter{}
+
+	telemetry.Init(e)
+	defer telemetry.SetExporter("none")
+
+	r := http_api.NewRouter("/test")
+
+	r.GET("/tabs/:id", func(w http.ResponseWriter, r *http.Request) {
+		http_api.ErrorRes(w, errs.NotFound)
+	})
+
+	r.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/test/tabs/1", nil))
+
+	// unknown routes have no metrics
+	r.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/test/spaces", nil))
+
+	telemetry.Flush(context.Background())
+
+	if len(e.points) != 1 {
+		t.Fatalf("Metrics [Want] 1 point | [Actual] %+v", e.points)
+	}
+
+	want := []telemetry.Attr{telemetry.String("route", "GET /test/tabs/:id"), telemetry.String("status", "404")}
+
+	if got := e.points[0]; got.Name != "http.server.request.duration" || fmt.Sprint(got.Attrs) != fmt.Sprint(want) {
+		t.Errorf("Metrics [Want] %v | [Actual] %v %v", want, got.Name, got.Attrs)
+	}
+}
@@ -0,0 +1,153 @@
+package http_api
+
+import (
+	"fmt"
+	"strings"
+)
+
+// * route tree
+// each node is a path segment, a path is matched by precedence regardless of the registration order:
+// static segment > :param > *wildcard (rest of the path, can be empty)
+
+type node struct {
+	static   map[string]*node
+	param    *node
+	wildcard *node
+	// routes of the node path, by method
+	routes map[string]*Route
+}
+
+func newNode() *node {
+	return &node{
+		static: map[string]*node{},
+		routes: map[string]*Route{},
+	}
+}
+
+// path segments, a leading or trailing slash is ignored
+func splitPath(path string) []string {
+	path = strings.Trim(path, "/")
+
+	if path == "" {
+		return nil
+	}
+
+	return strings.Split(path, "/")
+}
+
+func isParam(segment string) bool {
+	return strings.HasPrefix(segment, ":")
+}
+
+func isWildcard(segment string) bool {
+	return strings.HasPrefix(segment, "*")
+}
+
+// adds the route, errors if the path already has a route for the method
+func (n *node) insert(route *Route) error {
+	for i, s := range route.PathSegments {
+		switch {
+		case isWildcard(s) || isParam(s):
+			if len(s) == 1 {
+				return fmt.Errorf("route %v %v: %v must have a name", route.Method, route.path(), s)
+			}
+
+			if isWildcard(s) {
+				if i != len(route.PathSegments)-1 {
+					return fmt.Errorf("route %v %v: wildcard %v must be the last segment", route.Method, route.path(), s)
+				}
+
+				if n.wildcard == nil {
+					n.wildcard = newNode()
+				}
+
+				n = n.wildcard
+				continue
+			}
+
+			if n.param == nil {
+				n.param = newNode()
+			}
+
+			n = n.param
+
+		default:
+			child, ok := n.static[s]
+
+			if !ok {
+				child = newNode()
+				n.static[s] = child
+			}
+
+			n = child
+		}
+	}
+
+	if existing, ok := n.routes[route.Method]; ok {
+		return fmt.Errorf("route %v %v conflicts with %v %v", route.Method, route.path(), existing.Method, existing.path())
+	}
+
+	n.routes[route.Method] = route
+
+	return nil
+}
+
+// calls fn for the routes of the node & its children
+func (n *node) each(fn func(route *Route)) {
+	for _, route := range n.routes {
+		fn(route)
+	}
+
+	for _, child := range n.static {
+		child.each(fn)
+	}
+
+	for _, child := range []*node{n.param, n.wildcard} {
+		if child != nil {
+			child.each(fn)
+		}
+	}
+}
+
+// route for the method & path, with the values of its :param & *wildcard segments in order
+func (n *node) lookup(method string, segments []string) (*Route, []string) {
+	var (
+		route  *Route
+		values []string
+	)
+
+	// values of a path are at most the segments & an empty wildcard
+	n.walk(segments, make([]string, 0, len(segments)+1), func(match *node, v []string) bool {
+		route = match.routes[method]
+		values = v
+		return route != nil
+	})
+
+	return route, values
+}
+
+// calls found for the nodes matching the path in precedence order, until it returns true
+func (n *node) walk(segments, values []string, found func(n *node, values []string) bool) bool {
+	if len(segments) == 0 {
+		if found(n, values) {
+			return true
+		}
+	} else {
+		s := segments[0]
+
+		if child, ok := n.static[s]; ok && child.walk(segments[1:], values, found) {
+			return true
+		}
+
+		// empty segment (e.g. /spaces//tabs) is not a param value
+		if n.param != nil && s != "" && n.param.walk(segments[1:], append(values, s), found) {
+			return true
+		}
+	}
+
+	if n.wildcard != nil {
+		return found(n.wildcard, append(values, strings.Join(segments, "/")))
+	}
+
+	return false
+}
@@ -0,0 +1,118 @@
+package http_api
+
+import (
+	"net/http"
+	"strings"
+	"testing"
+)
+
+// * route matching benchmarks, tree vs the previous linear scan of the routes
+
+var benchRoutes = []struct {
+	method string
+	path   string
+}{
+	{http.MethodPost, "/"},
+	{http.MethodGet, "/my"},
+	{http.MethodGet, "/:id"},
+	{http.MethodPatch, "/"},
+	{http.MethodDelete, "/:spaceId"},
+	{http.MethodPatch, "/order"},
+	{http.MethodPatch, "/:spaceId/pin"},
+	{http.MethodPatch, "/:spaceId/unpin"},
+	{http.MethodPatch, "/:spaceId/archive"},
+	{http.MethodPatch, "/:spaceId/unarchive"},
+	{http.MethodPost, "/import"},
+	{http.MethodGet, "/:spaceId/active-tab-index"},
+	{http.MethodPost, "/:spaceId/active-tab-index"},
+	{http.MethodGet, "/:spaceId/tabs"},
+	{http.MethodPost, "/:spaceId/tabs"},
+	{http.MethodGet, "/:spaceId/groups"},
+	{http.MethodPost, "/:spaceId/groups"},
+	{http.MethodPost, "/:spaceId/snoozed-tabs"},
+	{http.MethodGet, "/:spaceId/snoozed-tabs/:id"},
+	{http.MethodPatch, "/:spaceId/snoozed-tabs/switch-space"},
+	{http.MethodGet, "/snoozed-tabs/my"},
+	{http.MethodGet, "/:spaceId/snoozed-tabs"},
+	{http.MethodDelete, "/:spaceId/snoozed-tabs/:id"},
+}
+
+var benchRequests = []struct {
+	name   string
+	method string
+	path   string
+}{
+	{"first route", http.MethodPost, "/"},
+	{"param", http.MethodGet, "/space-1"},
+	{"nested params", http.MethodDelete, "/space-1/snoozed-tabs/tab-1"},
+	{"static", http.MethodGet, "/snoozed-tabs/my"},
+	{"not found", http.MethodGet, "/space-1/snoozed-tabs/tab-1/extra"},
+}
+
+// previous route matching, first registered route wins
+func linearMatch(routes []*Route, method, path string) (*Route, map[string]string) {
+	segments := strings.Split(strings.Trim(path, "/"), "/")
+
+	for _, route := range routes {
+		if route.Method != method || len(segments) != len(route.PathSegments) {
+			continue
+		}
+
+		params := make(map[string]string)
+		match := true
+
+		for i, s := range route.PathSegments {
+			if strings.HasPrefix(s, ":") {
+				params[s[1:]] = segments[i]
+			} else if s != segments[i] {
+				match = false
+				break
+			}
+		}
+
+		if match {
+			return route, params
+		}
+	}
+
+	return nil, nil
+}
+
+func BenchmarkLinearMatch(b *testing.B) {
+	routes := []*Route{}
+
+	for _, r := range benchRoutes {
+		routes = append(routes, &Route{
+			Method:       r.method,
+			PathSegments: strings.Split(strings.Trim(r.path, "/"), "/"),
+		})
+	}
+
+	for _, req := range benchRequests {
+		b.Run(req.name, func(b *testing.B) {
+			b.ReportAllocs()
+
+			for i := 0; i < b.N; i++ {
+				linearMatch(routes, req.method, req.path)
+			}
+		})
+	}
+}
+
+func BenchmarkTreeMatch(b *testing.B) {
+	r := NewRouter("").(*Router)
+
+	for _, route := range benchRoutes {
+		r.AddRoute(route.method, route.path, []Handler{func(w http.ResponseWriter, r *http.Request) {}})
+	}
+
+	for _, req := range benchRequests {
+		b.Run(req.name, func(b *testing.B) {
+			b.ReportAllocs()
+
+			for i := 0; i < b.N; i++ {
+				r.tree.lookup(req.method, splitPath(req.path))
+			}
+		})
+	}
+}
@@ -0,0 +1,226 @@
+package http_api
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/url"
+	"reflect"
+	"regexp"
+	"strings"
+	"sync"
+	"unicode/utf8"
+
+	"github.com/go-playground/validator/v10"
+
+	"github.com/manishMandal02/tabsflow-backend/pkg/errs"
+)
+
+// * request body decoding & validation
+// bodies are validated with the `validate` struct tags, the invalid fields are sent in the error details
+
+// max size of a request body, in bytes
+const MaxBodySize = 1 << 20
+
+var (
+	ErrInvalidBody  = errs.BadRequest.New("invalid_body", "Invalid request body")
+	ErrBodyTooLarge = errs.PayloadTooLarge.New("body_too_large", "Request body too large")
+	ErrValidation   = errs.BadRequest.New("validation_failed", "Invalid request body fields")
+)
+
+// field of the request body that failed, sent in the error details
+type FieldError struct {
+	// json path of the field, e.g. tabs[0].url
+	Field string `json:"field"`
+	// failed validation tag, e.g. required, or type/unknown for the decoding errors
+	Rule  string `json:"rule"`
+	Param string `json:"param,omitempty"`
+}
+
+// decodes the json body to T & validates it, unknown fields & bodies larger than MaxBodySize are rejected.
+// the error is an api error, to be sent with ErrorRes
+func DecodeAndValidate[T any](w http.ResponseWriter, r *http.Request) (*T, error) {
+	return DecodeAndValidateLimit[T](w, r, MaxBodySize)
+}
+
+// DecodeAndValidate, with the max body size in bytes
+func DecodeAndValidateLimit[T any](w http.ResponseWriter, r *http.Request, maxBytes int64) (*T, error) {
+	r.Body = http.MaxBytesReader(w, r.Body, maxBytes)
+
+	decoder := json.NewDecoder(r.Body)
+	decoder.DisallowUnknownFields()
+
+	v := new(T)
+
+	err := decoder.Decode(v)
+
+	if err != nil {
+		return nil, decodeError(err)
+	}
+
+	// body must be a single json value
+	_, err = decoder.Token()
+
+	if !errors.Is(err, io.EOF) {
+		return nil, ErrInvalidBody
+	}
+
+	err = Validate(v)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return v, nil
+}
+
+// validates the struct with the `validate` tags, returns ErrValidation with the invalid fields
+func Validate(v any) error {
+	err := sharedValidator().Struct(v)
+
+	if err == nil {
+		return nil
+	}
+
+	var vErrs validator.ValidationErrors
+
+	// not a struct, the caller's mistake
+	if !errors.As(err, &vErrs) {
+		return errs.Internal
+	}
+
+	// namespace starts with the struct name (e.g. space.title), anonymous structs have none
+	prefix := reflect.Indirect(reflect.ValueOf(v)).Type().Name() + "."
+
+	fields := make([]FieldError, 0, len(vErrs))
+
+	for _, e := range vErrs {
+		fields = append(fields, FieldError{
+			Field: strings.TrimPrefix(e.Namespace(), prefix),
+			Rule:  e.Tag(),
+			Param: e.Param(),
+		})
+	}
+
+	return ErrValidation.WithDetails(fields)
+}
+
+func decodeError(err error) error {
+	var maxBytesErr *http.MaxBytesError
+
+	if errors.As(err, &maxBytesErr) {
+		return ErrBodyTooLarge
+	}
+
+	var typeErr *json.UnmarshalTypeError
+
+	// field is empty if the body itself has the wrong type
+	if errors.As(err, &typeErr) && typeErr.Field != "" {
+		return ErrInvalidBody.WithDetails([]FieldError{{Field: typeErr.Field, Rule: "type", Param: typeErr.Type.String()}})
+	}
+
+	// encoding/json has no type for the unknown field error
+	if f, ok := strings.CutPrefix(err.Error(), "json: unknown field "); ok {
+		return ErrInvalidBody.WithDetails([]FieldError{{Field: strings.Trim(f, `"`), Rule: "unknown"}})
+	}
+
+	return ErrInvalidBody
+}
+
+// validator caches the struct info, so it's created once
+var sharedValidator = sync.OnceValue(func() *validator.Validate {
+	v := validator.New(validator.WithRequiredStructEnabled())
+
+	// field errors have the json names
+	v.RegisterTagNameFunc(func(f reflect.StructField) string {
+		name, _, _ := strings.Cut(f.Tag.Get("json"), ",")
+
+		switch name {
+		case "-":
+			return ""
+		case "":
+			return f.Name
+		}
+
+		return name
+	})
+
+	_ = v.RegisterValidation("http_url", func(fl validator.FieldLevel) bool {
+		return isHTTPURL(fl.Field().String())
+	})
+
+	_ = v.RegisterValidation("emoji", func(fl validator.FieldLevel) bool {
+		return isEmoji(fl.Field().String())
+	})
+
+	_ = v.RegisterValidation("hex_color", func(fl validator.FieldLevel) bool {
+		return hexColorRegex.MatchString(fl.Field().String())
+	})
+
+	return v
+})
+
+// * custom validators
+
+var hexColorRegex = regexp.MustCompile(`^#(?:[0-9a-fA-F]{3}|[0-9a-fA-F]{6})$`)
+
+// absolute http(s) url, the built-in url validator allows any scheme (e.g. javascript:)
+func isHTTPURL(s string) bool {
+	u, err := url.Parse(s)
+
+	if err != nil {
+		return false
+	}
+
+	return (u.Scheme == "http" || u.Scheme == "https") && u.Host != ""
+}
+
+// longest emoji sequences (e.g. family with skin tones) have ~10 code points
+const maxEmojiRunes = 16
+
+// a single emoji or an emoji sequence (skin tones, zwj sequences, flags, keycaps)
+func isEmoji(s string) bool {
+	if s == "" || utf8.RuneCountInString(s) > maxEmojiRunes {
+		return false
+	}
+
+	hasPictograph, hasKeycap := false, strings.ContainsRune(s, 0x20E3)
+
+	for _, r := range s {
+		switch {
+		case isPictograph(r):
+			hasPictograph = true
+		// zwj, variation selector, keycap & tag characters (subdivision flags)
+		case r == 0x200D || r == 0xFE0F || r == 0x20E3 || (r >= 0xE0020 && r <= 0xE007F):
+		// keycap base, e.g. 1️⃣
+		case hasKeycap && (r == '#' || r == '*' || (r >= '0' && r <= '9')):
+			hasPictograph = true
+		default:
+			return false
+		}
+	}
+
+	return hasPictograph
+}
+
+func isPictograph(r rune) bool {
+	switch {
+	// symbols & pictographs, emoticons, transport, flags (regional indicators), skin tones
+	case r >= 0x1F000 && r <= 0x1FAFF:
+		return true
+	// misc symbols & dingbats
+	case r >= 0x2600 && r <= 0x27BF:
+		return true
+	// misc technical (⌚, ⏰), arrows & geometric shapes (⭐, ⬛)
+	case r >= 0x2300 && r <= 0x23FF, r >= 0x2190 && r <= 0x21FF, r >= 0x2B00 && r <= 0x2BFF:
+		return true
+	}
+
+	switch r {
+	case 0xA9, 0xAE, 0x203C, 0x2049, 0x2122, 0x2139, 0x24C2, 0x25AA, 0x25AB, 0x25B6, 0x25C0, 0x3030, 0x303D, 0x3297, 0x3299:
+		return true
+	}
+
+	return r >= 0x25FB && r <= 0x25FE
+}
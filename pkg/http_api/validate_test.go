@@ -0,0 +1,142 @@
+package http_api_test
+
+import (
+	"errors"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/manishMandal02/tabsflow-backend/pkg/errs"
+	"github.com/manishMandal02/tabsflow-backend/pkg/http_api"
+)
+
+type testTab struct {
+	URL string `json:"url" validate:"required,http_url"`
+}
+
+type testSpace struct {
+	Title string    `json:"title" validate:"required"`
+	Emoji string    `json:"emoji" validate:"omitempty,emoji"`
+	Theme string    `json:"theme" validate:"omitempty,hex_color"`
+	Tabs  []testTab `json:"tabs" validate:"dive"`
+}
+
+func TestDecodeAndValidate(t *testing.T) {
+	tests := []struct {
+		name        string
+		body        string
+		wantErr     error
+		wantDetails []http_api.FieldError
+	}{
+		{
+			name: "valid",
+			body: `{"title":"Work","emoji":"🗂️","theme":"#38bdf8","tabs":[{"url":"https://tabsflow.com"}]}`,
+		},
+		{
+			name:    "empty body",
+			body:    ``,
+			wantErr: http_api.ErrInvalidBody,
+		},
+		{
+			name:        "unknown field",
+			body:        `{"title":"Work","name":"Work"}`,
+			wantErr:     http_api.ErrInvalidBody,
+			wantDetails: []http_api.FieldError{{Field: "name", Rule: "unknown"}},
+		},
+		{
+			name:        "wrong type",
+			body:        `{"title":1}`,
+			wantErr:     http_api.ErrInvalidBody,
+			wantDetails: []http_api.FieldError{{Field: "title", Rule: "type", Param: "string"}},
+		},
+		{
+			name:    "multiple values",
+			body:    `{"title":"Work"}{"title":"Home"}`,
+			wantErr: http_api.ErrInvalidBody,
+		},
+		{
+			name:    "too large",
+			body:    `{"title":"` + strings.Repeat("a", http_api.MaxBodySize) + `"}`,
+			wantErr: http_api.ErrBodyTooLarge,
+		},
+		{
+			name:    "invalid fields",
+			body:    `{"emoji":"abc","theme":"blue","tabs":[{"url":"https://tabsflow.com"},{"url":"javascript:alert(1)"}]}`,
+			wantErr: http_api.ErrValidation,
+			wantDetails: []http_api.FieldError{
+				{Field: "title", Rule: "required"},
+				{Field: "emoji", Rule: "emoji"},
+				{Field: "theme", Rule: "hex_color"},
+				{Field: "tabs[1].url", Rule: "http_url"},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest("POST", "/spaces", strings.NewReader(tt.body))
+
+			s, err := http_api.DecodeAndValidate[testSpace](httptest.NewRecorder(), req)
+
+			if tt.wantErr == nil {
+				if err != nil || s.Title != "Work" || len(s.Tabs) != 1 {
+					t.Fatalf("DecodeAndValidate() = %+v, %v", s, err)
+				}
+				return
+			}
+
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("DecodeAndValidate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+
+			if tt.wantDetails == nil {
+				return
+			}
+
+			var e *errs.Error
+
+			if !errors.As(err, &e) || !reflect.DeepEqual(e.Details, tt.wantDetails) {
+				t.Errorf("DecodeAndValidate() details = %+v, want %+v", e.Details, tt.wantDetails)
+			}
+		})
+	}
+}
+
+func TestCustomValidators(t *testing.T) {
+	tests := []struct {
+		name  string
+		space testSpace
+		valid bool
+	}{
+		{name: "emoji", space: testSpace{Emoji: "🚀"}, valid: true},
+		{name: "emoji with skin tone", space: testSpace{Emoji: "👍🏽"}, valid: true},
+		{name: "zwj emoji sequence", space: testSpace{Emoji: "👩‍💻"}, valid: true},
+		{name: "flag emoji", space: testSpace{Emoji: "🇮🇳"}, valid: true},
+		{name: "keycap emoji", space: testSpace{Emoji: "1️⃣"}, valid: true},
+		{name: "symbol emoji", space: testSpace{Emoji: "⭐"}, valid: true},
+		{name: "text", space: testSpace{Emoji: "a"}, valid: false},
+		{name: "digit without keycap", space: testSpace{Emoji: "1"}, valid: false},
+		{name: "emoji with text", space: testSpace{Emoji: "🚀 go"}, valid: false},
+		{name: "only zwj", space: testSpace{Emoji: "‍"}, valid: false},
+		{name: "short hex color", space: testSpace{Theme: "#fff"}, valid: true},
+		{name: "hex color", space: testSpace{Theme: "#38BDF8"}, valid: true},
+		{name: "hex color without #", space: testSpace{Theme: "38bdf8"}, valid: false},
+		{name: "hex color with alpha", space: testSpace{Theme: "#38bdf8ff"}, valid: false},
+		{name: "http url", space: testSpace{Tabs: []testTab{{URL: "http://localhost:3000/x"}}}, valid: true},
+		{name: "url without host", space: testSpace{Tabs: []testTab{{URL: "https://"}}}, valid: false},
+		{name: "non http url", space: testSpace{Tabs: []testTab{{URL: "chrome://extensions"}}}, valid: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.space.Title = "Work"
+
+			err := http_api.Validate(tt.space)
+
+			if (err == nil) != tt.valid {
+				t.Errorf("Validate() error = %v, valid %v", err, tt.valid)
+			}
+		})
+	}
+}
@@ -1,25 +1,134 @@
 package logger
 
-import "fmt"
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"strings"
+)
+
+// * leveled logs on log/slog
+// json on lambda (queried with cloudwatch logs insights), text locally.
+// the logs with a context have its request id, to trace a request through the services & queues
+
+const RequestIdKey = "request_id"
+
+var (
+	level = new(slog.LevelVar)
+	log   = newLogger(os.Stdout, os.Getenv("AWS_LAMBDA_FUNCTION_NAME") != "")
+)
+
+func init() {
+	SetLevel(os.Getenv("LOG_LEVEL"))
+}
+
+func newLogger(w io.Writer, json bool) *slog.Logger {
+	opts := &slog.HandlerOptions{Level: level}
+
+	var h slog.Handler = slog.NewTextHandler(w, opts)
+
+	if json {
+		h = slog.NewJSONHandler(w, opts)
+	}
+
+	return slog.New(&contextHandler{Handler: h})
+}
+
+// Init sets the output of the logs, json or text
+func Init(w io.Writer, json bool) {
+	log = newLogger(w, json)
+}
+
+// SetLevel sets the min level of the logs: debug, info, warn or error; info if not valid
+func SetLevel(l string) {
+	var v slog.Level
+
+	if err := v.UnmarshalText([]byte(strings.TrimSpace(l))); err != nil {
+		v = slog.LevelInfo
+	}
+
+	level.Set(v)
+}
 
 func Error(msg string, err error) {
-	fmt.Printf("❌ Log: %v\n [Error]: %v\n", msg, err)
+	log.Error(msg, "error", err)
 }
 
 func Errorf(format string, args ...interface{}) {
-	msg := fmt.Sprintf(format, args...)
-
-	fmt.Println("❌ Log:Error: ", msg)
+	log.Error(fmt.Sprintf(format, args...))
 }
 
+// debug logs, for local development
 func Dev(format string, args ...interface{}) {
-	msg := fmt.Sprintf(format, args...)
-
-	fmt.Println("🛜  Log:Dev:", msg)
+	log.Debug(fmt.Sprintf(format, args...))
 }
 
 func Info(format string, args ...interface{}) {
-	msg := fmt.Sprintf(format, args...)
+	log.Info(fmt.Sprintf(format, args...))
+}
+
+// * logs with the request id of the context & key-value fields
+
+func ErrorContext(ctx context.Context, msg string, err error, args ...any) {
+	log.ErrorContext(ctx, msg, append([]any{"error", err}, args...)...)
+}
+
+func WarnContext(ctx context.Context, msg string, args ...any) {
+	log.WarnContext(ctx, msg, args...)
+}
+
+func InfoContext(ctx context.Context, msg string, args ...any) {
+	log.InfoContext(ctx, msg, args...)
+}
+
+func DebugContext(ctx context.Context, msg string, args ...any) {
+	log.DebugContext(ctx, msg, args...)
+}
+
+type requestIdKey struct{}
+
+// WithRequestId returns the context with the request id, added to its logs & the queued events
+func WithRequestId(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, requestIdKey{}, id)
+}
+
+// RequestId of the context, empty if not set
+func RequestId(ctx context.Context) string {
+	id, _ := ctx.Value(requestIdKey{}).(string)
+	return id
+}
+
+// NewRequestId returns a random request id, for the requests & events without one
+func NewRequestId() string {
+	b := make([]byte, 16)
+
+	// never returns an error
+	_, _ = rand.Read(b)
+
+	return hex.EncodeToString(b)
+}
+
+// adds the request id of the context to the logs
+type contextHandler struct {
+	slog.Handler
+}
+
+func (h *contextHandler) Handle(ctx context.Context, r slog.Record) error {
+	if id := RequestId(ctx); id != "" {
+		r.AddAttrs(slog.String(RequestIdKey, id))
+	}
+
+	return h.Handler.Handle(ctx, r)
+}
+
+func (h *contextHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return &contextHandler{Handler: h.Handler.WithAttrs(attrs)}
+}
 
-	fmt.Println("ℹ️  Log:Info:", msg)
+func (h *contextHandler) WithGroup(name string) slog.Handler {
+	return &contextHandler{Handler: h.Handler.WithGroup(name)}
 }
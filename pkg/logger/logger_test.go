@@ -0,0 +1,65 @@
+package logger_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"os"
+	"testing"
+
+	"github.com/manishMandal02/tabsflow-backend/pkg/logger"
+)
+
+func TestContextLogs(t *testing.T) {
+	var buf bytes.Buffer
+
+	logger.Init(&buf, true)
+	defer logger.Init(os.Stdout, false)
+
+	logger.SetLevel("info")
+	defer logger.SetLevel("")
+
+	ctx := logger.WithRequestId(context.Background(), "req-1")
+
+	logger.ErrorContext(ctx, "error creating note", errors.New("failed"), "noteId", "1")
+
+	var entry map[string]any
+
+	if err := json.Unmarshal(buf.Bytes(), &entry); err != nil {
+		t.Fatalf("ErrorContext() log = %q, want json. err: %v", buf.String(), err)
+	}
+
+	want := map[string]any{"level": "ERROR", "msg": "error creating note", "error": "failed", "noteId": "1", logger.RequestIdKey: "req-1"}
+
+	for k, v := range want {
+		if entry[k] != v {
+			t.Errorf("ErrorContext() %v = %v, want %v", k, entry[k], v)
+		}
+	}
+
+	buf.Reset()
+
+	// below the level
+	logger.DebugContext(ctx, "note search terms")
+
+	if buf.Len() != 0 {
+		t.Errorf("DebugContext() logged %q at info level", buf.String())
+	}
+
+	logger.SetLevel("debug")
+
+	logger.DebugContext(context.Background(), "note search terms")
+
+	if buf.Len() == 0 || bytes.Contains(buf.Bytes(), []byte(logger.RequestIdKey)) {
+		t.Errorf("DebugContext() log = %q, want a log without request id", buf.String())
+	}
+}
+
+func TestNewRequestId(t *testing.T) {
+	a, b := logger.NewRequestId(), logger.NewRequestId()
+
+	if len(a) != 32 || a == b {
+		t.Errorf("NewRequestId() = %v, %v, want unique 32 char ids", a, b)
+	}
+}
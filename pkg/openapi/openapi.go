@@ -0,0 +1,270 @@
+package openapi
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/manishMandal02/tabsflow-backend/pkg/http_api"
+	"github.com/manishMandal02/tabsflow-backend/pkg/logger"
+)
+
+// * OpenAPI 3 document of the service routers
+// paths, params, bodies & responses are generated from the route docs, schemas from the go types
+
+type Document struct {
+	OpenAPI    string              `json:"openapi"`
+	Info       Info                `json:"info"`
+	Paths      map[string]PathItem `json:"paths"`
+	Components Components          `json:"components"`
+}
+
+type Info struct {
+	Title   string `json:"title"`
+	Version string `json:"version"`
+}
+
+// operations of a path, by lower case method
+type PathItem map[string]*Operation
+
+type Operation struct {
+	Tags        []string             `json:"tags,omitempty"`
+	Summary     string               `json:"summary,omitempty"`
+	Parameters  []Parameter          `json:"parameters,omitempty"`
+	RequestBody *RequestBody         `json:"requestBody,omitempty"`
+	Responses   map[string]*Response `json:"responses"`
+	// any of the requirements, public routes have none
+	Security []map[string][]string `json:"security,omitempty"`
+}
+
+type Parameter struct {
+	Name        string  `json:"name"`
+	In          string  `json:"in"`
+	Description string  `json:"description,omitempty"`
+	Required    bool    `json:"required"`
+	Schema      *Schema `json:"schema"`
+}
+
+type RequestBody struct {
+	Required bool                 `json:"required"`
+	Content  map[string]MediaType `json:"content"`
+}
+
+type Response struct {
+	Description string               `json:"description"`
+	Content     map[string]MediaType `json:"content,omitempty"`
+}
+
+type MediaType struct {
+	Schema *Schema `json:"schema"`
+}
+
+type Components struct {
+	SecuritySchemes map[string]SecurityScheme `json:"securitySchemes"`
+}
+
+type SecurityScheme struct {
+	Type   string `json:"type"`
+	In     string `json:"in,omitempty"`
+	Name   string `json:"name,omitempty"`
+	Scheme string `json:"scheme,omitempty"`
+}
+
+const (
+	contentTypeJSON = "application/json"
+	// security schemes
+	sessionAuth  = "session"
+	apiTokenAuth = "apiToken"
+)
+
+// document of the routes of the routers, the tag of an operation is its router base (e.g. /spaces)
+func New(title, version string, routers ...http_api.IRouter) *Document {
+	d := &Document{
+		OpenAPI: "3.0.3",
+		Info:    Info{Title: title, Version: version},
+		Paths:   map[string]PathItem{},
+		Components: Components{
+			SecuritySchemes: map[string]SecurityScheme{
+				sessionAuth:  {Type: "apiKey", In: "cookie", Name: "session"},
+				apiTokenAuth: {Type: "http", Scheme: "bearer"},
+			},
+		},
+	}
+
+	for _, router := range routers {
+		for _, route := range router.Routes() {
+			path, params := templatePath(route.Path)
+
+			if d.Paths[path] == nil {
+				d.Paths[path] = PathItem{}
+			}
+
+			d.Paths[path][strings.ToLower(route.Method)] = newOperation(route, params)
+		}
+	}
+
+	return d
+}
+
+// serves the document as json
+func Handler(d *Document) http.Handler {
+	b, err := json.Marshal(d)
+
+	if err != nil {
+		// the document is built from the go types at startup
+		panic(err)
+	}
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", contentTypeJSON)
+		w.Header().Set("Access-Control-Allow-Origin", "*")
+
+		_, err := w.Write(b)
+
+		if err != nil {
+			logger.Error("error writing openapi document", err)
+		}
+	})
+}
+
+// path with the :param & *wildcard segments as {name}, with the names
+func templatePath(path string) (string, []string) {
+	segments := strings.Split(path, "/")
+	params := []string{}
+
+	for i, s := range segments {
+		if strings.HasPrefix(s, ":") || strings.HasPrefix(s, "*") {
+			params = append(params, s[1:])
+			segments[i] = "{" + s[1:] + "}"
+		}
+	}
+
+	return strings.Join(segments, "/"), params
+}
+
+func newOperation(route http_api.RouteInfo, params []string) *Operation {
+	doc := route.Doc
+
+	// undocumented routes are still listed, with the default responses
+	if doc == nil {
+		doc = &http_api.RouteDoc{}
+	}
+
+	op := &Operation{
+		Tags:    []string{strings.SplitN(strings.TrimPrefix(route.Path, "/"), "/", 2)[0]},
+		Summary: doc.Summary,
+		Responses: map[string]*Response{
+			"default": {
+				Description: "Error",
+				Content:     map[string]MediaType{contentTypeJSON: {Schema: errorSchema()}},
+			},
+		},
+	}
+
+	for _, p := range params {
+		op.Parameters = append(op.Parameters, Parameter{Name: p, In: "path", Required: true, Schema: &Schema{Type: "string"}})
+	}
+
+	for _, q := range doc.Query {
+		op.Parameters = append(op.Parameters, Parameter{Name: q.Name, In: "query", Description: q.Description, Required: q.Required, Schema: &Schema{Type: "string"}})
+	}
+
+	if doc.Body != nil || len(doc.BodyFiles) > 0 {
+		op.RequestBody = &RequestBody{Required: true, Content: map[string]MediaType{}}
+
+		if doc.Body != nil {
+			op.RequestBody.Content[contentTypeJSON] = MediaType{Schema: RequestSchema(doc.Body)}
+		}
+
+		for _, contentType := range doc.BodyFiles {
+			op.RequestBody.Content[contentType] = MediaType{Schema: binarySchema()}
+		}
+	}
+
+	ok := &Response{Description: "Success", Content: map[string]MediaType{}}
+
+	// file routes can respond with json too, e.g. an export sent by email
+	if len(doc.Files) == 0 || doc.Response != nil {
+		ok.Content[contentTypeJSON] = MediaType{Schema: successSchema(doc.Response)}
+	}
+
+	for contentType, v := range doc.Files {
+		schema := binarySchema()
+
+		if v != nil {
+			schema = ResponseSchema(v)
+		}
+
+		// json file or the json response
+		if media, exists := ok.Content[contentType]; exists {
+			schema = &Schema{AnyOf: []*Schema{media.Schema, schema}}
+		}
+
+		ok.Content[contentType] = MediaType{Schema: schema}
+	}
+
+	if !doc.RedirectOnly {
+		op.Responses[strconv.Itoa(http.StatusOK)] = ok
+	}
+
+	if doc.Redirect != 0 {
+		op.Responses[strconv.Itoa(doc.Redirect)] = &Response{Description: "Redirect"}
+	}
+
+	switch doc.Auth {
+	case http_api.AuthDefault:
+		op.Security = []map[string][]string{{sessionAuth: {}}, {apiTokenAuth: {}}}
+	case http_api.AuthSession:
+		op.Security = []map[string][]string{{sessionAuth: {}}}
+	}
+
+	return op
+}
+
+func binarySchema() *Schema {
+	return &Schema{Type: "string", Format: "binary"}
+}
+
+// http_api.APIResponse of a success response, data is required if the route has a response type
+func successSchema(data any) *Schema {
+	s := &Schema{
+		Type: "object",
+		Properties: map[string]*Schema{
+			"success":  {Type: "boolean"},
+			"message":  {Type: "string"},
+			"metadata": ResponseSchema(http_api.Metadata{}),
+		},
+		Required:             []string{"success"},
+		AdditionalProperties: false,
+	}
+
+	if data != nil {
+		s.Properties["data"] = ResponseSchema(data)
+		s.Required = append(s.Required, "data")
+	}
+
+	return s
+}
+
+// http_api.APIResponse of an error response
+func errorSchema() *Schema {
+	return &Schema{
+		Type: "object",
+		Properties: map[string]*Schema{
+			"success": {Type: "boolean"},
+			"error": {
+				Type: "object",
+				Properties: map[string]*Schema{
+					"code":    {Type: "string"},
+					"message": {Type: "string"},
+					"details": {},
+				},
+				Required:             []string{"code", "message"},
+				AdditionalProperties: false,
+			},
+		},
+		Required:             []string{"success", "error"},
+		AdditionalProperties: false,
+	}
+}
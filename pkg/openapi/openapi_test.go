@@ -0,0 +1,209 @@
+package openapi_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+
+	"github.com/manishMandal02/tabsflow-backend/pkg/errs"
+	"github.com/manishMandal02/tabsflow-backend/pkg/http_api"
+	"github.com/manishMandal02/tabsflow-backend/pkg/openapi"
+)
+
+type testTab struct {
+	Id    string `json:"id" validate:"required"`
+	URL   string `json:"url" validate:"required,http_url"`
+	Title string `json:"title,omitempty"`
+	Kind  string `json:"kind,omitempty" validate:"omitempty,oneof=tab group"`
+}
+
+type testTabsReq struct {
+	Tabs []testTab `json:"tabs" validate:"min=1,dive"`
+}
+
+func testRouter() http_api.IRouter {
+	r := http_api.NewRouter("/test")
+
+	r.GET("/tabs/:id", func(w http.ResponseWriter, r *http.Request) {
+		if r.PathValue("id") == "missing" {
+			http_api.ErrorRes(w, errs.NotFound)
+			return
+		}
+
+		http_api.SuccessResData(w, testTab{Id: r.PathValue("id"), URL: "https://tabsflow.com"})
+	}).Doc(http_api.RouteDoc{
+		Summary:  "Get a tab",
+		Response: testTab{},
+	})
+
+	// static segment, matched before the param
+	r.GET("/tabs/my", func(w http.ResponseWriter, r *http.Request) {
+		// not the documented response
+		http_api.SuccessResData(w, map[string]int{"count": 1})
+	}).Doc(http_api.RouteDoc{
+		Summary:  "Tabs of the user",
+		Response: []testTab{},
+	})
+
+	// same path as GET /tabs/:id, with another param name
+	r.DELETE("/tabs/:tabId", func(w http.ResponseWriter, r *http.Request) {
+		http_api.SuccessResMsg(w, "tab deleted")
+	}).Doc(http_api.RouteDoc{
+		Summary: "Delete a tab",
+	})
+
+	r.POST("/tabs", func(w http.ResponseWriter, r *http.Request) {
+		http_api.SuccessResMsg(w, "tabs set")
+	}).Doc(http_api.RouteDoc{
+		Summary: "Set the tabs",
+		Body:    testTabsReq{},
+		Auth:    http_api.AuthSession,
+	})
+
+	r.GET("/export", func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Query().Get("format") == "zip" {
+			http_api.FileRes(w, []byte("zip"), "application/zip", "export.zip")
+			return
+		}
+
+		http_api.FileRes(w, []byte(`[{"id":"1","url":"https://tabsflow.com"}]`), "application/json", "export.json")
+	}).Doc(http_api.RouteDoc{
+		Summary: "Export the tabs",
+		Query:   []http_api.QueryParam{{Name: "format", Description: "json or zip"}},
+		Files:   map[string]any{"application/json": []testTab{}, "application/zip": nil},
+		Auth:    http_api.AuthNone,
+	})
+
+	r.GET("/logout", func(w http.ResponseWriter, r *http.Request) {
+		http.Redirect(w, r, "/", http.StatusTemporaryRedirect)
+	}).Doc(http_api.RouteDoc{
+		Summary:      "Logout",
+		Redirect:     http.StatusTemporaryRedirect,
+		RedirectOnly: true,
+	})
+
+	return r
+}
+
+func TestNew(t *testing.T) {
+	doc := openapi.New("Test API", "1.0.0", testRouter())
+
+	op := doc.Paths["/test/tabs/{id}"]["get"]
+
+	if op == nil {
+		t.Fatalf("New() paths = %v, want /test/tabs/{id}", reflect.ValueOf(doc.Paths).MapKeys())
+	}
+
+	if len(op.Parameters) != 1 || op.Parameters[0].Name != "id" || op.Parameters[0].In != "path" || !op.Parameters[0].Required {
+		t.Errorf("New() params = %+v, want the id path param", op.Parameters)
+	}
+
+	if op.Tags[0] != "test" || len(op.Security) != 2 {
+		t.Errorf("New() tags = %v, security = %v", op.Tags, op.Security)
+	}
+
+	post := doc.Paths["/test/tabs"]["post"]
+
+	body := post.RequestBody.Content["application/json"].Schema
+
+	tabs := body.Properties["tabs"]
+
+	if tabs.Type != "array" || !reflect.DeepEqual(tabs.Items.Required, []string{"id", "url"}) {
+		t.Errorf("New() body tabs = %+v, want the required fields of the items", tabs)
+	}
+
+	if tabs.Items.Properties["url"].Format != "uri" || !reflect.DeepEqual(tabs.Items.Properties["kind"].Enum, []any{"tab", "group", ""}) {
+		t.Errorf("New() body tab = %+v, want the url format & kind enum", tabs.Items.Properties)
+	}
+
+	if !reflect.DeepEqual(post.Security, []map[string][]string{{"session": {}}}) {
+		t.Errorf("New() security = %v, want session only", post.Security)
+	}
+
+	if _, ok := doc.Paths["/test/logout"]["get"].Responses["200"]; ok {
+		t.Errorf("New() redirect only route has a success response")
+	}
+
+	export := doc.Paths["/test/export"]["get"]
+
+	if len(export.Security) != 0 || len(export.Responses["200"].Content) != 2 {
+		t.Errorf("New() export = %+v, want a public route with json & zip files", export)
+	}
+}
+
+func TestValidateResponse(t *testing.T) {
+	router := testRouter()
+	doc := openapi.New("Test API", "1.0.0", router)
+
+	tests := []struct {
+		name    string
+		method  string
+		path    string
+		wantErr bool
+	}{
+		{name: "documented data", method: "GET", path: "/test/tabs/1"},
+		{name: "error response", method: "GET", path: "/test/tabs/missing"},
+		{name: "undocumented data", method: "GET", path: "/test/tabs/my", wantErr: true},
+		{name: "message response", method: "POST", path: "/test/tabs"},
+		{name: "param name of the method", method: "DELETE", path: "/test/tabs/1"},
+		{name: "json file", method: "GET", path: "/test/export"},
+		{name: "binary file", method: "GET", path: "/test/export?format=zip"},
+		{name: "documented redirect", method: "GET", path: "/test/logout"},
+		{name: "undocumented method", method: "PATCH", path: "/test/tabs", wantErr: true},
+		{name: "undocumented path", method: "GET", path: "/test/spaces", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(tt.method, tt.path, nil)
+			w := httptest.NewRecorder()
+
+			router.ServeHTTP(w, req)
+
+			err := doc.ValidateResponse(tt.method, req.URL.Path, w.Result())
+
+			if (err != nil) != tt.wantErr {
+				t.Errorf("ValidateResponse() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestResponseSchema(t *testing.T) {
+	type embedded struct {
+		CreatedAt int64 `json:"createdAt"`
+	}
+
+	type res struct {
+		*embedded
+		Name   string            `json:"name"`
+		Icon   string            `json:"icon,omitempty"`
+		Parent *res              `json:"parent"`
+		Tags   map[string]string `json:"tags,omitempty"`
+		Secret string            `json:"-"`
+	}
+
+	s := openapi.ResponseSchema(res{})
+
+	if !reflect.DeepEqual(s.Required, []string{"createdAt", "name", "parent"}) {
+		t.Errorf("ResponseSchema() required = %v, want the fields without omitempty", s.Required)
+	}
+
+	if _, ok := s.Properties["Secret"]; ok {
+		t.Errorf("ResponseSchema() has the ignored field")
+	}
+
+	if s.AdditionalProperties != false || s.Properties["createdAt"].Format != "int64" {
+		t.Errorf("ResponseSchema() = %+v", s)
+	}
+
+	// recursive type
+	if s.Properties["parent"].Type != "" {
+		t.Errorf("ResponseSchema() parent = %+v, want any value", s.Properties["parent"])
+	}
+
+	if tags := s.Properties["tags"]; tags.Type != "object" || !tags.Nullable {
+		t.Errorf("ResponseSchema() tags = %+v, want a nullable object", tags)
+	}
+}
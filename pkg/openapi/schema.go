@@ -0,0 +1,248 @@
+package openapi
+
+import (
+	"encoding"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"slices"
+	"strings"
+	"time"
+)
+
+// * json schemas of the go types
+// structs are objects of their json fields.
+// request schemas require the fields with the `validate:"required"` tag, unknown fields are left open (e.g. the webauthn credentials from the browser).
+// response schemas require the fields without omitempty, as they are always encoded, & have no unknown fields
+
+type Schema struct {
+	Type       string             `json:"type,omitempty"`
+	Format     string             `json:"format,omitempty"`
+	Pattern    string             `json:"pattern,omitempty"`
+	Enum       []any              `json:"enum,omitempty"`
+	Nullable   bool               `json:"nullable,omitempty"`
+	Items      *Schema            `json:"items,omitempty"`
+	Properties map[string]*Schema `json:"properties,omitempty"`
+	Required   []string           `json:"required,omitempty"`
+	// false or the schema of the values (maps)
+	AdditionalProperties any `json:"additionalProperties,omitempty"`
+	// any of the schemas, for the responses with more than one shape
+	AnyOf []*Schema `json:"anyOf,omitempty"`
+}
+
+// schema of the request body type
+func RequestSchema(v any) *Schema {
+	return (&schemaBuilder{request: true, seen: map[reflect.Type]bool{}}).schema(reflect.TypeOf(v), nil)
+}
+
+// schema of the response data type
+func ResponseSchema(v any) *Schema {
+	return (&schemaBuilder{seen: map[reflect.Type]bool{}}).schema(reflect.TypeOf(v), nil)
+}
+
+type schemaBuilder struct {
+	request bool
+	// structs being built, a recursive type is any value at the recursion
+	seen map[reflect.Type]bool
+}
+
+var (
+	timeType      = reflect.TypeFor[time.Time]()
+	rawJSONType   = reflect.TypeFor[json.RawMessage]()
+	marshalerType = reflect.TypeFor[json.Marshaler]()
+	textType      = reflect.TypeFor[encoding.TextMarshaler]()
+)
+
+// schema of the type, with the validation rules of the field
+func (b *schemaBuilder) schema(t reflect.Type, rules []string) *Schema {
+	if t == nil {
+		return &Schema{}
+	}
+
+	nullable := false
+
+	for t.Kind() == reflect.Pointer {
+		nullable = true
+		t = t.Elem()
+	}
+
+	s := b.typeSchema(t, rules)
+
+	// any value already allows null
+	if nullable && s.Type != "" {
+		s.Nullable = true
+	}
+
+	return s
+}
+
+func (b *schemaBuilder) typeSchema(t reflect.Type, rules []string) *Schema {
+	switch {
+	case t == timeType:
+		return &Schema{Type: "string", Format: "date-time"}
+	case t == rawJSONType:
+		return &Schema{}
+	case t.Implements(marshalerType) || reflect.PointerTo(t).Implements(marshalerType):
+		return &Schema{}
+	case t.Implements(textType) || reflect.PointerTo(t).Implements(textType):
+		return &Schema{Type: "string"}
+	}
+
+	// rules of the value, the rules after dive are of the items
+	fieldRules, itemRules := rules, []string(nil)
+
+	if i := slices.Index(rules, "dive"); i != -1 {
+		fieldRules, itemRules = rules[:i], rules[i+1:]
+	}
+
+	var s *Schema
+
+	switch t.Kind() {
+	case reflect.String:
+		s = &Schema{Type: "string"}
+	case reflect.Bool:
+		s = &Schema{Type: "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32:
+		s = &Schema{Type: "integer"}
+	case reflect.Int64, reflect.Uint64:
+		s = &Schema{Type: "integer", Format: "int64"}
+	case reflect.Float32, reflect.Float64:
+		s = &Schema{Type: "number"}
+	case reflect.Slice, reflect.Array:
+		// encoded as base64
+		if t.Elem().Kind() == reflect.Uint8 {
+			return &Schema{Type: "string", Format: "byte"}
+		}
+
+		s = &Schema{Type: "array", Items: b.schema(t.Elem(), itemRules)}
+		// nil slices are encoded as null
+		s.Nullable = t.Kind() == reflect.Slice
+	case reflect.Map:
+		s = &Schema{Type: "object", AdditionalProperties: b.schema(t.Elem(), itemRules), Nullable: true}
+	case reflect.Struct:
+		s = b.structSchema(t)
+	default:
+		// interfaces
+		return &Schema{}
+	}
+
+	applyRules(s, t, fieldRules)
+
+	return s
+}
+
+func (b *schemaBuilder) structSchema(t reflect.Type) *Schema {
+	if b.seen[t] {
+		return &Schema{}
+	}
+
+	b.seen[t] = true
+	defer delete(b.seen, t)
+
+	s := &Schema{Type: "object", Properties: map[string]*Schema{}}
+
+	if !b.request {
+		s.AdditionalProperties = false
+	}
+
+	b.addFields(s, t, true)
+
+	return s
+}
+
+// adds the json fields of the struct, embedded structs without a json name are flattened.
+// fields of a struct that isn't validated (`validate:"-"`) are not required
+func (b *schemaBuilder) addFields(s *Schema, t reflect.Type, validated bool) {
+	for i := range t.NumField() {
+		f := t.Field(i)
+
+		name, opts, _ := strings.Cut(f.Tag.Get("json"), ",")
+
+		if name == "-" && opts == "" {
+			continue
+		}
+
+		ft := f.Type
+
+		for ft.Kind() == reflect.Pointer {
+			ft = ft.Elem()
+		}
+
+		if f.Anonymous && name == "" && ft.Kind() == reflect.Struct {
+			b.addFields(s, ft, validated && f.Tag.Get("validate") != "-")
+			continue
+		}
+
+		if !f.IsExported() {
+			continue
+		}
+
+		if name == "" {
+			name = f.Name
+		}
+
+		rules := validateRules(f)
+
+		s.Properties[name] = b.schema(f.Type, rules)
+
+		if b.isRequired(opts, rules, validated) && !slices.Contains(s.Required, name) {
+			s.Required = append(s.Required, name)
+		}
+	}
+}
+
+func (b *schemaBuilder) isRequired(jsonOpts string, rules []string, validated bool) bool {
+	if b.request {
+		return validated && slices.Contains(rules, "required")
+	}
+
+	return !slices.Contains(strings.Split(jsonOpts, ","), "omitempty")
+}
+
+// rules of the `validate` tag, e.g. [required oneof=a b]
+func validateRules(f reflect.StructField) []string {
+	tag := f.Tag.Get("validate")
+
+	if tag == "" || tag == "-" {
+		return nil
+	}
+
+	return strings.Split(tag, ",")
+}
+
+// formats & enums of the custom & built-in validation rules
+func applyRules(s *Schema, t reflect.Type, rules []string) {
+	for _, rule := range rules {
+		name, param, _ := strings.Cut(rule, "=")
+
+		switch name {
+		case "email":
+			s.Format = "email"
+		case "http_url", "url":
+			s.Format = "uri"
+		case "hex_color":
+			s.Pattern = "^#(?:[0-9a-fA-F]{3}|[0-9a-fA-F]{6})$"
+		case "oneof":
+			for _, v := range strings.Fields(param) {
+				s.Enum = append(s.Enum, enumValue(t, v))
+			}
+
+			// omitempty allows the zero value
+			if slices.Contains(rules, "omitempty") {
+				s.Enum = append(s.Enum, enumValue(t, fmt.Sprint(reflect.Zero(t).Interface())))
+			}
+		}
+	}
+}
+
+// value of the oneof param, as the json value of the type
+func enumValue(t reflect.Type, v string) any {
+	var value any = v
+
+	if t.Kind() != reflect.String {
+		// numbers, the param is validated by the validator
+		_ = json.Unmarshal([]byte(v), &value)
+	}
+
+	return value
+}
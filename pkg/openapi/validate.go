@@ -0,0 +1,267 @@
+package openapi
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"mime"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// * response contract checks, for the tests of the handlers
+
+// errors if the response doesn't match the documented response of the route,
+// the path is the request path (e.g. /spaces/123/tabs)
+func (d *Document) ValidateResponse(method, path string, res *http.Response) error {
+	template, ok := d.matchPath("", path)
+
+	if !ok {
+		return fmt.Errorf("%v %v: path not documented", method, path)
+	}
+
+	// routes of a path can have different param names (e.g. GET /spaces/{id} & DELETE /spaces/{spaceId})
+	if t, ok := d.matchPath(strings.ToLower(method), path); ok {
+		template = t
+	}
+
+	op, ok := d.Paths[template][strings.ToLower(method)]
+
+	if !ok {
+		return fmt.Errorf("%v %v: method not documented for %v", method, path, template)
+	}
+
+	documented, ok := op.Responses[strconv.Itoa(res.StatusCode)]
+
+	if !ok {
+		if res.StatusCode < 400 {
+			return fmt.Errorf("%v %v: status %v not documented", method, path, res.StatusCode)
+		}
+
+		documented = op.Responses["default"]
+	}
+
+	// redirects, the body isn't documented
+	if documented.Content == nil {
+		return nil
+	}
+
+	contentType, _, _ := mime.ParseMediaType(res.Header.Get("Content-Type"))
+
+	media, ok := documented.Content[contentType]
+
+	if !ok {
+		return fmt.Errorf("%v %v %v: content type %q not documented", method, path, res.StatusCode, contentType)
+	}
+
+	if media.Schema.Format == "binary" {
+		return nil
+	}
+
+	decoder := json.NewDecoder(res.Body)
+	decoder.UseNumber()
+
+	var v any
+
+	if err := decoder.Decode(&v); err != nil {
+		return fmt.Errorf("%v %v %v: invalid json response: %w", method, path, res.StatusCode, err)
+	}
+
+	if err := validateValue(media.Schema, v, "body"); err != nil {
+		return fmt.Errorf("%v %v %v: %w", method, path, res.StatusCode, err)
+	}
+
+	return nil
+}
+
+// documented path of the request path & method (any method, if empty), static segments match before the params
+func (d *Document) matchPath(method, path string) (string, bool) {
+	segments := strings.Split(strings.Trim(path, "/"), "/")
+
+	var (
+		match      string
+		matchScore []bool
+	)
+
+	for template, item := range d.Paths {
+		// only the templates documenting the method, if set
+		if _, ok := item[method]; method != "" && !ok {
+			continue
+		}
+
+		tSegments := strings.Split(strings.Trim(template, "/"), "/")
+
+		if len(tSegments) != len(segments) {
+			continue
+		}
+
+		// static segments, compared in order for the precedence
+		score := make([]bool, len(segments))
+		ok := true
+
+		for i, s := range tSegments {
+			if strings.HasPrefix(s, "{") {
+				ok = segments[i] != ""
+			} else {
+				ok = s == segments[i]
+				score[i] = true
+			}
+
+			if !ok {
+				break
+			}
+		}
+
+		if ok && (matchScore == nil || slices.Compare(boolsToInts(score), boolsToInts(matchScore)) > 0) {
+			match, matchScore = template, score
+		}
+	}
+
+	return match, matchScore != nil
+}
+
+func boolsToInts(b []bool) []int {
+	ints := make([]int, len(b))
+
+	for i, v := range b {
+		if v {
+			ints[i] = 1
+		}
+	}
+
+	return ints
+}
+
+// checks the decoded json value against the schema, at is the json path of the value
+func validateValue(s *Schema, v any, at string) error {
+	if len(s.AnyOf) > 0 {
+		errs := []error{}
+
+		for _, schema := range s.AnyOf {
+			err := validateValue(schema, v, at)
+
+			if err == nil {
+				return nil
+			}
+
+			errs = append(errs, err)
+		}
+
+		return fmt.Errorf("%v: no schema matched: %w", at, errors.Join(errs...))
+	}
+
+	// any value
+	if s.Type == "" {
+		return nil
+	}
+
+	if v == nil {
+		if s.Nullable {
+			return nil
+		}
+
+		return fmt.Errorf("%v: null, want %v", at, s.Type)
+	}
+
+	if len(s.Enum) > 0 && !inEnum(s.Enum, v) {
+		return fmt.Errorf("%v: %v not in %v", at, v, s.Enum)
+	}
+
+	switch s.Type {
+	case "string":
+		if _, ok := v.(string); !ok {
+			return fmt.Errorf("%v: %T, want string", at, v)
+		}
+	case "boolean":
+		if _, ok := v.(bool); !ok {
+			return fmt.Errorf("%v: %T, want boolean", at, v)
+		}
+	case "number":
+		if _, ok := v.(json.Number); !ok {
+			return fmt.Errorf("%v: %T, want number", at, v)
+		}
+	case "integer":
+		n, ok := v.(json.Number)
+
+		if !ok {
+			return fmt.Errorf("%v: %T, want integer", at, v)
+		}
+
+		if _, err := n.Int64(); err != nil {
+			return fmt.Errorf("%v: %v, want integer", at, n)
+		}
+	case "array":
+		items, ok := v.([]any)
+
+		if !ok {
+			return fmt.Errorf("%v: %T, want array", at, v)
+		}
+
+		for i, item := range items {
+			if err := validateValue(s.Items, item, fmt.Sprintf("%v[%v]", at, i)); err != nil {
+				return err
+			}
+		}
+	case "object":
+		obj, ok := v.(map[string]any)
+
+		if !ok {
+			return fmt.Errorf("%v: %T, want object", at, v)
+		}
+
+		return validateObject(s, obj, at)
+	}
+
+	return nil
+}
+
+func validateObject(s *Schema, obj map[string]any, at string) error {
+	for _, name := range s.Required {
+		if _, ok := obj[name]; !ok {
+			return fmt.Errorf("%v: missing required property %v", at, name)
+		}
+	}
+
+	for name, v := range obj {
+		propSchema, ok := s.Properties[name]
+
+		if !ok {
+			switch additional := s.AdditionalProperties.(type) {
+			case *Schema:
+				propSchema = additional
+			case bool:
+				if !additional {
+					return fmt.Errorf("%v: unknown property %v", at, name)
+				}
+			}
+		}
+
+		if propSchema == nil {
+			continue
+		}
+
+		if err := validateValue(propSchema, v, at+"."+name); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// enum values are compared as json
+func inEnum(enum []any, v any) bool {
+	b, _ := json.Marshal(v)
+
+	for _, e := range enum {
+		eb, _ := json.Marshal(e)
+
+		if bytes.Equal(b, eb) {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,226 @@
+package telemetry
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Exporter sends the measurements to a backend, an opentelemetry (otlp) exporter can be added with the same interface
+type Exporter interface {
+	Export(ctx context.Context, points []Point) error
+}
+
+// discards the measurements
+type NoopExporter struct{}
+
+func (NoopExporter) Export(context.Context, []Point) error {
+	return nil
+}
+
+// writes a line per measurement, for local development
+type StdoutExporter struct {
+	mu sync.Mutex
+	w  io.Writer
+}
+
+func NewStdoutExporter(w io.Writer) *StdoutExporter {
+	return &StdoutExporter{w: w}
+}
+
+func (e *StdoutExporter) Export(_ context.Context, points []Point) error {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	for _, p := range points {
+		line := fmt.Sprintf("metric %v=%v%v", p.Name, p.Value, p.Unit)
+
+		for _, a := range p.Attrs {
+			line += fmt.Sprintf(" %v=%q", a.Key, a.Value)
+		}
+
+		if _, err := fmt.Fprintln(e.w, line); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// EMFExporter writes the measurements as cloudwatch embedded metric format logs,
+// extracted as metrics by cloudwatch from the lambda logs (no api calls)
+type EMFExporter struct {
+	mu        sync.Mutex
+	w         io.Writer
+	namespace string
+	// dimension of all the metrics, the lambda function name
+	service string
+}
+
+// max values of a metric in an emf log
+const emfMaxValues = 100
+
+func NewEMFExporter(w io.Writer, namespace, service string) *EMFExporter {
+	return &EMFExporter{w: w, namespace: namespace, service: service}
+}
+
+type emfMetric struct {
+	Name string `json:"Name"`
+	Unit string `json:"Unit"`
+}
+
+type emfDirective struct {
+	Namespace  string      `json:"Namespace"`
+	Dimensions [][]string  `json:"Dimensions"`
+	Metrics    []emfMetric `json:"Metrics"`
+}
+
+type emfMetadata struct {
+	Timestamp         int64          `json:"Timestamp"`
+	CloudWatchMetrics []emfDirective `json:"CloudWatchMetrics"`
+}
+
+// measurements with the same attributes, written as one log
+type emfGroup struct {
+	attrs  []Attr
+	units  map[string]string
+	values map[string][]float64
+	// metric names, in order
+	names []string
+}
+
+func (e *EMFExporter) Export(_ context.Context, points []Point) error {
+	groups := map[string]*emfGroup{}
+	keys := []string{}
+
+	for _, p := range points {
+		attrs := slices.Clone(p.Attrs)
+
+		if e.service != "" {
+			attrs = append(attrs, String("service", e.service))
+		}
+
+		attrs = sortedAttrs(attrs)
+
+		key := fmt.Sprint(attrs)
+
+		g, ok := groups[key]
+
+		if !ok {
+			g = &emfGroup{attrs: attrs, units: map[string]string{}, values: map[string][]float64{}}
+			groups[key] = g
+			keys = append(keys, key)
+		}
+
+		if _, ok := g.units[p.Name]; !ok {
+			g.names = append(g.names, p.Name)
+			g.units[p.Name] = emfUnit(p.Unit)
+		}
+
+		g.values[p.Name] = append(g.values[p.Name], p.Value)
+	}
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	for _, key := range keys {
+		if err := e.write(groups[key]); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// writes the group's logs, split to have at most the max values of a metric per log
+func (e *EMFExporter) write(g *emfGroup) error {
+	for start := 0; ; start += emfMaxValues {
+		doc := map[string]any{}
+
+		dimensions := []string{}
+
+		for _, a := range g.attrs {
+			doc[a.Key] = a.Value
+			dimensions = append(dimensions, a.Key)
+		}
+
+		metrics := []emfMetric{}
+
+		for _, name := range g.names {
+			values := g.values[name]
+
+			if start >= len(values) {
+				continue
+			}
+
+			values = values[start:min(start+emfMaxValues, len(values))]
+
+			metrics = append(metrics, emfMetric{Name: name, Unit: g.units[name]})
+			doc[name] = values
+		}
+
+		if len(metrics) == 0 {
+			return nil
+		}
+
+		doc["_aws"] = emfMetadata{
+			Timestamp: time.Now().UnixMilli(),
+			CloudWatchMetrics: []emfDirective{{
+				Namespace:  e.namespace,
+				Dimensions: [][]string{dimensions},
+				Metrics:    metrics,
+			}},
+		}
+
+		b, err := json.Marshal(doc)
+
+		if err != nil {
+			return err
+		}
+
+		if _, err := e.w.Write(append(b, '\n')); err != nil {
+			return err
+		}
+	}
+}
+
+// attributes sorted by key, with the last value of a duplicate key
+func sortedAttrs(attrs []Attr) []Attr {
+	slices.SortStableFunc(attrs, func(a, b Attr) int {
+		return strings.Compare(a.Key, b.Key)
+	})
+
+	sorted := []Attr{}
+
+	for _, a := range attrs {
+		if n := len(sorted); n > 0 && sorted[n-1].Key == a.Key {
+			sorted[n-1] = a
+			continue
+		}
+
+		sorted = append(sorted, a)
+	}
+
+	return sorted
+}
+
+// cloudwatch unit of the opentelemetry unit
+func emfUnit(unit string) string {
+	switch {
+	case unit == UnitMilliseconds:
+		return "Milliseconds"
+	case unit == "s":
+		return "Seconds"
+	case unit == "By":
+		return "Bytes"
+	case unit == UnitCount || strings.HasPrefix(unit, "{"):
+		return "Count"
+	}
+
+	return "None"
+}
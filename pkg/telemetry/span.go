@@ -0,0 +1,74 @@
+package telemetry
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"time"
+
+	"github.com/manishMandal02/tabsflow-backend/pkg/logger"
+)
+
+// Span times an operation, its duration is recorded as the <name>.duration histogram (ms).
+// the request id of the context is the trace id, the spans are logged at debug level
+type Span struct {
+	ctx      context.Context
+	name     string
+	id       string
+	parentId string
+	start    time.Time
+	attrs    []Attr
+}
+
+type spanKey struct{}
+
+// StartSpan starts a span, a child of the context's span
+func StartSpan(ctx context.Context, name string, attrs ...Attr) (context.Context, *Span) {
+	s := &Span{
+		name:  name,
+		id:    newSpanId(),
+		start: time.Now(),
+		attrs: attrs,
+	}
+
+	if parent, ok := ctx.Value(spanKey{}).(*Span); ok {
+		s.parentId = parent.id
+	}
+
+	s.ctx = context.WithValue(ctx, spanKey{}, s)
+
+	return s.ctx, s
+}
+
+// SetAttrs adds the attributes, e.g. the status once known
+func (s *Span) SetAttrs(attrs ...Attr) {
+	s.attrs = append(s.attrs, attrs...)
+}
+
+// End records the duration of the span
+func (s *Span) End() {
+	d := float64(time.Since(s.start).Microseconds()) / 1000
+
+	Record(s.ctx, s.name+".duration", UnitMilliseconds, d, s.attrs...)
+
+	args := []any{"span", s.name, "span_id", s.id, "duration_ms", d}
+
+	if s.parentId != "" {
+		args = append(args, "parent_span_id", s.parentId)
+	}
+
+	for _, a := range s.attrs {
+		args = append(args, a.Key, a.Value)
+	}
+
+	logger.DebugContext(s.ctx, "span ended", args...)
+}
+
+func newSpanId() string {
+	b := make([]byte, 8)
+
+	// never returns an error
+	_, _ = rand.Read(b)
+
+	return hex.EncodeToString(b)
+}
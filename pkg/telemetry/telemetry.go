@@ -0,0 +1,155 @@
+package telemetry
+
+import (
+	"context"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/manishMandal02/tabsflow-backend/pkg/logger"
+)
+
+// * metrics & spans of the services
+// the instruments follow the opentelemetry shape (counters & histograms with a unit & attributes),
+// the measurements are buffered & exported at the end of each lambda invocation.
+// exporters: cloudwatch embedded metric format (emf) on lambda, stdout or none locally
+
+const (
+	UnitMilliseconds = "ms"
+	UnitCount        = "1"
+	// dynamodb read/write capacity units
+	UnitCapacity = "{capacity}"
+)
+
+// cloudwatch namespace of the metrics
+const Namespace = "TabsFlow"
+
+// measurements are exported before the buffer grows past the max, for long running processes (local server)
+const maxBuffered = 1000
+
+type Kind int
+
+const (
+	KindCounter Kind = iota
+	KindHistogram
+)
+
+type Attr struct {
+	Key   string
+	Value string
+}
+
+func String(key, value string) Attr {
+	return Attr{Key: key, Value: value}
+}
+
+// measurement of an instrument
+type Point struct {
+	Name  string
+	Unit  string
+	Kind  Kind
+	Value float64
+	Attrs []Attr
+	Time  time.Time
+}
+
+var (
+	mu       sync.Mutex
+	buffer   []Point
+	exporter = newExporter("")
+)
+
+// Init sets the exporter of the metrics
+func Init(e Exporter) {
+	mu.Lock()
+	defer mu.Unlock()
+
+	exporter = e
+}
+
+// SetExporter sets the exporter by name: emf, stdout or none; emf on lambda & none locally if not valid
+func SetExporter(name string) {
+	Init(newExporter(name))
+}
+
+func newExporter(name string) Exporter {
+	onLambda := os.Getenv("AWS_LAMBDA_FUNCTION_NAME") != ""
+
+	switch strings.ToLower(strings.TrimSpace(name)) {
+	case "emf":
+		return NewEMFExporter(os.Stdout, Namespace, os.Getenv("AWS_LAMBDA_FUNCTION_NAME"))
+	case "stdout":
+		return NewStdoutExporter(os.Stdout)
+	case "none":
+		return NoopExporter{}
+	}
+
+	if onLambda {
+		return NewEMFExporter(os.Stdout, Namespace, os.Getenv("AWS_LAMBDA_FUNCTION_NAME"))
+	}
+
+	return NoopExporter{}
+}
+
+// Count adds 1 to the counter
+func Count(ctx context.Context, name string, attrs ...Attr) {
+	Add(ctx, name, UnitCount, 1, attrs...)
+}
+
+// Add adds the value to the counter
+func Add(ctx context.Context, name, unit string, value float64, attrs ...Attr) {
+	record(ctx, Point{Name: name, Unit: unit, Kind: KindCounter, Value: value, Attrs: attrs})
+}
+
+// Record records the value of the histogram, e.g. a latency
+func Record(ctx context.Context, name, unit string, value float64, attrs ...Attr) {
+	record(ctx, Point{Name: name, Unit: unit, Kind: KindHistogram, Value: value, Attrs: attrs})
+}
+
+func record(ctx context.Context, p Point) {
+	p.Time = time.Now()
+
+	mu.Lock()
+	buffer = append(buffer, p)
+	full := len(buffer) >= maxBuffered
+	mu.Unlock()
+
+	if full {
+		Flush(ctx)
+	}
+}
+
+// Status attribute of the operation's result, ok or error
+func Status(err error) Attr {
+	if err != nil {
+		return String("status", "error")
+	}
+
+	return String("status", "ok")
+}
+
+// Flush exports the buffered measurements
+func Flush(ctx context.Context) {
+	mu.Lock()
+	points, e := buffer, exporter
+	buffer = nil
+	mu.Unlock()
+
+	if len(points) == 0 {
+		return
+	}
+
+	if err := e.Export(ctx, points); err != nil {
+		logger.ErrorContext(ctx, "error exporting metrics", err, "count", len(points))
+	}
+}
+
+// Flushed wraps the lambda handler to export the measurements of each invocation
+func Flushed[E, R any](handler func(context.Context, E) (R, error)) func(context.Context, E) (R, error) {
+	return func(ctx context.Context, event E) (R, error) {
+		defer Flush(ctx)
+
+		return handler(ctx, event)
+	}
+}
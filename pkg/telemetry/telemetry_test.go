@@ -0,0 +1,117 @@
+package telemetry_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/manishMandal02/tabsflow-backend/pkg/telemetry"
+)
+
+type testExporter struct {
+	points []telemetry.Point
+}
+
+func (e *testExporter) Export(_ context.Context, points []telemetry.Point) error {
+	e.points = append(e.points, points...)
+	return nil
+}
+
+func TestEMFExporter(t *testing.T) {
+	var buf bytes.Buffer
+
+	telemetry.Init(telemetry.NewEMFExporter(&buf, "Test", "notes"))
+	defer telemetry.SetExporter("none")
+
+	ctx := context.Background()
+
+	route := telemetry.String("route", "GET /notes/:id")
+
+	for i := 0; i < 150; i++ {
+		telemetry.Record(ctx, "http.server.request.duration", telemetry.UnitMilliseconds, 12.5, route, telemetry.String("status", "200"))
+	}
+
+	telemetry.Count(ctx, "email.deliveries", telemetry.Status(nil))
+
+	telemetry.Flush(ctx)
+
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+
+	// the route's values split in 2 logs, the counter in its own
+	if len(lines) != 3 {
+		t.Fatalf("Export() logs = %v, want 3", len(lines))
+	}
+
+	var doc struct {
+		AWS struct {
+			CloudWatchMetrics []struct {
+				Namespace  string
+				Dimensions [][]string
+				Metrics    []struct{ Name, Unit string }
+			}
+		} `json:"_aws"`
+		Route    string    `json:"route"`
+		Service  string    `json:"service"`
+		Duration []float64 `json:"http.server.request.duration"`
+	}
+
+	if err := json.Unmarshal([]byte(lines[0]), &doc); err != nil {
+		t.Fatalf("Export() log = %v, want json. err: %v", lines[0], err)
+	}
+
+	m := doc.AWS.CloudWatchMetrics[0]
+
+	if m.Namespace != "Test" || !reflect.DeepEqual(m.Dimensions, [][]string{{"route", "service", "status"}}) {
+		t.Errorf("Export() directive = %+v", m)
+	}
+
+	if len(m.Metrics) != 1 || m.Metrics[0].Unit != "Milliseconds" {
+		t.Errorf("Export() metrics = %+v, want the duration in ms", m.Metrics)
+	}
+
+	if doc.Route != "GET /notes/:id" || doc.Service != "notes" || len(doc.Duration) != 100 {
+		t.Errorf("Export() route = %v, service = %v, values = %v", doc.Route, doc.Service, len(doc.Duration))
+	}
+
+	if !strings.Contains(lines[2], `"email.deliveries":[1]`) || !strings.Contains(lines[2], `"Unit":"Count"`) {
+		t.Errorf("Export() counter log = %v", lines[2])
+	}
+}
+
+func TestSpan(t *testing.T) {
+	e := &testExporter{}
+
+	telemetry.Init(e)
+	defer telemetry.SetExporter("none")
+
+	ctx, parent := telemetry.StartSpan(context.Background(), "http.server.request", telemetry.String("route", "GET /notes"))
+
+	_, child := telemetry.StartSpan(ctx, "db.client.operation")
+	child.SetAttrs(telemetry.String("status", "ok"))
+	child.End()
+
+	parent.End()
+
+	telemetry.Flush(ctx)
+
+	if len(e.points) != 2 {
+		t.Fatalf("End() points = %+v, want 2", e.points)
+	}
+
+	got := e.points[0]
+
+	if got.Name != "db.client.operation.duration" || got.Kind != telemetry.KindHistogram || got.Unit != telemetry.UnitMilliseconds {
+		t.Errorf("End() point = %+v, want the span duration", got)
+	}
+
+	if !reflect.DeepEqual(got.Attrs, []telemetry.Attr{telemetry.String("status", "ok")}) {
+		t.Errorf("End() attrs = %v, want the span attrs", got.Attrs)
+	}
+
+	if e.points[1].Name != "http.server.request.duration" || e.points[1].Value < got.Value {
+		t.Errorf("End() parent = %+v, want it to last longer than the child", e.points[1])
+	}
+}
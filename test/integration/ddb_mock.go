@@ -82,3 +82,11 @@ func (m *DynamoDBClientMock) TransactWriteItems(ctx context.Context, input *dyna
 	}
 	return args.Get(0).(*dynamodb.TransactWriteItemsOutput), args.Error(1)
 }
+
+func (m *DynamoDBClientMock) Scan(ctx context.Context, input *dynamodb.ScanInput, optFns ...func(*dynamodb.Options)) (*dynamodb.ScanOutput, error) {
+	args := m.Called(ctx, input, optFns)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*dynamodb.ScanOutput), args.Error(1)
+}
@@ -0,0 +1,92 @@
+package integration_test
+
+import (
+	"encoding/json"
+	"net/http/httptest"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/manishMandal02/tabsflow-backend/internal/auth"
+	"github.com/manishMandal02/tabsflow-backend/internal/notes"
+	"github.com/manishMandal02/tabsflow-backend/internal/notifications"
+	"github.com/manishMandal02/tabsflow-backend/internal/spaces"
+	"github.com/manishMandal02/tabsflow-backend/internal/users"
+	"github.com/manishMandal02/tabsflow-backend/pkg/http_api"
+	"github.com/manishMandal02/tabsflow-backend/pkg/openapi"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func serviceRouters() []http_api.IRouter {
+	db := NewDDBMock()
+	q := NewQueueMock()
+
+	return []http_api.IRouter{
+		auth.Router(db, q),
+		users.Router(db, db, db, q, q, q, NewPaddleClientMock()),
+		spaces.Router(db, q),
+		notes.Router(db, db, q),
+		notifications.Router(db),
+	}
+}
+
+// every route of the services is documented
+func TestOpenAPIRoutes(t *testing.T) {
+	routers := serviceRouters()
+
+	doc := openapi.New("TabsFlow API", "test", routers...)
+
+	for _, router := range routers {
+		for _, route := range router.Routes() {
+			t.Run(route.Method+"-"+route.Path, func(t *testing.T) {
+				require.NotNil(t, route.Doc, "route has no docs")
+				assert.NotEmpty(t, route.Doc.Summary)
+
+				path := route.Path
+
+				for _, s := range strings.Split(path, "/") {
+					if strings.HasPrefix(s, ":") {
+						path = strings.Replace(path, s, "{"+s[1:]+"}", 1)
+					}
+				}
+
+				op := doc.Paths[path][strings.ToLower(route.Method)]
+
+				require.NotNil(t, op, "operation not in the document")
+
+				// path params are declared
+				for _, s := range strings.Split(route.Path, "/") {
+					if !strings.HasPrefix(s, ":") {
+						continue
+					}
+
+					declared := slices.ContainsFunc(op.Parameters, func(p openapi.Parameter) bool {
+						return p.In == "path" && p.Name == s[1:] && p.Required
+					})
+
+					assert.Truef(t, declared, "path param %v not declared", s[1:])
+				}
+			})
+		}
+	}
+}
+
+func TestOpenAPIHandler(t *testing.T) {
+	h := openapi.Handler(openapi.New("TabsFlow API", "test", serviceRouters()...))
+
+	w := httptest.NewRecorder()
+
+	h.ServeHTTP(w, httptest.NewRequest("GET", "/openapi.json", nil))
+
+	require.Equal(t, 200, w.Code)
+	assert.Equal(t, "application/json", w.Header().Get("Content-Type"))
+
+	var doc openapi.Document
+
+	require.NoError(t, json.NewDecoder(w.Body).Decode(&doc))
+
+	assert.Equal(t, "3.0.3", doc.OpenAPI)
+	assert.Contains(t, doc.Paths, "/spaces/{spaceId}/snoozed-tabs/{id}")
+	assert.Contains(t, doc.Paths, "/users/export")
+}
@@ -23,3 +23,12 @@ func (p *PaddleClientMock) GetSubscription(ctx context.Context, req *paddle.GetS
 	}
 	return args.Get(0).(*paddle.Subscription), args.Error(1)
 }
+
+func (p *PaddleClientMock) CancelSubscription(ctx context.Context, req *paddle.CancelSubscriptionRequest) (res *paddle.Subscription, err error) {
+
+	args := p.Called(ctx, req)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*paddle.Subscription), args.Error(1)
+}
@@ -0,0 +1,329 @@
+package integration_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+	"github.com/manishMandal02/tabsflow-backend/config"
+	"github.com/manishMandal02/tabsflow-backend/internal/auth"
+	"github.com/manishMandal02/tabsflow-backend/internal/notes"
+	"github.com/manishMandal02/tabsflow-backend/internal/notifications"
+	"github.com/manishMandal02/tabsflow-backend/internal/spaces"
+	"github.com/manishMandal02/tabsflow-backend/pkg/db"
+	"github.com/manishMandal02/tabsflow-backend/pkg/http_api"
+	"github.com/manishMandal02/tabsflow-backend/pkg/openapi"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+// request to a service router, the response must match its api docs
+type contractTestCase struct {
+	name           string
+	method         string
+	path           string
+	body           interface{}
+	userId         string
+	setupMockDB    func(*DynamoDBClientMock)
+	expectedStatus int
+}
+
+// rate limit counter of the auth routes
+func mockDBRequestCount(count string) func(*DynamoDBClientMock) {
+	return func(mockDB *DynamoDBClientMock) {
+		mockDB.On("UpdateItem", mock.Anything, mock.AnythingOfType("*dynamodb.UpdateItemInput"), mock.Anything).Return(&dynamodb.UpdateItemOutput{
+			Attributes: map[string]types.AttributeValue{
+				"Count": &types.AttributeValueMemberN{Value: count},
+			},
+		}, nil).Once()
+	}
+}
+
+func mockDBGetItem(item map[string]types.AttributeValue, err error) func(*DynamoDBClientMock) {
+	return func(mockDB *DynamoDBClientMock) {
+		mockDB.On("GetItem", mock.Anything, mock.AnythingOfType("*dynamodb.GetItemInput"), mock.Anything).Return(&dynamodb.GetItemOutput{
+			Item: item,
+		}, err).Once()
+	}
+}
+
+func mockDBQuery(items []map[string]types.AttributeValue, err error) func(*DynamoDBClientMock) {
+	return func(mockDB *DynamoDBClientMock) {
+		mockDB.On("Query", mock.Anything, mock.AnythingOfType("*dynamodb.QueryInput"), mock.Anything).Return(&dynamodb.QueryOutput{
+			Items: items,
+			Count: int32(len(items)),
+		}, err).Once()
+	}
+}
+
+func authContractTestCases() []contractTestCase {
+	return []contractTestCase{
+		{
+			name:           "POST-/auth/send-otp > invalid email",
+			method:         "POST",
+			path:           "/auth/send-otp",
+			body:           map[string]string{"email": "test"},
+			setupMockDB:    mockDBRequestCount("1"),
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:           "POST-/auth/send-otp > rate limited",
+			method:         "POST",
+			path:           "/auth/send-otp",
+			body:           map[string]string{"email": testUser.Email},
+			setupMockDB:    mockDBRequestCount("1000"),
+			expectedStatus: http.StatusTooManyRequests,
+		},
+		{
+			name:           "GET-/auth/logout > success without session",
+			method:         "GET",
+			path:           "/auth/logout",
+			setupMockDB:    mockDBRequestCount("1"),
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:           "GET-/auth/sessions > invalid session",
+			method:         "GET",
+			path:           "/auth/sessions",
+			setupMockDB:    mockDBRequestCount("1"),
+			expectedStatus: http.StatusUnauthorized,
+		},
+	}
+}
+
+func spacesContractTestCases() []contractTestCase {
+	spaceItem := map[string]types.AttributeValue{
+		"PK":       &types.AttributeValueMemberS{Value: testUser.Id},
+		"SK":       &types.AttributeValueMemberS{Value: db.SORT_KEY.Space("1")},
+		"Id":       &types.AttributeValueMemberS{Value: "1"},
+		"Title":    &types.AttributeValueMemberS{Value: "Test"},
+		"Theme":    &types.AttributeValueMemberS{Value: "#38bdf8"},
+		"Emoji":    &types.AttributeValueMemberS{Value: "🗂️"},
+		"IsSaved":  &types.AttributeValueMemberBOOL{Value: true},
+		"WindowId": &types.AttributeValueMemberN{Value: "1"},
+	}
+
+	return []contractTestCase{
+		{
+			name:           "GET-/spaces/:id > success",
+			method:         "GET",
+			path:           "/spaces/1",
+			userId:         testUser.Id,
+			setupMockDB:    mockDBGetItem(spaceItem, nil),
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:           "GET-/spaces/:id > not found",
+			method:         "GET",
+			path:           "/spaces/1",
+			userId:         testUser.Id,
+			setupMockDB:    mockDBGetItem(nil, nil),
+			expectedStatus: http.StatusNotFound,
+		},
+		{
+			name:           "GET-/spaces/my > success",
+			method:         "GET",
+			path:           "/spaces/my",
+			userId:         testUser.Id,
+			setupMockDB:    mockDBQuery([]map[string]types.AttributeValue{spaceItem}, nil),
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:           "GET-/spaces/my > db error",
+			method:         "GET",
+			path:           "/spaces/my",
+			userId:         testUser.Id,
+			setupMockDB:    mockDBQuery(nil, errors.New("db error")),
+			expectedStatus: http.StatusBadGateway,
+		},
+		{
+			name:   "PATCH-/spaces/order > space not found",
+			method: "PATCH",
+			path:   "/spaces/order",
+			body:   map[string]interface{}{"spaceIds": []string{"1", "2"}},
+			userId: testUser.Id,
+			setupMockDB: func(mockDB *DynamoDBClientMock) {
+				mockDB.On("TransactWriteItems", mock.Anything, mock.AnythingOfType("*dynamodb.TransactWriteItemsInput"), mock.Anything).Return(&dynamodb.TransactWriteItemsOutput{}, &types.TransactionCanceledException{
+					CancellationReasons: []types.CancellationReason{{Code: aws.String("None")}, {Code: aws.String("ConditionalCheckFailed")}},
+				}).Once()
+			},
+			expectedStatus: http.StatusNotFound,
+		},
+		{
+			name:           "PATCH-/spaces/order > invalid body",
+			method:         "PATCH",
+			path:           "/spaces/order",
+			body:           map[string]interface{}{"spaceIds": []string{}},
+			userId:         testUser.Id,
+			expectedStatus: http.StatusBadRequest,
+		},
+	}
+}
+
+func notesContractTestCases() []contractTestCase {
+	return []contractTestCase{
+		{
+			name:   "GET-/notes/:noteId > success",
+			method: "GET",
+			path:   "/notes/1",
+			userId: testUser.Id,
+			setupMockDB: mockDBGetItem(map[string]types.AttributeValue{
+				"PK":    &types.AttributeValueMemberS{Value: testUser.Id},
+				"SK":    &types.AttributeValueMemberS{Value: db.SORT_KEY.Notes("1")},
+				"Id":    &types.AttributeValueMemberS{Value: "1"},
+				"Title": &types.AttributeValueMemberS{Value: "Test"},
+				"Text":  &types.AttributeValueMemberS{Value: "test note"},
+			}, nil),
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:           "GET-/notes/:noteId > not found",
+			method:         "GET",
+			path:           "/notes/1",
+			userId:         testUser.Id,
+			setupMockDB:    mockDBGetItem(nil, nil),
+			expectedStatus: http.StatusNotFound,
+		},
+		{
+			name:           "GET-/notes/my > invalid lastNoteId",
+			method:         "GET",
+			path:           "/notes/my?lastNoteId=test",
+			userId:         testUser.Id,
+			expectedStatus: http.StatusBadRequest,
+		},
+	}
+}
+
+func notificationsContractTestCases() []contractTestCase {
+	return []contractTestCase{
+		{
+			name:   "GET-/notifications/my > success",
+			method: "GET",
+			path:   "/notifications/my",
+			userId: testUser.Id,
+			setupMockDB: mockDBQuery([]map[string]types.AttributeValue{
+				{
+					"PK":        &types.AttributeValueMemberS{Value: testUser.Id},
+					"SK":        &types.AttributeValueMemberS{Value: db.SORT_KEY.Notifications("1")},
+					"Id":        &types.AttributeValueMemberS{Value: "1"},
+					"Type":      &types.AttributeValueMemberS{Value: "ACCOUNT"},
+					"Timestamp": &types.AttributeValueMemberN{Value: "1"},
+					"Message":   &types.AttributeValueMemberS{Value: "test"},
+				},
+			}, nil),
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:           "GET-/notifications/my > no notifications",
+			method:         "GET",
+			path:           "/notifications/my",
+			userId:         testUser.Id,
+			setupMockDB:    mockDBQuery(nil, nil),
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:           "GET-/notifications/:id > not found",
+			method:         "GET",
+			path:           "/notifications/1",
+			userId:         testUser.Id,
+			setupMockDB:    mockDBGetItem(nil, nil),
+			expectedStatus: http.StatusNotFound,
+		},
+		{
+			name:           "GET-/notifications/:id > db error",
+			method:         "GET",
+			path:           "/notifications/1",
+			userId:         testUser.Id,
+			setupMockDB:    mockDBGetItem(nil, errors.New("db error")),
+			expectedStatus: http.StatusBadGateway,
+		},
+	}
+}
+
+// * run test cases
+func TestServicesResponseContract(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping integration test")
+	}
+
+	services := []struct {
+		name   string
+		router func(*db.DDB) http_api.IRouter
+		tests  []contractTestCase
+	}{
+		{
+			name:   "auth",
+			router: func(d *db.DDB) http_api.IRouter { return auth.Router(d, NewQueueMock()) },
+			tests:  authContractTestCases(),
+		},
+		{
+			name:   "spaces",
+			router: func(d *db.DDB) http_api.IRouter { return spaces.Router(d, NewQueueMock()) },
+			tests:  spacesContractTestCases(),
+		},
+		{
+			name:   "notes",
+			router: func(d *db.DDB) http_api.IRouter { return notes.Router(d, d, NewQueueMock()) },
+			tests:  notesContractTestCases(),
+		},
+		{
+			name:   "notifications",
+			router: func(d *db.DDB) http_api.IRouter { return notifications.Router(d) },
+			tests:  notificationsContractTestCases(),
+		},
+	}
+
+	for _, s := range services {
+		doc := openapi.New("TabsFlow API", "test", s.router(NewDDBMock()))
+
+		for _, tc := range s.tests {
+			t.Run(tc.name, func(t *testing.T) {
+				ddb := NewDDBMock()
+
+				mockedDB, ok := ddb.Client.(*DynamoDBClientMock)
+
+				if !ok {
+					t.Fatal("failed to get mock db client")
+				}
+
+				if tc.setupMockDB != nil {
+					tc.setupMockDB(mockedDB)
+				}
+
+				var reqBody []byte
+				var err error
+				if tc.body != nil {
+					reqBody, err = json.Marshal(tc.body)
+					require.NoError(t, err)
+				}
+
+				req := httptest.NewRequest(tc.method, tc.path, bytes.NewBuffer(reqBody))
+
+				req.Header.Set("Content-Type", "application/json")
+				req.Header.Set("Origin", config.AllowedOrigins[0])
+
+				// mock authorizer's success res
+				if tc.userId != "" {
+					req.Header.Set("UserId", tc.userId)
+				}
+
+				w := httptest.NewRecorder()
+
+				s.router(ddb).ServeHTTP(w, req)
+
+				assert.Equal(t, tc.expectedStatus, w.Code)
+
+				assert.NoError(t, doc.ValidateResponse(tc.method, req.URL.Path, w.Result()))
+
+				mockedDB.AssertExpectations(t)
+			})
+		}
+	}
+}
@@ -33,3 +33,11 @@ func (m *SQSClientMock) DeleteMessage(ctx context.Context, params *sqs.DeleteMes
 	}
 	return args.Get(0).(*sqs.DeleteMessageOutput), args.Error(1)
 }
+
+func (m *SQSClientMock) SendMessageBatch(ctx context.Context, params *sqs.SendMessageBatchInput, optFns ...func(*sqs.Options)) (*sqs.SendMessageBatchOutput, error) {
+	args := m.Called(params)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*sqs.SendMessageBatchOutput), args.Error(1)
+}
@@ -4,8 +4,6 @@ import (
 	"bytes"
 	"encoding/json"
 	"errors"
-	"fmt"
-	"io"
 	"net/http"
 	"net/http/httptest"
 	"slices"
@@ -22,16 +20,28 @@ import (
 	"github.com/manishMandal02/tabsflow-backend/config"
 	"github.com/manishMandal02/tabsflow-backend/internal/users"
 	"github.com/manishMandal02/tabsflow-backend/pkg/db"
+	"github.com/manishMandal02/tabsflow-backend/pkg/errs"
 	"github.com/manishMandal02/tabsflow-backend/pkg/events"
+	"github.com/manishMandal02/tabsflow-backend/pkg/http_api"
+	"github.com/manishMandal02/tabsflow-backend/pkg/openapi"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
 	"github.com/stretchr/testify/require"
 )
 
+// error response body, as decoded by the tests
+func errorBody(e *errs.Error) map[string]interface{} {
+	var body map[string]interface{}
+
+	b, _ := json.Marshal(http_api.APIResponse{Success: false, Error: e})
+	_ = json.Unmarshal(b, &body)
+
+	return body
+}
+
 type testSetup struct {
 	router           http.Handler
 	mockDB           *db.DDB
-	mockClient       *mockClient
 	mockQueue        *events.Queue
 	mockPaddleClient *PaddleClientMock
 }
@@ -41,37 +51,29 @@ func newTestSetup() *testSetup {
 	q := NewQueueMock()
 	p := NewPaddleClientMock()
 
-	httpClient := new(mockClient)
 	return &testSetup{
 		mockDB:           db,
-		router:           users.Router(db, q, httpClient, p),
+		router:           users.Router(db, db, db, q, q, q, p),
 		mockQueue:        q,
-		mockClient:       httpClient,
 		mockPaddleClient: p,
 	}
 }
 
 // helper
-func mockClientAuthAPISuccessRes(userId string) func(*mockClient) {
-	return func(mockClient *mockClient) {
-		mockClient.On("Do", mock.Anything).Return(&http.Response{
-			StatusCode: http.StatusOK,
-			Body: io.NopCloser(bytes.NewBufferString(fmt.Sprintf(`{
-				"data":{
-					"userId": "%v",
-					"firstName": "New",
-					"lastName": "User",
-					"email": "test@test.com"
-				}
-				}`, userId))),
-		}, nil)
-	}
-}
+// user id of the email in the sessions table, set by the auth service on login
+func mockDBQueryUserId(userId string) func(*DynamoDBClientMock) {
+	return func(mockDB *DynamoDBClientMock) {
+		items := []map[string]types.AttributeValue{}
+
+		if userId != "" {
+			items = append(items, map[string]types.AttributeValue{
+				"PK": &types.AttributeValueMemberS{Value: testUser.Email},
+				"SK": &types.AttributeValueMemberS{Value: db.SORT_KEY_SESSIONS.UserId(userId)},
+			})
+		}
 
-func mockClientSpacesAPISuccessRes() func(*mockClient) {
-	return func(mockClient *mockClient) {
-		mockClient.On("Do", mock.Anything).Return(&http.Response{
-			StatusCode: http.StatusOK,
+		mockDB.On("Query", mock.Anything, mock.AnythingOfType("*dynamodb.QueryInput"), mock.Anything).Return(&dynamodb.QueryOutput{
+			Items: items,
 		}, nil)
 	}
 }
@@ -134,7 +136,7 @@ type TestCase struct {
 	path                      string
 	body                      interface{}
 	mockAuthHeader            func(r *http.Request) // mock authorizer's success res, add user id to header
-	setupMockClient           func(*mockClient)
+	setupMockAuth             func(*DynamoDBClientMock)
 	setupMockQueue            func(*testing.T, *SQSClientMock)
 	setupMockDB               func(*DynamoDBClientMock)
 	setupMockPaddleClient     func(*PaddleClientMock)
@@ -150,16 +152,14 @@ func getUserByIDTestCases() []TestCase {
 			method:         "GET",
 			path:           "/me",
 			expectedStatus: http.StatusBadRequest,
-			expectedBody: map[string]interface{}{"success": false,
-				"message": users.ErrMsg.InvalidUserId},
+			expectedBody:   errorBody(errs.BadRequest.WithMessage(users.ErrMsg.InvalidUserId)),
 		},
 		{
 			name:           "GET-/users/me > dynamodb error",
 			method:         "GET",
 			path:           "/me",
 			expectedStatus: http.StatusInternalServerError,
-			expectedBody: map[string]interface{}{"success": false,
-				"message": users.ErrMsg.GetUser},
+			expectedBody:   errorBody(errs.Internal.WithMessage(users.ErrMsg.GetUser)),
 			mockAuthHeader: func(r *http.Request) { r.Header.Set("UserId", testUser.Id) },
 			setupMockDB: func(mockDB *DynamoDBClientMock) {
 				mockDB.On("GetItem", mock.Anything, mock.AnythingOfType("*dynamodb.GetItemInput"), mock.Anything).Return(&dynamodb.GetItemOutput{}, errors.New("error getting user by id"))
@@ -170,8 +170,7 @@ func getUserByIDTestCases() []TestCase {
 			method:         "GET",
 			path:           "/me",
 			expectedStatus: http.StatusNotFound,
-			expectedBody: map[string]interface{}{"success": false,
-				"message": users.ErrMsg.UserNotFound},
+			expectedBody:   errorBody(users.ErrUserNotFound),
 			mockAuthHeader: func(r *http.Request) { r.Header.Set("UserId", testUser.Id) },
 			setupMockDB: func(mockDB *DynamoDBClientMock) {
 				mockDB.On("GetItem", mock.Anything, mock.AnythingOfType("*dynamodb.GetItemInput"), mock.Anything).Return(&dynamodb.GetItemOutput{Item: nil}, nil)
@@ -196,7 +195,6 @@ func getUserByIDTestCases() []TestCase {
 			setupMockDB: func(mockDB *DynamoDBClientMock) {
 				mockDBGetUser(mockDB)
 			},
-			setupMockClient: mockClientSpacesAPISuccessRes(),
 		},
 	}
 }
@@ -209,8 +207,7 @@ func createUserTestCases() []TestCase {
 			path:           "/",
 			body:           nil,
 			expectedStatus: http.StatusBadRequest,
-			expectedBody: map[string]interface{}{"success": false,
-				"message": users.ErrMsg.CreateUser},
+			expectedBody:   errorBody(http_api.ErrInvalidBody),
 		},
 		{
 			name:   "POST-/users/ > invalid body error",
@@ -221,8 +218,24 @@ func createUserTestCases() []TestCase {
 				"name": "Test Name",
 			},
 			expectedStatus: http.StatusBadRequest,
-			expectedBody: map[string]interface{}{"success": false,
-				"message": users.ErrMsg.CreateUser},
+			expectedBody:   errorBody(http_api.ErrInvalidBody.WithDetails([]http_api.FieldError{{Field: "name", Rule: "unknown"}})),
+		},
+		{
+			name:   "POST-/users/ > invalid fields error",
+			method: "POST",
+			path:   "/",
+			body: map[string]string{
+				"id":         "123",
+				"firstName":  "Test",
+				"email":      "not-an-email",
+				"profilePic": "javascript:alert(1)",
+			},
+			expectedStatus: http.StatusBadRequest,
+			expectedBody: errorBody(http_api.ErrValidation.WithDetails([]http_api.FieldError{
+				{Field: "lastName", Rule: "required"},
+				{Field: "email", Rule: "email"},
+				{Field: "profilePic", Rule: "http_url"},
+			})),
 		},
 		{
 			name:           "POST-/users/ > error checking if user exists",
@@ -230,8 +243,7 @@ func createUserTestCases() []TestCase {
 			path:           "/",
 			body:           testUser,
 			expectedStatus: http.StatusBadGateway,
-			expectedBody: map[string]interface{}{"success": false,
-				"message": users.ErrMsg.GetUser},
+			expectedBody:   errorBody(errs.BadGateway.WithMessage(users.ErrMsg.GetUser)),
 			setupMockDB: func(mockDB *DynamoDBClientMock) {
 				mockDB.On("GetItem", mock.Anything, mock.AnythingOfType("*dynamodb.GetItemInput"), mock.Anything).Return(nil, errors.New("error checking if user exists"))
 			},
@@ -242,29 +254,27 @@ func createUserTestCases() []TestCase {
 			path:           "/",
 			body:           testUser,
 			expectedStatus: http.StatusBadRequest,
-			expectedBody: map[string]interface{}{"success": false,
-				"message": users.ErrMsg.UserExists},
+			expectedBody:   errorBody(errs.BadRequest.WithMessage(users.ErrMsg.UserExists)),
 			setupMockDB: func(mockDB *DynamoDBClientMock) {
 				mockDBGetUser(mockDB)
 			},
 		},
 		{
-			name:           "POST-/users/ > unable to reach auth service",
+			name:           "POST-/users/ > error getting user id from sessions table",
 			method:         "POST",
 			path:           "/",
 			body:           testUser,
 			expectedStatus: http.StatusInternalServerError,
-			expectedBody: map[string]interface{}{"success": false,
-				"message": users.ErrMsg.CreateUser},
+			expectedBody:   errorBody(errs.Internal.WithMessage(users.ErrMsg.CreateUser)),
 			setupMockDB: func(mockDB *DynamoDBClientMock) {
 				mockDB.On("GetItem", mock.Anything, mock.AnythingOfType("*dynamodb.GetItemInput"), mock.Anything).Return(&dynamodb.GetItemOutput{}, nil)
 			},
-			setupMockClient: func(mockClient *mockClient) {
-				mockClient.On("Do", mock.Anything).Return(&http.Response{}, errors.New("failed to reach auth service"))
+			setupMockAuth: func(mockDB *DynamoDBClientMock) {
+				mockDB.On("Query", mock.Anything, mock.AnythingOfType("*dynamodb.QueryInput"), mock.Anything).Return(nil, errors.New("error querying sessions table"))
 			},
 		},
 		{
-			name:           "POST-/users/ > session not found for user from auth service, redirect to logout",
+			name:           "POST-/users/ > user id not found for email, redirect to logout",
 			method:         "POST",
 			path:           "/",
 			body:           testUser,
@@ -272,12 +282,7 @@ func createUserTestCases() []TestCase {
 			setupMockDB: func(mockDB *DynamoDBClientMock) {
 				mockDB.On("GetItem", mock.Anything, mock.AnythingOfType("*dynamodb.GetItemInput"), mock.Anything).Return(&dynamodb.GetItemOutput{}, nil)
 			},
-			setupMockClient: func(mockClient *mockClient) {
-				mockClient.On("Do", mock.Anything).Return(&http.Response{
-					StatusCode: http.StatusBadRequest,
-					Body:       io.NopCloser(strings.NewReader("{\"error\":\"session not found\"}")),
-				}, nil)
-			},
+			setupMockAuth: mockDBQueryUserId(""),
 		},
 		{
 			name:           "POST-/users/ > invalid user session, redirect to logout",
@@ -288,7 +293,7 @@ func createUserTestCases() []TestCase {
 			setupMockDB: func(mockDB *DynamoDBClientMock) {
 				mockDB.On("GetItem", mock.Anything, mock.AnythingOfType("*dynamodb.GetItemInput"), mock.Anything).Return(&dynamodb.GetItemOutput{}, nil)
 			},
-			setupMockClient: mockClientAuthAPISuccessRes("123-wrong-user-id"),
+			setupMockAuth: mockDBQueryUserId("123-wrong-user-id"),
 		},
 		{
 			name:           "POST-/users/ > error inserting data into dynamodb",
@@ -296,12 +301,11 @@ func createUserTestCases() []TestCase {
 			path:           "/",
 			body:           testUser,
 			expectedStatus: http.StatusBadGateway,
-			expectedBody: map[string]interface{}{"success": false,
-				"message": users.ErrMsg.CreateUser},
-			setupMockClient: mockClientAuthAPISuccessRes(testUser.Id),
+			expectedBody:   errorBody(errs.BadGateway.WithMessage(users.ErrMsg.CreateUser)),
+			setupMockAuth:  mockDBQueryUserId(testUser.Id),
 			setupMockDB: func(mockDB *DynamoDBClientMock) {
 				mockDB.On("GetItem", mock.Anything, mock.AnythingOfType("*dynamodb.GetItemInput"), mock.Anything).Return(&dynamodb.GetItemOutput{}, nil)
-				mockDB.On("PutItem", mock.Anything, mock.AnythingOfType("*dynamodb.PutItemInput"), mock.Anything).Return(nil, errors.New("error inserting data into dynamodb"))
+				mockDB.On("TransactWriteItems", mock.Anything, mock.AnythingOfType("*dynamodb.TransactWriteItemsInput"), mock.Anything).Return(nil, errors.New("error inserting data into dynamodb"))
 			},
 		},
 		{
@@ -309,13 +313,12 @@ func createUserTestCases() []TestCase {
 			method:         "POST",
 			path:           "/",
 			body:           testUser,
-			expectedStatus: http.StatusInternalServerError,
-			expectedBody: map[string]interface{}{"success": false,
-				"message": users.ErrMsg.CreateUser},
-			setupMockClient: mockClientAuthAPISuccessRes(testUser.Id),
+			expectedStatus: http.StatusBadGateway,
+			expectedBody:   errorBody(errs.BadGateway.WithMessage(users.ErrMsg.CreateUser)),
+			setupMockAuth:  mockDBQueryUserId(testUser.Id),
 			setupMockDB: func(mockDB *DynamoDBClientMock) {
 				mockDB.On("GetItem", mock.Anything, mock.AnythingOfType("*dynamodb.GetItemInput"), mock.Anything).Return(&dynamodb.GetItemOutput{}, nil)
-				mockDB.On("PutItem", mock.Anything, mock.AnythingOfType("*dynamodb.PutItemInput"), mock.Anything).Return(&dynamodb.PutItemOutput{}, nil)
+				mockDB.On("TransactWriteItems", mock.Anything, mock.AnythingOfType("*dynamodb.TransactWriteItemsInput"), mock.Anything).Return(&dynamodb.TransactWriteItemsOutput{}, nil)
 
 			},
 			setupMockQueue: func(t *testing.T, mockQueue *SQSClientMock) {
@@ -324,13 +327,13 @@ func createUserTestCases() []TestCase {
 		},
 
 		{
-			name:            "POST-/users/ > success",
-			method:          "POST",
-			path:            "/",
-			body:            testUser,
-			expectedStatus:  http.StatusOK,
-			expectedBody:    map[string]interface{}{"success": true, "message": "user created"},
-			setupMockClient: mockClientAuthAPISuccessRes(testUser.Id),
+			name:           "POST-/users/ > success",
+			method:         "POST",
+			path:           "/",
+			body:           testUser,
+			expectedStatus: http.StatusOK,
+			expectedBody:   map[string]interface{}{"success": true, "message": "user created"},
+			setupMockAuth:  mockDBQueryUserId(testUser.Id),
 			setupMockDBWithAssertions: func(t *testing.T, mockDB *DynamoDBClientMock) {
 
 				mockDB.On("GetItem", mock.Anything, mock.AnythingOfType("*dynamodb.GetItemInput"), mock.Anything).Run(
@@ -343,14 +346,18 @@ func createUserTestCases() []TestCase {
 					}),
 				).Return(&dynamodb.GetItemOutput{}, nil)
 
-				mockDB.On("PutItem", mock.Anything, mock.AnythingOfType("*dynamodb.PutItemInput"), mock.Anything).Run(
+				mockDB.On("TransactWriteItems", mock.Anything, mock.AnythingOfType("*dynamodb.TransactWriteItemsInput"), mock.Anything).Run(
 					(func(args mock.Arguments) {
-						// verify ddb put item values
-						input := args.Get(1).(*dynamodb.PutItemInput)
-						assert.Equal(t, "MainTable_test", *input.TableName)
-						assert.Equal(t, &types.AttributeValueMemberS{Value: testUser.Id}, input.Item["PK"])
+						// verify the profile is written with the default data, in the same transaction
+						input := args.Get(1).(*dynamodb.TransactWriteItemsInput)
+
+						profile := input.TransactItems[len(input.TransactItems)-1].Put
+						require.NotNil(t, profile)
+						assert.Equal(t, "MainTable_test", *profile.TableName)
+						assert.Equal(t, &types.AttributeValueMemberS{Value: testUser.Id}, profile.Item["PK"])
+						assert.Equal(t, &types.AttributeValueMemberS{Value: db.SORT_KEY.Profile}, profile.Item["SK"])
 					}),
-				).Return(&dynamodb.PutItemOutput{}, nil)
+				).Return(&dynamodb.TransactWriteItemsOutput{}, nil)
 
 			},
 			setupMockQueue: func(t *testing.T, mockQueue *SQSClientMock) {
@@ -380,10 +387,12 @@ func updateUserTestCases() []TestCase {
 			name:           "PATCH-/users/ > invalid data",
 			method:         "PATCH",
 			path:           "/",
-			body:           `{}`,
+			body:           map[string]string{},
 			expectedStatus: http.StatusBadRequest,
-			expectedBody: map[string]interface{}{"success": false,
-				"message": users.ErrMsg.UpdateUser},
+			expectedBody: errorBody(http_api.ErrValidation.WithDetails([]http_api.FieldError{
+				{Field: "firstName", Rule: "required"},
+				{Field: "lastName", Rule: "required"},
+			})),
 			mockAuthHeader: func(r *http.Request) { r.Header.Set("UserId", testUser.Id) },
 			setupMockDB: func(mockDB *DynamoDBClientMock) {
 				mockDBGetUser(mockDB)
@@ -394,11 +403,11 @@ func updateUserTestCases() []TestCase {
 			method: "PATCH",
 			path:   "/",
 			body: map[string]string{
-				"fullName": "Test Name 2",
+				"firstName": "Test",
+				"lastName":  "Name 2",
 			},
 			expectedStatus: http.StatusBadGateway,
-			expectedBody: map[string]interface{}{"success": false,
-				"message": users.ErrMsg.UpdateUser},
+			expectedBody:   errorBody(errs.BadGateway.WithMessage(users.ErrMsg.UpdateUser)),
 			mockAuthHeader: func(r *http.Request) { r.Header.Set("UserId", testUser.Id) },
 			setupMockDB: func(mockDB *DynamoDBClientMock) {
 				mockDBGetUser(mockDB)
@@ -410,7 +419,8 @@ func updateUserTestCases() []TestCase {
 			method: "PATCH",
 			path:   "/",
 			body: map[string]string{
-				"fullName": "Test Name 2",
+				"firstName": "Test",
+				"lastName":  "Name 2",
 			},
 			expectedStatus: http.StatusOK,
 			expectedBody:   map[string]interface{}{"success": true, "message": "user updated"},
@@ -430,12 +440,22 @@ func deleteUserTestCases() []TestCase {
 			method:         "DELETE",
 			path:           "/",
 			expectedStatus: http.StatusOK,
-			expectedBody:   map[string]interface{}{"success": true, "message": "user deleted"},
+			expectedBody:   map[string]interface{}{"success": true, "message": "account deletion requested"},
 			mockAuthHeader: func(r *http.Request) { r.Header.Set("UserId", testUser.Id) },
 			setupMockDB: func(mockDB *DynamoDBClientMock) {
 				mockDBGetUser(mockDB)
-				mockDB.On("Query", mock.Anything, mock.AnythingOfType("*dynamodb.QueryInput"), mock.Anything).Return(&dynamodb.QueryOutput{}, nil)
-				mockDB.On("BatchWriteItem", mock.Anything, mock.AnythingOfType("*dynamodb.BatchWriteItemInput"), mock.Anything).Return(&dynamodb.BatchWriteItemOutput{}, nil)
+			},
+			setupMockQueue: func(t *testing.T, mockQueue *SQSClientMock) {
+				mockQueue.On("SendMessage", mock.AnythingOfType("*sqs.SendMessageInput"), mock.Anything).Run(
+					(func(args mock.Arguments) {
+						input := args.Get(0).(*sqs.SendMessageInput)
+
+						ev, err := events.NewFromJSON[events.DeleteAccountPayload](*input.MessageBody)
+						require.NoError(t, err)
+
+						assert.Equal(t, events.EventTypeDeleteAccount, ev.EventType)
+						assert.Equal(t, testUser.Id, ev.Payload.UserId)
+					})).Return(&sqs.SendMessageOutput{}, nil)
 			},
 		},
 	}
@@ -479,8 +499,7 @@ func updateUserPreferencesTestCases() []TestCase {
 			},
 			expectedStatus: http.StatusBadRequest,
 
-			expectedBody: map[string]interface{}{"success": false,
-				"message": users.ErrMsg.PreferencesUpdate},
+			expectedBody:   errorBody(errs.BadRequest.WithMessage(users.ErrMsg.PreferencesUpdate)),
 			mockAuthHeader: func(r *http.Request) { r.Header.Set("UserId", testUser.Id) },
 			setupMockDB: func(mockDB *DynamoDBClientMock) {
 				mockDBGetUser(mockDB)
@@ -495,8 +514,7 @@ func updateUserPreferencesTestCases() []TestCase {
 				"Data": json.RawMessage(`{"theme": "dark"}`),
 			},
 			expectedStatus: http.StatusBadRequest,
-			expectedBody: map[string]interface{}{"success": false,
-				"message": users.ErrMsg.PreferencesUpdate},
+			expectedBody:   errorBody(errs.BadRequest.WithMessage(users.ErrMsg.PreferencesUpdate)),
 			mockAuthHeader: func(r *http.Request) { r.Header.Set("UserId", testUser.Id) },
 			setupMockDB: func(mockDB *DynamoDBClientMock) {
 				mockDBGetUser(mockDB)
@@ -512,8 +530,7 @@ func updateUserPreferencesTestCases() []TestCase {
 				"Data": json.RawMessage(`{"theme": "dark"}`),
 			},
 			expectedStatus: http.StatusBadRequest,
-			expectedBody: map[string]interface{}{"success": false,
-				"message": users.ErrMsg.PreferencesUpdate},
+			expectedBody:   errorBody(errs.BadRequest.WithMessage(users.ErrMsg.PreferencesUpdate)),
 			mockAuthHeader: func(r *http.Request) { r.Header.Set("UserId", testUser.Id) },
 			setupMockDB: func(mockDB *DynamoDBClientMock) {
 				mockDBGetUser(mockDB)
@@ -523,9 +540,9 @@ func updateUserPreferencesTestCases() []TestCase {
 			name:   "PATCH-/users/preferences > success",
 			method: "PATCH",
 			path:   "/preferences",
+			// sub preferences by their type
 			body: map[string]interface{}{
-				"Type": "General",
-				"Data": json.RawMessage(`{"openSpace": "sameWindow"}`),
+				"General": json.RawMessage(`{"openSpace": "sameWindow"}`),
 			},
 			expectedStatus: http.StatusOK,
 			expectedBody: map[string]interface{}{
@@ -549,8 +566,7 @@ func getUserSubscriptionsTestCases() []TestCase {
 			method:         "GET",
 			path:           "/subscription",
 			expectedStatus: http.StatusBadGateway,
-			expectedBody: map[string]interface{}{"success": false,
-				"message": users.ErrMsg.SubscriptionGet},
+			expectedBody:   errorBody(errs.BadGateway.WithMessage(users.ErrMsg.SubscriptionGet)),
 			mockAuthHeader: func(r *http.Request) { r.Header.Set("UserId", testUser.Id) },
 			setupMockDB: func(mockDB *DynamoDBClientMock) {
 				mockDBGetUser(mockDB)
@@ -612,8 +628,7 @@ func getPaddleURLTestCases() []TestCase {
 			method:         "GET",
 			path:           "/subscription/paddle-url",
 			expectedStatus: http.StatusBadGateway,
-			expectedBody: map[string]interface{}{"success": false,
-				"message": users.ErrMsg.SubscriptionPaddleURL},
+			expectedBody:   errorBody(errs.BadGateway.WithMessage(users.ErrMsg.SubscriptionPaddleURL)),
 			mockAuthHeader: func(r *http.Request) { r.Header.Set("UserId", testUser.Id) },
 			setupMockDB: func(mockDB *DynamoDBClientMock) {
 				mockDBGetUser(mockDB)
@@ -735,6 +750,7 @@ func subscriptionWebhookTestCases() []TestCase {
 				"message": "event acknowledged",
 			},
 			setupMockDB: func(mockDB *DynamoDBClientMock) {
+				mockDBGetUser(mockDB)
 				mockDB.On("UpdateItem", mock.Anything, mock.AnythingOfType("*dynamodb.UpdateItemInput"), mock.Anything).Return(&dynamodb.UpdateItemOutput{}, nil).Once()
 			},
 		},
@@ -776,6 +792,9 @@ func TestUsersService(t *testing.T) {
 
 	tests := allTestCases()
 
+	// responses must match the api docs
+	doc := openapi.New("TabsFlow API", "test", users.Router(NewDDBMock(), NewDDBMock(), NewDDBMock(), NewQueueMock(), NewQueueMock(), NewQueueMock(), NewPaddleClientMock()))
+
 	for _, tc := range tests {
 		t.Run(tc.name, func(t *testing.T) {
 			// Create new test setup for each test case
@@ -789,9 +808,9 @@ func TestUsersService(t *testing.T) {
 				t.Fatal("failed to get mock db client")
 			}
 
-			// setup mock interceptor
-			if tc.setupMockClient != nil {
-				tc.setupMockClient(setup.mockClient)
+			// setup mock auth (sessions table)
+			if tc.setupMockAuth != nil {
+				tc.setupMockAuth(mockedDB)
 			}
 
 			// setup mock paddle client
@@ -839,6 +858,9 @@ func TestUsersService(t *testing.T) {
 			// assert status code
 			assert.Equal(t, tc.expectedStatus, w.Code)
 
+			// the test paths are relative to the router base
+			assert.NoError(t, doc.ValidateResponse(tc.method, "/users"+req.URL.Path, w.Result()))
+
 			// assert body
 			if tc.expectedBody != nil {
 				// check if expected body is a string